@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS track_embeddings (
+    track_id INTEGER NOT NULL PRIMARY KEY,
+    model TEXT NOT NULL,
+    dimensions INTEGER NOT NULL,
+    embedding BLOB NOT NULL,
+    document TEXT NOT NULL,
+    embedded_at TEXT NOT NULL,
+    FOREIGN KEY(track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_embeddings_model ON track_embeddings(model);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_track_embeddings_model;
+DROP TABLE IF EXISTS track_embeddings;
@@ -0,0 +1,8 @@
+-- +goose Up
+DROP INDEX IF EXISTS idx_track_embeddings_model;
+CREATE INDEX IF NOT EXISTS idx_track_embeddings_model_dimensions
+ON track_embeddings(model, dimensions);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_track_embeddings_model_dimensions;
+CREATE INDEX IF NOT EXISTS idx_track_embeddings_model ON track_embeddings(model);
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS navidrome_playlists (
+    name TEXT NOT NULL PRIMARY KEY,
+    navidrome_playlist_id TEXT NOT NULL UNIQUE,
+    song_count INTEGER NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS navidrome_playlists;
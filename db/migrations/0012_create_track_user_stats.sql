@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS track_user_stats (
+    track_id INTEGER NOT NULL PRIMARY KEY,
+    starred_at TEXT,
+    rating INTEGER NOT NULL DEFAULT 0 CHECK (rating BETWEEN 0 AND 5),
+    play_count INTEGER NOT NULL DEFAULT 0,
+    last_played_at TEXT,
+    updated_at TEXT NOT NULL,
+    FOREIGN KEY(track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS track_user_stats;
@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS track_extended_metadata (
+    track_id INTEGER NOT NULL PRIMARY KEY,
+    musicbrainz_id TEXT,
+    sort_name TEXT,
+    isrc TEXT,
+    bpm INTEGER CHECK (bpm IS NULL OR bpm > 0),
+    moods TEXT,
+    replaygain_track_gain_db REAL,
+    replaygain_track_peak REAL,
+    replaygain_album_gain_db REAL,
+    replaygain_album_peak REAL,
+    updated_at TEXT NOT NULL,
+    FOREIGN KEY(track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS track_genres (
+    track_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (track_id, position),
+    FOREIGN KEY(track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_genres_name ON track_genres(name);
+
+CREATE TABLE IF NOT EXISTS track_artists (
+    track_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('artist', 'album_artist')),
+    position INTEGER NOT NULL,
+    navidrome_artist_id TEXT,
+    name TEXT NOT NULL,
+    PRIMARY KEY (track_id, role, position),
+    FOREIGN KEY(track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_track_artists_artist ON track_artists(navidrome_artist_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_track_artists_artist;
+DROP TABLE IF EXISTS track_artists;
+DROP INDEX IF EXISTS idx_track_genres_name;
+DROP TABLE IF EXISTS track_genres;
+DROP TABLE IF EXISTS track_extended_metadata;
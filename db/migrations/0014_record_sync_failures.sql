@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE navidrome_syncs ADD COLUMN tracks_fetched INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE navidrome_syncs ADD COLUMN fetch_completed_at TEXT;
+ALTER TABLE navidrome_syncs ADD COLUMN failed_phase TEXT CHECK (failed_phase IS NULL OR failed_phase IN ('fetch', 'upsert', 'delete'));
+ALTER TABLE navidrome_syncs ADD COLUMN error TEXT;
+
+-- +goose Down
+ALTER TABLE navidrome_syncs DROP COLUMN error;
+ALTER TABLE navidrome_syncs DROP COLUMN failed_phase;
+ALTER TABLE navidrome_syncs DROP COLUMN fetch_completed_at;
+ALTER TABLE navidrome_syncs DROP COLUMN tracks_fetched;
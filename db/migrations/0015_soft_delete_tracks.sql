@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE tracks ADD COLUMN deleted_at TEXT;
+CREATE INDEX IF NOT EXISTS idx_tracks_deleted_at ON tracks(deleted_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tracks_deleted_at;
+ALTER TABLE tracks DROP COLUMN deleted_at;
@@ -0,0 +1,53 @@
+-- +goose Up
+ALTER TABLE track_audio_analysis ADD COLUMN next_attempt_at TEXT;
+ALTER TABLE track_embedding_jobs ADD COLUMN next_attempt_at TEXT;
+
+-- Failed jobs used to be terminal, often after a single transient error. A
+-- track's newest failed job gets a fresh start under the retry policy; it
+-- keeps its error, so it shows as retrying. Older failed jobs are dead
+-- letters.
+UPDATE track_audio_analysis
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = NULL,
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE status = 'failed'
+  AND id = (
+    SELECT MAX(latest.id)
+    FROM track_audio_analysis AS latest
+    WHERE latest.track_id = track_audio_analysis.track_id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM track_audio_analysis AS active
+    WHERE active.track_id = track_audio_analysis.track_id
+      AND active.status IN ('pending', 'processing')
+  );
+UPDATE track_embedding_jobs
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = NULL,
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE status = 'failed'
+  AND id = (
+    SELECT MAX(latest.id)
+    FROM track_embedding_jobs AS latest
+    WHERE latest.track_id = track_embedding_jobs.track_id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM track_embedding_jobs AS active
+    WHERE active.track_id = track_embedding_jobs.track_id
+      AND active.status IN ('pending', 'processing')
+  );
+UPDATE track_audio_analysis SET status = 'dead' WHERE status = 'failed';
+UPDATE track_embedding_jobs SET status = 'dead' WHERE status = 'failed';
+
+-- +goose Down
+UPDATE track_embedding_jobs SET status = 'failed' WHERE status = 'dead';
+UPDATE track_audio_analysis SET status = 'failed' WHERE status = 'dead';
+
+ALTER TABLE track_embedding_jobs DROP COLUMN next_attempt_at;
+ALTER TABLE track_audio_analysis DROP COLUMN next_attempt_at;
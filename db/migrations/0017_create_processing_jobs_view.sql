@@ -0,0 +1,45 @@
+-- +goose Up
+-- processing_jobs reads both job queues as one table for the jobs command.
+-- failed is 1 for jobs whose last attempt failed: retries waiting out their
+-- backoff and dead jobs.
+CREATE VIEW processing_jobs AS
+SELECT
+    'audio' AS queue,
+    id,
+    track_id,
+    status,
+    error,
+    attempts,
+    last_attempt_at,
+    next_attempt_at,
+    claimed_at,
+    claimed_by,
+    processed_at,
+    created_at,
+    CASE
+        WHEN status = 'dead' OR (status = 'pending' AND error IS NOT NULL) THEN 1
+        ELSE 0
+    END AS failed
+FROM track_audio_analysis
+UNION ALL
+SELECT
+    'embedding' AS queue,
+    id,
+    track_id,
+    status,
+    error,
+    attempts,
+    last_attempt_at,
+    next_attempt_at,
+    claimed_at,
+    claimed_by,
+    processed_at,
+    created_at,
+    CASE
+        WHEN status = 'dead' OR (status = 'pending' AND error IS NOT NULL) THEN 1
+        ELSE 0
+    END AS failed
+FROM track_embedding_jobs;
+
+-- +goose Down
+DROP VIEW IF EXISTS processing_jobs;
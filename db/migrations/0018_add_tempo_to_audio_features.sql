@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE track_audio_features ADD COLUMN measured_tempo_bpm REAL;
+ALTER TABLE track_audio_features ADD COLUMN measured_tempo_confidence REAL;
+ALTER TABLE track_audio_features ADD COLUMN tag_bpm REAL;
+ALTER TABLE track_audio_features ADD COLUMN effective_bpm REAL;
+ALTER TABLE track_audio_features ADD COLUMN effective_bpm_source TEXT NOT NULL DEFAULT 'none';
+
+-- +goose Down
+ALTER TABLE track_audio_features DROP COLUMN effective_bpm_source;
+ALTER TABLE track_audio_features DROP COLUMN effective_bpm;
+ALTER TABLE track_audio_features DROP COLUMN tag_bpm;
+ALTER TABLE track_audio_features DROP COLUMN measured_tempo_confidence;
+ALTER TABLE track_audio_features DROP COLUMN measured_tempo_bpm;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE track_audio_features ADD COLUMN measured_key TEXT;
+ALTER TABLE track_audio_features ADD COLUMN measured_mode TEXT;
+ALTER TABLE track_audio_features ADD COLUMN measured_key_confidence REAL;
+ALTER TABLE track_audio_features ADD COLUMN camelot_key TEXT;
+
+-- +goose Down
+ALTER TABLE track_audio_features DROP COLUMN camelot_key;
+ALTER TABLE track_audio_features DROP COLUMN measured_key_confidence;
+ALTER TABLE track_audio_features DROP COLUMN measured_mode;
+ALTER TABLE track_audio_features DROP COLUMN measured_key;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE track_audio_features ADD COLUMN measured_loudness_range REAL;
+ALTER TABLE track_audio_features ADD COLUMN spectral_centroid_hz REAL;
+ALTER TABLE track_audio_features ADD COLUMN spectral_rolloff_hz REAL;
+ALTER TABLE track_audio_features ADD COLUMN crest_factor_db REAL;
+ALTER TABLE track_audio_features ADD COLUMN zero_crossing_rate REAL;
+ALTER TABLE track_audio_features ADD COLUMN onset_density REAL;
+-- spectral_version records which extractor produced the spectral columns.
+ALTER TABLE track_audio_features ADD COLUMN spectral_version INTEGER;
+
+-- +goose Down
+ALTER TABLE track_audio_features DROP COLUMN spectral_version;
+ALTER TABLE track_audio_features DROP COLUMN onset_density;
+ALTER TABLE track_audio_features DROP COLUMN zero_crossing_rate;
+ALTER TABLE track_audio_features DROP COLUMN crest_factor_db;
+ALTER TABLE track_audio_features DROP COLUMN spectral_rolloff_hz;
+ALTER TABLE track_audio_features DROP COLUMN spectral_centroid_hz;
+ALTER TABLE track_audio_features DROP COLUMN measured_loudness_range;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- The file a track's features were measured from. audio_hash covers only the
+-- audio stream, so retagging a file does not change it.
+ALTER TABLE track_audio_features ADD COLUMN file_size INTEGER;
+ALTER TABLE track_audio_features ADD COLUMN file_mtime TEXT;
+ALTER TABLE track_audio_features ADD COLUMN audio_hash TEXT;
+ALTER TABLE track_audio_features ADD COLUMN analyzer_version INTEGER;
+ALTER TABLE audio_processing_runs ADD COLUMN jobs_skipped INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE audio_processing_runs DROP COLUMN jobs_skipped;
+ALTER TABLE track_audio_features DROP COLUMN analyzer_version;
+ALTER TABLE track_audio_features DROP COLUMN audio_hash;
+ALTER TABLE track_audio_features DROP COLUMN file_mtime;
+ALTER TABLE track_audio_features DROP COLUMN file_size;
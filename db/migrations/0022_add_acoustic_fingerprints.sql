@@ -0,0 +1,13 @@
+-- +goose Up
+-- acoustic_fingerprint holds one little-endian uint32 per analysis frame.
+-- duplicate_group_id is set by the duplicates command to the lowest track ID
+-- among recordings that sound the same, and survives re-analysis.
+ALTER TABLE track_audio_features ADD COLUMN acoustic_fingerprint BLOB;
+ALTER TABLE track_audio_features ADD COLUMN duplicate_group_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_audio_features_duplicate_group
+  ON track_audio_features(duplicate_group_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audio_features_duplicate_group;
+ALTER TABLE track_audio_features DROP COLUMN duplicate_group_id;
+ALTER TABLE track_audio_features DROP COLUMN acoustic_fingerprint;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Where a track's audible content starts and ends, in seconds from the start
+-- of the file, and whether it starts or ends abruptly rather than fading.
+-- flows_into_next is derived from these for the next track on the album.
+ALTER TABLE track_audio_features ADD COLUMN leading_silence_seconds REAL;
+ALTER TABLE track_audio_features ADD COLUMN trailing_silence_seconds REAL;
+ALTER TABLE track_audio_features ADD COLUMN content_start_seconds REAL;
+ALTER TABLE track_audio_features ADD COLUMN content_end_seconds REAL;
+ALTER TABLE track_audio_features ADD COLUMN abrupt_start INTEGER;
+ALTER TABLE track_audio_features ADD COLUMN abrupt_end INTEGER;
+ALTER TABLE track_audio_features ADD COLUMN flows_into_next INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE track_audio_features DROP COLUMN flows_into_next;
+ALTER TABLE track_audio_features DROP COLUMN abrupt_end;
+ALTER TABLE track_audio_features DROP COLUMN abrupt_start;
+ALTER TABLE track_audio_features DROP COLUMN content_end_seconds;
+ALTER TABLE track_audio_features DROP COLUMN content_start_seconds;
+ALTER TABLE track_audio_features DROP COLUMN trailing_silence_seconds;
+ALTER TABLE track_audio_features DROP COLUMN leading_silence_seconds;
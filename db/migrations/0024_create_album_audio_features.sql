@@ -0,0 +1,14 @@
+-- +goose Up
+-- Loudness of each album as a whole, aggregated from its tracks once every
+-- track on the album has been measured.
+CREATE TABLE IF NOT EXISTS album_audio_features (
+    album_id TEXT NOT NULL PRIMARY KEY,
+    track_count INTEGER NOT NULL,
+    duration_seconds REAL NOT NULL,
+    integrated_lufs REAL NOT NULL,
+    true_peak REAL,
+    computed_at TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS album_audio_features;
@@ -0,0 +1,72 @@
+-- name: ListAlbumLoudnessInputs :many
+-- Measured tracks on an album, with the file and server ReplayGain needed to
+-- recompute their effective gain.
+SELECT
+  tracks.id AS track_id,
+  tracks.album_id,
+  track_audio_features.file_duration_seconds,
+  track_audio_features.measured_integrated_lufs,
+  track_audio_features.measured_true_peak,
+  track_audio_features.replaygain_track_gain_db,
+  track_audio_features.replaygain_track_peak,
+  track_audio_features.replaygain_album_gain_db,
+  track_audio_features.replaygain_album_peak,
+  track_extended_metadata.replaygain_track_gain_db AS server_replaygain_track_gain_db,
+  track_extended_metadata.replaygain_track_peak AS server_replaygain_track_peak,
+  track_extended_metadata.replaygain_album_gain_db AS server_replaygain_album_gain_db,
+  track_extended_metadata.replaygain_album_peak AS server_replaygain_album_peak,
+  track_audio_features.effective_gain_db,
+  track_audio_features.effective_peak,
+  track_audio_features.effective_gain_source,
+  track_audio_features.effective_peak_source
+FROM tracks
+JOIN track_audio_features ON track_audio_features.track_id = tracks.id
+LEFT JOIN track_extended_metadata ON track_extended_metadata.track_id = tracks.id
+WHERE tracks.deleted_at IS NULL
+  AND tracks.album_id IS NOT NULL
+  AND tracks.album_id <> ''
+ORDER BY tracks.album_id, tracks.id;
+
+-- name: ListIncompleteAlbumIDs :many
+-- Albums with at least one track that has no measured loudness yet.
+SELECT DISTINCT tracks.album_id
+FROM tracks
+LEFT JOIN track_audio_features ON track_audio_features.track_id = tracks.id
+WHERE tracks.deleted_at IS NULL
+  AND tracks.album_id IS NOT NULL
+  AND tracks.album_id <> ''
+  AND track_audio_features.measured_integrated_lufs IS NULL;
+
+-- name: ClearAlbumAudioFeatures :exec
+DELETE FROM album_audio_features;
+
+-- name: InsertAlbumAudioFeatures :exec
+INSERT INTO album_audio_features (
+  album_id,
+  track_count,
+  duration_seconds,
+  integrated_lufs,
+  true_peak,
+  computed_at
+) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetAlbumAudioFeatures :one
+SELECT album_id, track_count, duration_seconds, integrated_lufs, true_peak, computed_at
+FROM album_audio_features
+WHERE album_id = ?;
+
+-- name: ListTrackLoudness :many
+-- Active tracks with measured loudness, with their album's loudness when the
+-- whole album has been measured.
+SELECT
+  sqlc.embed(tracks),
+  track_audio_features.measured_integrated_lufs,
+  track_audio_features.measured_true_peak,
+  album_audio_features.integrated_lufs AS album_integrated_lufs,
+  album_audio_features.true_peak AS album_true_peak
+FROM tracks
+JOIN track_audio_features ON track_audio_features.track_id = tracks.id
+LEFT JOIN album_audio_features ON album_audio_features.album_id = tracks.album_id
+WHERE tracks.deleted_at IS NULL
+  AND track_audio_features.measured_integrated_lufs IS NOT NULL
+ORDER BY tracks.path;
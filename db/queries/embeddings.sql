@@ -0,0 +1,94 @@
+-- name: ClaimPendingEmbeddingJobs :many
+UPDATE track_embedding_jobs
+SET status = 'processing',
+    claimed_at = ?,
+    claimed_by = ?,
+    attempts = attempts + 1,
+    last_attempt_at = ?,
+    next_attempt_at = NULL,
+    error = NULL
+WHERE id IN (
+  SELECT track_embedding_jobs.id
+  FROM track_embedding_jobs
+  JOIN tracks ON tracks.id = track_embedding_jobs.track_id
+  WHERE tracks.deleted_at IS NULL
+    AND (
+      (
+        track_embedding_jobs.status = 'pending'
+        AND (track_embedding_jobs.next_attempt_at IS NULL OR track_embedding_jobs.next_attempt_at <= ?)
+      )
+      OR (
+        track_embedding_jobs.status = 'processing'
+        AND track_embedding_jobs.claimed_at IS NOT NULL
+        AND track_embedding_jobs.claimed_at <= ?
+        AND track_embedding_jobs.attempts < ?
+      )
+    )
+  ORDER BY track_embedding_jobs.created_at, track_embedding_jobs.id
+  LIMIT ?
+)
+RETURNING id, track_id;
+
+-- name: ListEmbeddingJobsByIDs :many
+SELECT
+  track_embedding_jobs.id AS job_id,
+  sqlc.embed(tracks)
+FROM track_embedding_jobs
+JOIN tracks ON tracks.id = track_embedding_jobs.track_id
+WHERE track_embedding_jobs.id IN (sqlc.slice('job_ids'))
+ORDER BY track_embedding_jobs.id;
+
+-- name: UpdateEmbeddingJobStatus :exec
+UPDATE track_embedding_jobs
+SET status = ?,
+    processed_at = ?,
+    error = ?,
+    next_attempt_at = ?,
+    claimed_at = ?,
+    claimed_by = ?
+WHERE id = ?;
+
+-- name: GetEmbeddingJobAttempts :one
+SELECT attempts FROM track_embedding_jobs WHERE id = ?;
+
+-- name: MarkExhaustedEmbeddingJobsDead :exec
+UPDATE track_embedding_jobs
+SET status = 'dead',
+    error = 'claim expired on the final attempt',
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE status = 'processing'
+  AND claimed_at IS NOT NULL
+  AND claimed_at <= ?
+  AND attempts >= ?;
+
+-- name: UpsertTrackEmbedding :exec
+INSERT INTO track_embeddings (
+  track_id,
+  model,
+  dimensions,
+  embedding,
+  document,
+  embedded_at
+) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(track_id) DO UPDATE SET
+  model = excluded.model,
+  dimensions = excluded.dimensions,
+  embedding = excluded.embedding,
+  document = excluded.document,
+  embedded_at = excluded.embedded_at;
+
+-- name: ListTrackEmbeddingCandidates :many
+SELECT
+  track_embeddings.track_id,
+  tracks.navidrome_id,
+  tracks.genre,
+  tracks.year,
+  tracks.duration_seconds,
+  track_embeddings.embedding
+FROM track_embeddings
+JOIN tracks ON tracks.id = track_embeddings.track_id
+WHERE track_embeddings.model = ?
+  AND track_embeddings.dimensions = ?
+  AND tracks.deleted_at IS NULL
+ORDER BY track_embeddings.track_id;
@@ -0,0 +1,66 @@
+-- name: ListExtendedMetadataTrackIDs :many
+SELECT track_id
+FROM track_extended_metadata;
+
+-- name: ListTrackExtendedMetadataByIDs :many
+SELECT track_id, musicbrainz_id, sort_name, isrc, bpm, moods, replaygain_track_gain_db, replaygain_track_peak, replaygain_album_gain_db, replaygain_album_peak, updated_at
+FROM track_extended_metadata
+WHERE track_id IN (sqlc.slice('track_ids'));
+
+-- name: ListTrackGenresByIDs :many
+SELECT track_id, position, name
+FROM track_genres
+WHERE track_id IN (sqlc.slice('track_ids'))
+ORDER BY track_id, position;
+
+-- name: ListTrackArtistsByIDs :many
+SELECT track_id, role, position, navidrome_artist_id, name
+FROM track_artists
+WHERE track_id IN (sqlc.slice('track_ids'))
+ORDER BY track_id, role, position;
+
+-- name: UpsertTrackExtendedMetadata :exec
+INSERT INTO track_extended_metadata (
+  track_id,
+  musicbrainz_id,
+  sort_name,
+  isrc,
+  bpm,
+  moods,
+  replaygain_track_gain_db,
+  replaygain_track_peak,
+  replaygain_album_gain_db,
+  replaygain_album_peak,
+  updated_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(track_id) DO UPDATE SET
+  musicbrainz_id = excluded.musicbrainz_id,
+  sort_name = excluded.sort_name,
+  isrc = excluded.isrc,
+  bpm = excluded.bpm,
+  moods = excluded.moods,
+  replaygain_track_gain_db = excluded.replaygain_track_gain_db,
+  replaygain_track_peak = excluded.replaygain_track_peak,
+  replaygain_album_gain_db = excluded.replaygain_album_gain_db,
+  replaygain_album_peak = excluded.replaygain_album_peak,
+  updated_at = excluded.updated_at;
+
+-- name: DeleteTrackExtendedMetadata :exec
+DELETE FROM track_extended_metadata
+WHERE track_id = ?;
+
+-- name: DeleteTrackGenres :exec
+DELETE FROM track_genres
+WHERE track_id = ?;
+
+-- name: InsertTrackGenre :exec
+INSERT INTO track_genres (track_id, position, name)
+VALUES (?, ?, ?);
+
+-- name: DeleteTrackArtists :exec
+DELETE FROM track_artists
+WHERE track_id = ?;
+
+-- name: InsertTrackArtist :exec
+INSERT INTO track_artists (track_id, role, position, navidrome_artist_id, name)
+VALUES (?, ?, ?, ?, ?);
@@ -0,0 +1,144 @@
+-- name: ListProcessingJobs :many
+SELECT
+  processing_jobs.queue,
+  processing_jobs.id,
+  processing_jobs.track_id,
+  tracks.navidrome_id,
+  tracks.artist,
+  tracks.album,
+  tracks.title,
+  tracks.path,
+  tracks.deleted_at,
+  processing_jobs.status,
+  processing_jobs.error,
+  processing_jobs.attempts,
+  processing_jobs.last_attempt_at,
+  processing_jobs.next_attempt_at,
+  processing_jobs.claimed_at,
+  processing_jobs.claimed_by,
+  processing_jobs.processed_at,
+  processing_jobs.created_at
+FROM processing_jobs
+JOIN tracks ON tracks.id = processing_jobs.track_id
+WHERE tracks.deleted_at IS NULL
+  AND (sqlc.narg('queue') IS NULL OR processing_jobs.queue = sqlc.narg('queue'))
+  AND (sqlc.narg('status') IS NULL OR processing_jobs.status = sqlc.narg('status'))
+  AND processing_jobs.failed >= sqlc.arg('failed')
+ORDER BY processing_jobs.created_at DESC, processing_jobs.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetProcessingJob :one
+SELECT
+  processing_jobs.queue,
+  processing_jobs.id,
+  processing_jobs.track_id,
+  tracks.navidrome_id,
+  tracks.artist,
+  tracks.album,
+  tracks.title,
+  tracks.path,
+  tracks.deleted_at,
+  processing_jobs.status,
+  processing_jobs.error,
+  processing_jobs.attempts,
+  processing_jobs.last_attempt_at,
+  processing_jobs.next_attempt_at,
+  processing_jobs.claimed_at,
+  processing_jobs.claimed_by,
+  processing_jobs.processed_at,
+  processing_jobs.created_at
+FROM processing_jobs
+JOIN tracks ON tracks.id = processing_jobs.track_id
+WHERE processing_jobs.queue = ? AND processing_jobs.id = ?;
+
+-- name: CountProcessingJobs :many
+SELECT
+  processing_jobs.queue,
+  processing_jobs.status,
+  COUNT(*) AS jobs,
+  CAST(SUM(processing_jobs.failed) AS INTEGER) AS failed,
+  CAST(MIN(processing_jobs.created_at) AS TEXT) AS oldest_created_at
+FROM processing_jobs
+JOIN tracks ON tracks.id = processing_jobs.track_id
+WHERE tracks.deleted_at IS NULL
+GROUP BY processing_jobs.queue, processing_jobs.status
+ORDER BY processing_jobs.queue, processing_jobs.status;
+
+-- name: PurgeAudioJobs :execrows
+-- Deletes finished jobs older than the cutoff. A track's newest job is always
+-- kept, since jobs retry and requeue act on it.
+DELETE FROM track_audio_analysis
+WHERE status IN ('completed', 'dead')
+  AND (sqlc.narg('status') IS NULL OR status = sqlc.narg('status'))
+  AND COALESCE(processed_at, last_attempt_at, created_at) < sqlc.arg('cutoff')
+  AND id < (
+    SELECT MAX(latest.id)
+    FROM track_audio_analysis AS latest
+    WHERE latest.track_id = track_audio_analysis.track_id
+  );
+
+-- name: PurgeEmbeddingJobs :execrows
+-- Deletes finished jobs older than the cutoff. A track's newest job is always
+-- kept, since jobs retry and requeue act on it.
+DELETE FROM track_embedding_jobs
+WHERE status IN ('completed', 'dead')
+  AND (sqlc.narg('status') IS NULL OR status = sqlc.narg('status'))
+  AND COALESCE(processed_at, last_attempt_at, created_at) < sqlc.arg('cutoff')
+  AND id < (
+    SELECT MAX(latest.id)
+    FROM track_embedding_jobs AS latest
+    WHERE latest.track_id = track_embedding_jobs.track_id
+  );
+
+-- name: RetryAudioJobs :execrows
+-- Only a track's newest job is revived, so the track never ends up with two
+-- active jobs.
+UPDATE track_audio_analysis
+SET status = 'pending',
+    error = NULL,
+    attempts = 0,
+    next_attempt_at = NULL,
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE id IN (
+  SELECT processing_jobs.id
+  FROM processing_jobs
+  WHERE processing_jobs.queue = 'audio'
+    AND processing_jobs.failed = 1
+    AND (sqlc.narg('status') IS NULL OR processing_jobs.status = sqlc.narg('status'))
+)
+  AND id = (
+    SELECT MAX(latest.id)
+    FROM track_audio_analysis AS latest
+    WHERE latest.track_id = track_audio_analysis.track_id
+  );
+
+-- name: RetryEmbeddingJobs :execrows
+-- Only a track's newest job is revived, so the track never ends up with two
+-- active jobs.
+UPDATE track_embedding_jobs
+SET status = 'pending',
+    error = NULL,
+    attempts = 0,
+    next_attempt_at = NULL,
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE id IN (
+  SELECT processing_jobs.id
+  FROM processing_jobs
+  WHERE processing_jobs.queue = 'embedding'
+    AND processing_jobs.failed = 1
+    AND (sqlc.narg('status') IS NULL OR processing_jobs.status = sqlc.narg('status'))
+)
+  AND id = (
+    SELECT MAX(latest.id)
+    FROM track_embedding_jobs AS latest
+    WHERE latest.track_id = track_embedding_jobs.track_id
+  );
+
+-- name: ClearTrackAnalyzerVersion :exec
+-- Forgets which analyzer measured a track's features, so its next audio job
+-- analyzes the file even when the audio is unchanged.
+UPDATE track_audio_features
+SET analyzer_version = NULL
+WHERE track_id = ?;
@@ -0,0 +1,21 @@
+-- name: GetNavidromePlaylist :one
+SELECT name, navidrome_playlist_id, song_count, created_at, updated_at
+FROM navidrome_playlists
+WHERE name = ?;
+
+-- name: UpsertNavidromePlaylist :exec
+INSERT INTO navidrome_playlists (
+  name,
+  navidrome_playlist_id,
+  song_count,
+  created_at,
+  updated_at
+) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET
+  navidrome_playlist_id = excluded.navidrome_playlist_id,
+  song_count = excluded.song_count,
+  updated_at = excluded.updated_at;
+
+-- name: DeleteNavidromePlaylist :exec
+DELETE FROM navidrome_playlists
+WHERE name = ?;
@@ -3,7 +3,23 @@ INSERT INTO navidrome_syncs (started_at, status)
 VALUES (?, 'in_progress')
 RETURNING id;
 
+-- name: MarkSyncFetched :exec
+UPDATE navidrome_syncs
+SET fetch_completed_at = ?, tracks_fetched = ?
+WHERE id = ?;
+
 -- name: CompleteSync :exec
 UPDATE navidrome_syncs
 SET completed_at = ?, status = ?, tracks_processed = ?, tracks_updated = ?, tracks_deleted = ?
 WHERE id = ?;
+
+-- name: FailSync :exec
+UPDATE navidrome_syncs
+SET completed_at = ?, status = 'failed', failed_phase = ?, error = ?, tracks_processed = ?
+WHERE id = ?;
+
+-- name: ListRecentSyncs :many
+SELECT id, started_at, completed_at, tracks_processed, tracks_updated, tracks_deleted, status, created_at, tracks_fetched, fetch_completed_at, failed_phase, error
+FROM navidrome_syncs
+ORDER BY id DESC
+LIMIT ?;
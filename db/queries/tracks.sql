@@ -36,7 +36,8 @@ ON CONFLICT(navidrome_id) DO UPDATE SET
   path = excluded.path,
   content_type = excluded.content_type,
   suffix = excluded.suffix,
-  created_at = excluded.created_at;
+  created_at = excluded.created_at,
+  deleted_at = NULL;
 
 -- name: SelectTrackID :one
 SELECT id FROM tracks WHERE navidrome_id = ?;
@@ -53,11 +54,27 @@ ON CONFLICT(track_id) DO UPDATE SET
   sync_id = excluded.sync_id;
 
 -- name: ListTrackSyncStatus :many
-SELECT track_id, navidrome_id, last_synced_at FROM navidrome_track_sync_status;
+SELECT
+  navidrome_track_sync_status.track_id,
+  navidrome_track_sync_status.navidrome_id,
+  navidrome_track_sync_status.last_synced_at,
+  tracks.deleted_at
+FROM navidrome_track_sync_status
+JOIN tracks ON tracks.id = navidrome_track_sync_status.track_id;
+
+-- name: SoftDeleteTracksByNavidromeIDs :exec
+UPDATE tracks
+SET deleted_at = ?
+WHERE navidrome_id IN (sqlc.slice('nav_ids'))
+  AND deleted_at IS NULL;
 
--- name: DeleteTracksByNavidromeIDs :exec
+-- name: RestoreTrack :exec
+UPDATE tracks SET deleted_at = NULL WHERE id = ?;
+
+-- name: PurgeDeletedTracks :execrows
 DELETE FROM tracks
-WHERE navidrome_id IN (sqlc.slice('nav_ids'));
+WHERE deleted_at IS NOT NULL
+  AND deleted_at <= ?;
 
 -- name: UpsertTrackAudioFeatures :exec
 INSERT INTO track_audio_features (
@@ -73,8 +90,35 @@ INSERT INTO track_audio_features (
   effective_gain_db,
   effective_peak,
   effective_gain_source,
-  effective_peak_source
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  effective_peak_source,
+  measured_tempo_bpm,
+  measured_tempo_confidence,
+  tag_bpm,
+  effective_bpm,
+  effective_bpm_source,
+  measured_key,
+  measured_mode,
+  measured_key_confidence,
+  camelot_key,
+  measured_loudness_range,
+  spectral_centroid_hz,
+  spectral_rolloff_hz,
+  crest_factor_db,
+  zero_crossing_rate,
+  onset_density,
+  spectral_version,
+  file_size,
+  file_mtime,
+  audio_hash,
+  analyzer_version,
+  acoustic_fingerprint,
+  leading_silence_seconds,
+  trailing_silence_seconds,
+  content_start_seconds,
+  content_end_seconds,
+  abrupt_start,
+  abrupt_end
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(track_id) DO UPDATE SET
   analyzed_at = excluded.analyzed_at,
   file_duration_seconds = excluded.file_duration_seconds,
@@ -87,7 +131,108 @@ ON CONFLICT(track_id) DO UPDATE SET
   effective_gain_db = excluded.effective_gain_db,
   effective_peak = excluded.effective_peak,
   effective_gain_source = excluded.effective_gain_source,
-  effective_peak_source = excluded.effective_peak_source;
+  effective_peak_source = excluded.effective_peak_source,
+  measured_tempo_bpm = excluded.measured_tempo_bpm,
+  measured_tempo_confidence = excluded.measured_tempo_confidence,
+  tag_bpm = excluded.tag_bpm,
+  effective_bpm = excluded.effective_bpm,
+  effective_bpm_source = excluded.effective_bpm_source,
+  measured_key = excluded.measured_key,
+  measured_mode = excluded.measured_mode,
+  measured_key_confidence = excluded.measured_key_confidence,
+  camelot_key = excluded.camelot_key,
+  measured_loudness_range = excluded.measured_loudness_range,
+  spectral_centroid_hz = excluded.spectral_centroid_hz,
+  spectral_rolloff_hz = excluded.spectral_rolloff_hz,
+  crest_factor_db = excluded.crest_factor_db,
+  zero_crossing_rate = excluded.zero_crossing_rate,
+  onset_density = excluded.onset_density,
+  spectral_version = excluded.spectral_version,
+  file_size = excluded.file_size,
+  file_mtime = excluded.file_mtime,
+  audio_hash = excluded.audio_hash,
+  analyzer_version = excluded.analyzer_version,
+  acoustic_fingerprint = excluded.acoustic_fingerprint,
+  leading_silence_seconds = excluded.leading_silence_seconds,
+  trailing_silence_seconds = excluded.trailing_silence_seconds,
+  content_start_seconds = excluded.content_start_seconds,
+  content_end_seconds = excluded.content_end_seconds,
+  abrupt_start = excluded.abrupt_start,
+  abrupt_end = excluded.abrupt_end;
+
+-- name: UpdateTrackAudioFingerprint :exec
+UPDATE track_audio_features
+SET file_size = ?, file_mtime = ?, audio_hash = ?
+WHERE track_id = ?;
+
+-- name: GetTrackMeasuredAudio :one
+SELECT measured_integrated_lufs, measured_true_peak, measured_tempo_bpm, measured_tempo_confidence
+FROM track_audio_features
+WHERE track_id = ?;
+
+-- name: UpdateTrackFileTags :exec
+UPDATE track_audio_features
+SET replaygain_track_gain_db = ?,
+    replaygain_track_peak = ?,
+    replaygain_album_gain_db = ?,
+    replaygain_album_peak = ?,
+    tag_bpm = ?,
+    effective_gain_db = ?,
+    effective_peak = ?,
+    effective_gain_source = ?,
+    effective_peak_source = ?,
+    effective_bpm = ?,
+    effective_bpm_source = ?
+WHERE track_id = ?;
+
+-- name: ListAudioFingerprintsByTrackIDs :many
+SELECT track_id, file_size, file_mtime, audio_hash, analyzer_version
+FROM track_audio_features
+WHERE track_id IN (sqlc.slice('track_ids'));
+
+-- name: ListAcousticFingerprints :many
+SELECT sqlc.embed(tracks), track_audio_features.acoustic_fingerprint
+FROM tracks
+JOIN track_audio_features ON track_audio_features.track_id = tracks.id
+WHERE tracks.deleted_at IS NULL
+  AND track_audio_features.acoustic_fingerprint IS NOT NULL
+ORDER BY tracks.id;
+
+-- name: ClearDuplicateGroups :exec
+UPDATE track_audio_features
+SET duplicate_group_id = NULL
+WHERE duplicate_group_id IS NOT NULL;
+
+-- name: SetDuplicateGroup :exec
+UPDATE track_audio_features
+SET duplicate_group_id = ?
+WHERE track_id = ?;
+
+-- name: UpdateAlbumFlowThrough :exec
+-- A track flows into the next one on its album when it ends abruptly with
+-- almost no trailing silence and the next track starts the same way, as on
+-- live and concept albums.
+UPDATE track_audio_features
+SET flows_into_next = EXISTS (
+  SELECT 1
+  FROM tracks AS this_track
+  JOIN tracks AS next_track
+    ON next_track.album_id = this_track.album_id
+   AND COALESCE(next_track.disc_number, 1) = COALESCE(this_track.disc_number, 1)
+   AND next_track.track_number = this_track.track_number + 1
+   AND next_track.deleted_at IS NULL
+  JOIN track_audio_features AS next_features ON next_features.track_id = next_track.id
+  WHERE this_track.id = track_audio_features.track_id
+    AND track_audio_features.abrupt_end = 1
+    AND track_audio_features.trailing_silence_seconds <= ?
+    AND next_features.abrupt_start = 1
+    AND next_features.leading_silence_seconds <= ?
+);
+
+-- name: UpdateTrackEffectiveGain :exec
+UPDATE track_audio_features
+SET effective_gain_db = ?, effective_peak = ?, effective_gain_source = ?, effective_peak_source = ?
+WHERE track_id = ?;
 
 -- name: CreateAudioProcessingRun :one
 INSERT INTO audio_processing_runs (started_at, status)
@@ -96,7 +241,7 @@ RETURNING id;
 
 -- name: CompleteAudioProcessingRun :exec
 UPDATE audio_processing_runs
-SET completed_at = ?, status = ?, jobs_claimed = ?, jobs_completed = ?, jobs_failed = ?
+SET completed_at = ?, status = ?, jobs_claimed = ?, jobs_completed = ?, jobs_failed = ?, jobs_skipped = ?
 WHERE id = ?;
 
 -- name: EnsureTrackAudioJob :exec
@@ -107,9 +252,10 @@ INSERT INTO track_audio_analysis (
   error,
   attempts,
   last_attempt_at,
+  next_attempt_at,
   claimed_at,
   claimed_by
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(track_id) WHERE status IN ('pending', 'processing') DO UPDATE SET
   status = CASE
     WHEN track_audio_analysis.status = 'processing' THEN 'processing'
@@ -131,6 +277,10 @@ ON CONFLICT(track_id) WHERE status IN ('pending', 'processing') DO UPDATE SET
     WHEN track_audio_analysis.status = 'processing' THEN track_audio_analysis.last_attempt_at
     ELSE excluded.last_attempt_at
   END,
+  next_attempt_at = CASE
+    WHEN track_audio_analysis.status = 'processing' THEN track_audio_analysis.next_attempt_at
+    ELSE excluded.next_attempt_at
+  END,
   claimed_at = CASE
     WHEN track_audio_analysis.status = 'processing' THEN track_audio_analysis.claimed_at
     ELSE excluded.claimed_at
@@ -148,9 +298,10 @@ INSERT INTO track_embedding_jobs (
   error,
   attempts,
   last_attempt_at,
+  next_attempt_at,
   claimed_at,
   claimed_by
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(track_id) WHERE status IN ('pending', 'processing') DO UPDATE SET
   status = CASE
     WHEN track_embedding_jobs.status = 'processing' THEN 'processing'
@@ -172,6 +323,10 @@ ON CONFLICT(track_id) WHERE status IN ('pending', 'processing') DO UPDATE SET
     WHEN track_embedding_jobs.status = 'processing' THEN track_embedding_jobs.last_attempt_at
     ELSE excluded.last_attempt_at
   END,
+  next_attempt_at = CASE
+    WHEN track_embedding_jobs.status = 'processing' THEN track_embedding_jobs.next_attempt_at
+    ELSE excluded.next_attempt_at
+  END,
   claimed_at = CASE
     WHEN track_embedding_jobs.status = 'processing' THEN track_embedding_jobs.claimed_at
     ELSE excluded.claimed_at
@@ -186,16 +341,27 @@ UPDATE track_audio_analysis
 SET status = 'processing',
     claimed_at = ?,
     claimed_by = ?,
+    attempts = attempts + 1,
+    last_attempt_at = ?,
+    next_attempt_at = NULL,
     error = NULL
 WHERE id IN (
-  SELECT id
+  SELECT track_audio_analysis.id
   FROM track_audio_analysis
-  WHERE track_audio_analysis.status = 'pending'
-     OR (
-       track_audio_analysis.status = 'processing'
-       AND track_audio_analysis.claimed_at IS NOT NULL
-       AND track_audio_analysis.claimed_at <= ?
-     )
+  JOIN tracks ON tracks.id = track_audio_analysis.track_id
+  WHERE tracks.deleted_at IS NULL
+    AND (
+      (
+        track_audio_analysis.status = 'pending'
+        AND (track_audio_analysis.next_attempt_at IS NULL OR track_audio_analysis.next_attempt_at <= ?)
+      )
+      OR (
+        track_audio_analysis.status = 'processing'
+        AND track_audio_analysis.claimed_at IS NOT NULL
+        AND track_audio_analysis.claimed_at <= ?
+        AND track_audio_analysis.attempts < ?
+      )
+    )
   ORDER BY track_audio_analysis.created_at, track_audio_analysis.id
   LIMIT ?
 )
@@ -217,6 +383,7 @@ SELECT
 FROM track_audio_analysis
 JOIN tracks ON tracks.id = track_audio_analysis.track_id
 WHERE track_audio_analysis.status = 'pending'
+  AND tracks.deleted_at IS NULL
 ORDER BY track_audio_analysis.created_at, track_audio_analysis.id
 LIMIT ?;
 
@@ -225,8 +392,90 @@ UPDATE track_audio_analysis
 SET status = ?,
     processed_at = ?,
     error = ?,
-    attempts = attempts + 1,
-    last_attempt_at = ?,
+    next_attempt_at = ?,
     claimed_at = ?,
     claimed_by = ?
 WHERE id = ?;
+
+-- name: GetAudioJobAttempts :one
+SELECT attempts FROM track_audio_analysis WHERE id = ?;
+
+-- name: MarkExhaustedAudioJobsDead :exec
+UPDATE track_audio_analysis
+SET status = 'dead',
+    error = 'claim expired on the final attempt',
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE status = 'processing'
+  AND claimed_at IS NOT NULL
+  AND claimed_at <= ?
+  AND attempts >= ?;
+
+-- name: ReleaseAudioJobs :exec
+-- Claimed jobs that were never started go back to pending and get back the
+-- attempt their claim counted.
+UPDATE track_audio_analysis
+SET status = 'pending',
+    attempts = MAX(attempts - 1, 0),
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE id IN (sqlc.slice('job_ids'))
+  AND status = 'processing';
+
+-- name: RequeueOutdatedAudioJobs :execrows
+-- Completed jobs of tracks whose features come from an older analyzer go back
+-- to pending. Failed and dead jobs are left to jobs retry.
+UPDATE track_audio_analysis
+SET status = 'pending',
+    processed_at = NULL,
+    error = NULL,
+    attempts = 0,
+    last_attempt_at = NULL,
+    next_attempt_at = NULL,
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE status = 'completed'
+  AND track_id IN (
+    SELECT track_audio_features.track_id
+    FROM track_audio_features
+    JOIN tracks ON tracks.id = track_audio_features.track_id
+    WHERE tracks.deleted_at IS NULL
+      AND (track_audio_features.analyzer_version IS NULL OR track_audio_features.analyzer_version < ?)
+  )
+  AND id = (
+    SELECT MAX(latest.id)
+    FROM track_audio_analysis AS latest
+    WHERE latest.track_id = track_audio_analysis.track_id
+  );
+
+-- name: ListTracksWithAudioFeaturesByIDs :many
+SELECT
+  sqlc.embed(tracks),
+  track_audio_features.file_duration_seconds,
+  track_audio_features.measured_integrated_lufs,
+  track_audio_features.measured_true_peak,
+  track_audio_features.effective_gain_db,
+  track_audio_features.effective_gain_source,
+  track_audio_features.effective_bpm,
+  track_audio_features.effective_bpm_source,
+  track_audio_features.camelot_key,
+  track_audio_features.measured_loudness_range,
+  track_audio_features.spectral_centroid_hz,
+  track_audio_features.spectral_rolloff_hz,
+  track_audio_features.crest_factor_db,
+  track_audio_features.zero_crossing_rate,
+  track_audio_features.onset_density,
+  track_audio_features.duplicate_group_id,
+  track_audio_features.content_start_seconds,
+  track_audio_features.content_end_seconds,
+  track_audio_features.flows_into_next,
+  track_user_stats.starred_at,
+  track_user_stats.rating,
+  track_user_stats.play_count,
+  track_user_stats.last_played_at
+FROM tracks
+LEFT JOIN track_audio_features ON track_audio_features.track_id = tracks.id
+LEFT JOIN track_user_stats ON track_user_stats.track_id = tracks.id
+WHERE tracks.deleted_at IS NULL
+  AND tracks.id IN (sqlc.slice('track_ids'))
+ORDER BY tracks.id;
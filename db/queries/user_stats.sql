@@ -0,0 +1,19 @@
+-- name: ListTrackUserStats :many
+SELECT track_id, starred_at, rating, play_count, last_played_at
+FROM track_user_stats;
+
+-- name: UpsertTrackUserStats :exec
+INSERT INTO track_user_stats (
+  track_id,
+  starred_at,
+  rating,
+  play_count,
+  last_played_at,
+  updated_at
+) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(track_id) DO UPDATE SET
+  starred_at = excluded.starred_at,
+  rating = excluded.rating,
+  play_count = excluded.play_count,
+  last_played_at = excluded.last_played_at,
+  updated_at = excluded.updated_at;
@@ -28,6 +28,63 @@ type Track struct {
 	Suffix      string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	Stats       UserStats
+	Extended    ExtendedMetadata
+}
+
+// UserStats are the listening signals Navidrome keeps for the syncing user.
+// They are tracked separately from metadata so a new rating or play does not
+// count as a metadata change.
+type UserStats struct {
+	// StarredAt is zero when the track is not starred.
+	StarredAt time.Time
+	// Rating is 1-5, or 0 when unrated.
+	Rating       int
+	PlayCount    int64
+	LastPlayedAt time.Time
+}
+
+// ExtendedMetadata holds the OpenSubsonic song fields. It is empty when the
+// server does not support the OpenSubsonic extensions.
+type ExtendedMetadata struct {
+	MusicBrainzID string
+	SortName      string
+	ISRC          []string
+	// BPM is 0 when the server does not know the tempo.
+	BPM          int
+	Moods        []string
+	Genres       []string
+	Artists      []ArtistCredit
+	AlbumArtists []ArtistCredit
+	ReplayGain   ReplayGain
+}
+
+// IsZero reports whether no extended field is set.
+func (m ExtendedMetadata) IsZero() bool {
+	return m.MusicBrainzID == "" &&
+		m.SortName == "" &&
+		len(m.ISRC) == 0 &&
+		m.BPM == 0 &&
+		len(m.Moods) == 0 &&
+		len(m.Genres) == 0 &&
+		len(m.Artists) == 0 &&
+		len(m.AlbumArtists) == 0 &&
+		m.ReplayGain == (ReplayGain{})
+}
+
+// ArtistCredit is one entry of a track's artist or album artist list.
+type ArtistCredit struct {
+	ID   string
+	Name string
+}
+
+// ReplayGain is the server-reported ReplayGain for a track. Nil fields are
+// unknown.
+type ReplayGain struct {
+	TrackGainDB *float64
+	TrackPeak   *float64
+	AlbumGainDB *float64
+	AlbumPeak   *float64
 }
 
 // NavidromePort fetches tracks from Navidrome.
@@ -35,9 +92,44 @@ type NavidromePort interface {
 	ListTracks(ctx context.Context) ([]Track, error)
 }
 
-// TrackStore persists tracks to storage.
+// TrackStore persists tracks to storage and keeps a history of sync runs.
 type TrackStore interface {
-	SaveTracks(ctx context.Context, tracks []Track) (SaveStats, error)
+	// StartSync records a new in-progress sync run and returns its ID.
+	StartSync(ctx context.Context, startedAt time.Time) (int64, error)
+	// SaveTracks persists tracks for the run and marks it completed. On error
+	// the returned stats count the tracks handled before the failure.
+	SaveTracks(ctx context.Context, syncID int64, tracks []Track) (SaveStats, error)
+	// FailSync marks the run as failed.
+	FailSync(ctx context.Context, syncID int64, failure SyncFailure) error
+}
+
+// Sync phases recorded when a run fails.
+const (
+	SyncPhaseFetch  = "fetch"
+	SyncPhaseUpsert = "upsert"
+	SyncPhaseDelete = "delete"
+)
+
+// PhaseError tags a sync error with the phase it happened in.
+type PhaseError struct {
+	Phase string
+	Err   error
+}
+
+func (e *PhaseError) Error() string {
+	return e.Phase + ": " + e.Err.Error()
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// SyncFailure describes why a sync run failed.
+type SyncFailure struct {
+	Phase string
+	Err   error
+	// Processed counts the fetched tracks handled before the failure.
+	Processed int
 }
 
 // SaveStats reports sync outcomes.
@@ -45,7 +137,13 @@ type SaveStats struct {
 	Fetched int
 	Updated int
 	Skipped int
+	// Deleted counts tracks tombstoned because Navidrome no longer lists them.
 	Deleted int
+	// Restored counts tombstoned tracks that reappeared in Navidrome.
+	Restored int
+	// StatsUpdated counts tracks whose user stats changed, independently of
+	// whether their metadata was updated or skipped.
+	StatsUpdated int
 }
 
 // Dependencies groups external adapters required by the App.
@@ -72,23 +170,58 @@ func New(deps Dependencies) (*App, error) {
 	}, nil
 }
 
-// SyncTracks pulls tracks from Navidrome and optionally persists them.
+// SyncTracks pulls tracks from Navidrome and optionally persists them. With a
+// store configured every run is recorded, including runs that fail.
 func (a *App) SyncTracks(ctx context.Context) (SaveStats, error) {
+	var syncID int64
+	if a.store != nil {
+		id, err := a.store.StartSync(ctx, time.Now().UTC())
+		if err != nil {
+			return SaveStats{}, fmt.Errorf("start sync: %w", err)
+		}
+		syncID = id
+	}
+
 	tracks, err := a.navidrome.ListTracks(ctx)
 	if err != nil {
-		return SaveStats{}, fmt.Errorf("fetch tracks: %w", err)
+		err = fmt.Errorf("fetch tracks: %w", err)
+		return SaveStats{}, a.failSync(ctx, syncID, SyncFailure{Phase: SyncPhaseFetch, Err: err})
 	}
 
 	stats := SaveStats{Fetched: len(tracks)}
 	if a.store != nil {
-		storeStats, err := a.store.SaveTracks(ctx, tracks)
+		storeStats, err := a.store.SaveTracks(ctx, syncID, tracks)
 		if err != nil {
-			return SaveStats{}, fmt.Errorf("save tracks: %w", err)
+			phase := SyncPhaseUpsert
+			var phaseErr *PhaseError
+			if errors.As(err, &phaseErr) {
+				phase = phaseErr.Phase
+			}
+			err = fmt.Errorf("save tracks: %w", err)
+			return SaveStats{}, a.failSync(ctx, syncID, SyncFailure{
+				Phase:     phase,
+				Err:       err,
+				Processed: storeStats.Updated + storeStats.Skipped,
+			})
 		}
 		stats.Updated = storeStats.Updated
 		stats.Skipped = storeStats.Skipped
 		stats.Deleted = storeStats.Deleted
+		stats.Restored = storeStats.Restored
+		stats.StatsUpdated = storeStats.StatsUpdated
 	}
 
 	return stats, nil
 }
+
+// failSync records the failure and returns its error. The record is written
+// even when ctx is canceled, so interrupted runs show up in the history.
+func (a *App) failSync(ctx context.Context, syncID int64, failure SyncFailure) error {
+	if a.store == nil {
+		return failure.Err
+	}
+	if err := a.store.FailSync(context.WithoutCancel(ctx), syncID, failure); err != nil {
+		return errors.Join(failure.Err, fmt.Errorf("record failed sync: %w", err))
+	}
+	return failure.Err
+}
@@ -3,7 +3,9 @@ package app
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewAppRequiresNavidrome(t *testing.T) {
@@ -31,6 +33,29 @@ func TestSyncTracks(t *testing.T) {
 		}
 	})
 
+	t.Run("records fetch failures", func(t *testing.T) {
+		store := &storeStub{}
+		app, err := New(Dependencies{
+			Navidrome: navidromeStub(func(ctx context.Context) ([]Track, error) {
+				return nil, errors.New("boom")
+			}),
+			Store: store,
+		})
+		if err != nil {
+			t.Fatalf("new app: %v", err)
+		}
+
+		if _, err := app.SyncTracks(context.Background()); err == nil {
+			t.Fatalf("expected fetch error")
+		}
+		if store.saved {
+			t.Fatalf("expected no save after a failed fetch")
+		}
+		if store.failure == nil || store.failure.Phase != SyncPhaseFetch || store.failedID != store.syncID {
+			t.Fatalf("unexpected recorded failure %+v for sync %d", store.failure, store.failedID)
+		}
+	})
+
 	t.Run("persists when store configured", func(t *testing.T) {
 		store := &storeStub{}
 		app, err := New(Dependencies{
@@ -59,11 +84,15 @@ func TestSyncTracks(t *testing.T) {
 	})
 
 	t.Run("store error propagated", func(t *testing.T) {
+		store := &storeStub{
+			saveStats: SaveStats{Updated: 1, Skipped: 2},
+			saveErr:   &PhaseError{Phase: SyncPhaseDelete, Err: errors.New("save failed")},
+		}
 		app, err := New(Dependencies{
 			Navidrome: navidromeStub(func(ctx context.Context) ([]Track, error) {
 				return tracks, nil
 			}),
-			Store: storeStubErr{err: errors.New("save failed")},
+			Store: store,
 		})
 		if err != nil {
 			t.Fatalf("new app: %v", err)
@@ -71,6 +100,47 @@ func TestSyncTracks(t *testing.T) {
 		if _, err := app.SyncTracks(context.Background()); err == nil {
 			t.Fatalf("expected store error")
 		}
+		if store.failure == nil || store.failure.Phase != SyncPhaseDelete || store.failure.Processed != 3 {
+			t.Fatalf("unexpected recorded failure %+v", store.failure)
+		}
+	})
+
+	t.Run("failure is recorded after cancellation", func(t *testing.T) {
+		store := &storeStub{}
+		ctx, cancel := context.WithCancel(context.Background())
+		app, err := New(Dependencies{
+			Navidrome: navidromeStub(func(ctx context.Context) ([]Track, error) {
+				cancel()
+				return nil, ctx.Err()
+			}),
+			Store: store,
+		})
+		if err != nil {
+			t.Fatalf("new app: %v", err)
+		}
+		if _, err := app.SyncTracks(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected cancellation, got %v", err)
+		}
+		if store.failure == nil || store.failCtxErr != nil {
+			t.Fatalf("expected failure recorded with a live context, got %+v (ctx err %v)", store.failure, store.failCtxErr)
+		}
+	})
+
+	t.Run("error recording failure is joined", func(t *testing.T) {
+		store := &storeStub{failErr: errors.New("disk full")}
+		app, err := New(Dependencies{
+			Navidrome: navidromeStub(func(ctx context.Context) ([]Track, error) {
+				return nil, errors.New("boom")
+			}),
+			Store: store,
+		})
+		if err != nil {
+			t.Fatalf("new app: %v", err)
+		}
+		_, err = app.SyncTracks(context.Background())
+		if err == nil || !strings.Contains(err.Error(), "boom") || !strings.Contains(err.Error(), "disk full") {
+			t.Fatalf("expected both errors, got %v", err)
+		}
 	})
 }
 
@@ -81,18 +151,32 @@ func (n navidromeStub) ListTracks(ctx context.Context) ([]Track, error) {
 }
 
 type storeStub struct {
-	saved bool
+	syncID     int64
+	saved      bool
+	saveStats  SaveStats
+	saveErr    error
+	failedID   int64
+	failure    *SyncFailure
+	failErr    error
+	failCtxErr error
 }
 
-func (s *storeStub) SaveTracks(ctx context.Context, tracks []Track) (SaveStats, error) {
-	s.saved = true
-	return SaveStats{Updated: len(tracks)}, nil
+func (s *storeStub) StartSync(ctx context.Context, startedAt time.Time) (int64, error) {
+	s.syncID = 42
+	return s.syncID, nil
 }
 
-type storeStubErr struct {
-	err error
+func (s *storeStub) SaveTracks(ctx context.Context, syncID int64, tracks []Track) (SaveStats, error) {
+	s.saved = true
+	if s.saveErr != nil {
+		return s.saveStats, s.saveErr
+	}
+	return SaveStats{Updated: len(tracks)}, nil
 }
 
-func (e storeStubErr) SaveTracks(ctx context.Context, tracks []Track) (SaveStats, error) {
-	return SaveStats{}, e.err
+func (s *storeStub) FailSync(ctx context.Context, syncID int64, failure SyncFailure) error {
+	s.failedID = syncID
+	s.failure = &failure
+	s.failCtxErr = ctx.Err()
+	return s.failErr
 }
@@ -0,0 +1,260 @@
+package audio
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"sort"
+)
+
+const (
+	// acousticFrameSize is about 370 ms at tempoSampleRate, and frames start
+	// every acousticHopSize samples, about 46 ms, so neighbouring
+	// sub-fingerprints overlap and survive small misalignments.
+	acousticFrameSize = 4096
+	acousticHopSize   = 512
+	// acousticBands log-spaced bands between acousticMinHz and acousticMaxHz
+	// give the 32 energy differences of each sub-fingerprint.
+	acousticBands = 33
+	acousticMinHz = 300
+	acousticMaxHz = 2000
+	// acousticMinFrames is about three seconds of audio.
+	acousticMinFrames = 64
+	// acousticMaxOffset is how far, about ten seconds, two fingerprints are
+	// shifted against each other when compared, covering releases that add
+	// or trim lead-in silence.
+	acousticMaxOffset = 215
+	// acousticSilence is the frame energy below which leading and trailing
+	// frames are trimmed.
+	acousticSilence = 1e-6
+)
+
+// AcousticFingerprint is a compact hash of how a recording sounds: one 32-bit
+// sub-fingerprint per frame, each bit the sign of how the energy difference of
+// two neighbouring bands changed since the previous frame (Haitsma and
+// Kalker). The same recording on another release or in another format
+// differs in a few bits; unrelated recordings differ in about half.
+type AcousticFingerprint []uint32
+
+// DetectAcousticFingerprint computes the fingerprint of mono samples after
+// trimming leading and trailing silence. ok is false when fewer than three
+// seconds of sound remain.
+func DetectAcousticFingerprint(samples []float32, sampleRate int) (AcousticFingerprint, bool) {
+	if sampleRate <= 0 || len(samples) < acousticFrameSize {
+		return nil, false
+	}
+	bandOf := make([]int, acousticFrameSize/2)
+	for bin := range bandOf {
+		bandOf[bin] = -1
+		freq := float64(bin) * float64(sampleRate) / acousticFrameSize
+		if freq < acousticMinHz || freq >= acousticMaxHz {
+			continue
+		}
+		bandOf[bin] = min(acousticBands-1, int(acousticBands*math.Log(freq/acousticMinHz)/math.Log(acousticMaxHz/acousticMinHz)))
+	}
+	window := make([]float64, acousticFrameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/(acousticFrameSize-1))
+	}
+
+	var energies [][acousticBands]float64
+	var loudness []float64
+	buf := make([]complex128, acousticFrameSize)
+	for start := 0; start+acousticFrameSize <= len(samples); start += acousticHopSize {
+		for i := range buf {
+			buf[i] = complex(float64(samples[start+i])*window[i], 0)
+		}
+		fft(buf)
+		var bands [acousticBands]float64
+		total := 0.0
+		for bin, band := range bandOf {
+			if band < 0 {
+				continue
+			}
+			power := cmplx.Abs(buf[bin])
+			power *= power
+			bands[band] += power
+			total += power
+		}
+		energies = append(energies, bands)
+		loudness = append(loudness, total/acousticFrameSize)
+	}
+
+	first, last := 0, len(energies)-1
+	for first <= last && loudness[first] < acousticSilence {
+		first++
+	}
+	for last >= first && loudness[last] < acousticSilence {
+		last--
+	}
+	if last-first < acousticMinFrames {
+		return nil, false
+	}
+
+	fingerprint := make(AcousticFingerprint, 0, last-first)
+	for n := first + 1; n <= last; n++ {
+		var word uint32
+		for m := 0; m < acousticBands-1; m++ {
+			now := energies[n][m] - energies[n][m+1]
+			before := energies[n-1][m] - energies[n-1][m+1]
+			if now-before > 0 {
+				word |= 1 << m
+			}
+		}
+		fingerprint = append(fingerprint, word)
+	}
+	return fingerprint, true
+}
+
+// Similarity compares two fingerprints at every alignment up to about ten
+// seconds apart and returns the best share of matching bits: about 0.5 for
+// unrelated audio and 1 for identical audio. Alignments overlapping by fewer
+// than three seconds are ignored, and 0 is returned when none is left.
+func (f AcousticFingerprint) Similarity(other AcousticFingerprint) float64 {
+	best := 0.0
+	for offset := -acousticMaxOffset; offset <= acousticMaxOffset; offset++ {
+		a, b := f, other
+		if offset > 0 {
+			if offset >= len(a) {
+				continue
+			}
+			a = a[offset:]
+		} else if offset < 0 {
+			if -offset >= len(b) {
+				continue
+			}
+			b = b[-offset:]
+		}
+		n := min(len(a), len(b))
+		if n < acousticMinFrames {
+			continue
+		}
+		differing := 0
+		for i := 0; i < n; i++ {
+			differing += bits.OnesCount32(a[i] ^ b[i])
+		}
+		best = math.Max(best, 1-float64(differing)/float64(32*n))
+	}
+	return best
+}
+
+const (
+	// duplicateMinSharedHashes is how many identical sub-fingerprints two
+	// tracks must share before they are compared in full.
+	duplicateMinSharedHashes = 2
+	// duplicateMaxPostings skips sub-fingerprints found in more tracks than
+	// this, which come from silence or test tones rather than one recording.
+	duplicateMaxPostings = 50
+)
+
+// FingerprintedTrack is one track offered to GroupDuplicates.
+type FingerprintedTrack struct {
+	TrackID     int64
+	Fingerprint AcousticFingerprint
+}
+
+// DuplicateGroup is a set of tracks that are versions of one recording.
+// Members are ordered by track ID and the first one's ID names the group.
+type DuplicateGroup struct {
+	Members []DuplicateMember
+}
+
+// ID is the lowest track ID in the group.
+func (g DuplicateGroup) ID() int64 {
+	return g.Members[0].TrackID
+}
+
+// DuplicateMember is a track in a DuplicateGroup with its highest Similarity
+// to another member.
+type DuplicateMember struct {
+	TrackID    int64
+	Similarity float64
+}
+
+// GroupDuplicates groups tracks whose fingerprints are at least threshold
+// similar, joining chains of matches into one group. Only pairs sharing
+// identical sub-fingerprints are compared, so the whole library need not be
+// compared pairwise. Groups are ordered by ID.
+func GroupDuplicates(tracks []FingerprintedTrack, threshold float64) []DuplicateGroup {
+	type posting struct {
+		hash  uint32
+		track int
+	}
+	var postings []posting
+	for i, track := range tracks {
+		seen := make(map[uint32]struct{}, len(track.Fingerprint))
+		for _, hash := range track.Fingerprint {
+			if _, ok := seen[hash]; ok {
+				continue
+			}
+			seen[hash] = struct{}{}
+			postings = append(postings, posting{hash: hash, track: i})
+		}
+	}
+	sort.Slice(postings, func(i, j int) bool {
+		if postings[i].hash != postings[j].hash {
+			return postings[i].hash < postings[j].hash
+		}
+		return postings[i].track < postings[j].track
+	})
+
+	shared := make(map[[2]int]int)
+	for start := 0; start < len(postings); {
+		end := start + 1
+		for end < len(postings) && postings[end].hash == postings[start].hash {
+			end++
+		}
+		if n := end - start; n > 1 && n <= duplicateMaxPostings {
+			for a := start; a < end; a++ {
+				for b := a + 1; b < end; b++ {
+					shared[[2]int{postings[a].track, postings[b].track}]++
+				}
+			}
+		}
+		start = end
+	}
+
+	parent := make([]int, len(tracks))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	best := make([]float64, len(tracks))
+	for pair, count := range shared {
+		if count < duplicateMinSharedHashes {
+			continue
+		}
+		a, b := pair[0], pair[1]
+		similarity := tracks[a].Fingerprint.Similarity(tracks[b].Fingerprint)
+		if similarity < threshold {
+			continue
+		}
+		best[a] = math.Max(best[a], similarity)
+		best[b] = math.Max(best[b], similarity)
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[ra] = rb
+		}
+	}
+
+	members := make(map[int][]DuplicateMember)
+	for i, track := range tracks {
+		if best[i] == 0 {
+			continue
+		}
+		root := find(i)
+		members[root] = append(members[root], DuplicateMember{TrackID: track.TrackID, Similarity: best[i]})
+	}
+	groups := make([]DuplicateGroup, 0, len(members))
+	for _, group := range members {
+		sort.Slice(group, func(i, j int) bool { return group[i].TrackID < group[j].TrackID })
+		groups = append(groups, DuplicateGroup{Members: group})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID() < groups[j].ID() })
+	return groups
+}
@@ -0,0 +1,107 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAcousticFingerprintMatchesOtherReleasesOfARecording(t *testing.T) {
+	original := melody(1, 60)
+	// A remaster: quieter, slightly noisy and with an extra second and a half
+	// of silence before the music starts.
+	rng := rand.New(rand.NewSource(9))
+	remaster := make([]float32, 3*tempoSampleRate/2, 3*tempoSampleRate/2+len(original))
+	for _, s := range original {
+		remaster = append(remaster, 0.6*s+float32(0.01*(rng.Float64()*2-1)))
+	}
+
+	a, ok := DetectAcousticFingerprint(original, tempoSampleRate)
+	if !ok {
+		t.Fatalf("expected a fingerprint")
+	}
+	b, ok := DetectAcousticFingerprint(remaster, tempoSampleRate)
+	if !ok {
+		t.Fatalf("expected a fingerprint for the remaster")
+	}
+	other, _ := DetectAcousticFingerprint(melody(2, 60), tempoSampleRate)
+
+	if got := a.Similarity(b); got < 0.75 {
+		t.Fatalf("expected the remaster to match, similarity %v", got)
+	}
+	if got := a.Similarity(other); got > 0.65 {
+		t.Fatalf("expected a different recording not to match, similarity %v", got)
+	}
+	if got := a.Similarity(a[:10]); got != 0 {
+		t.Fatalf("expected too short an overlap to score 0, got %v", got)
+	}
+}
+
+func TestDetectAcousticFingerprintRejectsSilence(t *testing.T) {
+	if _, ok := DetectAcousticFingerprint(make([]float32, 10*tempoSampleRate), tempoSampleRate); ok {
+		t.Fatalf("expected silence to be rejected")
+	}
+	if _, ok := DetectAcousticFingerprint(melody(1, 1), tempoSampleRate); ok {
+		t.Fatalf("expected a one-second clip to be rejected")
+	}
+}
+
+func TestGroupDuplicates(t *testing.T) {
+	fingerprint := func(samples []float32) AcousticFingerprint {
+		f, ok := DetectAcousticFingerprint(samples, tempoSampleRate)
+		if !ok {
+			t.Fatalf("expected a fingerprint")
+		}
+		return f
+	}
+	song := melody(1, 30)
+	rng := rand.New(rand.NewSource(9))
+	remaster := make([]float32, len(song))
+	for i, s := range song {
+		remaster[i] = 0.5*s + float32(0.01*(rng.Float64()*2-1))
+	}
+	tracks := []FingerprintedTrack{
+		{TrackID: 7, Fingerprint: fingerprint(song)},
+		{TrackID: 3, Fingerprint: fingerprint(melody(2, 30))},
+		{TrackID: 5, Fingerprint: fingerprint(remaster)},
+		{TrackID: 9, Fingerprint: fingerprint(melody(3, 30))},
+		{TrackID: 4, Fingerprint: fingerprint(song[tempoSampleRate:])},
+	}
+
+	groups := GroupDuplicates(tracks, 0.7)
+	if len(groups) != 1 {
+		t.Fatalf("expected one duplicate group, got %+v", groups)
+	}
+	group := groups[0]
+	if group.ID() != 4 || len(group.Members) != 3 || group.Members[1].TrackID != 5 || group.Members[2].TrackID != 7 {
+		t.Fatalf("unexpected group %+v", group)
+	}
+	for _, member := range group.Members {
+		if member.Similarity < 0.7 || member.Similarity > 1 {
+			t.Fatalf("unexpected similarity %+v", member)
+		}
+	}
+	if groups := GroupDuplicates(tracks[1:2], 0.7); len(groups) != 0 {
+		t.Fatalf("expected no groups for one track, got %+v", groups)
+	}
+}
+
+// melody is a seeded sequence of plucked two-note chords between 300 and 2000
+// Hz, standing in for a recording.
+func melody(seed int64, seconds float64) []float32 {
+	rng := rand.New(rand.NewSource(seed))
+	samples := make([]float32, int(seconds*tempoSampleRate))
+	noteLength := tempoSampleRate / 4
+	for start := 0; start < len(samples); start += noteLength {
+		freqs := []float64{300 + rng.Float64()*1700, 300 + rng.Float64()*1700}
+		for i := 0; i < noteLength && start+i < len(samples); i++ {
+			envelope := math.Exp(-3 * float64(i) / float64(noteLength))
+			var v float64
+			for _, f := range freqs {
+				v += math.Sin(2 * math.Pi * f * float64(i) / tempoSampleRate)
+			}
+			samples[start+i] = float32(0.4 * envelope * v)
+		}
+	}
+	return samples
+}
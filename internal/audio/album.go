@@ -0,0 +1,61 @@
+package audio
+
+import "math"
+
+// ReplayGainReferenceLUFS is the loudness ReplayGain 2.0 normalizes to. Gains
+// derived from measurements are relative to it.
+const ReplayGainReferenceLUFS = -18.0
+
+// silenceGateLUFS is the absolute gate of EBU R128. Tracks measured at or
+// below it, which is how silence is stored, add nothing to an album's
+// loudness.
+const silenceGateLUFS = -70.0
+
+// AlbumTrackLoudness is what was measured for one track of an album.
+type AlbumTrackLoudness struct {
+	DurationSeconds float64
+	IntegratedLUFS  float64
+	TruePeak        *float64
+}
+
+// AlbumLoudness is the loudness of an album as a whole, aggregated from its
+// tracks. TruePeak is nil when no track has a measured peak.
+type AlbumLoudness struct {
+	IntegratedLUFS float64
+	TruePeak       *float64
+}
+
+// GainDB is the ReplayGain-style album gain: the change in level that brings
+// the album to ReplayGainReferenceLUFS.
+func (a AlbumLoudness) GainDB() float64 {
+	return ReplayGainReferenceLUFS - a.IntegratedLUFS
+}
+
+// AggregateAlbumLoudness combines track measurements into album loudness. The
+// integrated loudness is the duration-weighted mean of the tracks' power,
+// which is what measuring the album as one programme gives up to gating, and
+// the true peak is the highest of the tracks'. ok is false when every track
+// is silent.
+func AggregateAlbumLoudness(tracks []AlbumTrackLoudness) (AlbumLoudness, bool) {
+	var (
+		power    float64
+		duration float64
+		album    AlbumLoudness
+	)
+	for _, track := range tracks {
+		if track.TruePeak != nil && (album.TruePeak == nil || *track.TruePeak > *album.TruePeak) {
+			peak := *track.TruePeak
+			album.TruePeak = &peak
+		}
+		if track.IntegratedLUFS <= silenceGateLUFS || track.DurationSeconds <= 0 {
+			continue
+		}
+		power += track.DurationSeconds * math.Pow(10, track.IntegratedLUFS/10)
+		duration += track.DurationSeconds
+	}
+	if duration == 0 {
+		return AlbumLoudness{}, false
+	}
+	album.IntegratedLUFS = 10 * math.Log10(power/duration)
+	return album, true
+}
@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAggregateAlbumLoudness(t *testing.T) {
+	peak := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name     string
+		tracks   []AlbumTrackLoudness
+		wantLUFS float64
+		wantPeak *float64
+		ok       bool
+	}{
+		{
+			name: "equal tracks keep their loudness",
+			tracks: []AlbumTrackLoudness{
+				{DurationSeconds: 200, IntegratedLUFS: -14, TruePeak: peak(-1.5)},
+				{DurationSeconds: 100, IntegratedLUFS: -14, TruePeak: peak(-0.4)},
+			},
+			wantLUFS: -14,
+			wantPeak: peak(-0.4),
+			ok:       true,
+		},
+		{
+			name: "louder and longer tracks weigh more",
+			tracks: []AlbumTrackLoudness{
+				{DurationSeconds: 300, IntegratedLUFS: -10},
+				{DurationSeconds: 100, IntegratedLUFS: -20},
+			},
+			wantLUFS: 10 * math.Log10((300*math.Pow(10, -1)+100*math.Pow(10, -2))/400),
+			ok:       true,
+		},
+		{
+			name: "silent tracks are gated out",
+			tracks: []AlbumTrackLoudness{
+				{DurationSeconds: 200, IntegratedLUFS: -12},
+				{DurationSeconds: 30, IntegratedLUFS: -70},
+			},
+			wantLUFS: -12,
+			ok:       true,
+		},
+		{
+			name:   "silent album",
+			tracks: []AlbumTrackLoudness{{DurationSeconds: 30, IntegratedLUFS: -70}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := AggregateAlbumLoudness(tc.tracks)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %v, got %v", tc.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(got.IntegratedLUFS-tc.wantLUFS) > 1e-9 {
+				t.Fatalf("expected %v LUFS, got %v", tc.wantLUFS, got.IntegratedLUFS)
+			}
+			if (got.TruePeak == nil) != (tc.wantPeak == nil) || (got.TruePeak != nil && *got.TruePeak != *tc.wantPeak) {
+				t.Fatalf("unexpected true peak %v", got.TruePeak)
+			}
+		})
+	}
+}
+
+func TestAlbumLoudnessGainDB(t *testing.T) {
+	if got := (AlbumLoudness{IntegratedLUFS: -11.5}).GainDB(); got != -6.5 {
+		t.Fatalf("expected -6.5 dB, got %v", got)
+	}
+}
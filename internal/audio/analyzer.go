@@ -6,10 +6,20 @@ import (
 	"time"
 )
 
+// MeasuredAudio holds what was measured from the audio itself. LoudnessRange
+// is the EBU R128 loudness range (LRA) in LU. Optional measurements are nil
+// when the stage did not run or found nothing.
 type MeasuredAudio struct {
 	FileDurationSeconds float64
 	IntegratedLUFS      *float64
 	TruePeak            *float64
+	LoudnessRange       *float64
+	TempoBPM            *float64
+	TempoConfidence     *float64
+	Key                 *Key
+	Spectral            *SpectralFeatures
+	AcousticFingerprint AcousticFingerprint
+	Boundaries          *Boundaries
 }
 
 type RawReplayGain struct {
@@ -19,11 +29,26 @@ type RawReplayGain struct {
 	AlbumPeak   *float64
 }
 
+// FileTags are the tags read from the audio file itself.
+type FileTags struct {
+	ReplayGain RawReplayGain
+	BPM        *float64
+}
+
+// ServerTags are the values Navidrome reports for a track. They are used when
+// the file has no tags of its own.
+type ServerTags struct {
+	ReplayGain RawReplayGain
+	BPM        *float64
+}
+
 type EffectiveAudio struct {
-	GainDB     *float64
-	Peak       *float64
-	GainSource string
-	PeakSource string
+	GainDB      *float64
+	Peak        *float64
+	GainSource  string
+	PeakSource  string
+	TempoBPM    *float64
+	TempoSource string
 }
 
 type AnalysisResult struct {
@@ -31,69 +56,123 @@ type AnalysisResult struct {
 	FilePath   string
 	Measured   MeasuredAudio
 	ReplayGain RawReplayGain
+	TagBPM     *float64
 	Effective  EffectiveAudio
 }
 
-type ProbeRunner interface {
-	Measure(context.Context, string) (MeasuredAudio, error)
-}
-
-type ReplayGainReader interface {
-	Read(context.Context, string) (RawReplayGain, error)
+type TagReader interface {
+	Read(context.Context, string) (FileTags, error)
 }
 
+// Analyzer measures library files. Pass probes and decodes each file once,
+// and the PCM extractors, including the acoustic fingerprint and content
+// boundaries, run on the decoded samples. Hasher is used by Fingerprint and
+// Tags by FileTags; a nil Tags reads them with ffprobe.
 type Analyzer struct {
-	Root  string
-	Probe ProbeRunner
-	Tags  ReplayGainReader
-	Now   func() time.Time
+	Root   string
+	Pass   SinglePass
+	Hasher AudioHasher
+	Tags   TagReader
+	Now    func() time.Time
 }
 
-func (a Analyzer) Analyze(ctx context.Context, navPath string) (AnalysisResult, error) {
+// FileTags reads the ReplayGain and BPM tags of the file at navPath without
+// analyzing it, for files whose audio is unchanged since they were measured.
+func (a Analyzer) FileTags(ctx context.Context, navPath string) (FileTags, error) {
 	filePath, err := ResolveLibraryPath(a.Root, navPath)
 	if err != nil {
-		return AnalysisResult{}, err
+		return FileTags{}, err
 	}
-	if a.Probe == nil {
-		return AnalysisResult{}, fmt.Errorf("probe runner is required")
+	reader := a.Tags
+	if reader == nil {
+		reader = FFProbeTagReader{}
 	}
-	if a.Tags == nil {
-		return AnalysisResult{}, fmt.Errorf("replaygain reader is required")
+	tags, err := reader.Read(ctx, filePath)
+	if err != nil {
+		return FileTags{}, fmt.Errorf("read tags of %s: %w", filePath, err)
 	}
+	return tags, nil
+}
 
-	measured, err := a.Probe.Measure(ctx, filePath)
+// Analyze measures the file at navPath. server holds what Navidrome reports
+// for the track, used when the file has no tags of its own.
+func (a Analyzer) Analyze(ctx context.Context, navPath string, server ServerTags) (AnalysisResult, error) {
+	filePath, err := ResolveLibraryPath(a.Root, navPath)
 	if err != nil {
-		return AnalysisResult{}, fmt.Errorf("analyze %s: %w", filePath, err)
+		return AnalysisResult{}, err
+	}
+	if a.Pass == nil {
+		return AnalysisResult{}, fmt.Errorf("single pass is required")
 	}
-	rawTags, err := a.Tags.Read(ctx, filePath)
+	pass, err := a.Pass.Run(ctx, filePath)
 	if err != nil {
-		return AnalysisResult{
-			AnalyzedAt: timestamp(a.Now),
-			FilePath:   filePath,
-			Measured:   measured,
-			ReplayGain: RawReplayGain{},
-			Effective:  EffectiveValues(RawReplayGain{}, measured),
-		}, nil
+		return AnalysisResult{}, fmt.Errorf("analyze %s: %w", filePath, err)
+	}
+	measured := pass.Measured
+	measurePCM(&measured, pass.Samples, pass.SampleRate)
+	if boundaries, ok := DetectBoundaries(pass.Samples, pass.Tail, pass.TailStartSeconds, pass.SampleRate); ok {
+		measured.Boundaries = &boundaries
 	}
+	return a.result(filePath, measured, pass.Tags, server), nil
+}
 
+// measurePCM runs the tempo, key, spectral and acoustic fingerprint
+// extractors over decoded samples. Extractors that find nothing leave their
+// fields nil.
+func measurePCM(measured *MeasuredAudio, samples []float32, sampleRate int) {
+	if tempo, ok := DetectTempo(samples, sampleRate); ok {
+		measured.TempoBPM = &tempo.BPM
+		measured.TempoConfidence = &tempo.Confidence
+	}
+	if key, ok := DetectKey(samples, sampleRate); ok {
+		measured.Key = &key
+	}
+	if features, ok := DetectSpectral(samples, sampleRate); ok {
+		measured.Spectral = &features
+	}
+	if fingerprint, ok := DetectAcousticFingerprint(samples, sampleRate); ok {
+		measured.AcousticFingerprint = fingerprint
+	}
+}
+
+func (a Analyzer) result(filePath string, measured MeasuredAudio, tags FileTags, server ServerTags) AnalysisResult {
+	effective := EffectiveValues(tags.ReplayGain, server.ReplayGain, nil, measured)
+	effective.TempoBPM, effective.TempoSource = EffectiveTempo(tags.BPM, server.BPM, measured)
 	return AnalysisResult{
 		AnalyzedAt: timestamp(a.Now),
 		FilePath:   filePath,
 		Measured:   measured,
-		ReplayGain: rawTags,
-		Effective:  EffectiveValues(rawTags, measured),
-	}, nil
+		ReplayGain: tags.ReplayGain,
+		TagBPM:     tags.BPM,
+		Effective:  effective,
+	}
 }
 
-func EffectiveValues(raw RawReplayGain, measured MeasuredAudio) EffectiveAudio {
+// EffectiveValues picks the gain and peak to use for a track. File tags win
+// over server-reported ReplayGain, which wins over measured values. Among
+// measured values the album's loudness, when every track of its album has
+// been measured, wins over the track's own so untagged albums keep their
+// internal balance.
+func EffectiveValues(raw, server RawReplayGain, album *AlbumLoudness, measured MeasuredAudio) EffectiveAudio {
+	var albumGain, albumPeak *float64
+	if album != nil {
+		gain := album.GainDB()
+		albumGain, albumPeak = &gain, album.TruePeak
+	}
 	gain, gainSource := firstValue([]valueSource{
 		{raw.AlbumGainDB, "replaygain_album"},
 		{raw.TrackGainDB, "replaygain_track"},
+		{server.AlbumGainDB, "server_replaygain_album"},
+		{server.TrackGainDB, "server_replaygain_track"},
+		{albumGain, "measured_album"},
 		{measured.IntegratedLUFS, "measured_integrated_lufs"},
 	})
 	peak, peakSource := firstValue([]valueSource{
 		{raw.AlbumPeak, "replaygain_album"},
 		{raw.TrackPeak, "replaygain_track"},
+		{server.AlbumPeak, "server_replaygain_album"},
+		{server.TrackPeak, "server_replaygain_track"},
+		{albumPeak, "measured_album"},
 		{measured.TruePeak, "measured_true_peak"},
 	})
 	return EffectiveAudio{
@@ -31,7 +31,7 @@ func TestEffectiveValuesPreferReplayGainOverMeasuredValues(t *testing.T) {
 		TrackPeak:   &trackPeak,
 		AlbumGainDB: &albumGain,
 		AlbumPeak:   &albumPeak,
-	}, MeasuredAudio{
+	}, RawReplayGain{}, nil, MeasuredAudio{
 		IntegratedLUFS: &lufs,
 		TruePeak:       &peak,
 	})
@@ -47,7 +47,7 @@ func TestEffectiveValuesPreferReplayGainOverMeasuredValues(t *testing.T) {
 func TestEffectiveValuesFallBackToMeasuredValues(t *testing.T) {
 	lufs := -10.4
 	peak := 0.97
-	got := EffectiveValues(RawReplayGain{}, MeasuredAudio{
+	got := EffectiveValues(RawReplayGain{}, RawReplayGain{}, nil, MeasuredAudio{
 		IntegratedLUFS: &lufs,
 		TruePeak:       &peak,
 	})
@@ -59,6 +59,61 @@ func TestEffectiveValuesFallBackToMeasuredValues(t *testing.T) {
 	}
 }
 
+func TestEffectiveValuesUseServerReplayGainBetweenTagsAndMeasuredValues(t *testing.T) {
+	tagGain := -6.5
+	serverAlbumGain := -7.25
+	serverTrackGain := -8.0
+	serverTrackPeak := 0.88
+	lufs := -10.4
+	peak := 0.97
+	measured := MeasuredAudio{IntegratedLUFS: &lufs, TruePeak: &peak}
+	server := RawReplayGain{
+		AlbumGainDB: &serverAlbumGain,
+		TrackGainDB: &serverTrackGain,
+		TrackPeak:   &serverTrackPeak,
+	}
+
+	got := EffectiveValues(RawReplayGain{}, server, nil, measured)
+	if got.GainDB == nil || *got.GainDB != serverAlbumGain || got.GainSource != "server_replaygain_album" {
+		t.Fatalf("unexpected effective gain %+v", got)
+	}
+	if got.Peak == nil || *got.Peak != serverTrackPeak || got.PeakSource != "server_replaygain_track" {
+		t.Fatalf("unexpected effective peak %+v", got)
+	}
+
+	got = EffectiveValues(RawReplayGain{TrackGainDB: &tagGain}, server, nil, measured)
+	if got.GainSource != "replaygain_track" || *got.GainDB != tagGain {
+		t.Fatalf("expected file tags to win, got %+v", got)
+	}
+
+	got = EffectiveValues(RawReplayGain{}, RawReplayGain{}, nil, MeasuredAudio{})
+	if got.GainDB != nil || got.GainSource != "none" || got.PeakSource != "none" {
+		t.Fatalf("expected no effective values, got %+v", got)
+	}
+}
+
+func TestEffectiveValuesUseMeasuredAlbumLoudnessBeforeTrackLoudness(t *testing.T) {
+	lufs := -10.4
+	peak := -0.5
+	albumPeak := -0.2
+	measured := MeasuredAudio{IntegratedLUFS: &lufs, TruePeak: &peak}
+	album := &AlbumLoudness{IntegratedLUFS: -12, TruePeak: &albumPeak}
+
+	got := EffectiveValues(RawReplayGain{}, RawReplayGain{}, album, measured)
+	if got.GainDB == nil || *got.GainDB != -6 || got.GainSource != "measured_album" {
+		t.Fatalf("unexpected effective gain %+v", got)
+	}
+	if got.Peak == nil || *got.Peak != albumPeak || got.PeakSource != "measured_album" {
+		t.Fatalf("unexpected effective peak %+v", got)
+	}
+
+	serverTrackGain := -8.0
+	got = EffectiveValues(RawReplayGain{}, RawReplayGain{TrackGainDB: &serverTrackGain}, album, measured)
+	if got.GainSource != "server_replaygain_track" || got.PeakSource != "measured_album" {
+		t.Fatalf("expected tags to win over album loudness, got %+v", got)
+	}
+}
+
 func TestAnalyzerUsesMeasuredAndTagDataToBuildRecord(t *testing.T) {
 	lufs := -11.4
 	peak := 0.91
@@ -67,19 +122,21 @@ func TestAnalyzerUsesMeasuredAndTagDataToBuildRecord(t *testing.T) {
 	now := time.Unix(100, 0).UTC()
 	analyzer := Analyzer{
 		Root: "/library",
-		Probe: probeStub{measured: MeasuredAudio{
-			FileDurationSeconds: 123.4,
-			IntegratedLUFS:      &lufs,
-			TruePeak:            &peak,
-		}},
-		Tags: replayGainStub{raw: RawReplayGain{
-			AlbumGainDB: &albumGain,
-			AlbumPeak:   &albumPeak,
+		Pass: passStub{pass: Pass{
+			Measured: MeasuredAudio{
+				FileDurationSeconds: 123.4,
+				IntegratedLUFS:      &lufs,
+				TruePeak:            &peak,
+			},
+			Tags: FileTags{ReplayGain: RawReplayGain{
+				AlbumGainDB: &albumGain,
+				AlbumPeak:   &albumPeak,
+			}},
 		}},
 		Now: func() time.Time { return now },
 	}
 
-	got, err := analyzer.Analyze(context.Background(), "/albums/song.flac")
+	got, err := analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
 	if err != nil {
 		t.Fatalf("analyze: %v", err)
 	}
@@ -99,15 +156,14 @@ func TestAnalyzerHandlesMissingReplayGainTags(t *testing.T) {
 	peak := 0.98
 	analyzer := Analyzer{
 		Root: "/library",
-		Probe: probeStub{measured: MeasuredAudio{
+		Pass: passStub{pass: Pass{Measured: MeasuredAudio{
 			FileDurationSeconds: 98.7,
 			IntegratedLUFS:      &lufs,
 			TruePeak:            &peak,
-		}},
-		Tags: replayGainStub{err: errors.New("no tags")},
+		}}},
 	}
 
-	got, err := analyzer.Analyze(context.Background(), "/albums/song.flac")
+	got, err := analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
 	if err != nil {
 		t.Fatalf("analyze: %v", err)
 	}
@@ -116,45 +172,54 @@ func TestAnalyzerHandlesMissingReplayGainTags(t *testing.T) {
 	}
 }
 
+func TestAnalyzerFileTagsReadsTheResolvedFile(t *testing.T) {
+	var probed string
+	analyzer := Analyzer{
+		Root: "/library",
+		Tags: FFProbeTagReader{Runner: commandRunnerStub{
+			run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				probed = args[len(args)-1]
+				return []byte(`{"format":{"tags":{"REPLAYGAIN_TRACK_GAIN":"-6.50 dB","BPM":"124"}}}`), nil
+			},
+		}},
+	}
+
+	tags, err := analyzer.FileTags(context.Background(), "/albums/song.flac")
+	if err != nil {
+		t.Fatalf("file tags: %v", err)
+	}
+	if probed != "/library/albums/song.flac" {
+		t.Fatalf("expected the resolved path to be probed, got %q", probed)
+	}
+	if tags.ReplayGain.TrackGainDB == nil || *tags.ReplayGain.TrackGainDB != -6.5 || tags.BPM == nil || *tags.BPM != 124 {
+		t.Fatalf("unexpected tags %+v", tags)
+	}
+}
+
 func TestAnalyzerIncludesResolvedPathAndProbeOutputInError(t *testing.T) {
 	analyzer := Analyzer{
 		Root: "/library",
-		Probe: probeStub{
-			err: errors.New("ffprobe duration: /library/albums/song.flac: No such file or directory"),
+		Pass: passStub{
+			err: errors.New("ffprobe: /library/albums/song.flac: No such file or directory"),
 		},
-		Tags: replayGainStub{},
 	}
 
-	_, err := analyzer.Analyze(context.Background(), "/albums/song.flac")
+	_, err := analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
 	if err == nil {
 		t.Fatal("expected error")
 	}
 	if got := err.Error(); got == "" ||
 		!containsAll(got,
 			"analyze /library/albums/song.flac",
-			"ffprobe duration",
+			"ffprobe",
 			"No such file or directory",
 		) {
 		t.Fatalf("unexpected error %q", got)
 	}
-}
-
-type probeStub struct {
-	measured MeasuredAudio
-	err      error
-}
 
-func (p probeStub) Measure(context.Context, string) (MeasuredAudio, error) {
-	return p.measured, p.err
-}
-
-type replayGainStub struct {
-	raw RawReplayGain
-	err error
-}
-
-func (r replayGainStub) Read(context.Context, string) (RawReplayGain, error) {
-	return r.raw, r.err
+	if _, err := (Analyzer{Root: "/library"}).Analyze(context.Background(), "/albums/song.flac", ServerTags{}); err == nil {
+		t.Fatal("expected error without a single pass")
+	}
 }
 
 func containsAll(s string, parts ...string) bool {
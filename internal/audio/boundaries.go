@@ -0,0 +1,107 @@
+package audio
+
+import "math"
+
+const (
+	// boundaryTailSeconds of the end of each track are decoded, besides the
+	// first tempoMaxSeconds, to find where its audible content ends.
+	boundaryTailSeconds = 30
+	// boundaryWindowSeconds is the RMS window that tells sound from silence.
+	boundaryWindowSeconds = 0.05
+	// boundarySilenceDB is the RMS level in dBFS below which a window is
+	// silent.
+	boundarySilenceDB = -60
+	// A track starts or ends abruptly when the window at the edge of its
+	// content is within boundaryAbruptDB of the loudest window in the
+	// boundaryContextSeconds beside it. Fades and decaying final notes reach
+	// the edge far below that.
+	boundaryAbruptDB       = 20
+	boundaryContextSeconds = 5
+)
+
+// FlowMaxGapSeconds is the most silence a track may end with, and the next
+// track on its album start with, for the first to flow into the second.
+const FlowMaxGapSeconds = 0.5
+
+// Boundaries describes where the audible content of a track starts and ends.
+// Times are in seconds from the start of the file.
+type Boundaries struct {
+	LeadingSilenceSeconds  float64
+	TrailingSilenceSeconds float64
+	ContentStartSeconds    float64
+	ContentEndSeconds      float64
+	// AbruptStart is true when the content starts near full level instead of
+	// fading in, and AbruptEnd when it stops without fading out or decaying,
+	// as when a track runs straight into the next one.
+	AbruptStart bool
+	AbruptEnd   bool
+}
+
+// DetectBoundaries finds the content boundaries of a track from mono samples
+// of its start, head, and of its end, tail, which begins tailStart seconds
+// into the file. The two may overlap. ok is false when either holds no
+// sound.
+func DetectBoundaries(head, tail []float32, tailStart float64, sampleRate int) (Boundaries, bool) {
+	window := int(boundaryWindowSeconds * float64(sampleRate))
+	if window <= 0 {
+		return Boundaries{}, false
+	}
+	headLevels := windowLevels(head, window)
+	tailLevels := windowLevels(tail, window)
+	first := -1
+	for i, level := range headLevels {
+		if level >= boundarySilenceDB {
+			first = i
+			break
+		}
+	}
+	last := -1
+	for i := len(tailLevels) - 1; i >= 0; i-- {
+		if tailLevels[i] >= boundarySilenceDB {
+			last = i
+			break
+		}
+	}
+	if first < 0 || last < 0 {
+		return Boundaries{}, false
+	}
+
+	windowSeconds := float64(window) / float64(sampleRate)
+	context := int(boundaryContextSeconds / windowSeconds)
+	start := float64(first) * windowSeconds
+	end := tailStart + float64(last+1)*windowSeconds
+	fileEnd := tailStart + float64(len(tail))/float64(sampleRate)
+	return Boundaries{
+		LeadingSilenceSeconds:  start,
+		TrailingSilenceSeconds: math.Max(0, fileEnd-end),
+		ContentStartSeconds:    start,
+		ContentEndSeconds:      end,
+		AbruptStart:            headLevels[first] >= maxLevel(headLevels[first:min(len(headLevels), first+context)])-boundaryAbruptDB,
+		AbruptEnd:              tailLevels[last] >= maxLevel(tailLevels[max(0, last-context+1):last+1])-boundaryAbruptDB,
+	}, true
+}
+
+// windowLevels returns the RMS level in dBFS of each whole window of samples.
+// Digital silence is reported as -120 dBFS.
+func windowLevels(samples []float32, window int) []float64 {
+	levels := make([]float64, len(samples)/window)
+	for i := range levels {
+		sum := 0.0
+		for _, s := range samples[i*window : (i+1)*window] {
+			sum += float64(s) * float64(s)
+		}
+		levels[i] = -120
+		if sum > 0 {
+			levels[i] = math.Max(-120, 10*math.Log10(sum/float64(window)))
+		}
+	}
+	return levels
+}
+
+func maxLevel(levels []float64) float64 {
+	best := math.Inf(-1)
+	for _, level := range levels {
+		best = math.Max(best, level)
+	}
+	return best
+}
@@ -0,0 +1,53 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDetectBoundaries(t *testing.T) {
+	rate := tempoSampleRate
+	music := tones([]float64{220, 330}, 36, rate)
+	seconds := func(s float64) int { return int(s * float64(rate)) }
+
+	// 1.5 s of silence, a hard start, a three-second fade-out and two seconds
+	// of silence at the end.
+	faded := append(make([]float32, seconds(1.5)), music...)
+	for i := seconds(34.5); i < len(faded); i++ {
+		faded[i] *= float32(math.Max(0, 1-float64(i-seconds(34.5))/float64(seconds(3))))
+	}
+	faded = append(faded, make([]float32, seconds(2))...)
+	got, ok := DetectBoundaries(faded, faded[seconds(10):], 10, rate)
+	if !ok {
+		t.Fatalf("expected boundaries")
+	}
+	if math.Abs(got.LeadingSilenceSeconds-1.5) > 0.06 || got.ContentStartSeconds != got.LeadingSilenceSeconds || !got.AbruptStart {
+		t.Fatalf("unexpected start %+v", got)
+	}
+	if got.ContentEndSeconds < 37 || got.ContentEndSeconds > 37.6 || math.Abs(got.TrailingSilenceSeconds+got.ContentEndSeconds-39.5) > 0.01 ||
+		got.AbruptEnd {
+		t.Fatalf("unexpected end %+v", got)
+	}
+
+	// A four-second fade-in and a track cut off mid-note, as when it runs
+	// into the next one.
+	segue := append([]float32(nil), music...)
+	for i := 0; i < seconds(4); i++ {
+		segue[i] *= float32(i) / float32(seconds(4))
+	}
+	got, ok = DetectBoundaries(segue, segue[seconds(6):], 6, rate)
+	if !ok {
+		t.Fatalf("expected boundaries for the segue")
+	}
+	if got.AbruptStart || got.LeadingSilenceSeconds > 0.06 {
+		t.Fatalf("expected a fade-in without silence, got %+v", got)
+	}
+	if !got.AbruptEnd || got.TrailingSilenceSeconds > 0.05 || math.Abs(got.ContentEndSeconds-36) > 0.05 {
+		t.Fatalf("expected an abrupt end, got %+v", got)
+	}
+
+	silence := make([]float32, seconds(10))
+	if _, ok := DetectBoundaries(silence, silence, 0, rate); ok {
+		t.Fatalf("expected silence to be rejected")
+	}
+}
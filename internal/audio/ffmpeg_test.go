@@ -2,27 +2,45 @@ package audio
 
 import (
 	"context"
-	"errors"
-	"strings"
 	"testing"
 )
 
-func TestFFmpegProbeRunnerIncludesCommandOutputOnDurationFailure(t *testing.T) {
-	runner := FFmpegProbeRunner{
+func TestFFProbeTagReaderParsesReplayGainAndBPM(t *testing.T) {
+	reader := FFProbeTagReader{
 		Runner: commandRunnerStub{
 			run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
-				return []byte("/library/albums/song.flac: No such file or directory"), errors.New("exit status 1")
+				return []byte(`{"format":{"tags":{"REPLAYGAIN_TRACK_GAIN":"-7.20 dB","TBPM":"127.5"}}}`), nil
 			},
 		},
 	}
+	got, err := reader.Read(context.Background(), "/library/song.mp3")
+	if err != nil {
+		t.Fatalf("read tags: %v", err)
+	}
+	if got.ReplayGain.TrackGainDB == nil || *got.ReplayGain.TrackGainDB != -7.2 {
+		t.Fatalf("unexpected replaygain %+v", got.ReplayGain)
+	}
+	if got.BPM == nil || *got.BPM != 127.5 {
+		t.Fatalf("unexpected bpm %v", got.BPM)
+	}
+}
 
-	_, err := runner.Measure(context.Background(), "/library/albums/song.flac")
-	if err == nil {
-		t.Fatal("expected error")
+func TestFFProbeTagReaderReadsOpusR128Gains(t *testing.T) {
+	reader := FFProbeTagReader{
+		Runner: commandRunnerStub{
+			run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+				return []byte(`{"format":{},"streams":[{"tags":{"R128_TRACK_GAIN":"-1536","R128_ALBUM_GAIN":"256"}}]}`), nil
+			},
+		},
+	}
+	got, err := reader.Read(context.Background(), "/library/song.opus")
+	if err != nil {
+		t.Fatalf("read tags: %v", err)
 	}
-	got := err.Error()
-	if !strings.Contains(got, "ffprobe duration") || !strings.Contains(got, "/library/albums/song.flac: No such file or directory") {
-		t.Fatalf("unexpected error %q", got)
+	// -6 dB from -23 LUFS is -1 dB from the ReplayGain reference.
+	if got.ReplayGain.TrackGainDB == nil || *got.ReplayGain.TrackGainDB != -1 ||
+		got.ReplayGain.AlbumGainDB == nil || *got.ReplayGain.AlbumGainDB != 6 {
+		t.Fatalf("unexpected replaygain %+v", got.ReplayGain)
 	}
 }
 
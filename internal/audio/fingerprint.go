@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// AnalyzerVersion identifies what the analyzer measures. Bump it whenever a
+// change alters stored results, such as a new extractor, so that tracks
+// analyzed by an older version are queued again. Version 2 added acoustic
+// fingerprints and version 3 content boundaries.
+const AnalyzerVersion = 3
+
+// Fingerprint identifies the file a track was analyzed from. AudioHash covers
+// only the audio stream, so retagging a file changes its size and
+// modification time but not its hash.
+type Fingerprint struct {
+	Size      int64
+	ModTime   time.Time
+	AudioHash string
+}
+
+// SameAudio reports whether both fingerprints hash to the same audio. An empty
+// hash matches nothing.
+func (f Fingerprint) SameAudio(other Fingerprint) bool {
+	return f.AudioHash != "" && f.AudioHash == other.AudioHash
+}
+
+type AudioHasher interface {
+	HashAudio(context.Context, string) (string, error)
+}
+
+// FFmpegAudioHasher hashes the first audio stream of a file by stream-copying
+// it into ffmpeg's hash muxer, which reads the file without decoding it.
+type FFmpegAudioHasher struct {
+	Runner CommandRunner
+}
+
+var audioHashPattern = regexp.MustCompile(`SHA256=([0-9a-f]{64})`)
+
+func (h FFmpegAudioHasher) HashAudio(ctx context.Context, path string) (string, error) {
+	runner := h.Runner
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	out, err := runner.Run(ctx, "ffmpeg",
+		"-hide_banner",
+		"-nostdin",
+		"-v", "error",
+		"-i", path,
+		"-map", "0:a:0",
+		"-c", "copy",
+		"-f", "hash",
+		"-hash", "sha256",
+		"-",
+	)
+	if err != nil {
+		return "", commandError("ffmpeg hash", err, out)
+	}
+	match := audioHashPattern.FindSubmatch(out)
+	if match == nil {
+		return "", fmt.Errorf("ffmpeg hash output missing SHA256")
+	}
+	return "sha256:" + string(match[1]), nil
+}
+
+// Fingerprint stats the file at navPath and hashes its audio stream. When the
+// size and modification time match previous, previous's hash is reused and
+// the file is not read. Without a Hasher the hash is left empty.
+func (a Analyzer) Fingerprint(ctx context.Context, navPath string, previous *Fingerprint) (Fingerprint, error) {
+	filePath, err := ResolveLibraryPath(a.Root, navPath)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("fingerprint %s: %w", filePath, err)
+	}
+	fingerprint := Fingerprint{Size: info.Size(), ModTime: info.ModTime().UTC()}
+	if previous != nil && previous.Size == fingerprint.Size && previous.ModTime.Equal(fingerprint.ModTime) {
+		fingerprint.AudioHash = previous.AudioHash
+		return fingerprint, nil
+	}
+	if a.Hasher == nil {
+		return fingerprint, nil
+	}
+	hash, err := a.Hasher.HashAudio(ctx, filePath)
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("fingerprint %s: %w", filePath, err)
+	}
+	fingerprint.AudioHash = hash
+	return fingerprint, nil
+}
@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzerFingerprintHashesOnlyChangedFiles(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "albums", "song.flac")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("fLaC audio"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	hasher := &hasherStub{hash: "sha256:new"}
+	analyzer := Analyzer{Root: root, Hasher: hasher}
+	ctx := context.Background()
+
+	got, err := analyzer.Fingerprint(ctx, "/albums/song.flac", nil)
+	if err != nil {
+		t.Fatalf("fingerprint: %v", err)
+	}
+	if got.Size != 10 || !got.ModTime.Equal(modTime) || got.AudioHash != "sha256:new" || hasher.calls != 1 {
+		t.Fatalf("unexpected fingerprint %+v after %d hashes", got, hasher.calls)
+	}
+
+	same := Fingerprint{Size: 10, ModTime: modTime, AudioHash: "sha256:stored"}
+	got, err = analyzer.Fingerprint(ctx, "/albums/song.flac", &same)
+	if err != nil {
+		t.Fatalf("fingerprint unchanged: %v", err)
+	}
+	if got.AudioHash != "sha256:stored" || hasher.calls != 1 {
+		t.Fatalf("expected the stored hash to be reused without hashing, got %+v after %d hashes", got, hasher.calls)
+	}
+
+	retagged := Fingerprint{Size: 10, ModTime: modTime.Add(-time.Hour), AudioHash: "sha256:stored"}
+	got, err = analyzer.Fingerprint(ctx, "/albums/song.flac", &retagged)
+	if err != nil {
+		t.Fatalf("fingerprint touched: %v", err)
+	}
+	if got.AudioHash != "sha256:new" || hasher.calls != 2 {
+		t.Fatalf("expected a changed mtime to rehash, got %+v after %d hashes", got, hasher.calls)
+	}
+
+	if _, err := analyzer.Fingerprint(ctx, "/albums/missing.flac", nil); err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a missing file error, got %v", err)
+	}
+}
+
+func TestFingerprintSameAudio(t *testing.T) {
+	a := Fingerprint{Size: 1, AudioHash: "sha256:x"}
+	if !a.SameAudio(Fingerprint{Size: 2, AudioHash: "sha256:x"}) {
+		t.Fatalf("expected equal hashes to match regardless of size")
+	}
+	if (Fingerprint{}).SameAudio(Fingerprint{}) {
+		t.Fatalf("expected empty hashes not to match")
+	}
+}
+
+func TestFFmpegAudioHasherParsesHashMuxerOutput(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	output := "SHA256=" + digest + "\n"
+	runner := commandRunnerStub{run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if name != "ffmpeg" || !containsAll(strings.Join(args, " "), "-map 0:a:0", "-c copy", "-f hash") {
+			t.Fatalf("unexpected command %s %v", name, args)
+		}
+		return []byte(output), nil
+	}}
+	got, err := FFmpegAudioHasher{Runner: runner}.HashAudio(context.Background(), "/library/song.flac")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if got != "sha256:"+digest {
+		t.Fatalf("unexpected hash %q", got)
+	}
+
+	output = "Stream map '0:a:0' matches no streams."
+	if _, err := (FFmpegAudioHasher{Runner: runner}).HashAudio(context.Background(), "/library/song.flac"); err == nil {
+		t.Fatalf("expected error without a hash")
+	}
+}
+
+type hasherStub struct {
+	hash  string
+	calls int
+}
+
+func (h *hasherStub) HashAudio(context.Context, string) (string, error) {
+	h.calls++
+	return h.hash, nil
+}
@@ -0,0 +1,191 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+const (
+	// keyFrameSize gives about 1.3 Hz bins at tempoSampleRate, enough to
+	// separate semitones from the bass register up.
+	keyFrameSize = 8192
+	keyMinHz     = 65.0
+	keyMaxHz     = 2100.0
+	keyMinFrames = 4
+)
+
+// Mode names used by Key.
+const (
+	ModeMajor = "major"
+	ModeMinor = "minor"
+)
+
+var pitchClassNames = [12]string{"C", "C#", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+
+// Krumhansl–Kessler key profiles, starting from the tonic.
+var (
+	majorKeyProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorKeyProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// Key is an estimated musical key. Tonic is a pitch class, 0 for C through 11
+// for B. Confidence is the correlation between the track's chroma and the
+// key profile, from 0 to 1.
+type Key struct {
+	Tonic      int
+	Mode       string
+	Confidence float64
+}
+
+// TonicName returns the tonic spelled the way DJ software usually shows it.
+func (k Key) TonicName() string {
+	return pitchClassNames[((k.Tonic%12)+12)%12]
+}
+
+// String returns the key as "A minor".
+func (k Key) String() string {
+	return k.TonicName() + " " + k.Mode
+}
+
+// Camelot returns the key's Camelot wheel code, such as "8A" for A minor.
+// Neighbouring codes mix harmonically.
+func (k Key) Camelot() string {
+	tonic := ((k.Tonic % 12) + 12) % 12
+	letter := "B"
+	if k.Mode == ModeMinor {
+		// A minor key sits on the same number as its relative major.
+		tonic = (tonic + 3) % 12
+		letter = "A"
+	}
+	// Each step clockwise on the wheel is a fifth; C major is 8B.
+	return fmt.Sprintf("%d%s", (tonic*7+7)%12+1, letter)
+}
+
+// DetectKey estimates the key of mono samples. It folds the spectrum of each
+// frame into a 12-bin chromagram, sums the frames, and picks the major or
+// minor key profile that correlates best with the result. ok is false when
+// there is too little non-silent audio.
+func DetectKey(samples []float32, sampleRate int) (Key, bool) {
+	chroma, ok := chromagram(samples, sampleRate)
+	if !ok {
+		return Key{}, false
+	}
+	best := Key{Confidence: math.Inf(-1)}
+	for tonic := 0; tonic < 12; tonic++ {
+		for _, mode := range []string{ModeMajor, ModeMinor} {
+			profile := majorKeyProfile
+			if mode == ModeMinor {
+				profile = minorKeyProfile
+			}
+			var rotated [12]float64
+			for i := range rotated {
+				rotated[(i+tonic)%12] = profile[i]
+			}
+			if r := correlation(chroma, rotated); r > best.Confidence {
+				best = Key{Tonic: tonic, Mode: mode, Confidence: r}
+			}
+		}
+	}
+	best.Confidence = math.Round(math.Max(0, math.Min(1, best.Confidence))*1000) / 1000
+	return best, true
+}
+
+// chromagram sums per-frame pitch class energy. Each frame is normalized to
+// its strongest pitch class so loud passages do not outvote quiet ones.
+func chromagram(samples []float32, sampleRate int) ([12]float64, bool) {
+	var chroma [12]float64
+	if sampleRate <= 0 {
+		return chroma, false
+	}
+	pitchClass := make([]int, keyFrameSize/2)
+	for bin := range pitchClass {
+		pitchClass[bin] = -1
+		freq := float64(bin) * float64(sampleRate) / keyFrameSize
+		if freq < keyMinHz || freq > keyMaxHz {
+			continue
+		}
+		midi := int(math.Round(12*math.Log2(freq/440) + 69))
+		pitchClass[bin] = midi % 12
+	}
+	window := make([]float64, keyFrameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/(keyFrameSize-1))
+	}
+
+	frames := 0
+	buf := make([]complex128, keyFrameSize)
+	for start := 0; start+keyFrameSize <= len(samples); start += keyFrameSize {
+		for i := range buf {
+			buf[i] = complex(float64(samples[start+i])*window[i], 0)
+		}
+		fft(buf)
+		var frame [12]float64
+		for bin, pc := range pitchClass {
+			if pc >= 0 {
+				frame[pc] += cmplx.Abs(buf[bin])
+			}
+		}
+		peak := 0.0
+		for _, v := range frame {
+			peak = math.Max(peak, v)
+		}
+		if peak < 1e-3 {
+			continue
+		}
+		for i := range chroma {
+			chroma[i] += frame[i] / peak
+		}
+		frames++
+	}
+	return chroma, frames >= keyMinFrames
+}
+
+// fft is an in-place iterative radix-2 FFT. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := x[start+k], w*x[start+k+size/2]
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// correlation is the Pearson correlation of a and b.
+func correlation(a, b [12]float64) float64 {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= 12
+	meanB /= 12
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
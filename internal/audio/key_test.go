@@ -0,0 +1,128 @@
+package audio
+
+import (
+	"context"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestDetectKeyFindsSyntheticKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		notes   []float64
+		want    string
+		camelot string
+	}{
+		// Tonic triads with the tonic doubled an octave down.
+		{name: "C major", notes: []float64{130.81, 261.63, 329.63, 392.00}, want: "C major", camelot: "8B"},
+		{name: "A minor", notes: []float64{110.00, 220.00, 261.63, 329.63}, want: "A minor", camelot: "8A"},
+		{name: "G major", notes: []float64{98.00, 196.00, 246.94, 293.66}, want: "G major", camelot: "9B"},
+		{name: "F# minor", notes: []float64{92.50, 185.00, 220.00, 277.18}, want: "F# minor", camelot: "11A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DetectKey(tones(tt.notes, 8, tempoSampleRate), tempoSampleRate)
+			if !ok {
+				t.Fatalf("expected a key")
+			}
+			if got.String() != tt.want || got.Camelot() != tt.camelot {
+				t.Fatalf("got %s (%s), want %s (%s)", got, got.Camelot(), tt.want, tt.camelot)
+			}
+			if got.Confidence <= 0.5 || got.Confidence > 1 {
+				t.Fatalf("unexpected confidence %v", got.Confidence)
+			}
+		})
+	}
+}
+
+func TestDetectKeyRejectsSilenceAndShortAudio(t *testing.T) {
+	if _, ok := DetectKey(make([]float32, 10*tempoSampleRate), tempoSampleRate); ok {
+		t.Fatalf("expected silence to be rejected")
+	}
+	if _, ok := DetectKey(tones([]float64{440}, 1, tempoSampleRate), tempoSampleRate); ok {
+		t.Fatalf("expected short audio to be rejected")
+	}
+}
+
+func TestKeyCamelotCoversTheWheel(t *testing.T) {
+	seen := make(map[string]bool)
+	for tonic := 0; tonic < 12; tonic++ {
+		for _, mode := range []string{ModeMajor, ModeMinor} {
+			seen[Key{Tonic: tonic, Mode: mode}.Camelot()] = true
+		}
+	}
+	if len(seen) != 24 {
+		t.Fatalf("expected 24 distinct codes, got %v", seen)
+	}
+	for key, want := range map[Key]string{
+		{Tonic: 2, Mode: ModeMajor}:  "10B",
+		{Tonic: 5, Mode: ModeMajor}:  "7B",
+		{Tonic: 0, Mode: ModeMinor}:  "5A",
+		{Tonic: 11, Mode: ModeMajor}: "1B",
+	} {
+		if got := key.Camelot(); got != want {
+			t.Fatalf("%s: got %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestFFTMatchesDirectTransform(t *testing.T) {
+	x := []complex128{1, 2, 0, -1, 3, 0.5, -2, 1}
+	want := make([]complex128, len(x))
+	for k := range want {
+		for n, v := range x {
+			want[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(k*n)/float64(len(x))))
+		}
+	}
+	fft(x)
+	for i := range x {
+		if cmplx.Abs(x[i]-want[i]) > 1e-9 {
+			t.Fatalf("bin %d: got %v, want %v", i, x[i], want[i])
+		}
+	}
+}
+
+func TestAnalyzerStoresKey(t *testing.T) {
+	analyzer := Analyzer{
+		Root: "/library",
+		Pass: passStub{pass: Pass{
+			Measured:   MeasuredAudio{FileDurationSeconds: 200},
+			Samples:    tones([]float64{110, 220, 261.63, 329.63}, 8, tempoSampleRate),
+			SampleRate: tempoSampleRate,
+		}},
+	}
+	got, err := analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if got.Measured.Key == nil || got.Measured.Key.Camelot() != "8A" {
+		t.Fatalf("unexpected key %+v", got.Measured.Key)
+	}
+
+	analyzer.Pass = passStub{pass: Pass{
+		Measured:   MeasuredAudio{FileDurationSeconds: 200},
+		Samples:    make([]float32, 8*tempoSampleRate),
+		SampleRate: tempoSampleRate,
+	}}
+	got, err = analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
+	if err != nil {
+		t.Fatalf("analyze silence: %v", err)
+	}
+	if got.Measured.Key != nil {
+		t.Fatalf("expected no key, got %+v", got.Measured.Key)
+	}
+}
+
+// tones renders equal-amplitude sine waves at the given frequencies.
+func tones(freqs []float64, seconds float64, sampleRate int) []float32 {
+	samples := make([]float32, int(seconds*float64(sampleRate)))
+	for i := range samples {
+		var v float64
+		for _, f := range freqs {
+			v += math.Sin(2 * math.Pi * f * float64(i) / float64(sampleRate))
+		}
+		samples[i] = float32(v / float64(len(freqs)))
+	}
+	return samples
+}
@@ -0,0 +1,242 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pass is everything one probe and one decode of a file yield: the measured
+// loudness, the file's tags, and the start of the audio as mono PCM for the
+// Go-side extractors. Tail holds the end of the audio at the same rate,
+// starting TailStartSeconds into the file, for boundary detection.
+type Pass struct {
+	Measured         MeasuredAudio
+	Tags             FileTags
+	Samples          []float32
+	SampleRate       int
+	Tail             []float32
+	TailStartSeconds float64
+}
+
+// SinglePass probes and decodes a file once.
+type SinglePass interface {
+	Run(context.Context, string) (Pass, error)
+}
+
+// FFmpegPipeline analyzes a file with one ffprobe call for format, stream and
+// tag data and one ffmpeg decode. The decode measures loudness with the
+// ebur128 filter over the whole file and, from the same decoded audio, writes
+// the first two minutes and the last thirty seconds as mono float PCM to
+// temporary files.
+type FFmpegPipeline struct {
+	Runner CommandRunner
+	// TempDir holds the PCM files while a track is analyzed. It defaults to
+	// the system temporary directory.
+	TempDir string
+}
+
+func (p FFmpegPipeline) Run(ctx context.Context, path string) (Pass, error) {
+	runner := p.Runner
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+
+	probeOut, err := runner.Run(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		"-select_streams", "a:0",
+		path,
+	)
+	if err != nil {
+		return Pass{}, commandError("ffprobe", err, probeOut)
+	}
+	duration, tags, err := parseProbeOutput(probeOut)
+	if err != nil {
+		return Pass{}, err
+	}
+
+	pcmPath, err := p.tempPCM()
+	if err != nil {
+		return Pass{}, err
+	}
+	defer os.Remove(pcmPath)
+	tailPath, err := p.tempPCM()
+	if err != nil {
+		return Pass{}, err
+	}
+	defer os.Remove(tailPath)
+
+	tailStart := math.Max(0, duration-boundaryTailSeconds)
+	decodeOut, err := runner.Run(ctx, "ffmpeg", pipelineDecodeArgs(path, pcmPath, tailPath, tailStart)...)
+	if err != nil {
+		return Pass{}, commandError("ffmpeg decode", err, decodeOut)
+	}
+	loudness, err := parseEBUR128Summary(decodeOut)
+	if err != nil {
+		return Pass{}, err
+	}
+	raw, err := os.ReadFile(pcmPath)
+	if err != nil {
+		return Pass{}, fmt.Errorf("read pcm: %w", err)
+	}
+	rawTail, err := os.ReadFile(tailPath)
+	if err != nil {
+		return Pass{}, fmt.Errorf("read tail pcm: %w", err)
+	}
+
+	return Pass{
+		Measured: MeasuredAudio{
+			FileDurationSeconds: duration,
+			IntegratedLUFS:      loudness.integrated,
+			TruePeak:            loudness.truePeak,
+			LoudnessRange:       loudness.lra,
+		},
+		Tags:             tags,
+		Samples:          decodeFloat32LE(raw),
+		SampleRate:       tempoSampleRate,
+		Tail:             decodeFloat32LE(rawTail),
+		TailStartSeconds: tailStart,
+	}, nil
+}
+
+func (p FFmpegPipeline) tempPCM() (string, error) {
+	pcm, err := os.CreateTemp(p.TempDir, "playlistgen-*.pcm")
+	if err != nil {
+		return "", fmt.Errorf("create pcm file: %w", err)
+	}
+	pcm.Close()
+	return pcm.Name(), nil
+}
+
+// pipelineDecodeArgs builds the single ffmpeg decode. ebur128 sees every
+// sample and its summary goes to stderr; asplit hands copies to two branches
+// that are trimmed to the start and, from tailStart seconds, the end of the
+// audio and downmixed for the PCM extractors. The head branch ending early
+// does not stop the others, and the loudness branch drains into the null
+// muxer.
+func pipelineDecodeArgs(path, pcmPath, tailPath string, tailStart float64) []string {
+	format := fmt.Sprintf("aformat=sample_fmts=flt:sample_rates=%d:channel_layouts=mono", tempoSampleRate)
+	graph := fmt.Sprintf(
+		"[0:a:0]ebur128=peak=true:framelog=quiet,asplit=3[loudness][pcm][tail];"+
+			"[pcm]atrim=duration=%d,%s[pcmout];"+
+			"[tail]atrim=start=%.3f,%s[tailout]",
+		tempoMaxSeconds, format, tailStart, format)
+	return []string{
+		"-hide_banner",
+		"-nostats",
+		"-nostdin",
+		"-v", "info",
+		"-i", path,
+		"-filter_complex", graph,
+		"-map", "[loudness]", "-f", "null", "-",
+		"-map", "[pcmout]", "-f", "f32le", "-y", pcmPath,
+		"-map", "[tailout]", "-f", "f32le", "-y", tailPath,
+	}
+}
+
+// parseProbeOutput reads the duration and tags from ffprobe's JSON. Tags are
+// taken from the container first and then the audio stream, where Ogg and
+// Opus keep theirs.
+func parseProbeOutput(out []byte) (float64, FileTags, error) {
+	var payload struct {
+		Format struct {
+			Duration string            `json:"duration"`
+			Tags     map[string]string `json:"tags"`
+		} `json:"format"`
+		Streams []struct {
+			Duration string            `json:"duration"`
+			Tags     map[string]string `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(out, &payload); err != nil {
+		return 0, FileTags{}, fmt.Errorf("decode ffprobe payload: %w", err)
+	}
+
+	rawDuration := payload.Format.Duration
+	tagMaps := []map[string]string{payload.Format.Tags}
+	if len(payload.Streams) > 0 {
+		if rawDuration == "" {
+			rawDuration = payload.Streams[0].Duration
+		}
+		tagMaps = append(tagMaps, payload.Streams[0].Tags)
+	}
+	duration, err := strconv.ParseFloat(rawDuration, 64)
+	if err != nil {
+		return 0, FileTags{}, fmt.Errorf("parse duration: %w", err)
+	}
+	return duration, parseFileTags(tagMaps...), nil
+}
+
+type loudnessStats struct {
+	integrated *float64
+	truePeak   *float64
+	lra        *float64
+}
+
+var (
+	ebur128Summary    = regexp.MustCompile(`Summary:`)
+	ebur128Integrated = regexp.MustCompile(`I:\s+(-?[\d.]+|-inf) LUFS`)
+	ebur128Range      = regexp.MustCompile(`LRA:\s+(-?[\d.]+) LU`)
+	ebur128Peak       = regexp.MustCompile(`Peak:\s+(-?[\d.]+|-inf) dBFS`)
+)
+
+// parseEBUR128Summary reads the summary ebur128 logs when the stream ends.
+// Per-frame lines also contain "I:" and "LRA:", so only text after the last
+// "Summary:" is considered.
+func parseEBUR128Summary(out []byte) (loudnessStats, error) {
+	locs := ebur128Summary.FindAllIndex(out, -1)
+	if len(locs) == 0 {
+		return loudnessStats{}, fmt.Errorf("ffmpeg ebur128 output missing summary")
+	}
+	summary := out[locs[len(locs)-1][1]:]
+
+	integrated, err := summaryValue(ebur128Integrated, summary)
+	if err != nil {
+		return loudnessStats{}, fmt.Errorf("parse integrated loudness: %w", err)
+	}
+	peak, err := summaryValue(ebur128Peak, summary)
+	if err != nil {
+		return loudnessStats{}, fmt.Errorf("parse true peak: %w", err)
+	}
+	if integrated == nil || peak == nil {
+		return loudnessStats{}, fmt.Errorf("ffmpeg ebur128 summary missing loudness or true peak")
+	}
+	lra, _ := summaryValue(ebur128Range, summary)
+	return loudnessStats{integrated: integrated, truePeak: peak, lra: lra}, nil
+}
+
+// summaryValue returns nil for a missing value. Silence measures -inf, which
+// is stored as -70, the absolute gate of EBU R128.
+func summaryValue(pattern *regexp.Regexp, summary []byte) (*float64, error) {
+	match := pattern.FindSubmatch(summary)
+	if match == nil {
+		return nil, nil
+	}
+	raw := strings.TrimSpace(string(match[1]))
+	if raw == "-inf" {
+		v := -70.0
+		return &v, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func decodeFloat32LE(raw []byte) []float32 {
+	samples := make([]float32, len(raw)/4)
+	for i := range samples {
+		samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	return samples
+}
@@ -0,0 +1,267 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+const ebur128TestOutput = `[Parsed_ebur128_0 @ 0x55d0] t: 0.1  TARGET:-23 LUFS  M: -40.1 S:-120.7  I: -40.1 LUFS  LRA:   0.0 LU
+[Parsed_ebur128_0 @ 0x55d0] Summary:
+
+  Integrated loudness:
+    I:         -9.8 LUFS
+    Threshold: -20.1 LUFS
+
+  Loudness range:
+    LRA:         5.4 LU
+    Threshold: -30.2 LUFS
+    LRA low:   -14.2 LUFS
+    LRA high:   -8.8 LUFS
+
+  True peak:
+    Peak:        0.3 dBFS
+`
+
+func TestFFmpegPipelineProbesAndDecodesOnce(t *testing.T) {
+	samples := clickTrack(120, 20, tempoSampleRate)
+	runner := &pipelineRunnerStub{samples: samples}
+	pass, err := FFmpegPipeline{Runner: runner, TempDir: t.TempDir()}.Run(context.Background(), "/library/song.opus")
+	if err != nil {
+		t.Fatalf("run pipeline: %v", err)
+	}
+	if calls := runner.calls.Load(); calls != 2 {
+		t.Fatalf("expected one ffprobe and one ffmpeg call, got %d", calls)
+	}
+	m := pass.Measured
+	if m.FileDurationSeconds != 241.5 || *m.IntegratedLUFS != -9.8 || *m.TruePeak != 0.3 || *m.LoudnessRange != 5.4 {
+		t.Fatalf("unexpected measurements %+v", m)
+	}
+	if pass.Tags.ReplayGain.TrackGainDB == nil || *pass.Tags.ReplayGain.TrackGainDB != -6.5 {
+		t.Fatalf("expected container replaygain, got %+v", pass.Tags.ReplayGain)
+	}
+	if pass.Tags.BPM == nil || *pass.Tags.BPM != 120 {
+		t.Fatalf("expected lowercase stream bpm tag, got %v", pass.Tags.BPM)
+	}
+	if len(pass.Samples) != len(samples) || pass.SampleRate != tempoSampleRate {
+		t.Fatalf("unexpected pcm: %d samples at %d Hz", len(pass.Samples), pass.SampleRate)
+	}
+	if len(pass.Tail) != len(samples) || pass.TailStartSeconds != 211.5 {
+		t.Fatalf("unexpected tail: %d samples from %vs", len(pass.Tail), pass.TailStartSeconds)
+	}
+	for _, path := range []string{runner.pcmPath, runner.tailPath} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected pcm file %q to be removed, got %v", path, err)
+		}
+	}
+}
+
+func TestFFmpegPipelineIncludesDecodeOutputInError(t *testing.T) {
+	runner := &pipelineRunnerStub{decodeErr: errors.New("exit status 1")}
+	_, err := FFmpegPipeline{Runner: runner, TempDir: t.TempDir()}.Run(context.Background(), "/library/song.flac")
+	if err == nil || !containsAll(err.Error(), "ffmpeg decode", "Invalid data found", "exit status 1") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestParseEBUR128Summary(t *testing.T) {
+	got, err := parseEBUR128Summary([]byte("Summary:\n  I: -inf LUFS\n  LRA: 0.0 LU\n  Peak: -inf dBFS\n"))
+	if err != nil {
+		t.Fatalf("parse silent summary: %v", err)
+	}
+	if *got.integrated != -70 || *got.truePeak != -70 || *got.lra != 0 {
+		t.Fatalf("unexpected silent stats %+v", got)
+	}
+	if _, err := parseEBUR128Summary([]byte("t: 0.1 I: -40.1 LUFS")); err == nil {
+		t.Fatalf("expected error without a summary")
+	}
+	if _, err := parseEBUR128Summary([]byte("Summary:\n  LRA: 1.0 LU\n")); err == nil {
+		t.Fatalf("expected error without integrated loudness")
+	}
+}
+
+func TestAnalyzerRunsExtractorsOnSinglePassSamples(t *testing.T) {
+	lufs, peak := -9.0, -0.5
+	analyzer := Analyzer{
+		Root: "/library",
+		Pass: passStub{pass: Pass{
+			Measured:   MeasuredAudio{FileDurationSeconds: 20, IntegratedLUFS: &lufs, TruePeak: &peak},
+			Samples:    clickTrack(120, 20, tempoSampleRate),
+			SampleRate: tempoSampleRate,
+			Tail:       clickTrack(120, 20, tempoSampleRate),
+		}},
+	}
+	got, err := analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if got.Measured.TempoBPM == nil || math.Abs(*got.Measured.TempoBPM-120) > 2.4 || got.Effective.TempoSource != "measured_tempo" {
+		t.Fatalf("unexpected tempo %+v", got.Effective)
+	}
+	if got.Measured.Spectral == nil || got.Measured.Key == nil || got.Measured.AcousticFingerprint == nil ||
+		got.Measured.Boundaries == nil || got.Effective.GainSource != "measured_integrated_lufs" {
+		t.Fatalf("expected every extractor to run, got %+v", got.Measured)
+	}
+
+	analyzer.Pass = passStub{err: errors.New("ffprobe: No such file or directory")}
+	if _, err := analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{}); err == nil ||
+		!containsAll(err.Error(), "analyze /library/albums/song.flac", "No such file") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// The benchmarks run the analysis against fakes that regenerate the PCM on
+// every decode, standing in for ffmpeg decoding the file. execs/op counts the
+// subprocesses a real run would start.
+
+func BenchmarkAnalyzerSeparatePasses(b *testing.B) {
+	runner := &pipelineRunnerStub{}
+	decoder := &decodingStub{}
+	analyze := func(ctx context.Context, path string) error {
+		_, err := analyzeSeparatePasses(ctx, runner, decoder, path)
+		return err
+	}
+	benchmarkAnalyzer(b, analyze, func() int64 { return runner.calls.Load() + decoder.calls.Load() })
+}
+
+func BenchmarkAnalyzerSinglePass(b *testing.B) {
+	runner := &pipelineRunnerStub{}
+	analyzer := Analyzer{
+		Root: "/library",
+		Pass: FFmpegPipeline{Runner: runner, TempDir: b.TempDir()},
+	}
+	analyze := func(ctx context.Context, path string) error {
+		_, err := analyzer.Analyze(ctx, path, ServerTags{})
+		return err
+	}
+	benchmarkAnalyzer(b, analyze, runner.calls.Load)
+}
+
+func benchmarkAnalyzer(b *testing.B, analyze func(context.Context, string) error, execs func() int64) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := analyze(context.Background(), "/albums/song.flac"); err != nil {
+			b.Fatalf("analyze: %v", err)
+		}
+	}
+	b.ReportMetric(float64(execs())/float64(b.N), "execs/op")
+}
+
+// analyzeSeparatePasses is the baseline FFmpegPipeline replaced: a duration
+// probe, a loudness scan, a tag probe, and one decode for each PCM extractor.
+func analyzeSeparatePasses(ctx context.Context, runner *pipelineRunnerStub, decoder *decodingStub, path string) (AnalysisResult, error) {
+	probeOut, err := runner.Run(ctx, "ffprobe", "-show_entries", "format=duration", path)
+	if err != nil {
+		return AnalysisResult{}, err
+	}
+	duration, _, err := parseProbeOutput(probeOut)
+	if err != nil {
+		return AnalysisResult{}, err
+	}
+	loudnessOut, err := runner.Run(ctx, "ffmpeg", "-i", path, "-af", "ebur128=peak=true", "-f", "null", "-")
+	if err != nil {
+		return AnalysisResult{}, err
+	}
+	loudness, err := parseEBUR128Summary(loudnessOut)
+	if err != nil {
+		return AnalysisResult{}, err
+	}
+	tags, err := FFProbeTagReader{Runner: runner}.Read(ctx, path)
+	if err != nil {
+		return AnalysisResult{}, err
+	}
+
+	measured := MeasuredAudio{
+		FileDurationSeconds: duration,
+		IntegratedLUFS:      loudness.integrated,
+		TruePeak:            loudness.truePeak,
+		LoudnessRange:       loudness.lra,
+	}
+	if tempo, ok := DetectTempo(decoder.DecodeMono(tempoSampleRate, tempoMaxSeconds), tempoSampleRate); ok {
+		measured.TempoBPM = &tempo.BPM
+		measured.TempoConfidence = &tempo.Confidence
+	}
+	if key, ok := DetectKey(decoder.DecodeMono(tempoSampleRate, tempoMaxSeconds), tempoSampleRate); ok {
+		measured.Key = &key
+	}
+	if features, ok := DetectSpectral(decoder.DecodeMono(tempoSampleRate, tempoMaxSeconds), tempoSampleRate); ok {
+		measured.Spectral = &features
+	}
+	return Analyzer{}.result(path, measured, tags, ServerTags{}), nil
+}
+
+// pipelineRunnerStub answers ffprobe with format and stream JSON and ffmpeg
+// with an ebur128 summary, writing the PCM to the output paths the way the
+// real decode does.
+type pipelineRunnerStub struct {
+	samples   []float32
+	decodeErr error
+	calls     atomic.Int64
+	pcmPath   string
+	tailPath  string
+}
+
+func (r *pipelineRunnerStub) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.calls.Add(1)
+	if name == "ffprobe" {
+		return []byte(`{
+			"streams": [{"duration": "241.500000", "tags": {"bpm": "120", "REPLAYGAIN_TRACK_GAIN": "-1.00 dB"}}],
+			"format": {"duration": "241.500000", "tags": {"replaygain_track_gain": "-6.50 dB"}}
+		}`), nil
+	}
+	if r.decodeErr != nil {
+		return []byte("/library/song.flac: Invalid data found when processing input"), r.decodeErr
+	}
+	samples := r.samples
+	if samples == nil {
+		samples = clickTrack(120, tempoMaxSeconds, tempoSampleRate)
+	}
+	for i, arg := range args {
+		// Each PCM output is "-map <label> -f f32le -y <path>".
+		switch arg {
+		case "[pcmout]":
+			r.pcmPath = args[i+4]
+			if err := os.WriteFile(r.pcmPath, encodeFloat32LE(samples), 0o600); err != nil {
+				return nil, err
+			}
+		case "[tailout]":
+			r.tailPath = args[i+4]
+			tail := samples[max(0, len(samples)-boundaryTailSeconds*tempoSampleRate):]
+			if err := os.WriteFile(r.tailPath, encodeFloat32LE(tail), 0o600); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return []byte(ebur128TestOutput), nil
+}
+
+// decodingStub stands in for one ffmpeg decode to mono PCM.
+type decodingStub struct {
+	calls atomic.Int64
+}
+
+func (d *decodingStub) DecodeMono(sampleRate, maxSeconds int) []float32 {
+	d.calls.Add(1)
+	return clickTrack(120, float64(maxSeconds), sampleRate)
+}
+
+type passStub struct {
+	pass Pass
+	err  error
+}
+
+func (p passStub) Run(context.Context, string) (Pass, error) {
+	return p.pass, p.err
+}
+
+func encodeFloat32LE(samples []float32) []byte {
+	raw := make([]byte, 4*len(samples))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(s))
+	}
+	return raw
+}
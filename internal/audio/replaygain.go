@@ -7,6 +7,8 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
 )
 
 type CommandRunner interface {
@@ -20,11 +22,23 @@ func (ExecRunner) Run(ctx context.Context, name string, args ...string) ([]byte,
 	return cmd.CombinedOutput()
 }
 
-type FFProbeReplayGainReader struct {
+// FFProbeTagReader reads ReplayGain and BPM tags with ffprobe, from the
+// container and then the first audio stream, where Ogg and Opus keep theirs.
+type FFProbeTagReader struct {
 	Runner CommandRunner
 }
 
-func (r FFProbeReplayGainReader) Read(ctx context.Context, path string) (RawReplayGain, error) {
+func (r FFProbeTagReader) Read(ctx context.Context, path string) (FileTags, error) {
+	tags, err := r.ReadTags(ctx, path)
+	if err != nil {
+		return FileTags{}, err
+	}
+	return parseFileTags(tags), nil
+}
+
+// ReadTags returns every tag on the file at path, keyed in upper case, with
+// container tags taking precedence over stream tags.
+func (r FFProbeTagReader) ReadTags(ctx context.Context, path string) (map[string]string, error) {
 	runner := r.Runner
 	if runner == nil {
 		runner = ExecRunner{}
@@ -32,26 +46,80 @@ func (r FFProbeReplayGainReader) Read(ctx context.Context, path string) (RawRepl
 	out, err := runner.Run(ctx, "ffprobe",
 		"-v", "error",
 		"-print_format", "json",
-		"-show_entries", "format_tags",
+		"-show_entries", "format_tags:stream_tags",
+		"-select_streams", "a:0",
 		path,
 	)
 	if err != nil {
-		return RawReplayGain{}, fmt.Errorf("ffprobe replaygain tags: %w", err)
+		return nil, fmt.Errorf("ffprobe tags: %w", err)
 	}
 	var payload struct {
 		Format struct {
 			Tags map[string]string `json:"tags"`
 		} `json:"format"`
+		Streams []struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"streams"`
 	}
 	if err := json.Unmarshal(out, &payload); err != nil {
-		return RawReplayGain{}, fmt.Errorf("decode replaygain tags: %w", err)
+		return nil, fmt.Errorf("decode tags: %w", err)
 	}
-	return RawReplayGain{
-		TrackGainDB: parseReplayGainValue(payload.Format.Tags["REPLAYGAIN_TRACK_GAIN"]),
-		TrackPeak:   parseReplayGainValue(payload.Format.Tags["REPLAYGAIN_TRACK_PEAK"]),
-		AlbumGainDB: parseReplayGainValue(payload.Format.Tags["REPLAYGAIN_ALBUM_GAIN"]),
-		AlbumPeak:   parseReplayGainValue(payload.Format.Tags["REPLAYGAIN_ALBUM_PEAK"]),
-	}, nil
+	tagMaps := []map[string]string{payload.Format.Tags}
+	if len(payload.Streams) > 0 {
+		tagMaps = append(tagMaps, payload.Streams[0].Tags)
+	}
+	return mergeTags(tagMaps...), nil
+}
+
+// parseFileTags reads ReplayGain and BPM from container tags. Tag key case
+// depends on the container (Vorbis comments are often lowercase), so keys are
+// matched case-insensitively. Later maps fill keys missing from earlier ones.
+// Opus R128 gains are used when there are no ReplayGain gains.
+func parseFileTags(maps ...map[string]string) FileTags {
+	tags := mergeTags(maps...)
+	return FileTags{
+		ReplayGain: RawReplayGain{
+			TrackGainDB: firstGain(parseReplayGainValue(tags["REPLAYGAIN_TRACK_GAIN"]), parseR128Gain(tags["R128_TRACK_GAIN"])),
+			TrackPeak:   parseReplayGainValue(tags["REPLAYGAIN_TRACK_PEAK"]),
+			AlbumGainDB: firstGain(parseReplayGainValue(tags["REPLAYGAIN_ALBUM_GAIN"]), parseR128Gain(tags["R128_ALBUM_GAIN"])),
+			AlbumPeak:   parseReplayGainValue(tags["REPLAYGAIN_ALBUM_PEAK"]),
+		},
+		BPM: parseBPMTag(firstTag(tags, "BPM", "TBPM", "TMPO")),
+	}
+}
+
+// mergeTags upper-cases the keys of maps into one map. Earlier maps win.
+func mergeTags(maps ...map[string]string) map[string]string {
+	tags := make(map[string]string)
+	for _, m := range maps {
+		for key, value := range m {
+			key = strings.ToUpper(key)
+			if _, ok := tags[key]; !ok {
+				tags[key] = value
+			}
+		}
+	}
+	return tags
+}
+
+// firstTag returns the first non-empty tag among keys.
+func firstTag(tags map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if v := strings.TrimSpace(tags[key]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseBPMTag parses a BPM tag such as "128" or "127.96". Zero and negative
+// values are treated as missing.
+func parseBPMTag(raw string) *float64 {
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || value <= 0 {
+		return nil
+	}
+	return &value
 }
 
 func parseReplayGainValue(raw string) *float64 {
@@ -65,3 +133,45 @@ func parseReplayGainValue(raw string) *float64 {
 	}
 	return &value
 }
+
+// parseR128Gain parses an Opus R128 gain tag, a Q7.8 fixed-point number of
+// dB relative to r128ReferenceLUFS, into a ReplayGain gain.
+func parseR128Gain(raw string) *float64 {
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return nil
+	}
+	gain := float64(value)/256 + ReplayGainReferenceLUFS - r128ReferenceLUFS
+	return &gain
+}
+
+func firstGain(values ...*float64) *float64 {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// ServerMetadata converts what Navidrome reports for a track into the
+// fallback values Analyze uses when the file has no tags of its own.
+func ServerMetadata(meta app.ExtendedMetadata) ServerTags {
+	tags := ServerTags{ReplayGain: ServerReplayGain(meta.ReplayGain)}
+	if meta.BPM > 0 {
+		bpm := float64(meta.BPM)
+		tags.BPM = &bpm
+	}
+	return tags
+}
+
+// ServerReplayGain converts the ReplayGain Navidrome reports for a track into
+// the form EffectiveValues expects.
+func ServerReplayGain(rg app.ReplayGain) RawReplayGain {
+	return RawReplayGain{
+		TrackGainDB: rg.TrackGainDB,
+		TrackPeak:   rg.TrackPeak,
+		AlbumGainDB: rg.AlbumGainDB,
+		AlbumPeak:   rg.AlbumPeak,
+	}
+}
@@ -0,0 +1,169 @@
+package audio
+
+import "math"
+
+// SpectralVersion identifies how SpectralFeatures are computed. Bump it when
+// the extraction changes so stored values from older versions can be told
+// apart.
+const SpectralVersion = 1
+
+const (
+	spectralFrameSize = 2048
+	// spectralRolloff is the share of spectral energy below the rolloff
+	// frequency.
+	spectralRolloff = 0.85
+	// spectralSilence is the mean squared sample level below which a frame
+	// is ignored.
+	spectralSilence = 1e-7
+	// onsetPeakWindow is how many onset frames on each side a peak must
+	// dominate, about 35 ms at tempoHopSize.
+	onsetPeakWindow = 3
+	// onsetMinStrength ignores rises in log energy too small to hear.
+	onsetMinStrength = 0.1
+)
+
+// SpectralFeatures describe a track's brightness, dynamics and busyness.
+// Frequencies are limited to half the analysis sample rate.
+type SpectralFeatures struct {
+	// CentroidHz is the mean spectral centroid, a measure of brightness.
+	CentroidHz float64
+	// RolloffHz is the mean frequency below which 85% of the energy lies.
+	RolloffHz float64
+	// CrestFactorDB is the peak-to-RMS ratio; heavily compressed masters
+	// score low.
+	CrestFactorDB float64
+	// ZeroCrossingRate is the share of adjacent samples that change sign,
+	// high for noisy or distorted material.
+	ZeroCrossingRate float64
+	// OnsetDensity is the number of detected note or drum onsets per second.
+	OnsetDensity float64
+}
+
+// DetectSpectral computes SpectralFeatures from mono samples. ok is false
+// when the audio is shorter than one analysis frame or silent.
+func DetectSpectral(samples []float32, sampleRate int) (SpectralFeatures, bool) {
+	if sampleRate <= 0 || len(samples) < spectralFrameSize {
+		return SpectralFeatures{}, false
+	}
+
+	var peak, sumSquares float64
+	crossings := 0
+	for i, s := range samples {
+		v := float64(s)
+		peak = math.Max(peak, math.Abs(v))
+		sumSquares += v * v
+		if i > 0 && (samples[i-1] < 0) != (s < 0) {
+			crossings++
+		}
+	}
+	rms := math.Sqrt(sumSquares / float64(len(samples)))
+	if rms*rms < spectralSilence {
+		return SpectralFeatures{}, false
+	}
+
+	centroid, rolloff, ok := spectralShape(samples, sampleRate)
+	if !ok {
+		return SpectralFeatures{}, false
+	}
+	seconds := float64(len(samples)) / float64(sampleRate)
+	return SpectralFeatures{
+		CentroidHz:       round(centroid, 1),
+		RolloffHz:        round(rolloff, 1),
+		CrestFactorDB:    round(20*math.Log10(peak/rms), 2),
+		ZeroCrossingRate: round(float64(crossings)/float64(len(samples)-1), 4),
+		OnsetDensity:     round(float64(countOnsets(onsetStrength(samples)))/seconds, 2),
+	}, true
+}
+
+// spectralShape averages the centroid and rolloff of every non-silent frame.
+func spectralShape(samples []float32, sampleRate int) (float64, float64, bool) {
+	window := make([]float64, spectralFrameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/(spectralFrameSize-1))
+	}
+	binHz := float64(sampleRate) / spectralFrameSize
+	buf := make([]complex128, spectralFrameSize)
+	power := make([]float64, spectralFrameSize/2)
+
+	var centroidSum, rolloffSum float64
+	frames := 0
+	for start := 0; start+spectralFrameSize <= len(samples); start += spectralFrameSize {
+		var level float64
+		for i := range buf {
+			v := float64(samples[start+i])
+			level += v * v
+			buf[i] = complex(v*window[i], 0)
+		}
+		if level/spectralFrameSize < spectralSilence {
+			continue
+		}
+		fft(buf)
+		var total, weighted float64
+		for bin := range power {
+			re, im := real(buf[bin]), imag(buf[bin])
+			power[bin] = re*re + im*im
+			total += power[bin]
+			weighted += power[bin] * float64(bin) * binHz
+		}
+		if total == 0 {
+			continue
+		}
+		var cumulative float64
+		rolloffBin := len(power) - 1
+		for bin, p := range power {
+			cumulative += p
+			if cumulative >= spectralRolloff*total {
+				rolloffBin = bin
+				break
+			}
+		}
+		centroidSum += weighted / total
+		rolloffSum += float64(rolloffBin) * binHz
+		frames++
+	}
+	if frames == 0 {
+		return 0, 0, false
+	}
+	return centroidSum / float64(frames), rolloffSum / float64(frames), true
+}
+
+// countOnsets counts local maxima of the onset strength that stand out from
+// the track's own average, so quiet and loud masters are judged alike.
+func countOnsets(strength []float64) int {
+	if len(strength) == 0 {
+		return 0
+	}
+	var mean, variance float64
+	for _, v := range strength {
+		mean += v
+	}
+	mean /= float64(len(strength))
+	for _, v := range strength {
+		variance += (v - mean) * (v - mean)
+	}
+	threshold := math.Max(onsetMinStrength, mean+math.Sqrt(variance/float64(len(strength))))
+
+	count := 0
+	for i, v := range strength {
+		if v < threshold {
+			continue
+		}
+		peak := true
+		for j := max(0, i-onsetPeakWindow); j <= min(len(strength)-1, i+onsetPeakWindow); j++ {
+			// Ties go to the earliest frame so a flat peak counts once.
+			if strength[j] > v || (strength[j] == v && j < i) {
+				peak = false
+				break
+			}
+		}
+		if peak {
+			count++
+		}
+	}
+	return count
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDetectSpectralOnSineAndNoise(t *testing.T) {
+	sine, ok := DetectSpectral(tones([]float64{440}, 5, tempoSampleRate), tempoSampleRate)
+	if !ok {
+		t.Fatalf("expected features for a sine")
+	}
+	if math.Abs(sine.CentroidHz-440) > 20 || math.Abs(sine.RolloffHz-440) > 20 {
+		t.Fatalf("unexpected sine brightness %+v", sine)
+	}
+	if math.Abs(sine.CrestFactorDB-3.01) > 0.1 {
+		t.Fatalf("expected a sine crest factor of 3 dB, got %v", sine.CrestFactorDB)
+	}
+	if want := 2 * 440.0 / tempoSampleRate; math.Abs(sine.ZeroCrossingRate-want) > 0.002 {
+		t.Fatalf("expected zero crossing rate %v, got %v", want, sine.ZeroCrossingRate)
+	}
+	if sine.OnsetDensity > 0.5 {
+		t.Fatalf("expected a steady tone to have almost no onsets, got %v", sine.OnsetDensity)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	noise := make([]float32, 5*tempoSampleRate)
+	for i := range noise {
+		noise[i] = float32(rng.Float64()*2 - 1)
+	}
+	hiss, ok := DetectSpectral(noise, tempoSampleRate)
+	if !ok {
+		t.Fatalf("expected features for noise")
+	}
+	if hiss.CentroidHz < 2000 || hiss.RolloffHz < 4000 || hiss.ZeroCrossingRate < 0.4 {
+		t.Fatalf("expected noise to be bright and noisy, got %+v", hiss)
+	}
+}
+
+func TestDetectSpectralCountsOnsets(t *testing.T) {
+	got, ok := DetectSpectral(clickTrack(120, 20, tempoSampleRate), tempoSampleRate)
+	if !ok {
+		t.Fatalf("expected features")
+	}
+	if math.Abs(got.OnsetDensity-2) > 0.2 {
+		t.Fatalf("expected about two onsets per second, got %v", got.OnsetDensity)
+	}
+	if got.CrestFactorDB < 10 {
+		t.Fatalf("expected sparse clicks to have a high crest factor, got %v", got.CrestFactorDB)
+	}
+}
+
+func TestDetectSpectralRejectsSilence(t *testing.T) {
+	if _, ok := DetectSpectral(make([]float32, 5*tempoSampleRate), tempoSampleRate); ok {
+		t.Fatalf("expected silence to be rejected")
+	}
+	if _, ok := DetectSpectral(make([]float32, 100), tempoSampleRate); ok {
+		t.Fatalf("expected short audio to be rejected")
+	}
+}
+
+func TestAnalyzerStoresSpectralFeatures(t *testing.T) {
+	analyzer := Analyzer{
+		Root: "/library",
+		Pass: passStub{pass: Pass{
+			Measured:   MeasuredAudio{FileDurationSeconds: 200},
+			Samples:    tones([]float64{440}, 5, tempoSampleRate),
+			SampleRate: tempoSampleRate,
+		}},
+	}
+	got, err := analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if got.Measured.Spectral == nil || got.Measured.Spectral.CentroidHz == 0 {
+		t.Fatalf("expected spectral features, got %+v", got.Measured.Spectral)
+	}
+
+	analyzer.Pass = passStub{pass: Pass{
+		Measured:   MeasuredAudio{FileDurationSeconds: 200},
+		Samples:    make([]float32, 5*tempoSampleRate),
+		SampleRate: tempoSampleRate,
+	}}
+	got, err = analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
+	if err != nil {
+		t.Fatalf("analyze silence: %v", err)
+	}
+	if got.Measured.Spectral != nil {
+		t.Fatalf("expected no spectral features for silence, got %+v", got.Measured.Spectral)
+	}
+}
@@ -0,0 +1,201 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// r128ReferenceLUFS is the loudness Opus R128 gain tags normalize to
+// (RFC 7845).
+const r128ReferenceLUFS = -23.0
+
+// TagWriteFormats are the file formats FFmpegTagWriter can tag, named by
+// their file extension.
+var TagWriteFormats = []string{"flac", "mp3", "ogg", "opus"}
+
+// FileFormat names the format of a file by its lowercased extension.
+func FileFormat(path string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// Tag is one tag to write.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// ReplayGainFromLoudness turns measured loudness into ReplayGain values. The
+// gains bring the track, and the album when album is not nil, to
+// ReplayGainReferenceLUFS, and the true peaks in dBFS become linear sample
+// peaks. ok is false for a silent track, whose gain would be meaningless.
+func ReplayGainFromLoudness(lufs float64, truePeak *float64, album *AlbumLoudness) (RawReplayGain, bool) {
+	if lufs <= silenceGateLUFS {
+		return RawReplayGain{}, false
+	}
+	trackGain := ReplayGainReferenceLUFS - lufs
+	rg := RawReplayGain{TrackGainDB: &trackGain, TrackPeak: linearPeak(truePeak)}
+	if album != nil {
+		albumGain := album.GainDB()
+		rg.AlbumGainDB = &albumGain
+		rg.AlbumPeak = linearPeak(album.TruePeak)
+	}
+	return rg, true
+}
+
+func linearPeak(dbfs *float64) *float64 {
+	if dbfs == nil {
+		return nil
+	}
+	peak := math.Pow(10, *dbfs/20)
+	return &peak
+}
+
+// ReplayGainTags renders rg as the tags a file of format carries. Opus files
+// get R128 gains, which have no peaks; everything else gets ReplayGain tags.
+// Missing values are left out.
+func ReplayGainTags(format string, rg RawReplayGain) []Tag {
+	var tags []Tag
+	if format == "opus" {
+		for _, gain := range []struct {
+			key   string
+			value *float64
+		}{{"R128_TRACK_GAIN", rg.TrackGainDB}, {"R128_ALBUM_GAIN", rg.AlbumGainDB}} {
+			if gain.value != nil {
+				q78 := math.Round((*gain.value + r128ReferenceLUFS - ReplayGainReferenceLUFS) * 256)
+				tags = append(tags, Tag{Key: gain.key, Value: strconv.Itoa(int(q78))})
+			}
+		}
+		return tags
+	}
+	for _, value := range []struct {
+		key   string
+		value *float64
+		gain  bool
+	}{
+		{"REPLAYGAIN_TRACK_GAIN", rg.TrackGainDB, true},
+		{"REPLAYGAIN_TRACK_PEAK", rg.TrackPeak, false},
+		{"REPLAYGAIN_ALBUM_GAIN", rg.AlbumGainDB, true},
+		{"REPLAYGAIN_ALBUM_PEAK", rg.AlbumPeak, false},
+	} {
+		switch {
+		case value.value == nil:
+		case value.gain:
+			tags = append(tags, Tag{Key: value.key, Value: fmt.Sprintf("%.2f dB", *value.value)})
+		default:
+			tags = append(tags, Tag{Key: value.key, Value: fmt.Sprintf("%.6f", *value.value)})
+		}
+	}
+	return tags
+}
+
+// ReplayGainFromTags reads back the gains ReplayGainTags writes for format
+// from tags keyed in upper case. Opus files are read from their R128 gains
+// only, so ReplayGain tags left by another tool do not mask them.
+func ReplayGainFromTags(format string, tags map[string]string) RawReplayGain {
+	if format == "opus" {
+		return RawReplayGain{
+			TrackGainDB: parseR128Gain(tags["R128_TRACK_GAIN"]),
+			AlbumGainDB: parseR128Gain(tags["R128_ALBUM_GAIN"]),
+		}
+	}
+	return RawReplayGain{
+		TrackGainDB: parseReplayGainValue(tags["REPLAYGAIN_TRACK_GAIN"]),
+		TrackPeak:   parseReplayGainValue(tags["REPLAYGAIN_TRACK_PEAK"]),
+		AlbumGainDB: parseReplayGainValue(tags["REPLAYGAIN_ALBUM_GAIN"]),
+		AlbumPeak:   parseReplayGainValue(tags["REPLAYGAIN_ALBUM_PEAK"]),
+	}
+}
+
+// StaleReplayGainTags lists the ReplayGain tags an Opus file carries
+// alongside its R128 gains, as empty tags that remove them when written.
+// Players and the tag reader prefer those tags, so leaving them would hide
+// the R128 gains. Other formats have none.
+func StaleReplayGainTags(format string, tags map[string]string) []Tag {
+	if format != "opus" {
+		return nil
+	}
+	var stale []Tag
+	for _, key := range []string{"REPLAYGAIN_TRACK_GAIN", "REPLAYGAIN_TRACK_PEAK", "REPLAYGAIN_ALBUM_GAIN", "REPLAYGAIN_ALBUM_PEAK"} {
+		if _, ok := tags[key]; ok {
+			stale = append(stale, Tag{Key: key})
+		}
+	}
+	return stale
+}
+
+// FFmpegTagWriter writes tags by stream-copying a file with ffmpeg into a
+// temporary file beside it, which then replaces the original. The audio is
+// not re-encoded and the file's other tags are kept.
+type FFmpegTagWriter struct {
+	Runner CommandRunner
+}
+
+// WriteTags writes tags to the file at path; a tag with an empty value is
+// removed. verify, when not nil, is given the path of the rewritten file
+// before it replaces the original; if it fails, the original is left
+// untouched.
+func (w FFmpegTagWriter) WriteTags(ctx context.Context, path string, tags []Tag, verify func(context.Context, string) error) (err error) {
+	runner := w.Runner
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	format := FileFormat(path)
+	// Ogg and Opus keep their tags on the stream rather than the container.
+	metadata := "-metadata"
+	switch format {
+	case "flac", "mp3":
+	case "ogg", "opus":
+		metadata = "-metadata:s:a:0"
+	default:
+		return fmt.Errorf("cannot write tags to %s files", format)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmp.Close()
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	args := []string{
+		"-hide_banner",
+		"-nostdin",
+		"-v", "error",
+		"-i", path,
+		"-map", "0",
+		"-map_metadata", "0",
+		"-c", "copy",
+	}
+	for _, tag := range tags {
+		args = append(args, metadata, tag.Key+"="+tag.Value)
+	}
+	args = append(args, "-f", format, "-y", tmp.Name())
+	if out, err := runner.Run(ctx, "ffmpeg", args...); err != nil {
+		return commandError("ffmpeg tags", err, out)
+	}
+	if verify != nil {
+		if err := verify(ctx, tmp.Name()); err != nil {
+			return fmt.Errorf("verify %s: %w", path, err)
+		}
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("chmod %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReplayGainTags(t *testing.T) {
+	truePeak := -0.5
+	albumPeak := -0.1
+	rg, ok := ReplayGainFromLoudness(-11.5, &truePeak, &AlbumLoudness{IntegratedLUFS: -12.25, TruePeak: &albumPeak})
+	if !ok {
+		t.Fatal("expected replaygain values")
+	}
+
+	want := []Tag{
+		{Key: "REPLAYGAIN_TRACK_GAIN", Value: "-6.50 dB"},
+		{Key: "REPLAYGAIN_TRACK_PEAK", Value: "0.944061"},
+		{Key: "REPLAYGAIN_ALBUM_GAIN", Value: "-5.75 dB"},
+		{Key: "REPLAYGAIN_ALBUM_PEAK", Value: "0.988553"},
+	}
+	if got := ReplayGainTags("flac", rg); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected tags %+v", got)
+	}
+	// R128 gains are relative to -23 LUFS in 1/256 dB steps.
+	want = []Tag{{Key: "R128_TRACK_GAIN", Value: "-2944"}, {Key: "R128_ALBUM_GAIN", Value: "-2752"}}
+	if got := ReplayGainTags("opus", rg); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected opus tags %+v", got)
+	}
+
+	rg, _ = ReplayGainFromLoudness(-11.5, nil, nil)
+	if got := ReplayGainTags("mp3", rg); !reflect.DeepEqual(got, []Tag{{Key: "REPLAYGAIN_TRACK_GAIN", Value: "-6.50 dB"}}) {
+		t.Fatalf("expected only the track gain, got %+v", got)
+	}
+	if _, ok := ReplayGainFromLoudness(-70, nil, nil); ok {
+		t.Fatal("expected no replaygain values for silence")
+	}
+}
+
+func TestReplayGainTagsReadBack(t *testing.T) {
+	rg, _ := ReplayGainFromLoudness(-9.3, nil, &AlbumLoudness{IntegratedLUFS: -10})
+	tags := make(map[string]string)
+	for _, tag := range ReplayGainTags("opus", rg) {
+		tags[tag.Key] = tag.Value
+	}
+	got := parseFileTags(tags).ReplayGain
+	if !reflect.DeepEqual(ReplayGainTags("opus", got), ReplayGainTags("opus", rg)) {
+		t.Fatalf("R128 tags did not round-trip: %+v", got)
+	}
+}
+
+func TestReplayGainFromTagsReadsTheFormatsOwnKeys(t *testing.T) {
+	tags := map[string]string{"REPLAYGAIN_TRACK_GAIN": "-1.00 dB", "REPLAYGAIN_TRACK_PEAK": "0.9", "R128_TRACK_GAIN": "-2944"}
+
+	opus := ReplayGainFromTags("opus", tags)
+	if opus.TrackGainDB == nil || *opus.TrackGainDB != -6.5 || opus.TrackPeak != nil {
+		t.Fatalf("expected opus to read only its R128 gain, got %+v", opus)
+	}
+	flac := ReplayGainFromTags("flac", tags)
+	if flac.TrackGainDB == nil || *flac.TrackGainDB != -1 || flac.TrackPeak == nil {
+		t.Fatalf("expected flac to read its ReplayGain tags, got %+v", flac)
+	}
+
+	want := []Tag{{Key: "REPLAYGAIN_TRACK_GAIN"}, {Key: "REPLAYGAIN_TRACK_PEAK"}}
+	if got := StaleReplayGainTags("opus", tags); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := StaleReplayGainTags("flac", tags); got != nil {
+		t.Fatalf("expected no stale tags for flac, got %v", got)
+	}
+}
+
+func TestFFmpegTagWriterReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "song.opus")
+	if err := os.WriteFile(path, []byte("original"), 0o640); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	var gotArgs []string
+	writer := FFmpegTagWriter{Runner: commandRunnerStub{
+		run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			gotArgs = args
+			return nil, os.WriteFile(args[len(args)-1], []byte("tagged"), 0o600)
+		},
+	}}
+	var verified string
+	verify := func(ctx context.Context, tmp string) error {
+		verified = tmp
+		return nil
+	}
+	if err := writer.WriteTags(context.Background(), path, []Tag{{Key: "R128_TRACK_GAIN", Value: "-2944"}}, verify); err != nil {
+		t.Fatalf("write tags: %v", err)
+	}
+	if verified != gotArgs[len(gotArgs)-1] {
+		t.Fatalf("expected the temp file to be verified, got %q", verified)
+	}
+
+	if !slices.Contains(gotArgs, "-metadata:s:a:0") || !slices.Contains(gotArgs, "R128_TRACK_GAIN=-2944") ||
+		!slices.Contains(gotArgs, "copy") || gotArgs[len(gotArgs)-3] != "opus" {
+		t.Fatalf("unexpected ffmpeg args %v", gotArgs)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "tagged" {
+		t.Fatalf("expected the tagged file in place, got %q (%v)", data, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Fatalf("expected the original mode, got %v", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected no temp files left, got %d entries", len(entries))
+	}
+
+	if err := writer.WriteTags(context.Background(), filepath.Join(dir, "song.m4a"), nil, nil); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
+
+func TestFFmpegTagWriterKeepsOriginalWhenVerifyFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "song.flac")
+	if err := os.WriteFile(path, []byte("original"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	writer := FFmpegTagWriter{Runner: commandRunnerStub{
+		run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return nil, os.WriteFile(args[len(args)-1], []byte("tagged"), 0o600)
+		},
+	}}
+	verify := func(context.Context, string) error { return errors.New("tags missing") }
+
+	err := writer.WriteTags(context.Background(), path, []Tag{{Key: "REPLAYGAIN_TRACK_GAIN", Value: "-6.50 dB"}}, verify)
+	if err == nil || !strings.Contains(err.Error(), "tags missing") {
+		t.Fatalf("expected the verify error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "original" {
+		t.Fatalf("expected the original to be kept, got %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected the temp file to be removed, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,191 @@
+package audio
+
+import "math"
+
+const (
+	// tempoSampleRate is low enough to keep decoding cheap while still
+	// resolving the transients the onset envelope is built from.
+	tempoSampleRate = 11025
+	// tempoMaxSeconds caps how much of a track is decoded for the PCM
+	// extractors.
+	tempoMaxSeconds = 120
+	tempoMinSeconds = 10
+
+	tempoFrameSize = 512
+	tempoHopSize   = 128
+	tempoMinBPM    = 60.0
+	tempoMaxBPM    = 200.0
+	tempoPriorBPM  = 120.0
+
+	// trustedBPMMin and trustedBPMMax bound BPM tags worth believing.
+	trustedBPMMin = 40.0
+	trustedBPMMax = 250.0
+	// tempoTagTolerance is how far a tag may drift from a confident
+	// measurement, after octave folding, before the tag is ignored.
+	tempoTagTolerance = 0.04
+	// tempoConfidentAt is the measured confidence above which a BPM tag must
+	// agree with the measurement to be used.
+	tempoConfidentAt = 0.5
+)
+
+// Tempo is an estimated tempo. Confidence runs from 0 (no periodicity) to 1
+// (a perfectly regular pulse).
+type Tempo struct {
+	BPM        float64
+	Confidence float64
+}
+
+// DetectTempo estimates the tempo of mono samples. It builds an onset-strength
+// envelope from rises in log frame energy, autocorrelates it, and picks the
+// lag between 60 and 200 BPM that best explains the envelope, weighted
+// towards 120 BPM so half- and double-time readings lose ties. ok is false
+// when there is too little audio or no periodic onset pattern.
+func DetectTempo(samples []float32, sampleRate int) (Tempo, bool) {
+	if sampleRate <= 0 || len(samples) < tempoMinSeconds*sampleRate {
+		return Tempo{}, false
+	}
+	envelope := onsetEnvelope(samples)
+	framesPerSecond := float64(sampleRate) / tempoHopSize
+	minLag := int(math.Floor(framesPerSecond * 60 / tempoMaxBPM))
+	maxLag := int(math.Ceil(framesPerSecond * 60 / tempoMinBPM))
+	if len(envelope) <= 2*maxLag+2 {
+		return Tempo{}, false
+	}
+
+	ac := autocorrelate(envelope, 2*maxLag+2)
+	if ac[0] <= 0 {
+		return Tempo{}, false
+	}
+	scores := make([]float64, maxLag+2)
+	best := -1
+	for lag := minLag; lag <= maxLag+1; lag++ {
+		bpm := framesPerSecond * 60 / float64(lag)
+		octaves := math.Log2(bpm / tempoPriorBPM)
+		prior := math.Exp(-0.5 * octaves * octaves)
+		// Adding the second harmonic favours the lag whose multiples also
+		// line up with onsets, which separates the beat from off-beats.
+		scores[lag] = prior * (ac[lag] + 0.5*ac[2*lag])
+		if lag <= maxLag && (best < 0 || scores[lag] > scores[best]) {
+			best = lag
+		}
+	}
+	if best < 0 || scores[best] <= 0 {
+		return Tempo{}, false
+	}
+
+	lag := float64(best)
+	if best > minLag {
+		prev, cur, next := scores[best-1], scores[best], scores[best+1]
+		if denom := prev - 2*cur + next; denom < 0 {
+			lag += 0.5 * (prev - next) / denom
+		}
+	}
+	confidence := math.Max(0, math.Min(1, ac[best]/ac[0]))
+	return Tempo{
+		BPM:        math.Round(framesPerSecond*60/lag*10) / 10,
+		Confidence: math.Round(confidence*1000) / 1000,
+	}, true
+}
+
+// onsetEnvelope returns the onset strength smoothed and mean-removed so
+// autocorrelation measures periodicity rather than loudness.
+func onsetEnvelope(samples []float32) []float64 {
+	onsets := onsetStrength(samples)
+	if len(onsets) == 0 {
+		return nil
+	}
+	// A short triangular blur spreads each onset over neighbouring frames
+	// so beats that fall between hops still line up at the nearest lag.
+	smoothed := make([]float64, len(onsets))
+	var mean float64
+	for i := range onsets {
+		sum, weight := 2*onsets[i], 2.0
+		if i > 0 {
+			sum += onsets[i-1]
+			weight++
+		}
+		if i+1 < len(onsets) {
+			sum += onsets[i+1]
+			weight++
+		}
+		smoothed[i] = sum / weight
+		mean += smoothed[i]
+	}
+	mean /= float64(len(smoothed))
+	for i := range smoothed {
+		smoothed[i] -= mean
+	}
+	return smoothed
+}
+
+// onsetStrength returns the half-wave rectified rise in log energy between
+// frames of tempoHopSize samples.
+func onsetStrength(samples []float32) []float64 {
+	frames := (len(samples)-tempoFrameSize)/tempoHopSize + 1
+	if frames < 2 {
+		return nil
+	}
+	logEnergy := make([]float64, frames)
+	for f := range logEnergy {
+		start := f * tempoHopSize
+		var energy float64
+		prev := 0.0
+		if start > 0 {
+			prev = float64(samples[start-1])
+		}
+		for _, s := range samples[start : start+tempoFrameSize] {
+			// Pre-emphasis keeps sustained bass from masking attacks.
+			emphasized := float64(s) - 0.97*prev
+			prev = float64(s)
+			energy += emphasized * emphasized
+		}
+		logEnergy[f] = math.Log1p(1000 * energy / tempoFrameSize)
+	}
+
+	onsets := make([]float64, frames-1)
+	for i := range onsets {
+		onsets[i] = math.Max(0, logEnergy[i+1]-logEnergy[i])
+	}
+	return onsets
+}
+
+// autocorrelate returns the length-normalized autocorrelation of values for
+// lags 0 through maxLag inclusive.
+func autocorrelate(values []float64, maxLag int) []float64 {
+	ac := make([]float64, maxLag+1)
+	for lag := range ac {
+		var sum float64
+		for i := 0; i+lag < len(values); i++ {
+			sum += values[i] * values[i+lag]
+		}
+		ac[lag] = sum / float64(len(values)-lag)
+	}
+	return ac
+}
+
+// EffectiveTempo picks the tempo to use for a track. A file BPM tag wins over
+// the server-reported BPM, which wins over the measured tempo, but only while
+// the tag is trustworthy: inside a plausible range and, when the measurement
+// is confident, matching it up to a factor of two.
+func EffectiveTempo(tag, server *float64, measured MeasuredAudio) (*float64, string) {
+	return firstValue([]valueSource{
+		{trustedBPM(tag, measured), "tag_bpm"},
+		{trustedBPM(server, measured), "server_bpm"},
+		{measured.TempoBPM, "measured_tempo"},
+	})
+}
+
+func trustedBPM(bpm *float64, measured MeasuredAudio) *float64 {
+	if bpm == nil || *bpm < trustedBPMMin || *bpm > trustedBPMMax {
+		return nil
+	}
+	if measured.TempoBPM == nil || measured.TempoConfidence == nil || *measured.TempoConfidence < tempoConfidentAt {
+		return bpm
+	}
+	for _, factor := range []float64{1, 2, 0.5} {
+		if math.Abs(*bpm-*measured.TempoBPM*factor) <= *bpm*tempoTagTolerance {
+			return bpm
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDetectTempoFindsClickTrackTempo(t *testing.T) {
+	for _, bpm := range []float64{90, 120, 128, 174} {
+		samples := clickTrack(bpm, 30, tempoSampleRate)
+		got, ok := DetectTempo(samples, tempoSampleRate)
+		if !ok {
+			t.Fatalf("%v bpm: expected a tempo", bpm)
+		}
+		if math.Abs(got.BPM-bpm) > bpm*0.02 {
+			t.Fatalf("%v bpm: estimated %v", bpm, got.BPM)
+		}
+		if got.Confidence < 0.5 || got.Confidence > 1 {
+			t.Fatalf("%v bpm: unexpected confidence %v", bpm, got.Confidence)
+		}
+	}
+}
+
+func TestDetectTempoRejectsShortOrSilentAudio(t *testing.T) {
+	if _, ok := DetectTempo(clickTrack(120, 5, tempoSampleRate), tempoSampleRate); ok {
+		t.Fatalf("expected short audio to be rejected")
+	}
+	if _, ok := DetectTempo(make([]float32, 30*tempoSampleRate), tempoSampleRate); ok {
+		t.Fatalf("expected silence to be rejected")
+	}
+}
+
+func TestDecodeFloat32LE(t *testing.T) {
+	raw := make([]byte, 10)
+	binary.LittleEndian.PutUint32(raw[0:], math.Float32bits(0.5))
+	binary.LittleEndian.PutUint32(raw[4:], math.Float32bits(-1))
+	got := decodeFloat32LE(raw)
+	if len(got) != 2 || got[0] != 0.5 || got[1] != -1 {
+		t.Fatalf("unexpected samples %v", got)
+	}
+}
+
+func TestEffectiveTempoPrefersTrustedTags(t *testing.T) {
+	measuredBPM, confident, unsure := 128.0, 0.8, 0.2
+	measured := MeasuredAudio{TempoBPM: &measuredBPM, TempoConfidence: &confident}
+	tag, server := 127.5, 64.0
+
+	if got, source := EffectiveTempo(&tag, &server, measured); source != "tag_bpm" || *got != tag {
+		t.Fatalf("expected tag to win, got %v %s", got, source)
+	}
+	if got, source := EffectiveTempo(nil, &server, measured); source != "server_bpm" || *got != server {
+		t.Fatalf("expected half-time server bpm to be trusted, got %v %s", got, source)
+	}
+
+	wrong := 100.0
+	if got, source := EffectiveTempo(&wrong, nil, measured); source != "measured_tempo" || *got != measuredBPM {
+		t.Fatalf("expected disagreeing tag to lose to a confident measurement, got %v %s", got, source)
+	}
+	uncertain := MeasuredAudio{TempoBPM: &measuredBPM, TempoConfidence: &unsure}
+	if _, source := EffectiveTempo(&wrong, nil, uncertain); source != "tag_bpm" {
+		t.Fatalf("expected tag to win over an unsure measurement, got %s", source)
+	}
+
+	implausible := 999.0
+	if _, source := EffectiveTempo(&implausible, nil, MeasuredAudio{}); source != "none" {
+		t.Fatalf("expected implausible tag to be ignored, got %s", source)
+	}
+}
+
+func TestAnalyzerStoresTempo(t *testing.T) {
+	lufs := -9.0
+	tagBPM := 121.0
+	analyzer := Analyzer{
+		Root: "/library",
+		Pass: passStub{pass: Pass{
+			Measured:   MeasuredAudio{FileDurationSeconds: 200, IntegratedLUFS: &lufs},
+			Tags:       FileTags{BPM: &tagBPM},
+			Samples:    clickTrack(120, 20, tempoSampleRate),
+			SampleRate: tempoSampleRate,
+		}},
+	}
+	got, err := analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if got.Measured.TempoBPM == nil || math.Abs(*got.Measured.TempoBPM-120) > 2.4 || *got.Measured.TempoConfidence < 0.5 {
+		t.Fatalf("unexpected measured tempo %+v", got.Measured)
+	}
+	if got.TagBPM == nil || *got.Effective.TempoBPM != tagBPM || got.Effective.TempoSource != "tag_bpm" {
+		t.Fatalf("unexpected effective tempo %+v", got.Effective)
+	}
+
+	analyzer.Pass = passStub{pass: Pass{
+		Measured:   MeasuredAudio{FileDurationSeconds: 200, IntegratedLUFS: &lufs},
+		Samples:    make([]float32, 20*tempoSampleRate),
+		SampleRate: tempoSampleRate,
+	}}
+	got, err = analyzer.Analyze(context.Background(), "/albums/song.flac", ServerTags{})
+	if err != nil {
+		t.Fatalf("analyze silence: %v", err)
+	}
+	if got.Measured.TempoBPM != nil || got.Effective.TempoSource != "none" {
+		t.Fatalf("expected no tempo, got %+v", got.Effective)
+	}
+}
+
+// clickTrack renders short decaying noise bursts on every beat.
+func clickTrack(bpm, seconds float64, sampleRate int) []float32 {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float32, int(seconds*float64(sampleRate)))
+	period := 60 / bpm * float64(sampleRate)
+	clickLength := sampleRate / 50
+	for beat := 0.0; int(beat) < len(samples); beat += period {
+		for i := 0; i < clickLength && int(beat)+i < len(samples); i++ {
+			decay := math.Exp(-float64(i) / float64(clickLength) * 5)
+			samples[int(beat)+i] = float32((rng.Float64()*2 - 1) * decay)
+		}
+	}
+	return samples
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -19,9 +20,12 @@ import (
 const audioClaimStaleAfter = 5 * time.Minute
 
 type audioProcessConfig struct {
-	batchSize   int
-	workerCount int
-	processAll  bool
+	batchSize    int
+	workerCount  int
+	processAll   bool
+	maxAttempts  int
+	retryBackoff time.Duration
+	maxFailures  int
 }
 
 type audioJobStore interface {
@@ -29,13 +33,21 @@ type audioJobStore interface {
 	StartAudioProcessingRun(context.Context, time.Time) (int64, error)
 	CompleteAudioProcessingRun(context.Context, int64, sqlite.AudioProcessingRunSummary) error
 	UpsertTrackAudioFeatures(context.Context, sqlite.AudioFeatureRecord) error
+	UpdateTrackAudioFingerprint(context.Context, int64, sqlite.AudioFingerprintRecord) error
+	UpdateTrackFileTags(context.Context, int64, audio.FileTags, audio.ServerTags) error
+	RequeueOutdatedAudioJobs(context.Context, int) (int, error)
+	UpdateAlbumFlowThrough(context.Context, float64) error
+	UpdateAlbumLoudness(context.Context, time.Time) (int, error)
 	CompleteAudioJob(context.Context, int64) error
 	FailAudioJob(context.Context, int64, error) error
+	ReleaseAudioJobs(context.Context, []int64) error
 	Close() error
 }
 
 type audioAnalyzer interface {
-	Analyze(context.Context, string) (audio.AnalysisResult, error)
+	Fingerprint(context.Context, string, *audio.Fingerprint) (audio.Fingerprint, error)
+	FileTags(context.Context, string) (audio.FileTags, error)
+	Analyze(context.Context, string, audio.ServerTags) (audio.AnalysisResult, error)
 }
 
 func newAudioProcessCmd(opts *options) *cobra.Command {
@@ -55,6 +67,9 @@ func newAudioProcessCmd(opts *options) *cobra.Command {
 	cmd.Flags().IntVar(&cfg.batchSize, "batch-size", cfg.batchSize, "Number of audio jobs to fetch per batch")
 	cmd.Flags().IntVar(&cfg.workerCount, "workers", cfg.workerCount, "Number of concurrent audio workers")
 	cmd.Flags().BoolVar(&cfg.processAll, "all", false, "Process audio jobs until the queue is empty")
+	cmd.Flags().IntVar(&cfg.maxAttempts, "max-attempts", sqlite.DefaultJobMaxAttempts, "Attempts before a failing audio job is marked dead")
+	cmd.Flags().IntVar(&cfg.maxFailures, "max-failures", 0, "Stop the run after this many failed audio jobs (0 disables)")
+	cmd.Flags().DurationVar(&cfg.retryBackoff, "retry-backoff", sqlite.DefaultJobRetryDelay, "Delay before retrying a failed audio job; doubles on each retry")
 
 	return cmd
 }
@@ -83,12 +98,29 @@ func runAudioProcess(ctx context.Context, cmd *cobra.Command, opts *options, cfg
 		return fmt.Errorf("resolve db path: %w", err)
 	}
 
-	store, err := opts.newAudioStore(sqlite.Config{Path: dbPath})
+	store, err := opts.newAudioStore(sqlite.Config{
+		Path: dbPath,
+		AudioRetry: sqlite.JobRetryPolicy{
+			MaxAttempts: cfg.maxAttempts,
+			BaseDelay:   cfg.retryBackoff,
+		},
+	})
 	if err != nil {
 		return fmt.Errorf("open store: %w", err)
 	}
 	defer store.Close()
 
+	requeued, err := store.RequeueOutdatedAudioJobs(ctx, audio.AnalyzerVersion)
+	if err != nil {
+		return err
+	}
+	if requeued > 0 {
+		logger.Info("queued tracks analyzed by an older analyzer",
+			"tracks", requeued,
+			"analyzer_version", audio.AnalyzerVersion,
+		)
+	}
+
 	runStartedAt := time.Now().UTC()
 	runID, err := store.StartAudioProcessingRun(ctx, runStartedAt)
 	if err != nil {
@@ -96,6 +128,7 @@ func runAudioProcess(ctx context.Context, cmd *cobra.Command, opts *options, cfg
 	}
 
 	analyzer := opts.newAudioAnalyzer(opts.libraryRoot)
+	breaker := &failureBreaker{max: cfg.maxFailures}
 	claimedBy := fmt.Sprintf("audio-process-%d", os.Getpid())
 	summary := sqlite.AudioProcessingRunSummary{Status: "completed"}
 	totalProcessed := 0
@@ -133,9 +166,10 @@ func runAudioProcess(ctx context.Context, cmd *cobra.Command, opts *options, cfg
 			"jobs", len(jobs),
 			"workers", cfg.workerCount,
 		)
-		batchSummary, err := processAudioBatch(ctx, store, analyzer, jobs, cfg.workerCount, logger)
+		batchSummary, err := processAudioBatch(ctx, store, analyzer, jobs, cfg.workerCount, breaker, logger)
 		summary.JobsCompleted += batchSummary.completed
 		summary.JobsFailed += batchSummary.failed
+		summary.JobsSkipped += batchSummary.skipped
 		if err != nil {
 			summary.Status = "failed"
 			summary.CompletedAt = time.Now().UTC()
@@ -149,23 +183,78 @@ func runAudioProcess(ctx context.Context, cmd *cobra.Command, opts *options, cfg
 		}
 	}
 
+	// Whether a track flows into the next depends on its album neighbours,
+	// so the flags are recomputed once every batch is stored.
+	if err := store.UpdateAlbumFlowThrough(ctx, audio.FlowMaxGapSeconds); err != nil {
+		summary.Status = "failed"
+		summary.CompletedAt = time.Now().UTC()
+		_ = store.CompleteAudioProcessingRun(ctx, runID, summary)
+		return err
+	}
+	// Album loudness needs every track of an album, so it is aggregated the
+	// same way.
+	albums, err := store.UpdateAlbumLoudness(ctx, time.Now().UTC())
+	if err != nil {
+		summary.Status = "failed"
+		summary.CompletedAt = time.Now().UTC()
+		_ = store.CompleteAudioProcessingRun(ctx, runID, summary)
+		return err
+	}
+
 	summary.CompletedAt = time.Now().UTC()
 	if err := store.CompleteAudioProcessingRun(ctx, runID, summary); err != nil {
 		return fmt.Errorf("complete audio processing run: %w", err)
 	}
-	logger.Info("audio processing complete", "processed_jobs", totalProcessed)
+	logger.Info("audio processing complete",
+		"processed_jobs", totalProcessed,
+		"completed_jobs", summary.JobsCompleted,
+		"failed_jobs", summary.JobsFailed,
+		"skipped_jobs", summary.JobsSkipped,
+		"measured_albums", albums,
+	)
 	return nil
 }
 
 type audioBatchSummary struct {
 	completed int
 	failed    int
+	// skipped counts completed jobs whose audio was unchanged.
+	skipped int
+}
+
+// errTooManyFailures stops audio-process once --max-failures jobs have failed,
+// which usually means the library itself is unreachable.
+var errTooManyFailures = errors.New("too many failed audio jobs")
+
+// failureBreaker counts failed jobs across a run and trips at max. A nil
+// breaker or a max of zero never trips.
+type failureBreaker struct {
+	max    int
+	failed atomic.Int64
+}
+
+func (b *failureBreaker) record() {
+	if b != nil {
+		b.failed.Add(1)
+	}
+}
+
+func (b *failureBreaker) tripped() bool {
+	return b != nil && b.max > 0 && b.failed.Load() >= int64(b.max)
 }
 
-func processAudioBatch(ctx context.Context, store audioJobStore, analyzer audioAnalyzer, jobs []sqlite.AudioJob, workers int, logger *slog.Logger) (audioBatchSummary, error) {
+// processAudioBatch analyzes jobs on workers goroutines. A job whose file
+// cannot be analyzed is failed on its own and the batch carries on; only
+// store errors, cancellation or a tripped breaker end the batch with an error.
+// Jobs left unstarted by a tripped breaker are released back to pending.
+// A job whose audio is unchanged since it was analyzed by the current
+// analyzer version is completed without analyzing the file again; only its
+// tags are read, and its effective values recomputed from them.
+func processAudioBatch(ctx context.Context, store audioJobStore, analyzer audioAnalyzer, jobs []sqlite.AudioJob, workers int, breaker *failureBreaker, logger *slog.Logger) (audioBatchSummary, error) {
 	jobCh := make(chan sqlite.AudioJob)
 	errCh := make(chan error, len(jobs)+workers)
 	resultCh := make(chan audioBatchSummary, len(jobs))
+	releaseCh := make(chan int64, len(jobs))
 
 	var wg sync.WaitGroup
 	for i := 0; i < workers; i++ {
@@ -180,6 +269,10 @@ func processAudioBatch(ctx context.Context, store audioJobStore, analyzer audioA
 					return
 				default:
 				}
+				if breaker.tripped() {
+					releaseCh <- job.ID
+					continue
+				}
 
 				workerLogger.Info("processing audio job",
 					"job_id", job.ID,
@@ -188,30 +281,60 @@ func processAudioBatch(ctx context.Context, store audioJobStore, analyzer audioA
 					"path", job.Track.Path,
 				)
 
-				result, err := analyzer.Analyze(ctx, job.Track.Path)
+				fingerprint, err := analyzer.Fingerprint(ctx, job.Track.Path, storedFingerprint(job.Fingerprint))
+				unchanged := err == nil && audioUnchanged(job.Fingerprint, fingerprint)
+				var (
+					tags   audio.FileTags
+					result audio.AnalysisResult
+				)
+				switch {
+				case err != nil:
+				case unchanged:
+					// Only the tags may have changed, so they are read again
+					// without analyzing the file.
+					tags, err = analyzer.FileTags(ctx, job.Track.Path)
+				default:
+					result, err = analyzer.Analyze(ctx, job.Track.Path, audio.ServerMetadata(job.Track.Extended))
+				}
 				if err != nil {
+					if ctx.Err() != nil {
+						errCh <- ctx.Err()
+						return
+					}
 					workerLogger.Error("audio job failed", "job_id", job.ID, "error", err)
-					_ = store.FailAudioJob(ctx, job.ID, err)
-					errCh <- err
 					resultCh <- audioBatchSummary{failed: 1}
+					breaker.record()
+					if err := store.FailAudioJob(ctx, job.ID, err); err != nil {
+						errCh <- fmt.Errorf("record audio job %d failure: %w", job.ID, err)
+					}
 					continue
 				}
 
-				if err := store.UpsertTrackAudioFeatures(ctx, sqlite.AudioFeatureRecord{
-					TrackID:                job.TrackID,
-					AnalyzedAt:             result.AnalyzedAt,
-					FileDurationSeconds:    result.Measured.FileDurationSeconds,
-					MeasuredIntegratedLUFS: result.Measured.IntegratedLUFS,
-					MeasuredTruePeak:       result.Measured.TruePeak,
-					ReplayGainTrackGainDB:  result.ReplayGain.TrackGainDB,
-					ReplayGainTrackPeak:    result.ReplayGain.TrackPeak,
-					ReplayGainAlbumGainDB:  result.ReplayGain.AlbumGainDB,
-					ReplayGainAlbumPeak:    result.ReplayGain.AlbumPeak,
-					EffectiveGainDB:        result.Effective.GainDB,
-					EffectivePeak:          result.Effective.Peak,
-					EffectiveGainSource:    result.Effective.GainSource,
-					EffectivePeakSource:    result.Effective.PeakSource,
-				}); err != nil {
+				if unchanged {
+					if err := store.UpdateTrackAudioFingerprint(ctx, job.TrackID, fingerprintRecord(fingerprint)); err != nil {
+						errCh <- fmt.Errorf("update audio fingerprint for job %d: %w", job.ID, err)
+						resultCh <- audioBatchSummary{failed: 1}
+						continue
+					}
+					if err := store.UpdateTrackFileTags(ctx, job.TrackID, tags, audio.ServerMetadata(job.Track.Extended)); err != nil {
+						errCh <- fmt.Errorf("update file tags for job %d: %w", job.ID, err)
+						resultCh <- audioBatchSummary{failed: 1}
+						continue
+					}
+					if err := store.CompleteAudioJob(ctx, job.ID); err != nil {
+						errCh <- fmt.Errorf("complete audio job %d: %w", job.ID, err)
+						resultCh <- audioBatchSummary{failed: 1}
+						continue
+					}
+					workerLogger.Info("audio unchanged, skipped analysis", "job_id", job.ID)
+					resultCh <- audioBatchSummary{completed: 1, skipped: 1}
+					continue
+				}
+
+				stored := fingerprintRecord(fingerprint)
+				record := audioFeatureRecord(job.TrackID, result)
+				record.Fingerprint = &stored
+				if err := store.UpsertTrackAudioFeatures(ctx, record); err != nil {
 					_ = store.FailAudioJob(ctx, job.ID, err)
 					errCh <- fmt.Errorf("persist audio features for job %d: %w", job.ID, err)
 					resultCh <- audioBatchSummary{failed: 1}
@@ -244,17 +367,118 @@ func processAudioBatch(ctx context.Context, store audioJobStore, analyzer audioA
 	wg.Wait()
 	close(errCh)
 	close(resultCh)
+	close(releaseCh)
 
 	summary := audioBatchSummary{}
 	for result := range resultCh {
 		summary.completed += result.completed
 		summary.failed += result.failed
+		summary.skipped += result.skipped
+	}
+	// The claim counted an attempt against each job the breaker skipped;
+	// left claimed, they would go stale and the ones on their last attempt
+	// would be marked dead without ever being analyzed.
+	var released []int64
+	for id := range releaseCh {
+		released = append(released, id)
+	}
+	if err := store.ReleaseAudioJobs(ctx, released); err != nil {
+		return summary, err
 	}
 	for err := range errCh {
 		if err != nil && !errors.Is(err, context.Canceled) {
 			return summary, err
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return summary, err
+	}
+	if breaker.tripped() {
+		return summary, fmt.Errorf("%w: %d jobs failed", errTooManyFailures, breaker.failed.Load())
+	}
+	return summary, nil
+}
+
+// audioUnchanged reports whether stored was measured by the current analyzer
+// from the same audio current describes.
+func audioUnchanged(stored *sqlite.AudioFingerprintRecord, current audio.Fingerprint) bool {
+	if stored == nil || stored.AnalyzerVersion != audio.AnalyzerVersion {
+		return false
+	}
+	return current.SameAudio(*storedFingerprint(stored))
+}
+
+func storedFingerprint(record *sqlite.AudioFingerprintRecord) *audio.Fingerprint {
+	if record == nil {
+		return nil
+	}
+	return &audio.Fingerprint{
+		Size:      record.Size,
+		ModTime:   record.ModTime,
+		AudioHash: record.AudioHash,
+	}
+}
+
+// fingerprintRecord stores fingerprint as measured by the current analyzer.
+func fingerprintRecord(fingerprint audio.Fingerprint) sqlite.AudioFingerprintRecord {
+	return sqlite.AudioFingerprintRecord{
+		Size:            fingerprint.Size,
+		ModTime:         fingerprint.ModTime,
+		AudioHash:       fingerprint.AudioHash,
+		AnalyzerVersion: audio.AnalyzerVersion,
+	}
+}
 
-	return summary, ctx.Err()
+// audioFeatureRecord converts an analysis result into the stored feature row
+// for trackID.
+func audioFeatureRecord(trackID int64, result audio.AnalysisResult) sqlite.AudioFeatureRecord {
+	record := sqlite.AudioFeatureRecord{
+		TrackID:                 trackID,
+		AnalyzedAt:              result.AnalyzedAt,
+		FileDurationSeconds:     result.Measured.FileDurationSeconds,
+		MeasuredIntegratedLUFS:  result.Measured.IntegratedLUFS,
+		MeasuredTruePeak:        result.Measured.TruePeak,
+		MeasuredLoudnessRange:   result.Measured.LoudnessRange,
+		ReplayGainTrackGainDB:   result.ReplayGain.TrackGainDB,
+		ReplayGainTrackPeak:     result.ReplayGain.TrackPeak,
+		ReplayGainAlbumGainDB:   result.ReplayGain.AlbumGainDB,
+		ReplayGainAlbumPeak:     result.ReplayGain.AlbumPeak,
+		EffectiveGainDB:         result.Effective.GainDB,
+		EffectivePeak:           result.Effective.Peak,
+		EffectiveGainSource:     result.Effective.GainSource,
+		EffectivePeakSource:     result.Effective.PeakSource,
+		MeasuredTempoBPM:        result.Measured.TempoBPM,
+		MeasuredTempoConfidence: result.Measured.TempoConfidence,
+		TagBPM:                  result.TagBPM,
+		EffectiveBPM:            result.Effective.TempoBPM,
+		EffectiveBPMSource:      result.Effective.TempoSource,
+		AcousticFingerprint:     result.Measured.AcousticFingerprint,
+	}
+	if key := result.Measured.Key; key != nil {
+		record.MeasuredKey = key.TonicName()
+		record.MeasuredMode = key.Mode
+		record.MeasuredKeyConfidence = &key.Confidence
+		record.CamelotKey = key.Camelot()
+	}
+	if spectral := result.Measured.Spectral; spectral != nil {
+		record.Spectral = &sqlite.SpectralFeatureRecord{
+			Version:          audio.SpectralVersion,
+			CentroidHz:       spectral.CentroidHz,
+			RolloffHz:        spectral.RolloffHz,
+			CrestFactorDB:    spectral.CrestFactorDB,
+			ZeroCrossingRate: spectral.ZeroCrossingRate,
+			OnsetDensity:     spectral.OnsetDensity,
+		}
+	}
+	if boundaries := result.Measured.Boundaries; boundaries != nil {
+		record.Boundaries = &sqlite.BoundaryRecord{
+			LeadingSilenceSeconds:  boundaries.LeadingSilenceSeconds,
+			TrailingSilenceSeconds: boundaries.TrailingSilenceSeconds,
+			ContentStartSeconds:    boundaries.ContentStartSeconds,
+			ContentEndSeconds:      boundaries.ContentEndSeconds,
+			AbruptStart:            boundaries.AbruptStart,
+			AbruptEnd:              boundaries.AbruptEnd,
+		}
+	}
+	return record
 }
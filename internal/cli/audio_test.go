@@ -6,6 +6,7 @@ import (
 	"errors"
 	"log/slog"
 	"path/filepath"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -23,12 +24,15 @@ func TestRunAudioProcessClaimsJobsFromStore(t *testing.T) {
 	cmd.SetOut(out)
 	cmd.SetErr(out)
 
+	serverGain := -7.5
+	track := testAudioTrack("track-1")
+	track.Extended.ReplayGain.AlbumGainDB = &serverGain
 	store := &audioJobStoreStub{
 		claimBatches: [][]sqlite.AudioJob{
 			{{
 				ID:      1,
 				TrackID: 101,
-				Track:   testAudioTrack("track-1"),
+				Track:   track,
 			}},
 			nil,
 		},
@@ -37,6 +41,7 @@ func TestRunAudioProcessClaimsJobsFromStore(t *testing.T) {
 		AnalyzedAt: time.Unix(100, 0).UTC(),
 		Measured: audio.MeasuredAudio{
 			FileDurationSeconds: 120,
+			Boundaries:          &audio.Boundaries{ContentStartSeconds: 0.4, ContentEndSeconds: 119.9, AbruptEnd: true},
 		},
 		Effective: audio.EffectiveAudio{
 			GainSource: "none",
@@ -78,12 +83,197 @@ func TestRunAudioProcessClaimsJobsFromStore(t *testing.T) {
 	if len(store.featureRecords) != 1 || store.featureRecords[0].TrackID != 101 {
 		t.Fatalf("unexpected feature records %+v", store.featureRecords)
 	}
+	if b := store.featureRecords[0].Boundaries; b == nil || b.ContentStartSeconds != 0.4 || b.ContentEndSeconds != 119.9 || !b.AbruptEnd {
+		t.Fatalf("unexpected boundaries %+v", b)
+	}
+	if len(store.flowGaps) != 1 || store.flowGaps[0] != audio.FlowMaxGapSeconds {
+		t.Fatalf("expected album flow-through to be recomputed once, got %v", store.flowGaps)
+	}
+	if store.albumUpdates != 1 {
+		t.Fatalf("expected album loudness to be recomputed once, got %d", store.albumUpdates)
+	}
 	if len(store.runSummaries) != 1 || store.runSummaries[0].Status != "completed" {
 		t.Fatalf("unexpected run summaries %+v", store.runSummaries)
 	}
 	if analyzer.root != defaultLibraryRoot {
 		t.Fatalf("unexpected analyzer root %q", analyzer.root)
 	}
+	if len(analyzer.server) != 1 || analyzer.server[0].ReplayGain.AlbumGainDB == nil || *analyzer.server[0].ReplayGain.AlbumGainDB != serverGain {
+		t.Fatalf("expected server replay gain to reach the analyzer, got %+v", analyzer.server)
+	}
+}
+
+func TestRunAudioProcessSkipsUnchangedAudio(t *testing.T) {
+	cmd := &cobra.Command{}
+	stored := &sqlite.AudioFingerprintRecord{Size: 100, ModTime: time.Unix(10, 0).UTC(), AudioHash: "sha256:same", AnalyzerVersion: audio.AnalyzerVersion}
+	outdated := *stored
+	outdated.AnalyzerVersion = audio.AnalyzerVersion - 1
+	store := &audioJobStoreStub{
+		claimBatches: [][]sqlite.AudioJob{{
+			{ID: 1, TrackID: 101, Track: testAudioTrack("retagged"), Fingerprint: stored},
+			{ID: 2, TrackID: 102, Track: testAudioTrack("outdated"), Fingerprint: &outdated},
+		}},
+	}
+	retagged := audio.Fingerprint{Size: 120, ModTime: time.Unix(20, 0).UTC(), AudioHash: "sha256:same"}
+	tagGain := -6.5
+	analyzer := &audioAnalyzerStub{
+		fingerprint: retagged,
+		tags:        audio.FileTags{ReplayGain: audio.RawReplayGain{TrackGainDB: &tagGain}},
+		result:      audio.AnalysisResult{Effective: audio.EffectiveAudio{GainSource: "none", PeakSource: "none"}},
+	}
+	opts := &options{
+		dbPath: filepath.Join(t.TempDir(), "jobs.db"),
+		newAudioStore: func(cfg sqlite.Config) (audioJobStore, error) {
+			return store, nil
+		},
+		newAudioAnalyzer: func(root string) audioAnalyzer {
+			return analyzer
+		},
+		logFormat: "text",
+	}
+	if err := runAudioProcess(context.Background(), cmd, opts, audioProcessConfig{batchSize: 2, workerCount: 1}); err != nil {
+		t.Fatalf("runAudioProcess: %v", err)
+	}
+
+	if len(store.requeueVersions) != 1 || store.requeueVersions[0] != audio.AnalyzerVersion {
+		t.Fatalf("expected outdated tracks to be queued for version %d, got %v", audio.AnalyzerVersion, store.requeueVersions)
+	}
+	if len(analyzer.analyzed) != 1 || analyzer.analyzed[0] != "/music/outdated.flac" {
+		t.Fatalf("expected only the outdated track to be analyzed, got %v", analyzer.analyzed)
+	}
+	if got := store.fingerprints[101]; got.Size != 120 || !got.ModTime.Equal(retagged.ModTime) {
+		t.Fatalf("expected the skipped track's fingerprint to be refreshed, got %+v", store.fingerprints)
+	}
+	if got := store.fileTags[101].ReplayGain.TrackGainDB; len(store.fileTags) != 1 || got == nil || *got != tagGain {
+		t.Fatalf("expected the skipped track's tags to be refreshed, got %+v", store.fileTags)
+	}
+	if len(store.featureRecords) != 1 || store.featureRecords[0].Fingerprint == nil ||
+		store.featureRecords[0].Fingerprint.AnalyzerVersion != audio.AnalyzerVersion || store.featureRecords[0].Fingerprint.AudioHash != "sha256:same" {
+		t.Fatalf("expected the analyzed track to store its fingerprint, got %+v", store.featureRecords)
+	}
+	if len(store.completedJobIDs) != 2 {
+		t.Fatalf("expected both jobs to complete, got %v", store.completedJobIDs)
+	}
+	if summary := store.runSummaries[0]; summary.JobsCompleted != 2 || summary.JobsSkipped != 1 {
+		t.Fatalf("unexpected run summary %+v", summary)
+	}
+}
+
+func TestRequeuedTrackIsReanalyzedWhenUnchanged(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "requeue.db")
+	store, err := sqlite.New(sqlite.Config{Path: dbPath})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	syncID, err := store.StartSync(ctx, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("start sync: %v", err)
+	}
+	if _, err := store.SaveTracks(ctx, syncID, []app.Track{testAudioTrack("track-1")}); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+
+	analyzer := &audioAnalyzerStub{
+		fingerprint: audio.Fingerprint{Size: 100, ModTime: time.Unix(10, 0).UTC(), AudioHash: "sha256:same"},
+		result:      audio.AnalysisResult{Effective: audio.EffectiveAudio{GainSource: "none", PeakSource: "none"}},
+	}
+	opts := &options{
+		dbPath: dbPath,
+		newAudioStore: func(cfg sqlite.Config) (audioJobStore, error) {
+			return sqlite.New(cfg)
+		},
+		newAudioAnalyzer: func(root string) audioAnalyzer {
+			return analyzer
+		},
+		logFormat: "text",
+	}
+	run := func() {
+		t.Helper()
+		if err := runAudioProcess(ctx, &cobra.Command{}, opts, audioProcessConfig{batchSize: 10, workerCount: 1}); err != nil {
+			t.Fatalf("runAudioProcess: %v", err)
+		}
+	}
+
+	run()
+	if found, err := store.RequeueTrackJobs(ctx, "track-1", sqlite.AudioQueue); err != nil || !found {
+		t.Fatalf("requeue track: found=%v err=%v", found, err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("close store: %v", err)
+	}
+	run()
+
+	if len(analyzer.analyzed) != 2 {
+		t.Fatalf("expected the requeued track to be analyzed again, got %v", analyzer.analyzed)
+	}
+}
+
+func TestUnchangedAudioPicksUpNewTags(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "retag.db")
+	store, err := sqlite.New(sqlite.Config{Path: dbPath})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	track := testAudioTrack("track-1")
+	sync := func() {
+		t.Helper()
+		syncID, err := store.StartSync(ctx, time.Now().UTC())
+		if err != nil {
+			t.Fatalf("start sync: %v", err)
+		}
+		if _, err := store.SaveTracks(ctx, syncID, []app.Track{track}); err != nil {
+			t.Fatalf("save tracks: %v", err)
+		}
+	}
+
+	lufs := -10.0
+	analyzer := &audioAnalyzerStub{
+		fingerprint: audio.Fingerprint{Size: 100, ModTime: time.Unix(10, 0).UTC(), AudioHash: "sha256:same"},
+		result: audio.AnalysisResult{
+			Measured:  audio.MeasuredAudio{IntegratedLUFS: &lufs},
+			Effective: audio.EffectiveValues(audio.RawReplayGain{}, audio.RawReplayGain{}, nil, audio.MeasuredAudio{IntegratedLUFS: &lufs}),
+		},
+	}
+	opts := &options{
+		dbPath: dbPath,
+		newAudioStore: func(cfg sqlite.Config) (audioJobStore, error) {
+			return sqlite.New(cfg)
+		},
+		newAudioAnalyzer: func(root string) audioAnalyzer {
+			return analyzer
+		},
+		logFormat: "text",
+	}
+	run := func() {
+		t.Helper()
+		if err := runAudioProcess(ctx, &cobra.Command{}, opts, audioProcessConfig{batchSize: 10, workerCount: 1}); err != nil {
+			t.Fatalf("runAudioProcess: %v", err)
+		}
+	}
+
+	sync()
+	run()
+	// The file is retagged with ReplayGain; its audio is unchanged.
+	tagGain := -6.5
+	analyzer.tags = audio.FileTags{ReplayGain: audio.RawReplayGain{TrackGainDB: &tagGain}}
+	track.Title = "track-1 (retagged)"
+	sync()
+	run()
+
+	if len(analyzer.analyzed) != 1 {
+		t.Fatalf("expected unchanged audio to be analyzed once, got %v", analyzer.analyzed)
+	}
+	candidates, err := store.LoadTrackCandidates(ctx, []int64{1})
+	if err != nil || len(candidates) != 1 {
+		t.Fatalf("load candidates: %v (%d)", err, len(candidates))
+	}
+	features := candidates[0].Features
+	if features.EffectiveGainDB == nil || *features.EffectiveGainDB != tagGain || features.EffectiveGainSource != "replaygain_track" {
+		t.Fatalf("expected the effective gain to come from the new tag, got %v from %s", features.EffectiveGainDB, features.EffectiveGainSource)
+	}
 }
 
 func TestRunAudioProcessExitsWhenNoJobsCanBeClaimed(t *testing.T) {
@@ -127,7 +317,7 @@ func TestProcessAudioBatchReturnsOnContextCancel(t *testing.T) {
 
 	done := make(chan error, 1)
 	go func() {
-		_, err := processAudioBatch(ctx, store, analyzer, jobs, 1, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+		_, err := processAudioBatch(ctx, store, analyzer, jobs, 1, nil, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
 		done <- err
 	}()
 
@@ -183,14 +373,46 @@ func TestRunAudioProcessUsesConfiguredLibraryRoot(t *testing.T) {
 	}
 }
 
-func TestRunAudioProcessMarksJobFailedOnAnalyzerError(t *testing.T) {
+func TestRunAudioProcessPassesRetryPolicyToStore(t *testing.T) {
+	var got sqlite.Config
+	opts := &options{
+		dbPath: filepath.Join(t.TempDir(), "jobs.db"),
+		newAudioStore: func(cfg sqlite.Config) (audioJobStore, error) {
+			got = cfg
+			return &audioJobStoreStub{}, nil
+		},
+		newAudioAnalyzer: func(root string) audioAnalyzer {
+			return &audioAnalyzerStub{}
+		},
+		logFormat: "text",
+	}
+	if err := runAudioProcess(context.Background(), &cobra.Command{}, opts, audioProcessConfig{
+		batchSize:    1,
+		workerCount:  1,
+		maxAttempts:  3,
+		retryBackoff: 30 * time.Second,
+	}); err != nil {
+		t.Fatalf("runAudioProcess: %v", err)
+	}
+	if got.AudioRetry.MaxAttempts != 3 || got.AudioRetry.BaseDelay != 30*time.Second {
+		t.Fatalf("unexpected audio retry policy %+v", got.AudioRetry)
+	}
+}
+
+func TestRunAudioProcessContinuesPastFailedJobs(t *testing.T) {
 	cmd := &cobra.Command{}
 	store := &audioJobStoreStub{
-		claimBatches: [][]sqlite.AudioJob{{{
-			ID:      1,
-			TrackID: 101,
-			Track:   testAudioTrack("track-1"),
-		}}},
+		claimBatches: [][]sqlite.AudioJob{
+			{{ID: 1, TrackID: 101, Track: testAudioTrack("corrupt")}},
+			{{ID: 2, TrackID: 102, Track: testAudioTrack("track-2")}},
+		},
+	}
+	analyzer := &audioAnalyzerStub{
+		failPaths: map[string]error{"/music/corrupt.flac": errors.New("invalid data found when processing input")},
+		result: audio.AnalysisResult{
+			AnalyzedAt: time.Unix(100, 0).UTC(),
+			Effective:  audio.EffectiveAudio{GainSource: "none", PeakSource: "none"},
+		},
 	}
 	opts := &options{
 		dbPath: filepath.Join(t.TempDir(), "jobs.db"),
@@ -198,16 +420,82 @@ func TestRunAudioProcessMarksJobFailedOnAnalyzerError(t *testing.T) {
 			return store, nil
 		},
 		newAudioAnalyzer: func(root string) audioAnalyzer {
-			return &audioAnalyzerStub{err: errors.New("analyzer boom"), root: root}
+			return analyzer
 		},
 		logFormat: "text",
 	}
-	if err := runAudioProcess(context.Background(), cmd, opts, audioProcessConfig{batchSize: 1, workerCount: 1}); err == nil {
-		t.Fatal("expected error")
+	if err := runAudioProcess(context.Background(), cmd, opts, audioProcessConfig{batchSize: 1, workerCount: 1, processAll: true}); err != nil {
+		t.Fatalf("runAudioProcess: %v", err)
 	}
 	if len(store.failedJobIDs) != 1 || store.failedJobIDs[0] != 1 {
 		t.Fatalf("unexpected failed jobs %+v", store.failedJobIDs)
 	}
+	if len(store.completedJobIDs) != 1 || store.completedJobIDs[0] != 2 {
+		t.Fatalf("expected processing to continue past the failed job, got %+v", store.completedJobIDs)
+	}
+	if len(store.runSummaries) != 1 {
+		t.Fatalf("unexpected run summaries %+v", store.runSummaries)
+	}
+	if summary := store.runSummaries[0]; summary.Status != "completed" || summary.JobsFailed != 1 || summary.JobsCompleted != 1 {
+		t.Fatalf("unexpected run summary %+v", summary)
+	}
+}
+
+func TestRunAudioProcessStopsAtMaxFailures(t *testing.T) {
+	cmd := &cobra.Command{}
+	jobs := []sqlite.AudioJob{
+		{ID: 1, TrackID: 101, Track: testAudioTrack("track-1")},
+		{ID: 2, TrackID: 102, Track: testAudioTrack("track-2")},
+		{ID: 3, TrackID: 103, Track: testAudioTrack("track-3")},
+	}
+	store := &audioJobStoreStub{claimBatches: [][]sqlite.AudioJob{jobs, jobs}}
+	opts := &options{
+		dbPath: filepath.Join(t.TempDir(), "jobs.db"),
+		newAudioStore: func(cfg sqlite.Config) (audioJobStore, error) {
+			return store, nil
+		},
+		newAudioAnalyzer: func(root string) audioAnalyzer {
+			return &audioAnalyzerStub{err: errors.New("no such file or directory")}
+		},
+		logFormat: "text",
+	}
+	err := runAudioProcess(context.Background(), cmd, opts, audioProcessConfig{batchSize: 3, workerCount: 1, processAll: true, maxFailures: 2})
+	if !errors.Is(err, errTooManyFailures) {
+		t.Fatalf("expected errTooManyFailures, got %v", err)
+	}
+	if len(store.failedJobIDs) != 2 {
+		t.Fatalf("expected the breaker to stop after 2 failures, got %+v", store.failedJobIDs)
+	}
+	if !reflect.DeepEqual(store.releasedJobIDs, []int64{3}) {
+		t.Fatalf("expected the unstarted job to be released, got %+v", store.releasedJobIDs)
+	}
+	if store.claimCalls != 1 {
+		t.Fatalf("expected no further batches after the breaker tripped, got %d claims", store.claimCalls)
+	}
+	if len(store.runSummaries) != 1 || store.runSummaries[0].Status != "failed" || store.runSummaries[0].JobsFailed != 2 {
+		t.Fatalf("unexpected run summaries %+v", store.runSummaries)
+	}
+}
+
+func TestRunAudioProcessStopsOnStoreErrors(t *testing.T) {
+	cmd := &cobra.Command{}
+	store := &audioJobStoreStub{
+		claimBatches: [][]sqlite.AudioJob{{{ID: 1, TrackID: 101, Track: testAudioTrack("track-1")}}},
+		failErr:      errors.New("database is locked"),
+	}
+	opts := &options{
+		dbPath: filepath.Join(t.TempDir(), "jobs.db"),
+		newAudioStore: func(cfg sqlite.Config) (audioJobStore, error) {
+			return store, nil
+		},
+		newAudioAnalyzer: func(root string) audioAnalyzer {
+			return &audioAnalyzerStub{err: errors.New("analyzer boom")}
+		},
+		logFormat: "text",
+	}
+	if err := runAudioProcess(context.Background(), cmd, opts, audioProcessConfig{batchSize: 1, workerCount: 1, processAll: true}); err == nil {
+		t.Fatal("expected error")
+	}
 	if len(store.runSummaries) != 1 || store.runSummaries[0].Status != "failed" {
 		t.Fatalf("unexpected run summaries %+v", store.runSummaries)
 	}
@@ -220,9 +508,16 @@ type audioJobStoreStub struct {
 	lastClaimOptions sqlite.ClaimOptions
 	completedJobIDs  []int64
 	failedJobIDs     []int64
+	releasedJobIDs   []int64
 	featureRecords   []sqlite.AudioFeatureRecord
+	fingerprints     map[int64]sqlite.AudioFingerprintRecord
+	fileTags         map[int64]audio.FileTags
+	requeueVersions  []int
+	flowGaps         []float64
+	albumUpdates     int
 	runIDs           []int64
 	runSummaries     []sqlite.AudioProcessingRunSummary
+	failErr          error
 }
 
 func (s *audioJobStoreStub) ClaimPendingAudioJobs(ctx context.Context, opts sqlite.ClaimOptions) ([]sqlite.AudioJob, error) {
@@ -267,23 +562,93 @@ func (s *audioJobStoreStub) UpsertTrackAudioFeatures(ctx context.Context, record
 	return nil
 }
 
+func (s *audioJobStoreStub) UpdateTrackAudioFingerprint(ctx context.Context, trackID int64, fingerprint sqlite.AudioFingerprintRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fingerprints == nil {
+		s.fingerprints = map[int64]sqlite.AudioFingerprintRecord{}
+	}
+	s.fingerprints[trackID] = fingerprint
+	return nil
+}
+
+func (s *audioJobStoreStub) UpdateTrackFileTags(ctx context.Context, trackID int64, tags audio.FileTags, server audio.ServerTags) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fileTags == nil {
+		s.fileTags = map[int64]audio.FileTags{}
+	}
+	s.fileTags[trackID] = tags
+	return nil
+}
+
+func (s *audioJobStoreStub) RequeueOutdatedAudioJobs(ctx context.Context, version int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requeueVersions = append(s.requeueVersions, version)
+	return 0, nil
+}
+
+func (s *audioJobStoreStub) UpdateAlbumFlowThrough(ctx context.Context, maxGapSeconds float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flowGaps = append(s.flowGaps, maxGapSeconds)
+	return nil
+}
+
+func (s *audioJobStoreStub) UpdateAlbumLoudness(ctx context.Context, computedAt time.Time) (int, error) {
+	s.albumUpdates++
+	return 0, nil
+}
+
 func (s *audioJobStoreStub) FailAudioJob(ctx context.Context, jobID int64, err error) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.failedJobIDs = append(s.failedJobIDs, jobID)
+	return s.failErr
+}
+
+func (s *audioJobStoreStub) ReleaseAudioJobs(ctx context.Context, jobIDs []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releasedJobIDs = append(s.releasedJobIDs, jobIDs...)
 	return nil
 }
 
 type audioAnalyzerStub struct {
-	root   string
-	result audio.AnalysisResult
-	err    error
+	root        string
+	result      audio.AnalysisResult
+	err         error
+	failPaths   map[string]error
+	fingerprint audio.Fingerprint
+	tags        audio.FileTags
+
+	mu       sync.Mutex
+	server   []audio.ServerTags
+	analyzed []string
 }
 
-func (a *audioAnalyzerStub) Analyze(ctx context.Context, navPath string) (audio.AnalysisResult, error) {
+func (a *audioAnalyzerStub) Fingerprint(ctx context.Context, navPath string, previous *audio.Fingerprint) (audio.Fingerprint, error) {
+	return a.fingerprint, nil
+}
+
+func (a *audioAnalyzerStub) FileTags(ctx context.Context, navPath string) (audio.FileTags, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.tags, nil
+}
+
+func (a *audioAnalyzerStub) Analyze(ctx context.Context, navPath string, server audio.ServerTags) (audio.AnalysisResult, error) {
+	a.mu.Lock()
+	a.server = append(a.server, server)
+	a.analyzed = append(a.analyzed, navPath)
+	a.mu.Unlock()
 	if a.err != nil {
 		return audio.AnalysisResult{}, a.err
 	}
+	if err := a.failPaths[navPath]; err != nil {
+		return audio.AnalysisResult{}, err
+	}
 	return a.result, nil
 }
 
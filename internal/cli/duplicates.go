@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/audio"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+const defaultDuplicateThreshold = 0.7
+
+type duplicatesStore interface {
+	ListAcousticFingerprints(context.Context) ([]sqlite.TrackAcousticFingerprint, error)
+	SetDuplicateGroups(context.Context, map[int64]int64) error
+	Close() error
+}
+
+type duplicatesConfig struct {
+	threshold float64
+	dryRun    bool
+}
+
+func newDuplicatesCmd(opts *options) *cobra.Command {
+	cfg := duplicatesConfig{threshold: defaultDuplicateThreshold}
+	cmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "Group tracks that are versions of the same recording",
+		Long: "duplicates compares the acoustic fingerprints stored by audio-process and\n" +
+			"groups tracks that sound the same, such as a song on both an album and a\n" +
+			"compilation or a remaster. The groups are saved so generate picks at most\n" +
+			"one track from each.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDuplicates(cmd.Context(), cmd, opts, cfg)
+		},
+	}
+
+	cmd.Flags().Float64Var(&cfg.threshold, "threshold", cfg.threshold, "Share of matching fingerprint bits (0.5-1) for two tracks to be duplicates")
+	cmd.Flags().BoolVar(&cfg.dryRun, "dry-run", false, "Print the groups without saving them")
+
+	return cmd
+}
+
+func runDuplicates(ctx context.Context, cmd *cobra.Command, opts *options, cfg duplicatesConfig) error {
+	if opts.dbPath == "" {
+		return errors.New("db-path must be set to find duplicates")
+	}
+	if cfg.threshold <= 0.5 || cfg.threshold > 1 {
+		return errors.New("threshold must be above 0.5 and at most 1")
+	}
+
+	dbPath, err := filepath.Abs(opts.dbPath)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+	store, err := opts.newDuplicatesStore(sqlite.Config{Path: dbPath})
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	stored, err := store.ListAcousticFingerprints(ctx)
+	if err != nil {
+		return err
+	}
+	tracks := make(map[int64]app.Track, len(stored))
+	fingerprinted := make([]audio.FingerprintedTrack, 0, len(stored))
+	for _, track := range stored {
+		tracks[track.TrackID] = track.Track
+		fingerprinted = append(fingerprinted, audio.FingerprintedTrack{
+			TrackID:     track.TrackID,
+			Fingerprint: audio.AcousticFingerprint(track.Fingerprint),
+		})
+	}
+
+	groups := audio.GroupDuplicates(fingerprinted, cfg.threshold)
+	members := make(map[int64]int64)
+	for _, group := range groups {
+		for _, member := range group.Members {
+			members[member.TrackID] = group.ID()
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	if len(groups) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "GROUP\tTRACK\tSIMILARITY\tARTIST\tTITLE\tALBUM")
+		for _, group := range groups {
+			for _, member := range group.Members {
+				track := tracks[member.TrackID]
+				fmt.Fprintf(tw, "%d\t%d\t%.2f\t%s\t%s\t%s\n",
+					group.ID(), member.TrackID, member.Similarity, track.Artist, track.Title, track.Album)
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "found %d duplicate groups covering %d of %d fingerprinted tracks\n", len(groups), len(members), len(stored))
+	if cfg.dryRun {
+		fmt.Fprintln(w, "dry run: duplicate groups not saved")
+		return nil
+	}
+	return store.SetDuplicateGroups(ctx, members)
+}
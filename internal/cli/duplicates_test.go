@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+func TestRunDuplicates(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	fingerprint := func() []uint32 {
+		words := make([]uint32, 200)
+		for i := range words {
+			words[i] = rng.Uint32()
+		}
+		return words
+	}
+	song := fingerprint()
+	remaster := append([]uint32(nil), song...)
+	for i := 0; i < len(remaster); i += 10 {
+		remaster[i] ^= 1 << (i % 32)
+	}
+	store := &duplicatesStoreStub{tracks: []sqlite.TrackAcousticFingerprint{
+		{TrackID: 2, Track: app.Track{Artist: "Artist", Title: "Song", Album: "Album"}, Fingerprint: song},
+		{TrackID: 5, Track: app.Track{Artist: "Other", Title: "Else", Album: "Album"}, Fingerprint: fingerprint()},
+		{TrackID: 8, Track: app.Track{Artist: "Artist", Title: "Song (Remastered)", Album: "Best Of"}, Fingerprint: remaster},
+	}}
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	opts := &options{
+		dbPath: filepath.Join(t.TempDir(), "db.sqlite"),
+		newDuplicatesStore: func(cfg sqlite.Config) (duplicatesStore, error) {
+			return store, nil
+		},
+	}
+
+	if err := runDuplicates(context.Background(), cmd, opts, duplicatesConfig{threshold: 0.7, dryRun: true}); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if store.groups != nil || !store.closed {
+		t.Fatalf("expected a dry run to save nothing, got %v", store.groups)
+	}
+	got := out.String()
+	for _, want := range []string{"Song (Remastered)", "Best Of", "found 1 duplicate groups covering 2 of 3 fingerprinted tracks", "not saved"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output %q", want, got)
+		}
+	}
+	if strings.Contains(got, "Else") {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	if err := runDuplicates(context.Background(), cmd, opts, duplicatesConfig{threshold: 0.7}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(store.groups) != 2 || store.groups[2] != 2 || store.groups[8] != 2 {
+		t.Fatalf("unexpected saved groups %v", store.groups)
+	}
+
+	if err := runDuplicates(context.Background(), cmd, opts, duplicatesConfig{threshold: 0.4}); err == nil {
+		t.Fatalf("expected error for a threshold unrelated tracks would pass")
+	}
+	if err := runDuplicates(context.Background(), cmd, &options{}, duplicatesConfig{threshold: 0.7}); err == nil {
+		t.Fatalf("expected error without db path")
+	}
+}
+
+type duplicatesStoreStub struct {
+	tracks []sqlite.TrackAcousticFingerprint
+	groups map[int64]int64
+	closed bool
+}
+
+func (s *duplicatesStoreStub) ListAcousticFingerprints(context.Context) ([]sqlite.TrackAcousticFingerprint, error) {
+	return s.tracks, nil
+}
+
+func (s *duplicatesStoreStub) SetDuplicateGroups(ctx context.Context, groups map[int64]int64) error {
+	s.groups = groups
+	return nil
+}
+
+func (s *duplicatesStoreStub) Close() error {
+	s.closed = true
+	return nil
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/embedding"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+const embedClaimStaleAfter = 5 * time.Minute
+
+type embedProcessConfig struct {
+	batchSize    int
+	workerCount  int
+	processAll   bool
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+type embedJobStore interface {
+	ClaimPendingEmbeddingJobs(context.Context, sqlite.ClaimOptions) ([]sqlite.EmbeddingJob, error)
+	UpsertTrackEmbedding(context.Context, sqlite.EmbeddingRecord) error
+	CompleteEmbeddingJob(context.Context, int64) error
+	FailEmbeddingJob(context.Context, int64, error) error
+	Close() error
+}
+
+type embedder interface {
+	Model() string
+	Embed(context.Context, string) ([]float32, error)
+}
+
+func newEmbedProcessCmd(opts *options) *cobra.Command {
+	cfg := embedProcessConfig{
+		batchSize:   50,
+		workerCount: 2,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "embed-process",
+		Short: "Process pending embedding jobs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEmbedProcess(cmd.Context(), cmd, opts, cfg)
+		},
+	}
+
+	cmd.Flags().IntVar(&cfg.batchSize, "batch-size", cfg.batchSize, "Number of embedding jobs to fetch per batch")
+	cmd.Flags().IntVar(&cfg.workerCount, "workers", cfg.workerCount, "Number of concurrent embedding workers")
+	cmd.Flags().BoolVar(&cfg.processAll, "all", false, "Process embedding jobs until the queue is empty")
+	cmd.Flags().IntVar(&cfg.maxAttempts, "max-attempts", sqlite.DefaultJobMaxAttempts, "Attempts before a failing embedding job is marked dead")
+	cmd.Flags().DurationVar(&cfg.retryBackoff, "retry-backoff", sqlite.DefaultJobRetryDelay, "Delay before retrying a failed embedding job; doubles on each retry")
+
+	return cmd
+}
+
+func runEmbedProcess(ctx context.Context, cmd *cobra.Command, opts *options, cfg embedProcessConfig) error {
+	if err := opts.ensureLogger(cmd.ErrOrStderr()); err != nil {
+		return fmt.Errorf("init logger: %w", err)
+	}
+	logger := opts.logger
+
+	opts.populateFromEnv()
+
+	if opts.dbPath == "" {
+		return errors.New("db-path must be set to process embedding jobs")
+	}
+	if opts.ollamaURL == "" {
+		return errors.New("ollama URL must be set via --ollama-url or OLLAMA_URL")
+	}
+	if cfg.batchSize <= 0 {
+		return errors.New("batch-size must be greater than zero")
+	}
+	if cfg.workerCount <= 0 {
+		return errors.New("workers must be greater than zero")
+	}
+
+	dbPath, err := filepath.Abs(opts.dbPath)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+
+	client, err := opts.newEmbedder(embedding.Config{
+		BaseURL: opts.ollamaURL,
+		Model:   opts.embeddingModel,
+	})
+	if err != nil {
+		return fmt.Errorf("init embedder: %w", err)
+	}
+
+	store, err := opts.newEmbedStore(sqlite.Config{
+		Path: dbPath,
+		EmbeddingRetry: sqlite.JobRetryPolicy{
+			MaxAttempts: cfg.maxAttempts,
+			BaseDelay:   cfg.retryBackoff,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	logger.Info("starting embedding processing",
+		"ollama_url", opts.ollamaURL,
+		"model", client.Model(),
+	)
+
+	claimedBy := fmt.Sprintf("embed-process-%d", os.Getpid())
+	summary := embedBatchSummary{}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		jobs, err := store.ClaimPendingEmbeddingJobs(ctx, sqlite.ClaimOptions{
+			Limit:      cfg.batchSize,
+			ClaimedBy:  claimedBy,
+			StaleAfter: embedClaimStaleAfter,
+			Now:        time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("claim embedding jobs: %w", err)
+		}
+		if len(jobs) == 0 {
+			if summary.completed+summary.failed == 0 {
+				logger.Info("no pending embedding jobs found")
+			}
+			break
+		}
+
+		logger.Info("processing embedding batch",
+			"jobs", len(jobs),
+			"workers", cfg.workerCount,
+		)
+		batchSummary, err := processEmbeddingBatch(ctx, store, client, jobs, cfg.workerCount, logger)
+		summary.completed += batchSummary.completed
+		summary.failed += batchSummary.failed
+		if err != nil {
+			return err
+		}
+
+		if !cfg.processAll {
+			break
+		}
+	}
+
+	logger.Info("embedding processing complete",
+		"completed_jobs", summary.completed,
+		"failed_jobs", summary.failed,
+	)
+	return nil
+}
+
+type embedBatchSummary struct {
+	completed int
+	failed    int
+}
+
+// processEmbeddingBatch embeds jobs on workers goroutines. A job Ollama
+// cannot embed is failed on its own and the batch carries on; only store
+// errors or cancellation end the batch with an error.
+func processEmbeddingBatch(ctx context.Context, store embedJobStore, client embedder, jobs []sqlite.EmbeddingJob, workers int, logger *slog.Logger) (embedBatchSummary, error) {
+	jobCh := make(chan sqlite.EmbeddingJob)
+	errCh := make(chan error, len(jobs)+workers)
+	resultCh := make(chan embedBatchSummary, len(jobs))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			workerLogger := logger.With("worker", workerID)
+			for job := range jobCh {
+				select {
+				case <-ctx.Done():
+					errCh <- ctx.Err()
+					return
+				default:
+				}
+
+				document := embedding.Document(job.Track)
+				workerLogger.Info("processing embedding job",
+					"job_id", job.ID,
+					"track_id", job.Track.ID,
+					"title", job.Track.Title,
+				)
+
+				vector, err := client.Embed(ctx, document)
+				if err != nil {
+					if ctx.Err() != nil {
+						errCh <- ctx.Err()
+						return
+					}
+					workerLogger.Error("embedding job failed", "job_id", job.ID, "error", err)
+					resultCh <- embedBatchSummary{failed: 1}
+					if err := store.FailEmbeddingJob(ctx, job.ID, err); err != nil {
+						errCh <- fmt.Errorf("record embedding job %d failure: %w", job.ID, err)
+					}
+					continue
+				}
+
+				if err := store.UpsertTrackEmbedding(ctx, sqlite.EmbeddingRecord{
+					TrackID:    job.TrackID,
+					Model:      client.Model(),
+					Vector:     vector,
+					Document:   document,
+					EmbeddedAt: time.Now().UTC(),
+				}); err != nil {
+					_ = store.FailEmbeddingJob(ctx, job.ID, err)
+					errCh <- fmt.Errorf("persist embedding for job %d: %w", job.ID, err)
+					resultCh <- embedBatchSummary{failed: 1}
+					continue
+				}
+
+				if err := store.CompleteEmbeddingJob(ctx, job.ID); err != nil {
+					errCh <- fmt.Errorf("complete embedding job %d: %w", job.ID, err)
+					resultCh <- embedBatchSummary{failed: 1}
+					continue
+				}
+
+				workerLogger.Info("embedding job completed", "job_id", job.ID, "dimensions", len(vector))
+				resultCh <- embedBatchSummary{completed: 1}
+			}
+		}(i + 1)
+	}
+
+	go func() {
+		defer close(jobCh)
+		for _, job := range jobs {
+			select {
+			case <-ctx.Done():
+				return
+			case jobCh <- job:
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errCh)
+	close(resultCh)
+
+	summary := embedBatchSummary{}
+	for result := range resultCh {
+		summary.completed += result.completed
+		summary.failed += result.failed
+	}
+	for err := range errCh {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return summary, err
+		}
+	}
+
+	return summary, ctx.Err()
+}
@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/embedding"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+func TestRunEmbedProcessStoresVectorsFromOllama(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		prompts []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model  string `json:"model"`
+			Prompt string `json:"prompt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		mu.Lock()
+		prompts = append(prompts, body.Prompt)
+		mu.Unlock()
+		w.Write([]byte(`{"embedding":[0.1,0.2,0.3]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	store := &embedJobStoreStub{
+		claimBatches: [][]sqlite.EmbeddingJob{
+			{
+				{ID: 1, TrackID: 101, Track: testAudioTrack("track-1")},
+				{ID: 2, TrackID: 102, Track: testAudioTrack("track-2")},
+			},
+			nil,
+		},
+	}
+	opts := &options{
+		dbPath:         filepath.Join(t.TempDir(), "jobs.db"),
+		ollamaURL:      server.URL,
+		embeddingModel: "test-model",
+		newEmbedStore: func(cfg sqlite.Config) (embedJobStore, error) {
+			return store, nil
+		},
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		logFormat: "text",
+	}
+
+	if err := runEmbedProcess(context.Background(), &cobra.Command{}, opts, embedProcessConfig{
+		batchSize:   2,
+		workerCount: 2,
+		processAll:  true,
+	}); err != nil {
+		t.Fatalf("runEmbedProcess: %v", err)
+	}
+
+	if store.claimCalls != 2 {
+		t.Fatalf("expected 2 claim calls, got %d", store.claimCalls)
+	}
+	if store.lastClaimOptions.ClaimedBy == "" || !strings.HasPrefix(store.lastClaimOptions.ClaimedBy, "embed-process-") {
+		t.Fatalf("unexpected claimed_by %q", store.lastClaimOptions.ClaimedBy)
+	}
+	if len(store.completedJobIDs) != 2 {
+		t.Fatalf("unexpected completed jobs %+v", store.completedJobIDs)
+	}
+	if len(store.records) != 2 {
+		t.Fatalf("expected 2 embedding records, got %d", len(store.records))
+	}
+	for _, record := range store.records {
+		if record.Model != "test-model" || len(record.Vector) != 3 {
+			t.Fatalf("unexpected record %+v", record)
+		}
+		if !strings.Contains(record.Document, "Title: track-") {
+			t.Fatalf("unexpected document %q", record.Document)
+		}
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 ollama requests, got %d", len(prompts))
+	}
+}
+
+func TestRunEmbedProcessMarksJobFailedOnOllamaError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Prompt string `json:"prompt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if strings.Contains(body.Prompt, "track-7") {
+			http.Error(w, "model not loaded", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"embedding":[0.1,0.2,0.3]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	store := &embedJobStoreStub{
+		claimBatches: [][]sqlite.EmbeddingJob{
+			{{ID: 7, TrackID: 107, Track: testAudioTrack("track-7")}},
+			{{ID: 8, TrackID: 108, Track: testAudioTrack("track-8")}},
+		},
+	}
+	opts := &options{
+		dbPath:    filepath.Join(t.TempDir(), "jobs.db"),
+		ollamaURL: server.URL,
+		newEmbedStore: func(cfg sqlite.Config) (embedJobStore, error) {
+			return store, nil
+		},
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		logFormat: "text",
+	}
+
+	if err := runEmbedProcess(context.Background(), &cobra.Command{}, opts, embedProcessConfig{batchSize: 1, workerCount: 1, processAll: true}); err != nil {
+		t.Fatalf("expected the run to carry on past a failed job, got %v", err)
+	}
+	if len(store.failedJobIDs) != 1 || store.failedJobIDs[0] != 7 {
+		t.Fatalf("unexpected failed jobs %+v", store.failedJobIDs)
+	}
+	if len(store.completedJobIDs) != 1 || store.completedJobIDs[0] != 8 {
+		t.Fatalf("expected the next batch to be embedded, got %+v", store.completedJobIDs)
+	}
+	if len(store.records) != 1 || store.records[0].TrackID != 108 {
+		t.Fatalf("unexpected embedding records %+v", store.records)
+	}
+}
+
+func TestRunEmbedProcessReturnsFailureRecordError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	store := &embedJobStoreStub{
+		claimBatches: [][]sqlite.EmbeddingJob{{
+			{ID: 7, TrackID: 107, Track: testAudioTrack("track-7")},
+		}},
+		failErr: errors.New("database is locked"),
+	}
+	opts := &options{
+		dbPath:    filepath.Join(t.TempDir(), "jobs.db"),
+		ollamaURL: server.URL,
+		newEmbedStore: func(cfg sqlite.Config) (embedJobStore, error) {
+			return store, nil
+		},
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		logFormat: "text",
+	}
+
+	err := runEmbedProcess(context.Background(), &cobra.Command{}, opts, embedProcessConfig{batchSize: 1, workerCount: 1, processAll: true})
+	if err == nil || !strings.Contains(err.Error(), "database is locked") {
+		t.Fatalf("expected the store error, got %v", err)
+	}
+}
+
+func TestRunEmbedProcessRequiresOllamaURL(t *testing.T) {
+	t.Setenv("OLLAMA_URL", "")
+	opts := &options{
+		dbPath:    filepath.Join(t.TempDir(), "jobs.db"),
+		logFormat: "text",
+	}
+	if err := runEmbedProcess(context.Background(), &cobra.Command{}, opts, embedProcessConfig{batchSize: 1, workerCount: 1}); err == nil {
+		t.Fatal("expected error for missing ollama url")
+	}
+}
+
+type embedJobStoreStub struct {
+	mu               sync.Mutex
+	claimBatches     [][]sqlite.EmbeddingJob
+	claimCalls       int
+	lastClaimOptions sqlite.ClaimOptions
+	completedJobIDs  []int64
+	failedJobIDs     []int64
+	failErr          error
+	records          []sqlite.EmbeddingRecord
+}
+
+func (s *embedJobStoreStub) ClaimPendingEmbeddingJobs(ctx context.Context, opts sqlite.ClaimOptions) ([]sqlite.EmbeddingJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claimCalls++
+	s.lastClaimOptions = opts
+	if len(s.claimBatches) == 0 {
+		return nil, nil
+	}
+	batch := s.claimBatches[0]
+	s.claimBatches = s.claimBatches[1:]
+	return batch, nil
+}
+
+func (s *embedJobStoreStub) UpsertTrackEmbedding(ctx context.Context, record sqlite.EmbeddingRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *embedJobStoreStub) CompleteEmbeddingJob(ctx context.Context, jobID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completedJobIDs = append(s.completedJobIDs, jobID)
+	return nil
+}
+
+func (s *embedJobStoreStub) FailEmbeddingJob(ctx context.Context, jobID int64, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failedJobIDs = append(s.failedJobIDs, jobID)
+	return s.failErr
+}
+
+func (s *embedJobStoreStub) Close() error {
+	return nil
+}
@@ -0,0 +1,397 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/embedding"
+	"github.com/bowmanmike/playlistgen/internal/export"
+	"github.com/bowmanmike/playlistgen/internal/navidrome"
+	"github.com/bowmanmike/playlistgen/internal/playlist"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+const (
+	defaultPlaylistDuration = 60 * time.Minute
+	defaultPlaylistDir      = "/playlists"
+)
+
+type generateConfig struct {
+	duration        time.Duration
+	tolerance       time.Duration
+	maxTracks       int
+	maxPerArtist    int
+	artistGap       int
+	noAdjacentAlbum bool
+	variety         int
+	seed            uint64
+	starredOnly     bool
+	minRating       int
+	skipPlayed      time.Duration
+	starredBoost    float64
+	ratingBoost     float64
+	playCountBoost  float64
+	candidates      int
+	energy          string
+	harmonic        bool
+	output          string
+	playlistDir     string
+	pathMode        string
+	pathPrefix      string
+	pathRewrites    []string
+	cueHints        bool
+	navidrome       bool
+	name            string
+}
+
+type generateStore interface {
+	NearestTracks(context.Context, []float32, int, sqlite.VectorFilters) ([]sqlite.VectorMatch, error)
+	LoadTrackCandidates(context.Context, []int64) ([]sqlite.TrackCandidate, error)
+	export.PlaylistMappings
+	Close() error
+}
+
+func newGenerateCmd(opts *options) *cobra.Command {
+	cfg := generateConfig{
+		playlistDir:     getEnv("PLAYLISTGEN_PLAYLIST_DIR", defaultPlaylistDir),
+		pathMode:        getEnv("PLAYLISTGEN_PATH_MODE", string(export.PathAbsolute)),
+		pathPrefix:      os.Getenv("PLAYLISTGEN_PATH_PREFIX"),
+		maxTracks:       50,
+		maxPerArtist:    3,
+		artistGap:       2,
+		noAdjacentAlbum: true,
+		candidates:      200,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "generate <prompt>",
+		Short: "Generate a playlist from a natural-language prompt",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGenerate(cmd.Context(), cmd, opts, cfg, args[0])
+		},
+	}
+
+	cmd.Flags().DurationVar(&cfg.duration, "duration", 0, "Target playlist duration (defaults to a duration in the prompt, else 60m)")
+	cmd.Flags().DurationVar(&cfg.tolerance, "tolerance", 0, "Allowed deviation from the target duration (defaults to 5%)")
+	cmd.Flags().IntVar(&cfg.maxTracks, "max-tracks", cfg.maxTracks, "Maximum number of tracks in the playlist")
+	cmd.Flags().IntVar(&cfg.maxPerArtist, "max-per-artist", cfg.maxPerArtist, "Maximum tracks per artist (0 for no limit)")
+	cmd.Flags().IntVar(&cfg.artistGap, "artist-gap", cfg.artistGap, "Minimum tracks between repeats of an artist")
+	cmd.Flags().BoolVar(&cfg.noAdjacentAlbum, "no-adjacent-album", cfg.noAdjacentAlbum, "Forbid consecutive tracks from the same album")
+	cmd.Flags().IntVar(&cfg.variety, "variety", 0, "Let candidates drift up to this many places from their similarity rank")
+	cmd.Flags().Uint64Var(&cfg.seed, "seed", 0, "Seed for variety; the same seed reproduces the same playlist")
+	cmd.Flags().BoolVar(&cfg.starredOnly, "starred-only", false, "Only use tracks starred in Navidrome")
+	cmd.Flags().IntVar(&cfg.minRating, "min-rating", 0, "Only use tracks rated at least this many stars")
+	cmd.Flags().DurationVar(&cfg.skipPlayed, "skip-played-within", 0, "Skip tracks played more recently than this")
+	cmd.Flags().Float64Var(&cfg.starredBoost, "starred-boost", 0, "Rank places a starred track moves up")
+	cmd.Flags().Float64Var(&cfg.ratingBoost, "rating-boost", 0, "Rank places a track moves per star above 3 (down per star below)")
+	cmd.Flags().Float64Var(&cfg.playCountBoost, "play-count-boost", 0, "Rank places a track moves per doubling of its play count (negative favors less-played tracks)")
+	cmd.Flags().StringVar(&cfg.energy, "energy", "", "Energy curve to order tracks by: build, peak-middle, wind-down, flat, or comma-separated points in [0,1]")
+	cmd.Flags().BoolVar(&cfg.harmonic, "harmonic", false, "Prefer neighbouring tracks with compatible keys (Camelot wheel)")
+	cmd.Flags().IntVar(&cfg.candidates, "candidates", cfg.candidates, "Number of nearest tracks to consider")
+	cmd.Flags().StringVarP(&cfg.output, "output", "o", "", "Playlist output path, relative paths land in --playlist-dir (defaults to stdout)")
+	cmd.Flags().StringVar(&cfg.playlistDir, "playlist-dir", cfg.playlistDir, "Directory for relative --output paths (or PLAYLISTGEN_PLAYLIST_DIR)")
+	cmd.Flags().StringVar(&cfg.pathMode, "path-mode", cfg.pathMode, "How track paths are written: absolute, relative, or rewrite (or PLAYLISTGEN_PATH_MODE)")
+	cmd.Flags().StringVar(&cfg.pathPrefix, "path-prefix", cfg.pathPrefix, "Library root as players see it (defaults to --library-root; or PLAYLISTGEN_PATH_PREFIX)")
+	cmd.Flags().StringArrayVar(&cfg.pathRewrites, "path-rewrite", nil, "Rewrite a track path prefix, as from=to (repeatable; used with --path-mode rewrite)")
+	cmd.Flags().BoolVar(&cfg.cueHints, "cue-hints", false, "Add start and stop times that skip measured leading and trailing silence")
+	cmd.Flags().BoolVar(&cfg.navidrome, "navidrome", false, "Also create or update the playlist in Navidrome")
+	cmd.Flags().StringVar(&cfg.name, "name", "", "Playlist name (defaults to the prompt)")
+
+	return cmd
+}
+
+func runGenerate(ctx context.Context, cmd *cobra.Command, opts *options, cfg generateConfig, prompt string) error {
+	if err := opts.ensureLogger(cmd.ErrOrStderr()); err != nil {
+		return fmt.Errorf("init logger: %w", err)
+	}
+	logger := opts.logger
+
+	opts.populateFromEnv()
+
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return errors.New("prompt must not be empty")
+	}
+	if opts.dbPath == "" {
+		return errors.New("db-path must be set to generate playlists")
+	}
+	if opts.ollamaURL == "" {
+		return errors.New("ollama URL must be set via --ollama-url or OLLAMA_URL")
+	}
+	if cfg.maxTracks <= 0 {
+		return errors.New("max-tracks must be greater than zero")
+	}
+	if cfg.candidates <= 0 {
+		return errors.New("candidates must be greater than zero")
+	}
+	if cfg.navidrome {
+		if opts.navidromeURL == "" {
+			return errors.New("navidrome URL must be set via --navidrome-url or NAVIDROME_URL")
+		}
+		if opts.navidromeUsername == "" || opts.navidromePassword == "" {
+			return errors.New("navidrome username and password must be set via flags or environment")
+		}
+	}
+
+	target := cfg.duration
+	if target <= 0 {
+		target = parsePromptDuration(prompt)
+	}
+	if target <= 0 {
+		target = defaultPlaylistDuration
+	}
+	name := cfg.name
+	if name == "" {
+		name = prompt
+	}
+	var energy *playlist.EnergyProfile
+	if cfg.energy != "" {
+		profile, err := playlist.ParseEnergyProfile(cfg.energy)
+		if err != nil {
+			return err
+		}
+		energy = &profile
+	}
+
+	rewrites, err := export.ParseRewrites(cfg.pathRewrites)
+	if err != nil {
+		return err
+	}
+	pathPrefix := cfg.pathPrefix
+	if pathPrefix == "" {
+		pathPrefix = opts.libraryRoot
+	}
+	exporter, err := export.NewM3UExporter(export.Config{
+		Mode:     export.PathMode(cfg.pathMode),
+		Root:     pathPrefix,
+		Rewrites: rewrites,
+		CueHints: cfg.cueHints,
+	})
+	if err != nil {
+		return err
+	}
+	output := cfg.output
+	if output != "" && !filepath.IsAbs(output) && cfg.playlistDir != "" {
+		output = filepath.Join(cfg.playlistDir, output)
+	}
+	if output == "" && export.PathMode(cfg.pathMode) == export.PathRelative {
+		return errors.New("relative path mode requires --output")
+	}
+
+	dbPath, err := filepath.Abs(opts.dbPath)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+
+	client, err := opts.newEmbedder(embedding.Config{
+		BaseURL: opts.ollamaURL,
+		Model:   opts.embeddingModel,
+	})
+	if err != nil {
+		return fmt.Errorf("init embedder: %w", err)
+	}
+
+	store, err := opts.newGenerateStore(sqlite.Config{Path: dbPath})
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	logger.Info("generating playlist",
+		"prompt", prompt,
+		"target_duration", target.String(),
+		"max_tracks", cfg.maxTracks,
+	)
+
+	vector, err := client.Embed(ctx, prompt)
+	if err != nil {
+		return fmt.Errorf("embed prompt: %w", err)
+	}
+
+	matches, err := store.NearestTracks(ctx, vector, cfg.candidates, sqlite.VectorFilters{Model: client.Model()})
+	if err != nil {
+		return fmt.Errorf("search tracks: %w", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no tracks embedded with model %q; run embed-process first", client.Model())
+	}
+
+	trackIDs := make([]int64, 0, len(matches))
+	for _, match := range matches {
+		trackIDs = append(trackIDs, match.TrackID)
+	}
+	candidates, err := store.LoadTrackCandidates(ctx, trackIDs)
+	if err != nil {
+		return err
+	}
+
+	result, err := playlist.Build(toPlaylistCandidates(candidates), playlist.Rules{
+		TargetDuration:     target,
+		Tolerance:          cfg.tolerance,
+		MaxTracks:          cfg.maxTracks,
+		MaxTracksPerArtist: cfg.maxPerArtist,
+		MinArtistGap:       cfg.artistGap,
+		NoAdjacentAlbum:    cfg.noAdjacentAlbum,
+		Variety:            cfg.variety,
+		Seed:               cfg.seed,
+		Energy:             energy,
+		Harmonic:           cfg.harmonic,
+		Signals: playlist.Signals{
+			StarredOnly:      cfg.starredOnly,
+			MinRating:        cfg.minRating,
+			SkipPlayedWithin: cfg.skipPlayed,
+			StarredBoost:     cfg.starredBoost,
+			RatingBoost:      cfg.ratingBoost,
+			PlayCountBoost:   cfg.playCountBoost,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("build playlist: %w", err)
+	}
+	if len(result.Tracks) == 0 {
+		return errors.New("no candidate tracks fit the requested duration and filters")
+	}
+	if !result.WithinTolerance {
+		logger.Warn("playlist duration outside target window",
+			"target_duration", target.String(),
+			"duration", result.Duration.Round(time.Second).String(),
+		)
+	}
+
+	// The curve report goes to stderr when stdout carries the playlist itself.
+	report := cmd.OutOrStdout()
+	switch {
+	case output != "":
+		if err := exporter.WriteFile(output, name, result.Tracks); err != nil {
+			return err
+		}
+	case !cfg.navidrome:
+		report = cmd.ErrOrStderr()
+		if err := exporter.Write(cmd.OutOrStdout(), "", name, result.Tracks); err != nil {
+			return fmt.Errorf("write playlist: %w", err)
+		}
+	}
+	if cfg.navidrome {
+		client, err := opts.newPlaylistClient(navidrome.Config{
+			BaseURL:  opts.navidromeURL,
+			Username: opts.navidromeUsername,
+			Password: opts.navidromePassword,
+		})
+		if err != nil {
+			return fmt.Errorf("init navidrome client: %w", err)
+		}
+		target, err := export.NewNavidromeTarget(client, store)
+		if err != nil {
+			return err
+		}
+		pushed, err := target.Export(ctx, name, result.Tracks)
+		if err != nil {
+			return fmt.Errorf("export to navidrome: %w", err)
+		}
+		logger.Info("navidrome playlist exported",
+			"name", name,
+			"playlist_id", pushed.PlaylistID,
+			"created", pushed.Created,
+		)
+	}
+	if energy != nil {
+		if err := writeEnergyCurves(report, energy.Name, result.EnergyTarget, result.EnergyAchieved); err != nil {
+			return fmt.Errorf("write energy curves: %w", err)
+		}
+	}
+	if cfg.harmonic {
+		if _, err := fmt.Fprintf(report, "harmonic clashes: %d\n", result.HarmonicClashes); err != nil {
+			return fmt.Errorf("write harmonic report: %w", err)
+		}
+	}
+
+	logger.Info("playlist generated",
+		"tracks", len(result.Tracks),
+		"duration", result.Duration.Round(time.Second).String(),
+		"candidates", len(candidates),
+		"output", output,
+	)
+	return nil
+}
+
+func toPlaylistCandidates(candidates []sqlite.TrackCandidate) []playlist.Candidate {
+	out := make([]playlist.Candidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		out = append(out, playlist.Candidate{
+			TrackID: candidate.TrackID,
+			Track:   candidate.Track,
+			Features: playlist.Features{
+				DurationSeconds:     candidate.Features.FileDurationSeconds,
+				IntegratedLUFS:      candidate.Features.IntegratedLUFS,
+				TruePeak:            candidate.Features.TruePeak,
+				EffectiveGainDB:     candidate.Features.EffectiveGainDB,
+				EffectiveGainSource: candidate.Features.EffectiveGainSource,
+				TempoBPM:            candidate.Features.TempoBPM,
+				CamelotKey:          candidate.Features.CamelotKey,
+				LoudnessRange:       candidate.Features.LoudnessRange,
+				SpectralCentroidHz:  candidate.Features.SpectralCentroidHz,
+				SpectralRolloffHz:   candidate.Features.SpectralRolloffHz,
+				CrestFactorDB:       candidate.Features.CrestFactorDB,
+				ZeroCrossingRate:    candidate.Features.ZeroCrossingRate,
+				OnsetDensity:        candidate.Features.OnsetDensity,
+				DuplicateGroupID:    candidate.Features.DuplicateGroupID,
+				ContentStartSeconds: candidate.Features.ContentStartSeconds,
+				ContentEndSeconds:   candidate.Features.ContentEndSeconds,
+				FlowsIntoNext:       candidate.Features.FlowsIntoNext,
+			},
+		})
+	}
+	return out
+}
+
+var promptDurationPattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(hours?|hrs?|h|minutes?|mins?|m)\b`)
+
+// parsePromptDuration extracts a duration such as "90 minutes" or "2 hours"
+// from a prompt, returning zero when none is present.
+func parsePromptDuration(prompt string) time.Duration {
+	match := promptDurationPattern.FindStringSubmatch(prompt)
+	if match == nil {
+		return 0
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil || value <= 0 {
+		return 0
+	}
+	unit := time.Minute
+	if strings.HasPrefix(strings.ToLower(match[2]), "h") {
+		unit = time.Hour
+	}
+	return time.Duration(value * float64(unit))
+}
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// writeEnergyCurves prints the requested and achieved energy curves as
+// sparklines, one character per track, followed by the RMS error between them.
+func writeEnergyCurves(w io.Writer, profile string, target, achieved []float64) error {
+	_, err := fmt.Fprintf(w, "energy %s\n  target:   %s\n  achieved: %s\n  rms error: %.2f\n",
+		profile, sparkline(target), sparkline(achieved), playlist.CurveError(target, achieved))
+	return err
+}
+
+func sparkline(values []float64) string {
+	var b strings.Builder
+	for _, v := range values {
+		level := int(math.Round(v * float64(len(sparkLevels)-1)))
+		level = max(0, min(len(sparkLevels)-1, level))
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
@@ -0,0 +1,515 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/embedding"
+	"github.com/bowmanmike/playlistgen/internal/export"
+	"github.com/bowmanmike/playlistgen/internal/navidrome"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+func TestRunGenerateWritesPlaylistFromNearestTracks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embedding":[1,0,0]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	store := &generateStoreStub{
+		matches: []sqlite.VectorMatch{
+			{TrackID: 3, Distance: 0.1},
+			{TrackID: 1, Distance: 0.2},
+			{TrackID: 2, Distance: 0.3},
+		},
+		candidates: []sqlite.TrackCandidate{
+			testCandidate(3, "Blue in Green", 5*time.Minute),
+			testCandidate(1, "So What", 9*time.Minute),
+			testCandidate(2, "Freddie Freeloader", 10*time.Minute),
+		},
+	}
+	outPath := filepath.Join(t.TempDir(), "playlists", "rainy.m3u8")
+	opts := &options{
+		dbPath:         filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot:    "/library",
+		ollamaURL:      server.URL,
+		embeddingModel: "test-model",
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		newGenerateStore: func(cfg sqlite.Config) (generateStore, error) {
+			return store, nil
+		},
+		logFormat: "text",
+	}
+
+	if err := runGenerate(context.Background(), &cobra.Command{}, opts, generateConfig{
+		maxTracks:  10,
+		candidates: 25,
+		output:     outPath,
+	}, "rainy sunday jazz, 15 minutes"); err != nil {
+		t.Fatalf("runGenerate: %v", err)
+	}
+
+	if store.lastK != 25 || store.lastFilters.Model != "test-model" {
+		t.Fatalf("unexpected search k=%d filters=%+v", store.lastK, store.lastFilters)
+	}
+	if len(store.lastIDs) != 3 || store.lastIDs[0] != 3 {
+		t.Fatalf("unexpected candidate ids %v", store.lastIDs)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read playlist: %v", err)
+	}
+	want := "#EXTM3U\n" +
+		"#PLAYLIST:rainy sunday jazz, 15 minutes\n" +
+		"#EXTINF:300,Miles Davis - Blue in Green\n/library/music/Blue in Green.flac\n" +
+		"#EXTINF:540,Miles Davis - So What\n/library/music/So What.flac\n"
+	if string(data) != want {
+		t.Fatalf("unexpected playlist:\n%s", data)
+	}
+}
+
+func TestRunGenerateWritesToStdoutWithoutOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embedding":[1,0,0]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+
+	store := &generateStoreStub{
+		matches:    []sqlite.VectorMatch{{TrackID: 1}},
+		candidates: []sqlite.TrackCandidate{testCandidate(1, "So What", 9*time.Minute)},
+	}
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: "/library",
+		ollamaURL:   server.URL,
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		newGenerateStore: func(cfg sqlite.Config) (generateStore, error) {
+			return store, nil
+		},
+		logFormat: "text",
+	}
+
+	if err := runGenerate(context.Background(), cmd, opts, generateConfig{
+		duration:   10 * time.Minute,
+		maxTracks:  5,
+		candidates: 5,
+		name:       "Focus",
+	}, "modal jazz"); err != nil {
+		t.Fatalf("runGenerate: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "#EXTM3U\n#PLAYLIST:Focus\n") {
+		t.Fatalf("unexpected stdout %q", out.String())
+	}
+}
+
+func TestRunGeneratePrintsEnergyCurvesToStderrWhenStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embedding":[1,0,0]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+
+	loud := testCandidate(1, "So What", 5*time.Minute)
+	loudLUFS := -7.0
+	loud.Features.IntegratedLUFS = &loudLUFS
+	quiet := testCandidate(2, "Blue in Green", 5*time.Minute)
+	quietLUFS := -19.0
+	quiet.Features.IntegratedLUFS = &quietLUFS
+	quiet.Track.Artist = "Bill Evans"
+	quiet.Track.Album = "Portrait in Jazz"
+	loud.Features.CamelotKey = "8A"
+	quiet.Features.CamelotKey = "3B"
+
+	store := &generateStoreStub{
+		matches:    []sqlite.VectorMatch{{TrackID: 1}, {TrackID: 2}},
+		candidates: []sqlite.TrackCandidate{loud, quiet},
+	}
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: "/library",
+		ollamaURL:   server.URL,
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		newGenerateStore: func(cfg sqlite.Config) (generateStore, error) {
+			return store, nil
+		},
+		logFormat: "text",
+	}
+
+	if err := runGenerate(context.Background(), cmd, opts, generateConfig{
+		duration:   10 * time.Minute,
+		maxTracks:  5,
+		candidates: 5,
+		energy:     "build",
+		harmonic:   true,
+		name:       "Warmup",
+	}, "modal jazz"); err != nil {
+		t.Fatalf("runGenerate: %v", err)
+	}
+
+	wantPlaylist := "#EXTM3U\n" +
+		"#PLAYLIST:Warmup\n" +
+		"#EXTINF:300,Bill Evans - Blue in Green\n/library/music/Blue in Green.flac\n" +
+		"#EXTINF:300,Miles Davis - So What\n/library/music/So What.flac\n"
+	if out.String() != wantPlaylist {
+		t.Fatalf("unexpected stdout %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "energy build\n  target:   ▁█\n  achieved: ▁█\n  rms error: 0.00\n") {
+		t.Fatalf("expected energy curves on stderr, got %q", errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "harmonic clashes: 1\n") {
+		t.Fatalf("expected harmonic report on stderr, got %q", errOut.String())
+	}
+}
+
+func TestRunGenerateRejectsUnknownEnergyProfile(t *testing.T) {
+	opts := &options{
+		dbPath:    filepath.Join(t.TempDir(), "db.sqlite"),
+		ollamaURL: "http://ollama.invalid",
+		logFormat: "text",
+	}
+	err := runGenerate(context.Background(), &cobra.Command{}, opts, generateConfig{maxTracks: 5, candidates: 5, energy: "sideways"}, "anything")
+	if err == nil || !strings.Contains(err.Error(), "unknown energy profile") {
+		t.Fatalf("expected energy profile error, got %v", err)
+	}
+}
+
+func TestRunGenerateWritesRelativeOutputIntoPlaylistDir(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embedding":[1,0,0]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	store := &generateStoreStub{
+		matches:    []sqlite.VectorMatch{{TrackID: 1}},
+		candidates: []sqlite.TrackCandidate{testCandidate(1, "So What", 9*time.Minute)},
+	}
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: "/library",
+		ollamaURL:   server.URL,
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		newGenerateStore: func(cfg sqlite.Config) (generateStore, error) {
+			return store, nil
+		},
+		logFormat: "text",
+	}
+
+	playlistDir := t.TempDir()
+	if err := runGenerate(context.Background(), &cobra.Command{}, opts, generateConfig{
+		duration:     10 * time.Minute,
+		maxTracks:    5,
+		candidates:   5,
+		output:       "focus.m3u8",
+		playlistDir:  playlistDir,
+		pathMode:     "rewrite",
+		pathRewrites: []string{"/music=/storage/emulated/0/Music"},
+		name:         "Focus",
+	}, "modal jazz"); err != nil {
+		t.Fatalf("runGenerate: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(playlistDir, "focus.m3u8"))
+	if err != nil {
+		t.Fatalf("read playlist: %v", err)
+	}
+	if !strings.HasSuffix(string(data), "\n/storage/emulated/0/Music/So What.flac\n") {
+		t.Fatalf("unexpected playlist %q", data)
+	}
+}
+
+func TestRunGenerateRequiresOutputForRelativePaths(t *testing.T) {
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: "/library",
+		ollamaURL:   "http://ollama.invalid",
+		logFormat:   "text",
+	}
+	err := runGenerate(context.Background(), &cobra.Command{}, opts, generateConfig{maxTracks: 5, candidates: 5, pathMode: "relative"}, "anything")
+	if err == nil || !strings.Contains(err.Error(), "requires --output") {
+		t.Fatalf("expected output requirement error, got %v", err)
+	}
+}
+
+func TestRunGenerateExportsToNavidrome(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embedding":[1,0,0]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+
+	store := &generateStoreStub{
+		matches:    []sqlite.VectorMatch{{TrackID: 1}},
+		candidates: []sqlite.TrackCandidate{testCandidate(1, "So What", 9*time.Minute)},
+	}
+	client := &playlistClientStub{}
+	var gotCfg navidrome.Config
+	opts := &options{
+		dbPath:            filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot:       "/library",
+		ollamaURL:         server.URL,
+		navidromeURL:      "https://navidrome.local",
+		navidromeUsername: "user",
+		navidromePassword: "pass",
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		newGenerateStore: func(cfg sqlite.Config) (generateStore, error) {
+			return store, nil
+		},
+		newPlaylistClient: func(cfg navidrome.Config) (export.NavidromeClient, error) {
+			gotCfg = cfg
+			return client, nil
+		},
+		logFormat: "text",
+	}
+	cfg := generateConfig{
+		duration:   10 * time.Minute,
+		maxTracks:  5,
+		candidates: 5,
+		navidrome:  true,
+		name:       "Focus",
+	}
+
+	if err := runGenerate(context.Background(), cmd, opts, cfg, "modal jazz"); err != nil {
+		t.Fatalf("runGenerate: %v", err)
+	}
+	if gotCfg.BaseURL != "https://navidrome.local" || gotCfg.Username != "user" {
+		t.Fatalf("unexpected navidrome config %+v", gotCfg)
+	}
+	if len(client.created) != 1 || client.created[0] != "So What" {
+		t.Fatalf("unexpected created songs %v", client.created)
+	}
+	if store.mappings["Focus"].PlaylistID != "pl-new" {
+		t.Fatalf("expected mapping to be saved, got %+v", store.mappings)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no m3u on stdout when exporting to navidrome, got %q", out.String())
+	}
+
+	// A second run updates the playlist it created instead of adding another.
+	if err := runGenerate(context.Background(), cmd, opts, cfg, "modal jazz"); err != nil {
+		t.Fatalf("second runGenerate: %v", err)
+	}
+	if len(client.playlists) != 1 {
+		t.Fatalf("expected one navidrome playlist, got %+v", client.playlists)
+	}
+	last := client.updates[len(client.updates)-1]
+	if last.ID != "pl-new" || len(last.SongIDsToAdd) != 1 || len(last.SongIndexesToRemove) != 1 {
+		t.Fatalf("unexpected update %+v", last)
+	}
+}
+
+func TestRunGenerateNavidromeRequiresCredentials(t *testing.T) {
+	t.Setenv("NAVIDROME_URL", "")
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: "/library",
+		ollamaURL:   "http://ollama.invalid",
+		logFormat:   "text",
+	}
+	err := runGenerate(context.Background(), &cobra.Command{}, opts, generateConfig{maxTracks: 5, candidates: 5, navidrome: true}, "anything")
+	if err == nil || !strings.Contains(err.Error(), "navidrome URL") {
+		t.Fatalf("expected navidrome URL error, got %v", err)
+	}
+}
+
+func TestRunGenerateAppliesUserSignalFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embedding":[1,0,0]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+
+	starred := testCandidate(2, "Blue in Green", 5*time.Minute)
+	starred.Track.Stats.StarredAt = time.Now().Add(-time.Hour)
+	store := &generateStoreStub{
+		matches:    []sqlite.VectorMatch{{TrackID: 1}, {TrackID: 2}},
+		candidates: []sqlite.TrackCandidate{testCandidate(1, "So What", 5*time.Minute), starred},
+	}
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: "/library",
+		ollamaURL:   server.URL,
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		newGenerateStore: func(cfg sqlite.Config) (generateStore, error) {
+			return store, nil
+		},
+		logFormat: "text",
+	}
+
+	if err := runGenerate(context.Background(), cmd, opts, generateConfig{
+		duration:    10 * time.Minute,
+		maxTracks:   5,
+		candidates:  5,
+		starredOnly: true,
+		name:        "Favorites",
+	}, "modal jazz"); err != nil {
+		t.Fatalf("runGenerate: %v", err)
+	}
+	if strings.Contains(out.String(), "So What") || !strings.Contains(out.String(), "Blue in Green") {
+		t.Fatalf("expected only the starred track, got %q", out.String())
+	}
+}
+
+func TestRunGenerateRequiresEmbeddedTracks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embedding":[1,0,0]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: "/library",
+		ollamaURL:   server.URL,
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		newGenerateStore: func(cfg sqlite.Config) (generateStore, error) {
+			return &generateStoreStub{}, nil
+		},
+		logFormat: "text",
+	}
+	err := runGenerate(context.Background(), &cobra.Command{}, opts, generateConfig{maxTracks: 5, candidates: 5}, "anything")
+	if err == nil || !strings.Contains(err.Error(), "embed-process") {
+		t.Fatalf("expected hint to run embed-process, got %v", err)
+	}
+}
+
+func TestParsePromptDuration(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   time.Duration
+	}{
+		{"rainy sunday jazz, 90 minutes", 90 * time.Minute},
+		{"2 hours of focus music", 2 * time.Hour},
+		{"a 1.5h road trip", 90 * time.Minute},
+		{"45 mins of punk", 45 * time.Minute},
+		{"mellow evening", 0},
+	}
+	for _, tc := range tests {
+		if got := parsePromptDuration(tc.prompt); got != tc.want {
+			t.Fatalf("parsePromptDuration(%q) = %v, want %v", tc.prompt, got, tc.want)
+		}
+	}
+}
+
+type generateStoreStub struct {
+	matches     []sqlite.VectorMatch
+	candidates  []sqlite.TrackCandidate
+	lastK       int
+	lastFilters sqlite.VectorFilters
+	lastIDs     []int64
+	mappings    map[string]sqlite.NavidromePlaylistMapping
+}
+
+func (s *generateStoreStub) NearestTracks(ctx context.Context, vector []float32, k int, filters sqlite.VectorFilters) ([]sqlite.VectorMatch, error) {
+	s.lastK = k
+	s.lastFilters = filters
+	return s.matches, nil
+}
+
+func (s *generateStoreStub) LoadTrackCandidates(ctx context.Context, ids []int64) ([]sqlite.TrackCandidate, error) {
+	s.lastIDs = ids
+	return s.candidates, nil
+}
+
+func (s *generateStoreStub) NavidromePlaylist(ctx context.Context, name string) (sqlite.NavidromePlaylistMapping, bool, error) {
+	mapping, ok := s.mappings[name]
+	return mapping, ok, nil
+}
+
+func (s *generateStoreStub) SaveNavidromePlaylist(ctx context.Context, mapping sqlite.NavidromePlaylistMapping) error {
+	if s.mappings == nil {
+		s.mappings = map[string]sqlite.NavidromePlaylistMapping{}
+	}
+	s.mappings[mapping.Name] = mapping
+	return nil
+}
+
+func (s *generateStoreStub) DeleteNavidromePlaylist(ctx context.Context, name string) error {
+	delete(s.mappings, name)
+	return nil
+}
+
+func (s *generateStoreStub) Close() error {
+	return nil
+}
+
+type playlistClientStub struct {
+	playlists []navidrome.Playlist
+	created   []string
+	updates   []navidrome.PlaylistUpdate
+}
+
+func (s *playlistClientStub) GetPlaylists(ctx context.Context) ([]navidrome.Playlist, error) {
+	return s.playlists, nil
+}
+
+func (s *playlistClientStub) CreatePlaylist(ctx context.Context, name string, songIDs []string) (navidrome.Playlist, error) {
+	s.created = songIDs
+	playlist := navidrome.Playlist{ID: "pl-new", Name: name, SongCount: len(songIDs)}
+	s.playlists = append(s.playlists, playlist)
+	return playlist, nil
+}
+
+func (s *playlistClientStub) UpdatePlaylist(ctx context.Context, update navidrome.PlaylistUpdate) error {
+	s.updates = append(s.updates, update)
+	return nil
+}
+
+func (s *playlistClientStub) DeletePlaylist(ctx context.Context, id string) error {
+	return nil
+}
+
+func testCandidate(id int64, title string, duration time.Duration) sqlite.TrackCandidate {
+	return sqlite.TrackCandidate{
+		TrackID: id,
+		Track: app.Track{
+			ID:       title,
+			Title:    title,
+			Artist:   "Miles Davis",
+			Album:    "Kind of Blue",
+			Path:     "/music/" + title + ".flac",
+			Duration: duration,
+		},
+	}
+}
@@ -0,0 +1,487 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+const (
+	jobsFormatTable = "table"
+	jobsFormatJSON  = "json"
+)
+
+// maxJobErrorWidth keeps long ffmpeg errors from wrapping the jobs list;
+// jobs show prints the full error.
+const maxJobErrorWidth = 60
+
+type jobsStore interface {
+	ListJobs(context.Context, sqlite.JobFilter) ([]sqlite.Job, error)
+	Job(context.Context, string, int64) (sqlite.Job, bool, error)
+	RetryJobs(context.Context, sqlite.JobFilter) (int, error)
+	PurgeJobs(context.Context, sqlite.JobFilter, time.Time) (int, error)
+	RequeueTrackJobs(context.Context, string, string) (bool, error)
+	JobStats(context.Context) ([]sqlite.JobQueueStats, error)
+	Close() error
+}
+
+type jobsConfig struct {
+	format    string
+	queue     string
+	status    string
+	limit     int
+	track     string
+	olderThan time.Duration
+}
+
+func newJobsCmd(opts *options) *cobra.Command {
+	format := jobsFormatTable
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Inspect, retry, requeue and purge audio and embedding jobs",
+	}
+	cmd.PersistentFlags().StringVar(&format, "format", format, "Output format (table, json)")
+
+	cmd.AddCommand(newJobsListCmd(opts, &format))
+	cmd.AddCommand(newJobsShowCmd(opts, &format))
+	cmd.AddCommand(newJobsRetryCmd(opts, &format))
+	cmd.AddCommand(newJobsRequeueCmd(opts, &format))
+	cmd.AddCommand(newJobsPurgeCmd(opts, &format))
+	cmd.AddCommand(newJobsStatsCmd(opts, &format))
+
+	return cmd
+}
+
+func newJobsListCmd(opts *options, format *string) *cobra.Command {
+	cfg := jobsConfig{limit: 50}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List jobs, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg.format = *format
+			return runJobsList(cmd.Context(), cmd, opts, cfg)
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.queue, "queue", "", "Only list jobs of this queue (audio, embedding)")
+	cmd.Flags().StringVar(&cfg.status, "status", "", "Only list jobs with this status (pending, processing, completed, dead, or failed for retrying and dead jobs)")
+	cmd.Flags().IntVar(&cfg.limit, "limit", cfg.limit, "Number of jobs to show")
+
+	return cmd
+}
+
+func newJobsShowCmd(opts *options, format *string) *cobra.Command {
+	cfg := jobsConfig{queue: sqlite.AudioQueue}
+	cmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a job with its track, error and attempts",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg.format = *format
+			return runJobsShow(cmd.Context(), cmd, opts, cfg, args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.queue, "queue", cfg.queue, "Queue the job belongs to (audio, embedding)")
+
+	return cmd
+}
+
+func newJobsRetryCmd(opts *options, format *string) *cobra.Command {
+	cfg := jobsConfig{status: sqlite.JobStatusFailed}
+	cmd := &cobra.Command{
+		Use:   "retry",
+		Short: "Send failed jobs back to the queue with fresh attempts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg.format = *format
+			return runJobsRetry(cmd.Context(), cmd, opts, cfg)
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.queue, "queue", "", "Only retry jobs of this queue (audio, embedding)")
+	cmd.Flags().StringVar(&cfg.status, "status", cfg.status, "Jobs to retry: failed (retrying and dead) or dead")
+
+	return cmd
+}
+
+func newJobsRequeueCmd(opts *options, format *string) *cobra.Command {
+	cfg := jobsConfig{}
+	cmd := &cobra.Command{
+		Use:   "requeue",
+		Short: "Queue a track for processing again",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg.format = *format
+			return runJobsRequeue(cmd.Context(), cmd, opts, cfg)
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.track, "track", "", "Navidrome ID of the track to requeue")
+	cmd.Flags().StringVar(&cfg.queue, "queue", "", "Only requeue this queue (audio, embedding)")
+	_ = cmd.MarkFlagRequired("track")
+
+	return cmd
+}
+
+func newJobsPurgeCmd(opts *options, format *string) *cobra.Command {
+	cfg := jobsConfig{}
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Delete old completed and dead jobs",
+		Long: "Delete completed and dead jobs that finished longer ago than --older-than.\n" +
+			"Pending and processing jobs are never deleted, and neither is a track's\n" +
+			"newest job, which retry and requeue act on.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg.format = *format
+			return runJobsPurge(cmd.Context(), cmd, opts, cfg, time.Now())
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.queue, "queue", "", "Only purge jobs of this queue (audio, embedding)")
+	cmd.Flags().StringVar(&cfg.status, "status", "", "Only purge jobs with this status (completed, dead)")
+	cmd.Flags().DurationVar(&cfg.olderThan, "older-than", 0, "Only purge jobs that finished at least this long ago, e.g. 720h")
+	_ = cmd.MarkFlagRequired("older-than")
+
+	return cmd
+}
+
+func newJobsStatsCmd(opts *options, format *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Count jobs per status and show the oldest pending job",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runJobsStats(cmd.Context(), cmd, opts, jobsConfig{format: *format}, time.Now())
+		},
+	}
+}
+
+func openJobsStore(opts *options, format string) (jobsStore, error) {
+	if opts.dbPath == "" {
+		return nil, errors.New("db-path must be set to manage jobs")
+	}
+	if format != jobsFormatTable && format != jobsFormatJSON {
+		return nil, fmt.Errorf("unknown format %q (want %s or %s)", format, jobsFormatTable, jobsFormatJSON)
+	}
+	dbPath, err := filepath.Abs(opts.dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve db path: %w", err)
+	}
+	store, err := opts.newJobsStore(sqlite.Config{Path: dbPath})
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+	return store, nil
+}
+
+func runJobsList(ctx context.Context, cmd *cobra.Command, opts *options, cfg jobsConfig) error {
+	if cfg.limit <= 0 {
+		return errors.New("limit must be greater than zero")
+	}
+	store, err := openJobsStore(opts, cfg.format)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	jobs, err := store.ListJobs(ctx, sqlite.JobFilter{Queue: cfg.queue, Status: cfg.status, Limit: cfg.limit})
+	if err != nil {
+		return fmt.Errorf("list jobs: %w", err)
+	}
+	if cfg.format == jobsFormatJSON {
+		out := make([]jobOutput, 0, len(jobs))
+		for _, job := range jobs {
+			out = append(out, newJobOutput(job))
+		}
+		return writeJobsJSON(cmd.OutOrStdout(), out)
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUEUE\tID\tSTATUS\tATTEMPTS\tNEXT ATTEMPT\tTRACK\tERROR")
+	for _, job := range jobs {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\t%s\t%s\n",
+			job.Queue,
+			job.ID,
+			job.Status,
+			job.Attempts,
+			formatJobTime(job.NextAttemptAt),
+			job.Artist+" - "+job.Title,
+			truncateJobError(job.Error),
+		)
+	}
+	return tw.Flush()
+}
+
+func runJobsShow(ctx context.Context, cmd *cobra.Command, opts *options, cfg jobsConfig, arg string) error {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid job id %q", arg)
+	}
+	store, err := openJobsStore(opts, cfg.format)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	job, ok, err := store.Job(ctx, cfg.queue, id)
+	if err != nil {
+		return fmt.Errorf("get job: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("no %s job with id %d", cfg.queue, id)
+	}
+	if cfg.format == jobsFormatJSON {
+		return writeJobsJSON(cmd.OutOrStdout(), newJobOutput(job))
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Queue:\t%s\n", job.Queue)
+	fmt.Fprintf(tw, "ID:\t%d\n", job.ID)
+	fmt.Fprintf(tw, "Status:\t%s\n", job.Status)
+	fmt.Fprintf(tw, "Attempts:\t%d\n", job.Attempts)
+	fmt.Fprintf(tw, "Error:\t%s\n", valueOrDash(job.Error))
+	fmt.Fprintf(tw, "Created:\t%s\n", formatJobTime(job.CreatedAt))
+	fmt.Fprintf(tw, "Last attempt:\t%s\n", formatJobTime(job.LastAttemptAt))
+	fmt.Fprintf(tw, "Next attempt:\t%s\n", formatJobTime(job.NextAttemptAt))
+	fmt.Fprintf(tw, "Claimed by:\t%s\n", valueOrDash(job.ClaimedBy))
+	fmt.Fprintf(tw, "Processed:\t%s\n", formatJobTime(job.ProcessedAt))
+	fmt.Fprintf(tw, "Track:\t%s (id %d)\n", job.NavidromeID, job.TrackID)
+	fmt.Fprintf(tw, "Artist:\t%s\n", job.Artist)
+	fmt.Fprintf(tw, "Album:\t%s\n", job.Album)
+	fmt.Fprintf(tw, "Title:\t%s\n", job.Title)
+	fmt.Fprintf(tw, "Path:\t%s\n", job.Path)
+	if job.TrackDeleted {
+		fmt.Fprintln(tw, "Deleted:\tyes, missing from Navidrome")
+	}
+	return tw.Flush()
+}
+
+func runJobsRetry(ctx context.Context, cmd *cobra.Command, opts *options, cfg jobsConfig) error {
+	store, err := openJobsStore(opts, cfg.format)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	retried, err := store.RetryJobs(ctx, sqlite.JobFilter{Queue: cfg.queue, Status: cfg.status})
+	if err != nil {
+		return fmt.Errorf("retry jobs: %w", err)
+	}
+	if cfg.format == jobsFormatJSON {
+		return writeJobsJSON(cmd.OutOrStdout(), map[string]int{"retried": retried})
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "retried %d jobs\n", retried)
+	return nil
+}
+
+func runJobsRequeue(ctx context.Context, cmd *cobra.Command, opts *options, cfg jobsConfig) error {
+	if cfg.track == "" {
+		return errors.New("track must be set to requeue jobs")
+	}
+	store, err := openJobsStore(opts, cfg.format)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	found, err := store.RequeueTrackJobs(ctx, cfg.track, cfg.queue)
+	if err != nil {
+		return fmt.Errorf("requeue track: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("no track with navidrome id %q", cfg.track)
+	}
+	queues := []string{sqlite.AudioQueue, sqlite.EmbeddingQueue}
+	if cfg.queue != "" {
+		queues = []string{cfg.queue}
+	}
+	if cfg.format == jobsFormatJSON {
+		return writeJobsJSON(cmd.OutOrStdout(), map[string]any{"track": cfg.track, "queues": queues})
+	}
+	for _, queue := range queues {
+		fmt.Fprintf(cmd.OutOrStdout(), "requeued %s job for track %s\n", queue, cfg.track)
+	}
+	return nil
+}
+
+func runJobsPurge(ctx context.Context, cmd *cobra.Command, opts *options, cfg jobsConfig, now time.Time) error {
+	if cfg.olderThan <= 0 {
+		return errors.New("older-than must be greater than zero")
+	}
+	store, err := openJobsStore(opts, cfg.format)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	purged, err := store.PurgeJobs(ctx, sqlite.JobFilter{Queue: cfg.queue, Status: cfg.status}, now.Add(-cfg.olderThan))
+	if err != nil {
+		return fmt.Errorf("purge jobs: %w", err)
+	}
+	if cfg.format == jobsFormatJSON {
+		return writeJobsJSON(cmd.OutOrStdout(), map[string]int{"purged": purged})
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "purged %d jobs\n", purged)
+	return nil
+}
+
+func runJobsStats(ctx context.Context, cmd *cobra.Command, opts *options, cfg jobsConfig, now time.Time) error {
+	store, err := openJobsStore(opts, cfg.format)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	stats, err := store.JobStats(ctx)
+	if err != nil {
+		return fmt.Errorf("job stats: %w", err)
+	}
+	if cfg.format == jobsFormatJSON {
+		out := make([]jobStatsOutput, 0, len(stats))
+		for _, queue := range stats {
+			out = append(out, jobStatsOutput{
+				Queue:                   queue.Queue,
+				Pending:                 queue.Pending,
+				Retrying:                queue.Retrying,
+				Processing:              queue.Processing,
+				Completed:               queue.Completed,
+				Dead:                    queue.Dead,
+				OldestPendingAt:         formatJobJSONTime(queue.OldestPendingAt),
+				OldestPendingAgeSeconds: int64(pendingAge(queue, now).Seconds()),
+			})
+		}
+		return writeJobsJSON(cmd.OutOrStdout(), out)
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUEUE\tPENDING\tRETRYING\tPROCESSING\tCOMPLETED\tDEAD\tOLDEST PENDING")
+	for _, queue := range stats {
+		age := "-"
+		if d := pendingAge(queue, now); d > 0 {
+			age = d.String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			queue.Queue,
+			queue.Pending,
+			queue.Retrying,
+			queue.Processing,
+			queue.Completed,
+			queue.Dead,
+			age,
+		)
+	}
+	return tw.Flush()
+}
+
+// jobOutput is the JSON form of a job; unset times are omitted.
+type jobOutput struct {
+	Queue         string `json:"queue"`
+	ID            int64  `json:"id"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	Error         string `json:"error,omitempty"`
+	CreatedAt     string `json:"created_at,omitempty"`
+	LastAttemptAt string `json:"last_attempt_at,omitempty"`
+	NextAttemptAt string `json:"next_attempt_at,omitempty"`
+	ClaimedAt     string `json:"claimed_at,omitempty"`
+	ClaimedBy     string `json:"claimed_by,omitempty"`
+	ProcessedAt   string `json:"processed_at,omitempty"`
+	TrackID       int64  `json:"track_id"`
+	NavidromeID   string `json:"navidrome_id"`
+	Artist        string `json:"artist"`
+	Album         string `json:"album"`
+	Title         string `json:"title"`
+	Path          string `json:"path"`
+	TrackDeleted  bool   `json:"track_deleted,omitempty"`
+}
+
+func newJobOutput(job sqlite.Job) jobOutput {
+	return jobOutput{
+		Queue:         job.Queue,
+		ID:            job.ID,
+		Status:        job.Status,
+		Attempts:      job.Attempts,
+		Error:         job.Error,
+		CreatedAt:     formatJobJSONTime(job.CreatedAt),
+		LastAttemptAt: formatJobJSONTime(job.LastAttemptAt),
+		NextAttemptAt: formatJobJSONTime(job.NextAttemptAt),
+		ClaimedAt:     formatJobJSONTime(job.ClaimedAt),
+		ClaimedBy:     job.ClaimedBy,
+		ProcessedAt:   formatJobJSONTime(job.ProcessedAt),
+		TrackID:       job.TrackID,
+		NavidromeID:   job.NavidromeID,
+		Artist:        job.Artist,
+		Album:         job.Album,
+		Title:         job.Title,
+		Path:          job.Path,
+		TrackDeleted:  job.TrackDeleted,
+	}
+}
+
+type jobStatsOutput struct {
+	Queue                   string `json:"queue"`
+	Pending                 int    `json:"pending"`
+	Retrying                int    `json:"retrying"`
+	Processing              int    `json:"processing"`
+	Completed               int    `json:"completed"`
+	Dead                    int    `json:"dead"`
+	OldestPendingAt         string `json:"oldest_pending_at,omitempty"`
+	OldestPendingAgeSeconds int64  `json:"oldest_pending_age_seconds"`
+}
+
+// pendingAge is how long the oldest pending job of queue has waited, or zero
+// when nothing is pending.
+func pendingAge(queue sqlite.JobQueueStats, now time.Time) time.Duration {
+	if queue.OldestPendingAt.IsZero() {
+		return 0
+	}
+	return max(now.Sub(queue.OldestPendingAt).Round(time.Second), 0)
+}
+
+func writeJobsJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func formatJobTime(ts time.Time) string {
+	if ts.IsZero() {
+		return "-"
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
+
+func formatJobJSONTime(ts time.Time) string {
+	if ts.IsZero() {
+		return ""
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func truncateJobError(msg string) string {
+	runes := []rune(msg)
+	if len(runes) <= maxJobErrorWidth {
+		return msg
+	}
+	return string(runes[:maxJobErrorWidth-3]) + "..."
+}
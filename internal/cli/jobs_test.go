@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+func TestJobsListFiltersAndFormats(t *testing.T) {
+	store := &jobsStoreStub{jobs: []sqlite.Job{{
+		Queue:       sqlite.AudioQueue,
+		ID:          7,
+		NavidromeID: "nav-7",
+		Artist:      "Artist",
+		Title:       "Song",
+		Status:      "dead",
+		Attempts:    5,
+		Error:       "ffmpeg: " + strings.Repeat("x", 100),
+	}}}
+	opts := jobsTestOptions(t, store)
+
+	out := &bytes.Buffer{}
+	cmd := newRootCmd(opts)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"jobs", "list", "--queue", "audio", "--status", "failed", "--limit", "5"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("jobs list: %v", err)
+	}
+	if want := (sqlite.JobFilter{Queue: "audio", Status: "failed", Limit: 5}); store.filter != want {
+		t.Fatalf("unexpected filter %+v", store.filter)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "QUEUE") {
+		t.Fatalf("unexpected table %q", out.String())
+	}
+	if !strings.Contains(lines[1], "Artist - Song") || !strings.HasSuffix(lines[1], "...") {
+		t.Fatalf("expected track and truncated error, got %q", lines[1])
+	}
+	if !store.closed {
+		t.Fatalf("expected store to be closed")
+	}
+
+	out.Reset()
+	cmd = newRootCmd(opts)
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"jobs", "list", "--format", "json"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("jobs list json: %v", err)
+	}
+	var jobs []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &jobs); err != nil {
+		t.Fatalf("decode json %q: %v", out.String(), err)
+	}
+	if len(jobs) != 1 || jobs[0]["navidrome_id"] != "nav-7" || jobs[0]["status"] != "dead" {
+		t.Fatalf("unexpected json %v", jobs)
+	}
+	if _, ok := jobs[0]["next_attempt_at"]; ok {
+		t.Fatalf("expected unset times to be omitted, got %v", jobs[0])
+	}
+	if store.filter.Limit != 50 || store.filter.Queue != "" {
+		t.Fatalf("expected default filter, got %+v", store.filter)
+	}
+
+	cmd = newRootCmd(opts)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"jobs", "list", "--format", "yaml"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestJobsShow(t *testing.T) {
+	store := &jobsStoreStub{jobs: []sqlite.Job{{
+		Queue:       sqlite.EmbeddingQueue,
+		ID:          3,
+		NavidromeID: "nav-3",
+		Path:        "Artist/Album/03.flac",
+		Status:      "pending",
+		Error:       "ollama unavailable",
+		Attempts:    2,
+	}}}
+	opts := jobsTestOptions(t, store)
+
+	out := &bytes.Buffer{}
+	if err := runJobsShow(context.Background(), commandWithOutput(out), opts, jobsConfig{format: jobsFormatTable, queue: sqlite.EmbeddingQueue}, "3"); err != nil {
+		t.Fatalf("jobs show: %v", err)
+	}
+	for _, want := range []string{"ollama unavailable", "Artist/Album/03.flac", "nav-3", "Attempts:"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in %q", want, out.String())
+		}
+	}
+
+	if err := runJobsShow(context.Background(), commandWithOutput(out), opts, jobsConfig{format: jobsFormatTable, queue: sqlite.AudioQueue}, "3"); err == nil || !strings.Contains(err.Error(), "no audio job with id 3") {
+		t.Fatalf("expected missing job error, got %v", err)
+	}
+	if err := runJobsShow(context.Background(), commandWithOutput(out), opts, jobsConfig{format: jobsFormatTable}, "abc"); err == nil {
+		t.Fatalf("expected error for invalid id")
+	}
+}
+
+func TestJobsRetryAndRequeue(t *testing.T) {
+	store := &jobsStoreStub{retried: 4, tracks: map[string]bool{"nav-1": true}}
+	opts := jobsTestOptions(t, store)
+	ctx := context.Background()
+
+	out := &bytes.Buffer{}
+	if err := runJobsRetry(ctx, commandWithOutput(out), opts, jobsConfig{format: jobsFormatTable, queue: "embedding", status: "dead"}); err != nil {
+		t.Fatalf("jobs retry: %v", err)
+	}
+	if want := (sqlite.JobFilter{Queue: "embedding", Status: "dead"}); store.filter != want {
+		t.Fatalf("unexpected filter %+v", store.filter)
+	}
+	if got := out.String(); got != "retried 4 jobs\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	out.Reset()
+	if err := runJobsRequeue(ctx, commandWithOutput(out), opts, jobsConfig{format: jobsFormatTable, track: "nav-1"}); err != nil {
+		t.Fatalf("jobs requeue: %v", err)
+	}
+	if !strings.Contains(out.String(), "requeued audio job for track nav-1") || !strings.Contains(out.String(), "requeued embedding job for track nav-1") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	if err := runJobsRequeue(ctx, commandWithOutput(out), opts, jobsConfig{format: jobsFormatTable, track: "nav-404"}); err == nil {
+		t.Fatalf("expected error for unknown track")
+	}
+}
+
+func TestJobsPurge(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := &jobsStoreStub{purged: 9}
+	opts := jobsTestOptions(t, store)
+	ctx := context.Background()
+
+	out := &bytes.Buffer{}
+	cfg := jobsConfig{format: jobsFormatTable, queue: "audio", status: "completed", olderThan: 30 * 24 * time.Hour}
+	if err := runJobsPurge(ctx, commandWithOutput(out), opts, cfg, now); err != nil {
+		t.Fatalf("jobs purge: %v", err)
+	}
+	if want := (sqlite.JobFilter{Queue: "audio", Status: "completed"}); store.filter != want {
+		t.Fatalf("unexpected filter %+v", store.filter)
+	}
+	if want := time.Date(2026, 5, 2, 12, 0, 0, 0, time.UTC); !store.cutoff.Equal(want) {
+		t.Fatalf("expected cutoff %s, got %s", want, store.cutoff)
+	}
+	if got := out.String(); got != "purged 9 jobs\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	out.Reset()
+	cfg.format = jobsFormatJSON
+	if err := runJobsPurge(ctx, commandWithOutput(out), opts, cfg, now); err != nil {
+		t.Fatalf("jobs purge json: %v", err)
+	}
+	var got map[string]int
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil || got["purged"] != 9 {
+		t.Fatalf("unexpected json %q (%v)", out.String(), err)
+	}
+
+	if err := runJobsPurge(ctx, commandWithOutput(out), opts, jobsConfig{format: jobsFormatTable}, now); err == nil {
+		t.Fatalf("expected error without older-than")
+	}
+}
+
+func TestJobsStats(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := &jobsStoreStub{stats: []sqlite.JobQueueStats{
+		{Queue: sqlite.AudioQueue, Pending: 12, Retrying: 2, Dead: 1, OldestPendingAt: now.Add(-90 * time.Minute)},
+		{Queue: sqlite.EmbeddingQueue, Completed: 40},
+	}}
+	opts := jobsTestOptions(t, store)
+
+	out := &bytes.Buffer{}
+	if err := runJobsStats(context.Background(), commandWithOutput(out), opts, jobsConfig{format: jobsFormatTable}, now); err != nil {
+		t.Fatalf("jobs stats: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[1], "1h30m0s") || !strings.HasSuffix(lines[2], "-") {
+		t.Fatalf("unexpected stats table %q", out.String())
+	}
+
+	out.Reset()
+	if err := runJobsStats(context.Background(), commandWithOutput(out), opts, jobsConfig{format: jobsFormatJSON}, now); err != nil {
+		t.Fatalf("jobs stats json: %v", err)
+	}
+	var stats []jobStatsOutput
+	if err := json.Unmarshal(out.Bytes(), &stats); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(stats) != 2 || stats[0].OldestPendingAgeSeconds != 5400 || stats[0].Retrying != 2 || stats[1].OldestPendingAt != "" {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	if err := runJobsStats(context.Background(), commandWithOutput(out), &options{}, jobsConfig{format: jobsFormatTable}, now); err == nil {
+		t.Fatalf("expected error without db path")
+	}
+}
+
+func commandWithOutput(out *bytes.Buffer) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	return cmd
+}
+
+func jobsTestOptions(t *testing.T, store *jobsStoreStub) *options {
+	opts := newOptions()
+	opts.dbPath = filepath.Join(t.TempDir(), "db.sqlite")
+	opts.newJobsStore = func(cfg sqlite.Config) (jobsStore, error) {
+		return store, nil
+	}
+	return opts
+}
+
+type jobsStoreStub struct {
+	jobs    []sqlite.Job
+	stats   []sqlite.JobQueueStats
+	retried int
+	purged  int
+	cutoff  time.Time
+	tracks  map[string]bool
+	filter  sqlite.JobFilter
+	closed  bool
+}
+
+func (s *jobsStoreStub) ListJobs(ctx context.Context, filter sqlite.JobFilter) ([]sqlite.Job, error) {
+	s.filter = filter
+	return s.jobs, nil
+}
+
+func (s *jobsStoreStub) Job(ctx context.Context, queue string, id int64) (sqlite.Job, bool, error) {
+	for _, job := range s.jobs {
+		if job.Queue == queue && job.ID == id {
+			return job, true, nil
+		}
+	}
+	return sqlite.Job{}, false, nil
+}
+
+func (s *jobsStoreStub) RetryJobs(ctx context.Context, filter sqlite.JobFilter) (int, error) {
+	s.filter = filter
+	return s.retried, nil
+}
+
+func (s *jobsStoreStub) PurgeJobs(ctx context.Context, filter sqlite.JobFilter, cutoff time.Time) (int, error) {
+	s.filter = filter
+	s.cutoff = cutoff
+	return s.purged, nil
+}
+
+func (s *jobsStoreStub) RequeueTrackJobs(ctx context.Context, navidromeID, queue string) (bool, error) {
+	return s.tracks[navidromeID], nil
+}
+
+func (s *jobsStoreStub) JobStats(ctx context.Context) ([]sqlite.JobQueueStats, error) {
+	return s.stats, nil
+}
+
+func (s *jobsStoreStub) Close() error {
+	s.closed = true
+	return nil
+}
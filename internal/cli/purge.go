@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+const defaultPurgeGracePeriod = 30 * 24 * time.Hour
+
+type purgeStore interface {
+	PurgeDeletedTracks(context.Context, time.Time) (int, error)
+	Close() error
+}
+
+func newPurgeCmd(opts *options) *cobra.Command {
+	olderThan := defaultPurgeGracePeriod
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Permanently remove tracks that have been missing from Navidrome",
+		Long: "Tracks missing from a sync are tombstoned so their analysis survives a\n" +
+			"partial Navidrome scan. purge removes tombstoned tracks, with their audio\n" +
+			"features and embeddings, once they have been gone for the grace period.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPurge(cmd.Context(), cmd, opts, olderThan, time.Now())
+		},
+	}
+
+	cmd.Flags().DurationVar(&olderThan, "older-than", olderThan, "Grace period a track must have been missing before it is purged")
+
+	return cmd
+}
+
+func runPurge(ctx context.Context, cmd *cobra.Command, opts *options, olderThan time.Duration, now time.Time) error {
+	if opts.dbPath == "" {
+		return errors.New("db-path must be set to purge tracks")
+	}
+	if olderThan < 0 {
+		return errors.New("older-than must not be negative")
+	}
+
+	dbPath, err := filepath.Abs(opts.dbPath)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+	store, err := opts.newPurgeStore(sqlite.Config{Path: dbPath})
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	cutoff := now.Add(-olderThan).UTC()
+	purged, err := store.PurgeDeletedTracks(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "purged %d tracks deleted before %s\n", purged, cutoff.Format(time.RFC3339))
+	return nil
+}
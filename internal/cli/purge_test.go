@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+func TestRunPurge(t *testing.T) {
+	store := &purgeStoreStub{purged: 3}
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	opts := &options{
+		dbPath: filepath.Join(t.TempDir(), "db.sqlite"),
+		newPurgeStore: func(cfg sqlite.Config) (purgeStore, error) {
+			return store, nil
+		},
+	}
+
+	now := time.Date(2026, 5, 31, 12, 0, 0, 0, time.UTC)
+	if err := runPurge(context.Background(), cmd, opts, 72*time.Hour, now); err != nil {
+		t.Fatalf("runPurge: %v", err)
+	}
+	if want := now.Add(-72 * time.Hour); !store.before.Equal(want) || !store.closed {
+		t.Fatalf("unexpected store usage before=%s closed=%v", store.before, store.closed)
+	}
+	if got := out.String(); !strings.Contains(got, "purged 3 tracks deleted before 2026-05-28T12:00:00Z") {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	if err := runPurge(context.Background(), cmd, &options{}, time.Hour, now); err == nil {
+		t.Fatalf("expected error without db path")
+	}
+	if err := runPurge(context.Background(), cmd, opts, -time.Hour, now); err == nil {
+		t.Fatalf("expected error for negative grace period")
+	}
+}
+
+type purgeStoreStub struct {
+	purged int
+	before time.Time
+	closed bool
+}
+
+func (s *purgeStoreStub) PurgeDeletedTracks(ctx context.Context, before time.Time) (int, error) {
+	s.before = before
+	return s.purged, nil
+}
+
+func (s *purgeStoreStub) Close() error {
+	s.closed = true
+	return nil
+}
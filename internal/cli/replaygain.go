@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/audio"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+const (
+	backupNone    = "none"
+	backupSidecar = "sidecar"
+	backupDir     = "dir"
+)
+
+type replayGainStore interface {
+	ListTrackLoudness(context.Context) ([]sqlite.TrackLoudness, error)
+	Close() error
+}
+
+type tagReader interface {
+	ReadTags(context.Context, string) (map[string]string, error)
+}
+
+type tagWriter interface {
+	WriteTags(context.Context, string, []audio.Tag, func(context.Context, string) error) error
+}
+
+type replayGainWriteConfig struct {
+	dryRun    bool
+	formats   []string
+	backup    string
+	backupDir string
+}
+
+func newReplayGainCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replaygain",
+		Short: "Manage ReplayGain tags in library files",
+	}
+
+	cmd.AddCommand(newReplayGainWriteCmd(opts))
+
+	return cmd
+}
+
+func newReplayGainWriteCmd(opts *options) *cobra.Command {
+	cfg := replayGainWriteConfig{
+		formats: slices.Clone(audio.TagWriteFormats),
+		backup:  backupSidecar,
+	}
+	cmd := &cobra.Command{
+		Use:   "write",
+		Short: "Write measured loudness to files as ReplayGain tags",
+		Long: "Write the loudness audio-process measured into library files as tags:\n" +
+			"REPLAYGAIN_TRACK_GAIN/PEAK, and REPLAYGAIN_ALBUM_GAIN/PEAK once the whole\n" +
+			"album is measured, or R128_TRACK_GAIN/R128_ALBUM_GAIN for Opus, whose\n" +
+			"REPLAYGAIN_* tags are removed so they cannot mask the R128 gains. Files are\n" +
+			"rewritten without re-encoding, and each rewrite is read back to check the\n" +
+			"tags landed before it replaces the original. Files whose tags already\n" +
+			"match are left alone.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReplayGainWrite(cmd.Context(), cmd, opts, cfg)
+		},
+	}
+
+	cmd.Flags().BoolVar(&cfg.dryRun, "dry-run", false, "Print the tag changes without writing them")
+	cmd.Flags().StringSliceVar(&cfg.formats, "formats", cfg.formats, "File formats to write, by extension ("+strings.Join(audio.TagWriteFormats, ", ")+")")
+	cmd.Flags().StringVar(&cfg.backup, "backup", cfg.backup, "Keep each original before writing: sidecar (next to it as .bak), dir (under --backup-dir), or none")
+	cmd.Flags().StringVar(&cfg.backupDir, "backup-dir", "", "Directory that mirrors the library for --backup dir")
+
+	return cmd
+}
+
+func runReplayGainWrite(ctx context.Context, cmd *cobra.Command, opts *options, cfg replayGainWriteConfig) error {
+	if err := opts.ensureLogger(cmd.ErrOrStderr()); err != nil {
+		return fmt.Errorf("init logger: %w", err)
+	}
+	logger := opts.logger
+
+	if opts.dbPath == "" {
+		return errors.New("db-path must be set to write replaygain tags")
+	}
+	if opts.libraryRoot == "" {
+		opts.libraryRoot = defaultLibraryRoot
+	}
+	formats := make(map[string]struct{}, len(cfg.formats))
+	for _, format := range cfg.formats {
+		format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+		if !slices.Contains(audio.TagWriteFormats, format) {
+			return fmt.Errorf("unsupported format %q (want %s)", format, strings.Join(audio.TagWriteFormats, ", "))
+		}
+		formats[format] = struct{}{}
+	}
+	switch cfg.backup {
+	case backupNone, backupSidecar:
+	case backupDir:
+		if strings.TrimSpace(cfg.backupDir) == "" {
+			return errors.New("--backup dir requires --backup-dir")
+		}
+	default:
+		return fmt.Errorf("unknown backup mode %q (want sidecar, dir, or none)", cfg.backup)
+	}
+	if !cfg.dryRun {
+		if err := checkWritableRoot(opts.libraryRoot); err != nil {
+			return err
+		}
+	}
+
+	dbPath, err := filepath.Abs(opts.dbPath)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+	store, err := opts.newReplayGainStore(sqlite.Config{Path: dbPath})
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	tracks, err := store.ListTrackLoudness(ctx)
+	if err != nil {
+		return err
+	}
+	reader := opts.newTagReader()
+	writer := opts.newTagWriter()
+
+	w := cmd.OutOrStdout()
+	var changed, unchanged, skipped, failed int
+	for _, track := range tracks {
+		path, err := audio.ResolveLibraryPath(opts.libraryRoot, track.Track.Path)
+		if err != nil {
+			logger.Error("resolve track path", "track_id", track.TrackID, "path", track.Track.Path, "error", err)
+			failed++
+			continue
+		}
+		format := audio.FileFormat(path)
+		rg, ok := audio.ReplayGainFromLoudness(track.IntegratedLUFS, track.TruePeak, track.Album)
+		if _, allowed := formats[format]; !allowed || !ok {
+			skipped++
+			continue
+		}
+		current, err := reader.ReadTags(ctx, path)
+		if err != nil {
+			logger.Error("read tags", "path", path, "error", err)
+			failed++
+			continue
+		}
+		want := append(audio.ReplayGainTags(format, rg), audio.StaleReplayGainTags(format, current)...)
+		changes := tagChanges(format, current, want)
+		if len(changes) == 0 {
+			unchanged++
+			continue
+		}
+		printTagChanges(w, track.Track.Path, changes)
+		if cfg.dryRun {
+			changed++
+			continue
+		}
+
+		if err := writeReplayGain(ctx, reader, writer, cfg, path, track.Track.Path, want); err != nil {
+			logger.Error("write tags", "path", path, "error", err)
+			failed++
+			continue
+		}
+		changed++
+	}
+
+	if cfg.dryRun {
+		fmt.Fprintf(w, "dry run: would write tags to %d files (%d unchanged, %d skipped, %d failed)\n", changed, unchanged, skipped, failed)
+	} else {
+		fmt.Fprintf(w, "wrote tags to %d files (%d unchanged, %d skipped, %d failed)\n", changed, unchanged, skipped, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d files failed", failed)
+	}
+	return nil
+}
+
+// writeReplayGain backs up the file at path and writes want to it. The
+// rewritten file is read back before it replaces the original, so a write
+// whose tags did not land leaves the original in place.
+func writeReplayGain(ctx context.Context, reader tagReader, writer tagWriter, cfg replayGainWriteConfig, path, navPath string, want []audio.Tag) error {
+	backup := ""
+	switch cfg.backup {
+	case backupSidecar:
+		backup = path + ".bak"
+	case backupDir:
+		var err error
+		if backup, err = audio.ResolveLibraryPath(cfg.backupDir, navPath); err != nil {
+			return fmt.Errorf("resolve backup path: %w", err)
+		}
+	}
+	if backup != "" {
+		if err := copyFile(path, backup); err != nil {
+			return fmt.Errorf("back up: %w", err)
+		}
+	}
+
+	format := audio.FileFormat(path)
+	return writer.WriteTags(ctx, path, want, func(ctx context.Context, written string) error {
+		current, err := reader.ReadTags(ctx, written)
+		if err != nil {
+			return fmt.Errorf("read back: %w", err)
+		}
+		if changes := tagChanges(format, current, want); len(changes) > 0 {
+			return fmt.Errorf("read back %d mismatched tags, starting with %s", len(changes), changes[0].Key)
+		}
+		return nil
+	})
+}
+
+type tagChange struct {
+	Key string
+	Old string
+	New string
+}
+
+// tagChanges lists the tags in want that a file of format carrying tags
+// lacks or holds with a different value; a want tag with an empty value
+// changes only when the file still carries it. Gains are compared as written
+// for the format, so a value spelled differently by another tagger is kept.
+func tagChanges(format string, tags map[string]string, want []audio.Tag) []tagChange {
+	current := make(map[string]string)
+	for _, tag := range audio.ReplayGainTags(format, audio.ReplayGainFromTags(format, tags)) {
+		current[tag.Key] = tag.Value
+	}
+	var changes []tagChange
+	for _, tag := range want {
+		if tag.Value == "" {
+			if old, ok := tags[tag.Key]; ok {
+				changes = append(changes, tagChange{Key: tag.Key, Old: old})
+			}
+			continue
+		}
+		if old, ok := current[tag.Key]; !ok || old != tag.Value {
+			changes = append(changes, tagChange{Key: tag.Key, Old: old, New: tag.Value})
+		}
+	}
+	return changes
+}
+
+func printTagChanges(w io.Writer, path string, changes []tagChange) {
+	fmt.Fprintln(w, path)
+	for _, change := range changes {
+		old, updated := change.Old, change.New
+		if old == "" {
+			old = "(none)"
+		}
+		if updated == "" {
+			updated = "(removed)"
+		}
+		fmt.Fprintf(w, "  %s: %s -> %s\n", change.Key, old, updated)
+	}
+}
+
+// copyFile copies src to dst, creating dst's directory. An existing dst is
+// kept, so a backup always holds the file as it was before the first write.
+func copyFile(src, dst string) (err error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(dst)
+		}
+	}()
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// checkWritableRoot refuses a library root that cannot be written, such as
+// one mounted read-only, by creating and removing a file in it.
+func checkWritableRoot(root string) error {
+	probe, err := os.CreateTemp(root, ".playlistgen-write-check-*")
+	if err != nil {
+		return rootWriteError(root, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func rootWriteError(root string, err error) error {
+	if errors.Is(err, syscall.EROFS) {
+		return fmt.Errorf("library root %s is mounted read-only; remount it read-write to write tags", root)
+	}
+	return fmt.Errorf("library root %s is not writable: %w", root, err)
+}
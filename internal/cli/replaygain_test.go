@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/audio"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+func TestRunReplayGainWrite(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.flac", "b.opus", "c.flac", "d.m4a"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("audio "+name), 0o644); err != nil {
+			t.Fatalf("write file: %v", err)
+		}
+	}
+	peak, albumPeak := -0.5, -0.1
+	store := &replayGainStoreStub{tracks: []sqlite.TrackLoudness{
+		{TrackID: 1, Track: app.Track{Path: "a.flac"}, IntegratedLUFS: -11.5, TruePeak: &peak, Album: &audio.AlbumLoudness{IntegratedLUFS: -12.25, TruePeak: &albumPeak}},
+		{TrackID: 2, Track: app.Track{Path: "b.opus"}, IntegratedLUFS: -11.5},
+		{TrackID: 3, Track: app.Track{Path: "c.flac"}, IntegratedLUFS: -8},
+		{TrackID: 4, Track: app.Track{Path: "d.m4a"}, IntegratedLUFS: -8},
+	}}
+	files := &fakeTagFiles{tags: map[string]map[string]string{
+		filepath.Join(root, "c.flac"): {"replaygain_track_gain": "-10.00 dB"},
+	}}
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: root,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		newReplayGainStore: func(cfg sqlite.Config) (replayGainStore, error) {
+			return store, nil
+		},
+		newTagReader: func() tagReader { return audio.FFProbeTagReader{Runner: files} },
+		newTagWriter: func() tagWriter { return files },
+	}
+	cfg := replayGainWriteConfig{formats: audio.TagWriteFormats, backup: backupSidecar}
+
+	dryRun := cfg
+	dryRun.dryRun = true
+	if err := runReplayGainWrite(context.Background(), cmd, opts, dryRun); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"a.flac\n  REPLAYGAIN_TRACK_GAIN: (none) -> -6.50 dB\n",
+		"  REPLAYGAIN_ALBUM_PEAK: (none) -> 0.988553\n",
+		"b.opus\n  R128_TRACK_GAIN: (none) -> -2944\n",
+		"dry run: would write tags to 2 files (1 unchanged, 1 skipped, 0 failed)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output %q", want, got)
+		}
+	}
+	if strings.Contains(got, "c.flac") || files.writes != 0 {
+		t.Fatalf("expected a dry run to write nothing, got %d writes and output %q", files.writes, got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.flac.bak")); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup from a dry run, got %v", err)
+	}
+
+	out.Reset()
+	if err := runReplayGainWrite(context.Background(), cmd, opts, cfg); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !strings.Contains(out.String(), "wrote tags to 2 files (1 unchanged, 1 skipped, 0 failed)") || files.writes != 2 {
+		t.Fatalf("unexpected output %q after %d writes", out.String(), files.writes)
+	}
+	if backup, err := os.ReadFile(filepath.Join(root, "a.flac.bak")); err != nil || string(backup) != "audio a.flac" {
+		t.Fatalf("expected the original in the backup, got %q (%v)", backup, err)
+	}
+	if got := files.tags[filepath.Join(root, "b.opus")]; len(got) != 1 || got["R128_TRACK_GAIN"] != "-2944" {
+		t.Fatalf("unexpected opus tags %v", got)
+	}
+
+	out.Reset()
+	if err := runReplayGainWrite(context.Background(), cmd, opts, cfg); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	if !strings.Contains(out.String(), "wrote tags to 0 files (3 unchanged, 1 skipped, 0 failed)") {
+		t.Fatalf("expected written files to be left alone, got %q", out.String())
+	}
+}
+
+func TestRunReplayGainWriteReplacesOpusReplayGainTags(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.opus")
+	if err := os.WriteFile(path, []byte("audio"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	files := &fakeTagFiles{tags: map[string]map[string]string{
+		path: {"replaygain_track_gain": "-1.00 dB", "replaygain_track_peak": "0.900000", "TITLE": "a"},
+	}}
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: root,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		newReplayGainStore: func(cfg sqlite.Config) (replayGainStore, error) {
+			return &replayGainStoreStub{tracks: []sqlite.TrackLoudness{{TrackID: 1, Track: app.Track{Path: "a.opus"}, IntegratedLUFS: -11.5}}}, nil
+		},
+		newTagReader: func() tagReader { return audio.FFProbeTagReader{Runner: files} },
+		newTagWriter: func() tagWriter { return files },
+	}
+	cfg := replayGainWriteConfig{formats: []string{"opus"}, backup: backupNone}
+
+	if err := runReplayGainWrite(context.Background(), cmd, opts, cfg); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"  R128_TRACK_GAIN: (none) -> -2944\n",
+		"  REPLAYGAIN_TRACK_GAIN: -1.00 dB -> (removed)\n",
+		"  REPLAYGAIN_TRACK_PEAK: 0.900000 -> (removed)\n",
+		"wrote tags to 1 files (0 unchanged, 0 skipped, 0 failed)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output %q", want, got)
+		}
+	}
+	if got := files.tags[path]; len(got) != 2 || got["R128_TRACK_GAIN"] != "-2944" || got["TITLE"] != "a" {
+		t.Fatalf("unexpected opus tags %v", got)
+	}
+
+	out.Reset()
+	if err := runReplayGainWrite(context.Background(), cmd, opts, cfg); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	if !strings.Contains(out.String(), "wrote tags to 0 files (1 unchanged, 0 skipped, 0 failed)") || files.writes != 1 {
+		t.Fatalf("expected the opus file to be left alone, got %q after %d writes", out.String(), files.writes)
+	}
+}
+
+func TestRunReplayGainWriteVerifiesTags(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.mp3"), []byte("audio"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	original := map[string]string{"REPLAYGAIN_TRACK_GAIN": "-1.00 dB"}
+	files := &fakeTagFiles{tags: map[string]map[string]string{filepath.Join(root, "a.mp3"): original}, drop: true}
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	opts := &options{
+		dbPath:      filepath.Join(t.TempDir(), "db.sqlite"),
+		libraryRoot: root,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		newReplayGainStore: func(cfg sqlite.Config) (replayGainStore, error) {
+			return &replayGainStoreStub{tracks: []sqlite.TrackLoudness{{TrackID: 1, Track: app.Track{Path: "a.mp3"}, IntegratedLUFS: -9}}}, nil
+		},
+		newTagReader: func() tagReader { return audio.FFProbeTagReader{Runner: files} },
+		newTagWriter: func() tagWriter { return files },
+	}
+
+	err := runReplayGainWrite(context.Background(), cmd, opts, replayGainWriteConfig{formats: []string{"mp3"}, backup: backupNone})
+	if err == nil || !strings.Contains(out.String(), "0 files (0 unchanged, 0 skipped, 1 failed)") {
+		t.Fatalf("expected the unverified write to fail, got %v and %q", err, out.String())
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 1 {
+		t.Fatalf("expected no backup or probe files, got %d entries", len(entries))
+	}
+	if got := files.tags[filepath.Join(root, "a.mp3")]; len(got) != 1 || got["REPLAYGAIN_TRACK_GAIN"] != "-1.00 dB" {
+		t.Fatalf("expected the original tags to be kept, got %v", got)
+	}
+}
+
+func TestRunReplayGainWriteValidatesConfig(t *testing.T) {
+	opts := &options{dbPath: "db.sqlite", libraryRoot: t.TempDir(), logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	for _, cfg := range []replayGainWriteConfig{
+		{formats: []string{"m4a"}, backup: backupNone},
+		{formats: []string{"flac"}, backup: backupDir},
+		{formats: []string{"flac"}, backup: "move"},
+	} {
+		if err := runReplayGainWrite(context.Background(), &cobra.Command{}, opts, cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestCheckWritableRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := checkWritableRoot(root); err != nil {
+		t.Fatalf("check writable root: %v", err)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Fatalf("expected the probe file to be removed, got %d entries", len(entries))
+	}
+	err := rootWriteError("/library", &os.PathError{Op: "open", Path: "/library/x", Err: syscall.EROFS})
+	if err == nil || !strings.Contains(err.Error(), "mounted read-only") {
+		t.Fatalf("expected a read-only error, got %v", err)
+	}
+	if err := checkWritableRoot(filepath.Join(root, "missing")); err == nil {
+		t.Fatal("expected error for a missing root")
+	}
+}
+
+type replayGainStoreStub struct {
+	tracks []sqlite.TrackLoudness
+}
+
+func (s *replayGainStoreStub) ListTrackLoudness(ctx context.Context) ([]sqlite.TrackLoudness, error) {
+	return s.tracks, nil
+}
+
+func (s *replayGainStoreStub) Close() error { return nil }
+
+// fakeTagFiles keeps tags per path. It answers ffprobe for FFProbeTagReader
+// and records writes, staging each one under path+".tmp" for verify before
+// committing it; with drop set, written tags are lost.
+type fakeTagFiles struct {
+	tags   map[string]map[string]string
+	writes int
+	drop   bool
+}
+
+func (f *fakeTagFiles) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	payload := map[string]any{"format": map[string]any{"tags": f.tags[args[len(args)-1]]}}
+	return json.Marshal(payload)
+}
+
+func (f *fakeTagFiles) WriteTags(ctx context.Context, path string, tags []audio.Tag, verify func(context.Context, string) error) error {
+	f.writes++
+	staged := make(map[string]string, len(f.tags[path])+len(tags))
+	for key, value := range f.tags[path] {
+		staged[key] = value
+	}
+	if !f.drop {
+		for _, tag := range tags {
+			// ffmpeg matches keys case-insensitively and removes empty tags.
+			for key := range staged {
+				if strings.EqualFold(key, tag.Key) {
+					delete(staged, key)
+				}
+			}
+			if tag.Value != "" {
+				staged[tag.Key] = tag.Value
+			}
+		}
+	}
+	tmp := path + ".tmp"
+	f.tags[tmp] = staged
+	defer delete(f.tags, tmp)
+	if verify != nil {
+		if err := verify(ctx, tmp); err != nil {
+			return err
+		}
+	}
+	f.tags[path] = staged
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/bowmanmike/playlistgen/internal/app"
 	"github.com/bowmanmike/playlistgen/internal/audio"
+	"github.com/bowmanmike/playlistgen/internal/embedding"
+	"github.com/bowmanmike/playlistgen/internal/export"
 	"github.com/bowmanmike/playlistgen/internal/logging"
 	"github.com/bowmanmike/playlistgen/internal/navidrome"
 	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
@@ -40,30 +42,53 @@ func newRootCmd(opts *options) *cobra.Command {
 	cmd.PersistentFlags().StringVar(&opts.navidromePassword, "navidrome-password", "", "Navidrome password (or NAVIDROME_PASSWORD)")
 	cmd.PersistentFlags().StringVar(&opts.dbPath, "db-path", getEnv("PLAYLISTGEN_DB_PATH", defaultDBPath), "SQLite database path (or PLAYLISTGEN_DB_PATH)")
 	cmd.PersistentFlags().StringVar(&opts.libraryRoot, "library-root", getEnv("PLAYLISTGEN_LIBRARY_ROOT", defaultLibraryRoot), "Mounted library root (or PLAYLISTGEN_LIBRARY_ROOT)")
+	cmd.PersistentFlags().StringVar(&opts.ollamaURL, "ollama-url", "", "Ollama base URL (or OLLAMA_URL)")
+	cmd.PersistentFlags().StringVar(&opts.embeddingModel, "embedding-model", getEnv("PLAYLISTGEN_EMBEDDING_MODEL", embedding.DefaultModel), "Ollama embedding model (or PLAYLISTGEN_EMBEDDING_MODEL)")
 	cmd.PersistentFlags().StringVar(&opts.logLevel, "log-level", "info", "Log level (debug, info, warn, error)")
 	cmd.PersistentFlags().StringVar(&opts.logFormat, "log-format", "json", "Log format (json, text)")
 
 	cmd.AddCommand(newSyncCmd(opts))
 	cmd.AddCommand(newAudioProcessCmd(opts))
+	cmd.AddCommand(newEmbedProcessCmd(opts))
+	cmd.AddCommand(newGenerateCmd(opts))
+	cmd.AddCommand(newPurgeCmd(opts))
+	cmd.AddCommand(newJobsCmd(opts))
+	cmd.AddCommand(newDuplicatesCmd(opts))
+	cmd.AddCommand(newReplayGainCmd(opts))
 
 	return cmd
 }
 
 type options struct {
-	navidromeURL       string
-	navidromeUsername  string
-	navidromePassword  string
-	dbPath             string
-	libraryRoot        string
-	logLevel           string
-	logFormat          string
-	logger             *slog.Logger
-	forceProcessing    bool
-	newNavidromeClient func(navidrome.Config) (app.NavidromePort, error)
-	newStore           func(sqlite.Config) (app.TrackStore, error)
-	newAudioStore      func(sqlite.Config) (audioJobStore, error)
-	newAudioAnalyzer   func(root string) audioAnalyzer
-	newApp             func(app.Dependencies) (*app.App, error)
+	navidromeURL        string
+	navidromeUsername   string
+	navidromePassword   string
+	dbPath              string
+	libraryRoot         string
+	ollamaURL           string
+	embeddingModel      string
+	logLevel            string
+	logFormat           string
+	logger              *slog.Logger
+	forceProcessing     bool
+	albumConcurrency    int
+	maxDeletePercent    float64
+	newNavidromeClient  func(navidrome.Config) (app.NavidromePort, error)
+	newStore            func(sqlite.Config) (app.TrackStore, error)
+	newSyncHistoryStore func(sqlite.Config) (syncHistoryStore, error)
+	newAudioStore       func(sqlite.Config) (audioJobStore, error)
+	newAudioAnalyzer    func(root string) audioAnalyzer
+	newEmbedStore       func(sqlite.Config) (embedJobStore, error)
+	newEmbedder         func(embedding.Config) (embedder, error)
+	newGenerateStore    func(sqlite.Config) (generateStore, error)
+	newPlaylistClient   func(navidrome.Config) (export.NavidromeClient, error)
+	newPurgeStore       func(sqlite.Config) (purgeStore, error)
+	newJobsStore        func(sqlite.Config) (jobsStore, error)
+	newDuplicatesStore  func(sqlite.Config) (duplicatesStore, error)
+	newReplayGainStore  func(sqlite.Config) (replayGainStore, error)
+	newTagReader        func() tagReader
+	newTagWriter        func() tagWriter
+	newApp              func(app.Dependencies) (*app.App, error)
 }
 
 func newOptions() *options {
@@ -76,16 +101,49 @@ func newOptions() *options {
 		newStore: func(cfg sqlite.Config) (app.TrackStore, error) {
 			return sqlite.New(cfg)
 		},
+		newSyncHistoryStore: func(cfg sqlite.Config) (syncHistoryStore, error) {
+			return sqlite.New(cfg)
+		},
 		newAudioStore: func(cfg sqlite.Config) (audioJobStore, error) {
 			return sqlite.New(cfg)
 		},
 		newAudioAnalyzer: func(root string) audioAnalyzer {
 			return audio.Analyzer{
-				Root:  root,
-				Probe: audio.FFmpegProbeRunner{},
-				Tags:  audio.FFProbeReplayGainReader{},
+				Root:   root,
+				Pass:   audio.FFmpegPipeline{},
+				Hasher: audio.FFmpegAudioHasher{},
 			}
 		},
+		newEmbedStore: func(cfg sqlite.Config) (embedJobStore, error) {
+			return sqlite.New(cfg)
+		},
+		newEmbedder: func(cfg embedding.Config) (embedder, error) {
+			return embedding.NewOllamaClient(cfg)
+		},
+		newGenerateStore: func(cfg sqlite.Config) (generateStore, error) {
+			return sqlite.New(cfg)
+		},
+		newPlaylistClient: func(cfg navidrome.Config) (export.NavidromeClient, error) {
+			return navidrome.NewClient(cfg)
+		},
+		newPurgeStore: func(cfg sqlite.Config) (purgeStore, error) {
+			return sqlite.New(cfg)
+		},
+		newJobsStore: func(cfg sqlite.Config) (jobsStore, error) {
+			return sqlite.New(cfg)
+		},
+		newDuplicatesStore: func(cfg sqlite.Config) (duplicatesStore, error) {
+			return sqlite.New(cfg)
+		},
+		newReplayGainStore: func(cfg sqlite.Config) (replayGainStore, error) {
+			return sqlite.New(cfg)
+		},
+		newTagReader: func() tagReader {
+			return audio.FFProbeTagReader{}
+		},
+		newTagWriter: func() tagWriter {
+			return audio.FFmpegTagWriter{}
+		},
 		newApp: func(deps app.Dependencies) (*app.App, error) {
 			return app.New(deps)
 		},
@@ -109,6 +167,9 @@ func (o *options) populateFromEnv() {
 	if o.navidromePassword == "" {
 		o.navidromePassword = os.Getenv("NAVIDROME_PASSWORD")
 	}
+	if o.ollamaURL == "" {
+		o.ollamaURL = os.Getenv("OLLAMA_URL")
+	}
 }
 
 func (o *options) ensureLogger(dst io.Writer) error {
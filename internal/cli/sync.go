@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -14,6 +17,10 @@ import (
 	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
 )
 
+// defaultMaxDeletePercent guards against a Navidrome rescan or a missing
+// mount making most of the library look deleted.
+const defaultMaxDeletePercent = 20
+
 func newSyncCmd(opts *options) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "sync",
@@ -24,10 +31,91 @@ func newSyncCmd(opts *options) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&opts.forceProcessing, "force-processing-jobs", false, "Enqueue audio and embedding jobs for every track")
+	cmd.Flags().IntVar(&opts.albumConcurrency, "album-concurrency", navidrome.DefaultAlbumConcurrency, "Number of albums fetched from Navidrome in parallel")
+	cmd.Flags().Float64Var(&opts.maxDeletePercent, "max-delete-percent", defaultMaxDeletePercent, "Abort the sync if more than this percentage of tracks would be removed (0 disables)")
+
+	cmd.AddCommand(newSyncHistoryCmd(opts))
+
+	return cmd
+}
+
+type syncHistoryStore interface {
+	ListSyncRuns(context.Context, int) ([]sqlite.SyncRun, error)
+	Close() error
+}
+
+func newSyncHistoryCmd(opts *options) *cobra.Command {
+	limit := 20
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List recent Navidrome sync runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSyncHistory(cmd.Context(), cmd, opts, limit)
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", limit, "Number of runs to show")
 
 	return cmd
 }
 
+func runSyncHistory(ctx context.Context, cmd *cobra.Command, opts *options, limit int) error {
+	if opts.dbPath == "" {
+		return errors.New("db-path must be set to show sync history")
+	}
+	if limit <= 0 {
+		return errors.New("limit must be greater than zero")
+	}
+
+	dbPath, err := filepath.Abs(opts.dbPath)
+	if err != nil {
+		return fmt.Errorf("resolve db path: %w", err)
+	}
+	store, err := opts.newSyncHistoryStore(sqlite.Config{Path: dbPath})
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+
+	runs, err := store.ListSyncRuns(ctx, limit)
+	if err != nil {
+		return fmt.Errorf("list sync runs: %w", err)
+	}
+	return writeSyncHistory(cmd.OutOrStdout(), runs)
+}
+
+func writeSyncHistory(w io.Writer, runs []sqlite.SyncRun) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTARTED\tSTATUS\tFETCHED\tPROCESSED\tUPDATED\tDELETED\tFETCH\tDURATION\tERROR")
+	for _, run := range runs {
+		var failure string
+		if run.Status == "failed" {
+			failure = run.FailedPhase + ": " + run.Error
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			run.ID,
+			run.StartedAt.UTC().Format(time.RFC3339),
+			run.Status,
+			run.TracksFetched,
+			run.TracksProcessed,
+			run.TracksUpdated,
+			run.TracksDeleted,
+			formatRunDuration(run.FetchDuration()),
+			formatRunDuration(run.Duration()),
+			failure,
+		)
+	}
+	return tw.Flush()
+}
+
+func formatRunDuration(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return d.Round(time.Millisecond).String()
+}
+
 func runSync(ctx context.Context, cmd *cobra.Command, opts *options) error {
 	if err := opts.ensureLogger(cmd.ErrOrStderr()); err != nil {
 		return fmt.Errorf("init logger: %w", err)
@@ -52,9 +140,10 @@ func runSync(ctx context.Context, cmd *cobra.Command, opts *options) error {
 	}
 
 	client, err := opts.newNavidromeClient(navidrome.Config{
-		BaseURL:  opts.navidromeURL,
-		Username: opts.navidromeUsername,
-		Password: opts.navidromePassword,
+		BaseURL:          opts.navidromeURL,
+		Username:         opts.navidromeUsername,
+		Password:         opts.navidromePassword,
+		AlbumConcurrency: opts.albumConcurrency,
 	})
 	if err != nil {
 		return fmt.Errorf("init navidrome client: %w", err)
@@ -79,6 +168,7 @@ func runSync(ctx context.Context, cmd *cobra.Command, opts *options) error {
 		s, err := opts.newStore(sqlite.Config{
 			Path:                resolvedStorePath,
 			ForceProcessingJobs: opts.forceProcessing,
+			MaxDeletePercent:    opts.maxDeletePercent,
 		})
 		if err != nil {
 			return fmt.Errorf("init store: %w", err)
@@ -113,6 +203,8 @@ func runSync(ctx context.Context, cmd *cobra.Command, opts *options) error {
 		"updated", stats.Updated,
 		"skipped", stats.Skipped,
 		"deleted", stats.Deleted,
+		"restored", stats.Restored,
+		"stats_updated", stats.StatsUpdated,
 	)
 	return nil
 }
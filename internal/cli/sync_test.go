@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -118,6 +119,32 @@ func TestRunSync(t *testing.T) {
 		}
 	})
 
+	t.Run("passes album concurrency to client", func(t *testing.T) {
+		cmd := &cobra.Command{}
+		cmd.SetOut(&bytes.Buffer{})
+		opts := &options{
+			navidromeURL:      "https://navidrome.local",
+			navidromeUsername: "user",
+			navidromePassword: "pass",
+			albumConcurrency:  8,
+			newNavidromeClient: func(cfg navidrome.Config) (app.NavidromePort, error) {
+				if cfg.AlbumConcurrency != 8 {
+					t.Fatalf("expected album concurrency 8, got %d", cfg.AlbumConcurrency)
+				}
+				return navidromeClientFunc(func(ctx context.Context) ([]app.Track, error) {
+					return nil, nil
+				}), nil
+			},
+			newApp: func(deps app.Dependencies) (*app.App, error) {
+				return app.New(deps)
+			},
+		}
+
+		if err := runSync(context.Background(), cmd, opts); err != nil {
+			t.Fatalf("runSync error: %v", err)
+		}
+	})
+
 	t.Run("propagates client errors", func(t *testing.T) {
 		cmd := &cobra.Command{}
 		opts := &options{
@@ -165,10 +192,96 @@ func (f navidromeClientFunc) ListTracks(ctx context.Context) ([]app.Track, error
 }
 
 type trackStoreStub struct {
-	saved bool
+	saved   bool
+	failure *app.SyncFailure
+}
+
+func (s *trackStoreStub) StartSync(ctx context.Context, startedAt time.Time) (int64, error) {
+	return 1, nil
 }
 
-func (s *trackStoreStub) SaveTracks(ctx context.Context, tracks []app.Track) (app.SaveStats, error) {
+func (s *trackStoreStub) SaveTracks(ctx context.Context, syncID int64, tracks []app.Track) (app.SaveStats, error) {
 	s.saved = true
 	return app.SaveStats{Updated: len(tracks)}, nil
 }
+
+func (s *trackStoreStub) FailSync(ctx context.Context, syncID int64, failure app.SyncFailure) error {
+	s.failure = &failure
+	return nil
+}
+
+func TestRunSyncHistory(t *testing.T) {
+	started := time.Date(2026, 5, 1, 3, 0, 0, 0, time.UTC)
+	store := &syncHistoryStoreStub{runs: []sqlite.SyncRun{
+		{
+			ID:          8,
+			StartedAt:   started.Add(time.Hour),
+			CompletedAt: started.Add(time.Hour + 2*time.Second),
+			Status:      "failed",
+			FailedPhase: app.SyncPhaseFetch,
+			Error:       "fetch tracks: connection refused",
+		},
+		{
+			ID:               7,
+			StartedAt:        started,
+			FetchCompletedAt: started.Add(12 * time.Second),
+			CompletedAt:      started.Add(15 * time.Second),
+			Status:           "completed",
+			TracksFetched:    120,
+			TracksProcessed:  120,
+			TracksUpdated:    4,
+			TracksDeleted:    1,
+		},
+	}}
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	opts := &options{
+		dbPath: filepath.Join(t.TempDir(), "db.sqlite"),
+		newSyncHistoryStore: func(cfg sqlite.Config) (syncHistoryStore, error) {
+			return store, nil
+		},
+	}
+
+	if err := runSyncHistory(context.Background(), cmd, opts, 5); err != nil {
+		t.Fatalf("runSyncHistory: %v", err)
+	}
+	if store.limit != 5 || !store.closed {
+		t.Fatalf("unexpected store usage limit=%d closed=%v", store.limit, store.closed)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and two rows, got %q", out.String())
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "ID STARTED STATUS FETCHED PROCESSED UPDATED DELETED FETCH DURATION ERROR" {
+		t.Fatalf("unexpected header %q", lines[0])
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "8" || fields[2] != "failed" || fields[7] != "-" || fields[8] != "2s" ||
+		!strings.HasSuffix(lines[1], "fetch: fetch tracks: connection refused") {
+		t.Fatalf("unexpected failed row %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != "7 2026-05-01T03:00:00Z completed 120 120 4 1 12s 15s" {
+		t.Fatalf("unexpected completed row %q", lines[2])
+	}
+
+	if err := runSyncHistory(context.Background(), cmd, &options{}, 5); err == nil {
+		t.Fatalf("expected error without db path")
+	}
+}
+
+type syncHistoryStoreStub struct {
+	runs   []sqlite.SyncRun
+	limit  int
+	closed bool
+}
+
+func (s *syncHistoryStoreStub) ListSyncRuns(ctx context.Context, limit int) ([]sqlite.SyncRun, error) {
+	s.limit = limit
+	return s.runs, nil
+}
+
+func (s *syncHistoryStoreStub) Close() error {
+	s.closed = true
+	return nil
+}
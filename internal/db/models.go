@@ -8,6 +8,15 @@ import (
 	"database/sql"
 )
 
+type AlbumAudioFeature struct {
+	AlbumID         string          `json:"album_id"`
+	TrackCount      int64           `json:"track_count"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	IntegratedLufs  float64         `json:"integrated_lufs"`
+	TruePeak        sql.NullFloat64 `json:"true_peak"`
+	ComputedAt      string          `json:"computed_at"`
+}
+
 type AudioProcessingRun struct {
 	ID            int64          `json:"id"`
 	StartedAt     string         `json:"started_at"`
@@ -16,17 +25,30 @@ type AudioProcessingRun struct {
 	JobsClaimed   int64          `json:"jobs_claimed"`
 	JobsCompleted int64          `json:"jobs_completed"`
 	JobsFailed    int64          `json:"jobs_failed"`
+	JobsSkipped   int64          `json:"jobs_skipped"`
+}
+
+type NavidromePlaylist struct {
+	Name                string `json:"name"`
+	NavidromePlaylistID string `json:"navidrome_playlist_id"`
+	SongCount           int64  `json:"song_count"`
+	CreatedAt           string `json:"created_at"`
+	UpdatedAt           string `json:"updated_at"`
 }
 
 type NavidromeSync struct {
-	ID              int64          `json:"id"`
-	StartedAt       string         `json:"started_at"`
-	CompletedAt     sql.NullString `json:"completed_at"`
-	TracksProcessed int64          `json:"tracks_processed"`
-	TracksUpdated   int64          `json:"tracks_updated"`
-	TracksDeleted   int64          `json:"tracks_deleted"`
-	Status          string         `json:"status"`
-	CreatedAt       string         `json:"created_at"`
+	ID               int64          `json:"id"`
+	StartedAt        string         `json:"started_at"`
+	CompletedAt      sql.NullString `json:"completed_at"`
+	TracksProcessed  int64          `json:"tracks_processed"`
+	TracksUpdated    int64          `json:"tracks_updated"`
+	TracksDeleted    int64          `json:"tracks_deleted"`
+	Status           string         `json:"status"`
+	CreatedAt        string         `json:"created_at"`
+	TracksFetched    int64          `json:"tracks_fetched"`
+	FetchCompletedAt sql.NullString `json:"fetch_completed_at"`
+	FailedPhase      sql.NullString `json:"failed_phase"`
+	Error            sql.NullString `json:"error"`
 }
 
 type NavidromeTrackSyncStatus struct {
@@ -37,6 +59,22 @@ type NavidromeTrackSyncStatus struct {
 	CreatedAt    string `json:"created_at"`
 }
 
+type ProcessingJob struct {
+	Queue         string         `json:"queue"`
+	ID            int64          `json:"id"`
+	TrackID       int64          `json:"track_id"`
+	Status        string         `json:"status"`
+	Error         sql.NullString `json:"error"`
+	Attempts      int64          `json:"attempts"`
+	LastAttemptAt sql.NullString `json:"last_attempt_at"`
+	NextAttemptAt sql.NullString `json:"next_attempt_at"`
+	ClaimedAt     sql.NullString `json:"claimed_at"`
+	ClaimedBy     sql.NullString `json:"claimed_by"`
+	ProcessedAt   sql.NullString `json:"processed_at"`
+	CreatedAt     string         `json:"created_at"`
+	Failed        int64          `json:"failed"`
+}
+
 type Track struct {
 	ID              int64          `json:"id"`
 	NavidromeID     string         `json:"navidrome_id"`
@@ -57,6 +95,15 @@ type Track struct {
 	ContentType     sql.NullString `json:"content_type"`
 	Suffix          string         `json:"suffix"`
 	CreatedAt       string         `json:"created_at"`
+	DeletedAt       sql.NullString `json:"deleted_at"`
+}
+
+type TrackArtist struct {
+	TrackID           int64          `json:"track_id"`
+	Role              string         `json:"role"`
+	Position          int64          `json:"position"`
+	NavidromeArtistID sql.NullString `json:"navidrome_artist_id"`
+	Name              string         `json:"name"`
 }
 
 type TrackAudioAnalysis struct {
@@ -70,22 +117,61 @@ type TrackAudioAnalysis struct {
 	CreatedAt     string         `json:"created_at"`
 	ClaimedAt     sql.NullString `json:"claimed_at"`
 	ClaimedBy     sql.NullString `json:"claimed_by"`
+	NextAttemptAt sql.NullString `json:"next_attempt_at"`
 }
 
 type TrackAudioFeature struct {
-	TrackID                int64           `json:"track_id"`
-	AnalyzedAt             string          `json:"analyzed_at"`
-	FileDurationSeconds    float64         `json:"file_duration_seconds"`
-	MeasuredIntegratedLufs sql.NullFloat64 `json:"measured_integrated_lufs"`
-	MeasuredTruePeak       sql.NullFloat64 `json:"measured_true_peak"`
-	ReplaygainTrackGainDb  sql.NullFloat64 `json:"replaygain_track_gain_db"`
-	ReplaygainTrackPeak    sql.NullFloat64 `json:"replaygain_track_peak"`
-	ReplaygainAlbumGainDb  sql.NullFloat64 `json:"replaygain_album_gain_db"`
-	ReplaygainAlbumPeak    sql.NullFloat64 `json:"replaygain_album_peak"`
-	EffectiveGainDb        sql.NullFloat64 `json:"effective_gain_db"`
-	EffectivePeak          sql.NullFloat64 `json:"effective_peak"`
-	EffectiveGainSource    string          `json:"effective_gain_source"`
-	EffectivePeakSource    string          `json:"effective_peak_source"`
+	TrackID                 int64           `json:"track_id"`
+	AnalyzedAt              string          `json:"analyzed_at"`
+	FileDurationSeconds     float64         `json:"file_duration_seconds"`
+	MeasuredIntegratedLufs  sql.NullFloat64 `json:"measured_integrated_lufs"`
+	MeasuredTruePeak        sql.NullFloat64 `json:"measured_true_peak"`
+	ReplaygainTrackGainDb   sql.NullFloat64 `json:"replaygain_track_gain_db"`
+	ReplaygainTrackPeak     sql.NullFloat64 `json:"replaygain_track_peak"`
+	ReplaygainAlbumGainDb   sql.NullFloat64 `json:"replaygain_album_gain_db"`
+	ReplaygainAlbumPeak     sql.NullFloat64 `json:"replaygain_album_peak"`
+	EffectiveGainDb         sql.NullFloat64 `json:"effective_gain_db"`
+	EffectivePeak           sql.NullFloat64 `json:"effective_peak"`
+	EffectiveGainSource     string          `json:"effective_gain_source"`
+	EffectivePeakSource     string          `json:"effective_peak_source"`
+	MeasuredTempoBpm        sql.NullFloat64 `json:"measured_tempo_bpm"`
+	MeasuredTempoConfidence sql.NullFloat64 `json:"measured_tempo_confidence"`
+	TagBpm                  sql.NullFloat64 `json:"tag_bpm"`
+	EffectiveBpm            sql.NullFloat64 `json:"effective_bpm"`
+	EffectiveBpmSource      string          `json:"effective_bpm_source"`
+	MeasuredKey             sql.NullString  `json:"measured_key"`
+	MeasuredMode            sql.NullString  `json:"measured_mode"`
+	MeasuredKeyConfidence   sql.NullFloat64 `json:"measured_key_confidence"`
+	CamelotKey              sql.NullString  `json:"camelot_key"`
+	MeasuredLoudnessRange   sql.NullFloat64 `json:"measured_loudness_range"`
+	SpectralCentroidHz      sql.NullFloat64 `json:"spectral_centroid_hz"`
+	SpectralRolloffHz       sql.NullFloat64 `json:"spectral_rolloff_hz"`
+	CrestFactorDb           sql.NullFloat64 `json:"crest_factor_db"`
+	ZeroCrossingRate        sql.NullFloat64 `json:"zero_crossing_rate"`
+	OnsetDensity            sql.NullFloat64 `json:"onset_density"`
+	SpectralVersion         sql.NullInt64   `json:"spectral_version"`
+	FileSize                sql.NullInt64   `json:"file_size"`
+	FileMtime               sql.NullString  `json:"file_mtime"`
+	AudioHash               sql.NullString  `json:"audio_hash"`
+	AnalyzerVersion         sql.NullInt64   `json:"analyzer_version"`
+	AcousticFingerprint     []byte          `json:"acoustic_fingerprint"`
+	DuplicateGroupID        sql.NullInt64   `json:"duplicate_group_id"`
+	LeadingSilenceSeconds   sql.NullFloat64 `json:"leading_silence_seconds"`
+	TrailingSilenceSeconds  sql.NullFloat64 `json:"trailing_silence_seconds"`
+	ContentStartSeconds     sql.NullFloat64 `json:"content_start_seconds"`
+	ContentEndSeconds       sql.NullFloat64 `json:"content_end_seconds"`
+	AbruptStart             sql.NullInt64   `json:"abrupt_start"`
+	AbruptEnd               sql.NullInt64   `json:"abrupt_end"`
+	FlowsIntoNext           int64           `json:"flows_into_next"`
+}
+
+type TrackEmbedding struct {
+	TrackID    int64  `json:"track_id"`
+	Model      string `json:"model"`
+	Dimensions int64  `json:"dimensions"`
+	Embedding  []byte `json:"embedding"`
+	Document   string `json:"document"`
+	EmbeddedAt string `json:"embedded_at"`
 }
 
 type TrackEmbeddingJob struct {
@@ -99,4 +185,34 @@ type TrackEmbeddingJob struct {
 	CreatedAt     string         `json:"created_at"`
 	ClaimedAt     sql.NullString `json:"claimed_at"`
 	ClaimedBy     sql.NullString `json:"claimed_by"`
+	NextAttemptAt sql.NullString `json:"next_attempt_at"`
+}
+
+type TrackExtendedMetadatum struct {
+	TrackID               int64           `json:"track_id"`
+	MusicbrainzID         sql.NullString  `json:"musicbrainz_id"`
+	SortName              sql.NullString  `json:"sort_name"`
+	Isrc                  sql.NullString  `json:"isrc"`
+	Bpm                   sql.NullInt64   `json:"bpm"`
+	Moods                 sql.NullString  `json:"moods"`
+	ReplaygainTrackGainDb sql.NullFloat64 `json:"replaygain_track_gain_db"`
+	ReplaygainTrackPeak   sql.NullFloat64 `json:"replaygain_track_peak"`
+	ReplaygainAlbumGainDb sql.NullFloat64 `json:"replaygain_album_gain_db"`
+	ReplaygainAlbumPeak   sql.NullFloat64 `json:"replaygain_album_peak"`
+	UpdatedAt             string          `json:"updated_at"`
+}
+
+type TrackGenre struct {
+	TrackID  int64  `json:"track_id"`
+	Position int64  `json:"position"`
+	Name     string `json:"name"`
+}
+
+type TrackUserStat struct {
+	TrackID      int64          `json:"track_id"`
+	StarredAt    sql.NullString `json:"starred_at"`
+	Rating       int64          `json:"rating"`
+	PlayCount    int64          `json:"play_count"`
+	LastPlayedAt sql.NullString `json:"last_played_at"`
+	UpdatedAt    string         `json:"updated_at"`
 }
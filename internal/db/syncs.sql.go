@@ -49,3 +49,88 @@ func (q *Queries) CreateSync(ctx context.Context, startedAt string) (int64, erro
 	err := row.Scan(&id)
 	return id, err
 }
+
+const failSync = `-- name: FailSync :exec
+UPDATE navidrome_syncs
+SET completed_at = ?, status = 'failed', failed_phase = ?, error = ?, tracks_processed = ?
+WHERE id = ?
+`
+
+type FailSyncParams struct {
+	CompletedAt     sql.NullString `json:"completed_at"`
+	FailedPhase     sql.NullString `json:"failed_phase"`
+	Error           sql.NullString `json:"error"`
+	TracksProcessed int64          `json:"tracks_processed"`
+	ID              int64          `json:"id"`
+}
+
+func (q *Queries) FailSync(ctx context.Context, arg FailSyncParams) error {
+	_, err := q.db.ExecContext(ctx, failSync,
+		arg.CompletedAt,
+		arg.FailedPhase,
+		arg.Error,
+		arg.TracksProcessed,
+		arg.ID,
+	)
+	return err
+}
+
+const listRecentSyncs = `-- name: ListRecentSyncs :many
+SELECT id, started_at, completed_at, tracks_processed, tracks_updated, tracks_deleted, status, created_at, tracks_fetched, fetch_completed_at, failed_phase, error
+FROM navidrome_syncs
+ORDER BY id DESC
+LIMIT ?
+`
+
+func (q *Queries) ListRecentSyncs(ctx context.Context, limit int64) ([]NavidromeSync, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentSyncs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NavidromeSync
+	for rows.Next() {
+		var i NavidromeSync
+		if err := rows.Scan(
+			&i.ID,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.TracksProcessed,
+			&i.TracksUpdated,
+			&i.TracksDeleted,
+			&i.Status,
+			&i.CreatedAt,
+			&i.TracksFetched,
+			&i.FetchCompletedAt,
+			&i.FailedPhase,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markSyncFetched = `-- name: MarkSyncFetched :exec
+UPDATE navidrome_syncs
+SET fetch_completed_at = ?, tracks_fetched = ?
+WHERE id = ?
+`
+
+type MarkSyncFetchedParams struct {
+	FetchCompletedAt sql.NullString `json:"fetch_completed_at"`
+	TracksFetched    int64          `json:"tracks_fetched"`
+	ID               int64          `json:"id"`
+}
+
+func (q *Queries) MarkSyncFetched(ctx context.Context, arg MarkSyncFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, markSyncFetched, arg.FetchCompletedAt, arg.TracksFetched, arg.ID)
+	return err
+}
@@ -16,16 +16,27 @@ UPDATE track_audio_analysis
 SET status = 'processing',
     claimed_at = ?,
     claimed_by = ?,
+    attempts = attempts + 1,
+    last_attempt_at = ?,
+    next_attempt_at = NULL,
     error = NULL
 WHERE id IN (
-  SELECT id
+  SELECT track_audio_analysis.id
   FROM track_audio_analysis
-  WHERE track_audio_analysis.status = 'pending'
-     OR (
-       track_audio_analysis.status = 'processing'
-       AND track_audio_analysis.claimed_at IS NOT NULL
-       AND track_audio_analysis.claimed_at <= ?
-     )
+  JOIN tracks ON tracks.id = track_audio_analysis.track_id
+  WHERE tracks.deleted_at IS NULL
+    AND (
+      (
+        track_audio_analysis.status = 'pending'
+        AND (track_audio_analysis.next_attempt_at IS NULL OR track_audio_analysis.next_attempt_at <= ?)
+      )
+      OR (
+        track_audio_analysis.status = 'processing'
+        AND track_audio_analysis.claimed_at IS NOT NULL
+        AND track_audio_analysis.claimed_at <= ?
+        AND track_audio_analysis.attempts < ?
+      )
+    )
   ORDER BY track_audio_analysis.created_at, track_audio_analysis.id
   LIMIT ?
 )
@@ -33,10 +44,13 @@ RETURNING id, track_id
 `
 
 type ClaimPendingAudioJobsParams struct {
-	ClaimedAt   sql.NullString `json:"claimed_at"`
-	ClaimedBy   sql.NullString `json:"claimed_by"`
-	ClaimedAt_2 sql.NullString `json:"claimed_at_2"`
-	Limit       int64          `json:"limit"`
+	ClaimedAt     sql.NullString `json:"claimed_at"`
+	ClaimedBy     sql.NullString `json:"claimed_by"`
+	LastAttemptAt sql.NullString `json:"last_attempt_at"`
+	NextAttemptAt sql.NullString `json:"next_attempt_at"`
+	ClaimedAt_2   sql.NullString `json:"claimed_at_2"`
+	Attempts      int64          `json:"attempts"`
+	Limit         int64          `json:"limit"`
 }
 
 type ClaimPendingAudioJobsRow struct {
@@ -48,7 +62,10 @@ func (q *Queries) ClaimPendingAudioJobs(ctx context.Context, arg ClaimPendingAud
 	rows, err := q.db.QueryContext(ctx, claimPendingAudioJobs,
 		arg.ClaimedAt,
 		arg.ClaimedBy,
+		arg.LastAttemptAt,
+		arg.NextAttemptAt,
 		arg.ClaimedAt_2,
+		arg.Attempts,
 		arg.Limit,
 	)
 	if err != nil {
@@ -72,9 +89,20 @@ func (q *Queries) ClaimPendingAudioJobs(ctx context.Context, arg ClaimPendingAud
 	return items, nil
 }
 
+const clearDuplicateGroups = `-- name: ClearDuplicateGroups :exec
+UPDATE track_audio_features
+SET duplicate_group_id = NULL
+WHERE duplicate_group_id IS NOT NULL
+`
+
+func (q *Queries) ClearDuplicateGroups(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearDuplicateGroups)
+	return err
+}
+
 const completeAudioProcessingRun = `-- name: CompleteAudioProcessingRun :exec
 UPDATE audio_processing_runs
-SET completed_at = ?, status = ?, jobs_claimed = ?, jobs_completed = ?, jobs_failed = ?
+SET completed_at = ?, status = ?, jobs_claimed = ?, jobs_completed = ?, jobs_failed = ?, jobs_skipped = ?
 WHERE id = ?
 `
 
@@ -84,6 +112,7 @@ type CompleteAudioProcessingRunParams struct {
 	JobsClaimed   int64          `json:"jobs_claimed"`
 	JobsCompleted int64          `json:"jobs_completed"`
 	JobsFailed    int64          `json:"jobs_failed"`
+	JobsSkipped   int64          `json:"jobs_skipped"`
 	ID            int64          `json:"id"`
 }
 
@@ -94,6 +123,7 @@ func (q *Queries) CompleteAudioProcessingRun(ctx context.Context, arg CompleteAu
 		arg.JobsClaimed,
 		arg.JobsCompleted,
 		arg.JobsFailed,
+		arg.JobsSkipped,
 		arg.ID,
 	)
 	return err
@@ -112,26 +142,6 @@ func (q *Queries) CreateAudioProcessingRun(ctx context.Context, startedAt string
 	return id, err
 }
 
-const deleteTracksByNavidromeIDs = `-- name: DeleteTracksByNavidromeIDs :exec
-DELETE FROM tracks
-WHERE navidrome_id IN (/*SLICE:nav_ids*/?)
-`
-
-func (q *Queries) DeleteTracksByNavidromeIDs(ctx context.Context, navIds []string) error {
-	query := deleteTracksByNavidromeIDs
-	var queryParams []interface{}
-	if len(navIds) > 0 {
-		for _, v := range navIds {
-			queryParams = append(queryParams, v)
-		}
-		query = strings.Replace(query, "/*SLICE:nav_ids*/?", strings.Repeat(",?", len(navIds))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:nav_ids*/?", "NULL", 1)
-	}
-	_, err := q.db.ExecContext(ctx, query, queryParams...)
-	return err
-}
-
 const ensureTrackAudioJob = `-- name: EnsureTrackAudioJob :exec
 INSERT INTO track_audio_analysis (
   track_id,
@@ -140,9 +150,10 @@ INSERT INTO track_audio_analysis (
   error,
   attempts,
   last_attempt_at,
+  next_attempt_at,
   claimed_at,
   claimed_by
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(track_id) WHERE status IN ('pending', 'processing') DO UPDATE SET
   status = CASE
     WHEN track_audio_analysis.status = 'processing' THEN 'processing'
@@ -164,6 +175,10 @@ ON CONFLICT(track_id) WHERE status IN ('pending', 'processing') DO UPDATE SET
     WHEN track_audio_analysis.status = 'processing' THEN track_audio_analysis.last_attempt_at
     ELSE excluded.last_attempt_at
   END,
+  next_attempt_at = CASE
+    WHEN track_audio_analysis.status = 'processing' THEN track_audio_analysis.next_attempt_at
+    ELSE excluded.next_attempt_at
+  END,
   claimed_at = CASE
     WHEN track_audio_analysis.status = 'processing' THEN track_audio_analysis.claimed_at
     ELSE excluded.claimed_at
@@ -181,6 +196,7 @@ type EnsureTrackAudioJobParams struct {
 	Error         sql.NullString `json:"error"`
 	Attempts      int64          `json:"attempts"`
 	LastAttemptAt sql.NullString `json:"last_attempt_at"`
+	NextAttemptAt sql.NullString `json:"next_attempt_at"`
 	ClaimedAt     sql.NullString `json:"claimed_at"`
 	ClaimedBy     sql.NullString `json:"claimed_by"`
 }
@@ -193,6 +209,7 @@ func (q *Queries) EnsureTrackAudioJob(ctx context.Context, arg EnsureTrackAudioJ
 		arg.Error,
 		arg.Attempts,
 		arg.LastAttemptAt,
+		arg.NextAttemptAt,
 		arg.ClaimedAt,
 		arg.ClaimedBy,
 	)
@@ -207,9 +224,10 @@ INSERT INTO track_embedding_jobs (
   error,
   attempts,
   last_attempt_at,
+  next_attempt_at,
   claimed_at,
   claimed_by
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(track_id) WHERE status IN ('pending', 'processing') DO UPDATE SET
   status = CASE
     WHEN track_embedding_jobs.status = 'processing' THEN 'processing'
@@ -231,6 +249,10 @@ ON CONFLICT(track_id) WHERE status IN ('pending', 'processing') DO UPDATE SET
     WHEN track_embedding_jobs.status = 'processing' THEN track_embedding_jobs.last_attempt_at
     ELSE excluded.last_attempt_at
   END,
+  next_attempt_at = CASE
+    WHEN track_embedding_jobs.status = 'processing' THEN track_embedding_jobs.next_attempt_at
+    ELSE excluded.next_attempt_at
+  END,
   claimed_at = CASE
     WHEN track_embedding_jobs.status = 'processing' THEN track_embedding_jobs.claimed_at
     ELSE excluded.claimed_at
@@ -248,6 +270,7 @@ type EnsureTrackEmbeddingJobParams struct {
 	Error         sql.NullString `json:"error"`
 	Attempts      int64          `json:"attempts"`
 	LastAttemptAt sql.NullString `json:"last_attempt_at"`
+	NextAttemptAt sql.NullString `json:"next_attempt_at"`
 	ClaimedAt     sql.NullString `json:"claimed_at"`
 	ClaimedBy     sql.NullString `json:"claimed_by"`
 }
@@ -260,16 +283,165 @@ func (q *Queries) EnsureTrackEmbeddingJob(ctx context.Context, arg EnsureTrackEm
 		arg.Error,
 		arg.Attempts,
 		arg.LastAttemptAt,
+		arg.NextAttemptAt,
 		arg.ClaimedAt,
 		arg.ClaimedBy,
 	)
 	return err
 }
 
+const getAudioJobAttempts = `-- name: GetAudioJobAttempts :one
+SELECT attempts FROM track_audio_analysis WHERE id = ?
+`
+
+func (q *Queries) GetAudioJobAttempts(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAudioJobAttempts, id)
+	var attempts int64
+	err := row.Scan(&attempts)
+	return attempts, err
+}
+
+const getTrackMeasuredAudio = `-- name: GetTrackMeasuredAudio :one
+SELECT measured_integrated_lufs, measured_true_peak, measured_tempo_bpm, measured_tempo_confidence
+FROM track_audio_features
+WHERE track_id = ?
+`
+
+type GetTrackMeasuredAudioRow struct {
+	MeasuredIntegratedLufs  sql.NullFloat64 `json:"measured_integrated_lufs"`
+	MeasuredTruePeak        sql.NullFloat64 `json:"measured_true_peak"`
+	MeasuredTempoBpm        sql.NullFloat64 `json:"measured_tempo_bpm"`
+	MeasuredTempoConfidence sql.NullFloat64 `json:"measured_tempo_confidence"`
+}
+
+func (q *Queries) GetTrackMeasuredAudio(ctx context.Context, trackID int64) (GetTrackMeasuredAudioRow, error) {
+	row := q.db.QueryRowContext(ctx, getTrackMeasuredAudio, trackID)
+	var i GetTrackMeasuredAudioRow
+	err := row.Scan(
+		&i.MeasuredIntegratedLufs,
+		&i.MeasuredTruePeak,
+		&i.MeasuredTempoBpm,
+		&i.MeasuredTempoConfidence,
+	)
+	return i, err
+}
+
+const listAcousticFingerprints = `-- name: ListAcousticFingerprints :many
+SELECT tracks.id, tracks.navidrome_id, tracks.title, tracks.artist, tracks.artist_id, tracks.album, tracks.album_id, tracks.album_artist, tracks.genre, tracks.year, tracks.track_number, tracks.disc_number, tracks.duration_seconds, tracks.bitrate, tracks.file_size, tracks.path, tracks.content_type, tracks.suffix, tracks.created_at, tracks.deleted_at, track_audio_features.acoustic_fingerprint
+FROM tracks
+JOIN track_audio_features ON track_audio_features.track_id = tracks.id
+WHERE tracks.deleted_at IS NULL
+  AND track_audio_features.acoustic_fingerprint IS NOT NULL
+ORDER BY tracks.id
+`
+
+type ListAcousticFingerprintsRow struct {
+	Track               Track  `json:"track"`
+	AcousticFingerprint []byte `json:"acoustic_fingerprint"`
+}
+
+func (q *Queries) ListAcousticFingerprints(ctx context.Context) ([]ListAcousticFingerprintsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAcousticFingerprints)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAcousticFingerprintsRow
+	for rows.Next() {
+		var i ListAcousticFingerprintsRow
+		if err := rows.Scan(
+			&i.Track.ID,
+			&i.Track.NavidromeID,
+			&i.Track.Title,
+			&i.Track.Artist,
+			&i.Track.ArtistID,
+			&i.Track.Album,
+			&i.Track.AlbumID,
+			&i.Track.AlbumArtist,
+			&i.Track.Genre,
+			&i.Track.Year,
+			&i.Track.TrackNumber,
+			&i.Track.DiscNumber,
+			&i.Track.DurationSeconds,
+			&i.Track.Bitrate,
+			&i.Track.FileSize,
+			&i.Track.Path,
+			&i.Track.ContentType,
+			&i.Track.Suffix,
+			&i.Track.CreatedAt,
+			&i.Track.DeletedAt,
+			&i.AcousticFingerprint,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAudioFingerprintsByTrackIDs = `-- name: ListAudioFingerprintsByTrackIDs :many
+SELECT track_id, file_size, file_mtime, audio_hash, analyzer_version
+FROM track_audio_features
+WHERE track_id IN (/*SLICE:track_ids*/?)
+`
+
+type ListAudioFingerprintsByTrackIDsRow struct {
+	TrackID         int64          `json:"track_id"`
+	FileSize        sql.NullInt64  `json:"file_size"`
+	FileMtime       sql.NullString `json:"file_mtime"`
+	AudioHash       sql.NullString `json:"audio_hash"`
+	AnalyzerVersion sql.NullInt64  `json:"analyzer_version"`
+}
+
+func (q *Queries) ListAudioFingerprintsByTrackIDs(ctx context.Context, trackIds []int64) ([]ListAudioFingerprintsByTrackIDsRow, error) {
+	query := listAudioFingerprintsByTrackIDs
+	var queryParams []interface{}
+	if len(trackIds) > 0 {
+		for _, v := range trackIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:track_ids*/?", strings.Repeat(",?", len(trackIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:track_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAudioFingerprintsByTrackIDsRow
+	for rows.Next() {
+		var i ListAudioFingerprintsByTrackIDsRow
+		if err := rows.Scan(
+			&i.TrackID,
+			&i.FileSize,
+			&i.FileMtime,
+			&i.AudioHash,
+			&i.AnalyzerVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAudioJobsByIDs = `-- name: ListAudioJobsByIDs :many
 SELECT
   track_audio_analysis.id AS job_id,
-  tracks.id, tracks.navidrome_id, tracks.title, tracks.artist, tracks.artist_id, tracks.album, tracks.album_id, tracks.album_artist, tracks.genre, tracks.year, tracks.track_number, tracks.disc_number, tracks.duration_seconds, tracks.bitrate, tracks.file_size, tracks.path, tracks.content_type, tracks.suffix, tracks.created_at
+  tracks.id, tracks.navidrome_id, tracks.title, tracks.artist, tracks.artist_id, tracks.album, tracks.album_id, tracks.album_artist, tracks.genre, tracks.year, tracks.track_number, tracks.disc_number, tracks.duration_seconds, tracks.bitrate, tracks.file_size, tracks.path, tracks.content_type, tracks.suffix, tracks.created_at, tracks.deleted_at
 FROM track_audio_analysis
 JOIN tracks ON tracks.id = track_audio_analysis.track_id
 WHERE track_audio_analysis.id IN (/*SLICE:job_ids*/?)
@@ -321,6 +493,7 @@ func (q *Queries) ListAudioJobsByIDs(ctx context.Context, jobIds []int64) ([]Lis
 			&i.Track.ContentType,
 			&i.Track.Suffix,
 			&i.Track.CreatedAt,
+			&i.Track.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -338,10 +511,11 @@ func (q *Queries) ListAudioJobsByIDs(ctx context.Context, jobIds []int64) ([]Lis
 const listPendingAudioJobs = `-- name: ListPendingAudioJobs :many
 SELECT
   track_audio_analysis.id AS job_id,
-  tracks.id, tracks.navidrome_id, tracks.title, tracks.artist, tracks.artist_id, tracks.album, tracks.album_id, tracks.album_artist, tracks.genre, tracks.year, tracks.track_number, tracks.disc_number, tracks.duration_seconds, tracks.bitrate, tracks.file_size, tracks.path, tracks.content_type, tracks.suffix, tracks.created_at
+  tracks.id, tracks.navidrome_id, tracks.title, tracks.artist, tracks.artist_id, tracks.album, tracks.album_id, tracks.album_artist, tracks.genre, tracks.year, tracks.track_number, tracks.disc_number, tracks.duration_seconds, tracks.bitrate, tracks.file_size, tracks.path, tracks.content_type, tracks.suffix, tracks.created_at, tracks.deleted_at
 FROM track_audio_analysis
 JOIN tracks ON tracks.id = track_audio_analysis.track_id
 WHERE track_audio_analysis.status = 'pending'
+  AND tracks.deleted_at IS NULL
 ORDER BY track_audio_analysis.created_at, track_audio_analysis.id
 LIMIT ?
 `
@@ -381,6 +555,7 @@ func (q *Queries) ListPendingAudioJobs(ctx context.Context, limit int64) ([]List
 			&i.Track.ContentType,
 			&i.Track.Suffix,
 			&i.Track.CreatedAt,
+			&i.Track.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -396,13 +571,20 @@ func (q *Queries) ListPendingAudioJobs(ctx context.Context, limit int64) ([]List
 }
 
 const listTrackSyncStatus = `-- name: ListTrackSyncStatus :many
-SELECT track_id, navidrome_id, last_synced_at FROM navidrome_track_sync_status
+SELECT
+  navidrome_track_sync_status.track_id,
+  navidrome_track_sync_status.navidrome_id,
+  navidrome_track_sync_status.last_synced_at,
+  tracks.deleted_at
+FROM navidrome_track_sync_status
+JOIN tracks ON tracks.id = navidrome_track_sync_status.track_id
 `
 
 type ListTrackSyncStatusRow struct {
-	TrackID      int64  `json:"track_id"`
-	NavidromeID  string `json:"navidrome_id"`
-	LastSyncedAt string `json:"last_synced_at"`
+	TrackID      int64          `json:"track_id"`
+	NavidromeID  string         `json:"navidrome_id"`
+	LastSyncedAt string         `json:"last_synced_at"`
+	DeletedAt    sql.NullString `json:"deleted_at"`
 }
 
 func (q *Queries) ListTrackSyncStatus(ctx context.Context) ([]ListTrackSyncStatusRow, error) {
@@ -414,7 +596,147 @@ func (q *Queries) ListTrackSyncStatus(ctx context.Context) ([]ListTrackSyncStatu
 	var items []ListTrackSyncStatusRow
 	for rows.Next() {
 		var i ListTrackSyncStatusRow
-		if err := rows.Scan(&i.TrackID, &i.NavidromeID, &i.LastSyncedAt); err != nil {
+		if err := rows.Scan(
+			&i.TrackID,
+			&i.NavidromeID,
+			&i.LastSyncedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTracksWithAudioFeaturesByIDs = `-- name: ListTracksWithAudioFeaturesByIDs :many
+SELECT
+  tracks.id, tracks.navidrome_id, tracks.title, tracks.artist, tracks.artist_id, tracks.album, tracks.album_id, tracks.album_artist, tracks.genre, tracks.year, tracks.track_number, tracks.disc_number, tracks.duration_seconds, tracks.bitrate, tracks.file_size, tracks.path, tracks.content_type, tracks.suffix, tracks.created_at, tracks.deleted_at,
+  track_audio_features.file_duration_seconds,
+  track_audio_features.measured_integrated_lufs,
+  track_audio_features.measured_true_peak,
+  track_audio_features.effective_gain_db,
+  track_audio_features.effective_gain_source,
+  track_audio_features.effective_bpm,
+  track_audio_features.effective_bpm_source,
+  track_audio_features.camelot_key,
+  track_audio_features.measured_loudness_range,
+  track_audio_features.spectral_centroid_hz,
+  track_audio_features.spectral_rolloff_hz,
+  track_audio_features.crest_factor_db,
+  track_audio_features.zero_crossing_rate,
+  track_audio_features.onset_density,
+  track_audio_features.duplicate_group_id,
+  track_audio_features.content_start_seconds,
+  track_audio_features.content_end_seconds,
+  track_audio_features.flows_into_next,
+  track_user_stats.starred_at,
+  track_user_stats.rating,
+  track_user_stats.play_count,
+  track_user_stats.last_played_at
+FROM tracks
+LEFT JOIN track_audio_features ON track_audio_features.track_id = tracks.id
+LEFT JOIN track_user_stats ON track_user_stats.track_id = tracks.id
+WHERE tracks.deleted_at IS NULL
+  AND tracks.id IN (/*SLICE:track_ids*/?)
+ORDER BY tracks.id
+`
+
+type ListTracksWithAudioFeaturesByIDsRow struct {
+	Track                  Track           `json:"track"`
+	FileDurationSeconds    sql.NullFloat64 `json:"file_duration_seconds"`
+	MeasuredIntegratedLufs sql.NullFloat64 `json:"measured_integrated_lufs"`
+	MeasuredTruePeak       sql.NullFloat64 `json:"measured_true_peak"`
+	EffectiveGainDb        sql.NullFloat64 `json:"effective_gain_db"`
+	EffectiveGainSource    sql.NullString  `json:"effective_gain_source"`
+	EffectiveBpm           sql.NullFloat64 `json:"effective_bpm"`
+	EffectiveBpmSource     sql.NullString  `json:"effective_bpm_source"`
+	CamelotKey             sql.NullString  `json:"camelot_key"`
+	MeasuredLoudnessRange  sql.NullFloat64 `json:"measured_loudness_range"`
+	SpectralCentroidHz     sql.NullFloat64 `json:"spectral_centroid_hz"`
+	SpectralRolloffHz      sql.NullFloat64 `json:"spectral_rolloff_hz"`
+	CrestFactorDb          sql.NullFloat64 `json:"crest_factor_db"`
+	ZeroCrossingRate       sql.NullFloat64 `json:"zero_crossing_rate"`
+	OnsetDensity           sql.NullFloat64 `json:"onset_density"`
+	DuplicateGroupID       sql.NullInt64   `json:"duplicate_group_id"`
+	ContentStartSeconds    sql.NullFloat64 `json:"content_start_seconds"`
+	ContentEndSeconds      sql.NullFloat64 `json:"content_end_seconds"`
+	FlowsIntoNext          sql.NullInt64   `json:"flows_into_next"`
+	StarredAt              sql.NullString  `json:"starred_at"`
+	Rating                 sql.NullInt64   `json:"rating"`
+	PlayCount              sql.NullInt64   `json:"play_count"`
+	LastPlayedAt           sql.NullString  `json:"last_played_at"`
+}
+
+func (q *Queries) ListTracksWithAudioFeaturesByIDs(ctx context.Context, trackIds []int64) ([]ListTracksWithAudioFeaturesByIDsRow, error) {
+	query := listTracksWithAudioFeaturesByIDs
+	var queryParams []interface{}
+	if len(trackIds) > 0 {
+		for _, v := range trackIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:track_ids*/?", strings.Repeat(",?", len(trackIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:track_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTracksWithAudioFeaturesByIDsRow
+	for rows.Next() {
+		var i ListTracksWithAudioFeaturesByIDsRow
+		if err := rows.Scan(
+			&i.Track.ID,
+			&i.Track.NavidromeID,
+			&i.Track.Title,
+			&i.Track.Artist,
+			&i.Track.ArtistID,
+			&i.Track.Album,
+			&i.Track.AlbumID,
+			&i.Track.AlbumArtist,
+			&i.Track.Genre,
+			&i.Track.Year,
+			&i.Track.TrackNumber,
+			&i.Track.DiscNumber,
+			&i.Track.DurationSeconds,
+			&i.Track.Bitrate,
+			&i.Track.FileSize,
+			&i.Track.Path,
+			&i.Track.ContentType,
+			&i.Track.Suffix,
+			&i.Track.CreatedAt,
+			&i.Track.DeletedAt,
+			&i.FileDurationSeconds,
+			&i.MeasuredIntegratedLufs,
+			&i.MeasuredTruePeak,
+			&i.EffectiveGainDb,
+			&i.EffectiveGainSource,
+			&i.EffectiveBpm,
+			&i.EffectiveBpmSource,
+			&i.CamelotKey,
+			&i.MeasuredLoudnessRange,
+			&i.SpectralCentroidHz,
+			&i.SpectralRolloffHz,
+			&i.CrestFactorDb,
+			&i.ZeroCrossingRate,
+			&i.OnsetDensity,
+			&i.DuplicateGroupID,
+			&i.ContentStartSeconds,
+			&i.ContentEndSeconds,
+			&i.FlowsIntoNext,
+			&i.StarredAt,
+			&i.Rating,
+			&i.PlayCount,
+			&i.LastPlayedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -428,6 +750,113 @@ func (q *Queries) ListTrackSyncStatus(ctx context.Context) ([]ListTrackSyncStatu
 	return items, nil
 }
 
+const markExhaustedAudioJobsDead = `-- name: MarkExhaustedAudioJobsDead :exec
+UPDATE track_audio_analysis
+SET status = 'dead',
+    error = 'claim expired on the final attempt',
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE status = 'processing'
+  AND claimed_at IS NOT NULL
+  AND claimed_at <= ?
+  AND attempts >= ?
+`
+
+type MarkExhaustedAudioJobsDeadParams struct {
+	ClaimedAt sql.NullString `json:"claimed_at"`
+	Attempts  int64          `json:"attempts"`
+}
+
+func (q *Queries) MarkExhaustedAudioJobsDead(ctx context.Context, arg MarkExhaustedAudioJobsDeadParams) error {
+	_, err := q.db.ExecContext(ctx, markExhaustedAudioJobsDead, arg.ClaimedAt, arg.Attempts)
+	return err
+}
+
+const purgeDeletedTracks = `-- name: PurgeDeletedTracks :execrows
+DELETE FROM tracks
+WHERE deleted_at IS NOT NULL
+  AND deleted_at <= ?
+`
+
+func (q *Queries) PurgeDeletedTracks(ctx context.Context, deletedAt sql.NullString) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedTracks, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const releaseAudioJobs = `-- name: ReleaseAudioJobs :exec
+UPDATE track_audio_analysis
+SET status = 'pending',
+    attempts = MAX(attempts - 1, 0),
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE id IN (/*SLICE:job_ids*/?)
+  AND status = 'processing'
+`
+
+// Claimed jobs that were never started go back to pending and get back the
+// attempt their claim counted.
+func (q *Queries) ReleaseAudioJobs(ctx context.Context, jobIds []int64) error {
+	query := releaseAudioJobs
+	var queryParams []interface{}
+	if len(jobIds) > 0 {
+		for _, v := range jobIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:job_ids*/?", strings.Repeat(",?", len(jobIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:job_ids*/?", "NULL", 1)
+	}
+	_, err := q.db.ExecContext(ctx, query, queryParams...)
+	return err
+}
+
+const requeueOutdatedAudioJobs = `-- name: RequeueOutdatedAudioJobs :execrows
+UPDATE track_audio_analysis
+SET status = 'pending',
+    processed_at = NULL,
+    error = NULL,
+    attempts = 0,
+    last_attempt_at = NULL,
+    next_attempt_at = NULL,
+    claimed_at = NULL,
+    claimed_by = NULL
+WHERE status = 'completed'
+  AND track_id IN (
+    SELECT track_audio_features.track_id
+    FROM track_audio_features
+    JOIN tracks ON tracks.id = track_audio_features.track_id
+    WHERE tracks.deleted_at IS NULL
+      AND (track_audio_features.analyzer_version IS NULL OR track_audio_features.analyzer_version < ?)
+  )
+  AND id = (
+    SELECT MAX(latest.id)
+    FROM track_audio_analysis AS latest
+    WHERE latest.track_id = track_audio_analysis.track_id
+  )
+`
+
+// Completed jobs of tracks whose features come from an older analyzer go back
+// to pending. Failed and dead jobs are left to jobs retry.
+func (q *Queries) RequeueOutdatedAudioJobs(ctx context.Context, analyzerVersion sql.NullInt64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, requeueOutdatedAudioJobs, analyzerVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreTrack = `-- name: RestoreTrack :exec
+UPDATE tracks SET deleted_at = NULL WHERE id = ?
+`
+
+func (q *Queries) RestoreTrack(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, restoreTrack, id)
+	return err
+}
+
 const selectTrackID = `-- name: SelectTrackID :one
 SELECT id FROM tracks WHERE navidrome_id = ?
 `
@@ -439,13 +868,88 @@ func (q *Queries) SelectTrackID(ctx context.Context, navidromeID string) (int64,
 	return id, err
 }
 
+const setDuplicateGroup = `-- name: SetDuplicateGroup :exec
+UPDATE track_audio_features
+SET duplicate_group_id = ?
+WHERE track_id = ?
+`
+
+type SetDuplicateGroupParams struct {
+	DuplicateGroupID sql.NullInt64 `json:"duplicate_group_id"`
+	TrackID          int64         `json:"track_id"`
+}
+
+func (q *Queries) SetDuplicateGroup(ctx context.Context, arg SetDuplicateGroupParams) error {
+	_, err := q.db.ExecContext(ctx, setDuplicateGroup, arg.DuplicateGroupID, arg.TrackID)
+	return err
+}
+
+const softDeleteTracksByNavidromeIDs = `-- name: SoftDeleteTracksByNavidromeIDs :exec
+UPDATE tracks
+SET deleted_at = ?
+WHERE navidrome_id IN (/*SLICE:nav_ids*/?)
+  AND deleted_at IS NULL
+`
+
+type SoftDeleteTracksByNavidromeIDsParams struct {
+	DeletedAt sql.NullString `json:"deleted_at"`
+	NavIds    []string       `json:"nav_ids"`
+}
+
+func (q *Queries) SoftDeleteTracksByNavidromeIDs(ctx context.Context, arg SoftDeleteTracksByNavidromeIDsParams) error {
+	query := softDeleteTracksByNavidromeIDs
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.DeletedAt)
+	if len(arg.NavIds) > 0 {
+		for _, v := range arg.NavIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:nav_ids*/?", strings.Repeat(",?", len(arg.NavIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:nav_ids*/?", "NULL", 1)
+	}
+	_, err := q.db.ExecContext(ctx, query, queryParams...)
+	return err
+}
+
+const updateAlbumFlowThrough = `-- name: UpdateAlbumFlowThrough :exec
+UPDATE track_audio_features
+SET flows_into_next = EXISTS (
+  SELECT 1
+  FROM tracks AS this_track
+  JOIN tracks AS next_track
+    ON next_track.album_id = this_track.album_id
+   AND COALESCE(next_track.disc_number, 1) = COALESCE(this_track.disc_number, 1)
+   AND next_track.track_number = this_track.track_number + 1
+   AND next_track.deleted_at IS NULL
+  JOIN track_audio_features AS next_features ON next_features.track_id = next_track.id
+  WHERE this_track.id = track_audio_features.track_id
+    AND track_audio_features.abrupt_end = 1
+    AND track_audio_features.trailing_silence_seconds <= ?
+    AND next_features.abrupt_start = 1
+    AND next_features.leading_silence_seconds <= ?
+)
+`
+
+type UpdateAlbumFlowThroughParams struct {
+	TrailingSilenceSeconds sql.NullFloat64 `json:"trailing_silence_seconds"`
+	LeadingSilenceSeconds  sql.NullFloat64 `json:"leading_silence_seconds"`
+}
+
+// A track flows into the next one on its album when it ends abruptly with
+// almost no trailing silence and the next track starts the same way, as on
+// live and concept albums.
+func (q *Queries) UpdateAlbumFlowThrough(ctx context.Context, arg UpdateAlbumFlowThroughParams) error {
+	_, err := q.db.ExecContext(ctx, updateAlbumFlowThrough, arg.TrailingSilenceSeconds, arg.LeadingSilenceSeconds)
+	return err
+}
+
 const updateAudioJobStatus = `-- name: UpdateAudioJobStatus :exec
 UPDATE track_audio_analysis
 SET status = ?,
     processed_at = ?,
     error = ?,
-    attempts = attempts + 1,
-    last_attempt_at = ?,
+    next_attempt_at = ?,
     claimed_at = ?,
     claimed_by = ?
 WHERE id = ?
@@ -455,7 +959,7 @@ type UpdateAudioJobStatusParams struct {
 	Status        string         `json:"status"`
 	ProcessedAt   sql.NullString `json:"processed_at"`
 	Error         sql.NullString `json:"error"`
-	LastAttemptAt sql.NullString `json:"last_attempt_at"`
+	NextAttemptAt sql.NullString `json:"next_attempt_at"`
 	ClaimedAt     sql.NullString `json:"claimed_at"`
 	ClaimedBy     sql.NullString `json:"claimed_by"`
 	ID            int64          `json:"id"`
@@ -466,7 +970,7 @@ func (q *Queries) UpdateAudioJobStatus(ctx context.Context, arg UpdateAudioJobSt
 		arg.Status,
 		arg.ProcessedAt,
 		arg.Error,
-		arg.LastAttemptAt,
+		arg.NextAttemptAt,
 		arg.ClaimedAt,
 		arg.ClaimedBy,
 		arg.ID,
@@ -474,6 +978,103 @@ func (q *Queries) UpdateAudioJobStatus(ctx context.Context, arg UpdateAudioJobSt
 	return err
 }
 
+const updateTrackAudioFingerprint = `-- name: UpdateTrackAudioFingerprint :exec
+UPDATE track_audio_features
+SET file_size = ?, file_mtime = ?, audio_hash = ?
+WHERE track_id = ?
+`
+
+type UpdateTrackAudioFingerprintParams struct {
+	FileSize  sql.NullInt64  `json:"file_size"`
+	FileMtime sql.NullString `json:"file_mtime"`
+	AudioHash sql.NullString `json:"audio_hash"`
+	TrackID   int64          `json:"track_id"`
+}
+
+func (q *Queries) UpdateTrackAudioFingerprint(ctx context.Context, arg UpdateTrackAudioFingerprintParams) error {
+	_, err := q.db.ExecContext(ctx, updateTrackAudioFingerprint,
+		arg.FileSize,
+		arg.FileMtime,
+		arg.AudioHash,
+		arg.TrackID,
+	)
+	return err
+}
+
+const updateTrackEffectiveGain = `-- name: UpdateTrackEffectiveGain :exec
+UPDATE track_audio_features
+SET effective_gain_db = ?, effective_peak = ?, effective_gain_source = ?, effective_peak_source = ?
+WHERE track_id = ?
+`
+
+type UpdateTrackEffectiveGainParams struct {
+	EffectiveGainDb     sql.NullFloat64 `json:"effective_gain_db"`
+	EffectivePeak       sql.NullFloat64 `json:"effective_peak"`
+	EffectiveGainSource string          `json:"effective_gain_source"`
+	EffectivePeakSource string          `json:"effective_peak_source"`
+	TrackID             int64           `json:"track_id"`
+}
+
+func (q *Queries) UpdateTrackEffectiveGain(ctx context.Context, arg UpdateTrackEffectiveGainParams) error {
+	_, err := q.db.ExecContext(ctx, updateTrackEffectiveGain,
+		arg.EffectiveGainDb,
+		arg.EffectivePeak,
+		arg.EffectiveGainSource,
+		arg.EffectivePeakSource,
+		arg.TrackID,
+	)
+	return err
+}
+
+const updateTrackFileTags = `-- name: UpdateTrackFileTags :exec
+UPDATE track_audio_features
+SET replaygain_track_gain_db = ?,
+    replaygain_track_peak = ?,
+    replaygain_album_gain_db = ?,
+    replaygain_album_peak = ?,
+    tag_bpm = ?,
+    effective_gain_db = ?,
+    effective_peak = ?,
+    effective_gain_source = ?,
+    effective_peak_source = ?,
+    effective_bpm = ?,
+    effective_bpm_source = ?
+WHERE track_id = ?
+`
+
+type UpdateTrackFileTagsParams struct {
+	ReplaygainTrackGainDb sql.NullFloat64 `json:"replaygain_track_gain_db"`
+	ReplaygainTrackPeak   sql.NullFloat64 `json:"replaygain_track_peak"`
+	ReplaygainAlbumGainDb sql.NullFloat64 `json:"replaygain_album_gain_db"`
+	ReplaygainAlbumPeak   sql.NullFloat64 `json:"replaygain_album_peak"`
+	TagBpm                sql.NullFloat64 `json:"tag_bpm"`
+	EffectiveGainDb       sql.NullFloat64 `json:"effective_gain_db"`
+	EffectivePeak         sql.NullFloat64 `json:"effective_peak"`
+	EffectiveGainSource   string          `json:"effective_gain_source"`
+	EffectivePeakSource   string          `json:"effective_peak_source"`
+	EffectiveBpm          sql.NullFloat64 `json:"effective_bpm"`
+	EffectiveBpmSource    string          `json:"effective_bpm_source"`
+	TrackID               int64           `json:"track_id"`
+}
+
+func (q *Queries) UpdateTrackFileTags(ctx context.Context, arg UpdateTrackFileTagsParams) error {
+	_, err := q.db.ExecContext(ctx, updateTrackFileTags,
+		arg.ReplaygainTrackGainDb,
+		arg.ReplaygainTrackPeak,
+		arg.ReplaygainAlbumGainDb,
+		arg.ReplaygainAlbumPeak,
+		arg.TagBpm,
+		arg.EffectiveGainDb,
+		arg.EffectivePeak,
+		arg.EffectiveGainSource,
+		arg.EffectivePeakSource,
+		arg.EffectiveBpm,
+		arg.EffectiveBpmSource,
+		arg.TrackID,
+	)
+	return err
+}
+
 const upsertTrack = `-- name: UpsertTrack :exec
 INSERT INTO tracks (
   navidrome_id,
@@ -512,7 +1113,8 @@ ON CONFLICT(navidrome_id) DO UPDATE SET
   path = excluded.path,
   content_type = excluded.content_type,
   suffix = excluded.suffix,
-  created_at = excluded.created_at
+  created_at = excluded.created_at,
+  deleted_at = NULL
 `
 
 type UpsertTrackParams struct {
@@ -574,8 +1176,35 @@ INSERT INTO track_audio_features (
   effective_gain_db,
   effective_peak,
   effective_gain_source,
-  effective_peak_source
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  effective_peak_source,
+  measured_tempo_bpm,
+  measured_tempo_confidence,
+  tag_bpm,
+  effective_bpm,
+  effective_bpm_source,
+  measured_key,
+  measured_mode,
+  measured_key_confidence,
+  camelot_key,
+  measured_loudness_range,
+  spectral_centroid_hz,
+  spectral_rolloff_hz,
+  crest_factor_db,
+  zero_crossing_rate,
+  onset_density,
+  spectral_version,
+  file_size,
+  file_mtime,
+  audio_hash,
+  analyzer_version,
+  acoustic_fingerprint,
+  leading_silence_seconds,
+  trailing_silence_seconds,
+  content_start_seconds,
+  content_end_seconds,
+  abrupt_start,
+  abrupt_end
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(track_id) DO UPDATE SET
   analyzed_at = excluded.analyzed_at,
   file_duration_seconds = excluded.file_duration_seconds,
@@ -588,23 +1217,77 @@ ON CONFLICT(track_id) DO UPDATE SET
   effective_gain_db = excluded.effective_gain_db,
   effective_peak = excluded.effective_peak,
   effective_gain_source = excluded.effective_gain_source,
-  effective_peak_source = excluded.effective_peak_source
+  effective_peak_source = excluded.effective_peak_source,
+  measured_tempo_bpm = excluded.measured_tempo_bpm,
+  measured_tempo_confidence = excluded.measured_tempo_confidence,
+  tag_bpm = excluded.tag_bpm,
+  effective_bpm = excluded.effective_bpm,
+  effective_bpm_source = excluded.effective_bpm_source,
+  measured_key = excluded.measured_key,
+  measured_mode = excluded.measured_mode,
+  measured_key_confidence = excluded.measured_key_confidence,
+  camelot_key = excluded.camelot_key,
+  measured_loudness_range = excluded.measured_loudness_range,
+  spectral_centroid_hz = excluded.spectral_centroid_hz,
+  spectral_rolloff_hz = excluded.spectral_rolloff_hz,
+  crest_factor_db = excluded.crest_factor_db,
+  zero_crossing_rate = excluded.zero_crossing_rate,
+  onset_density = excluded.onset_density,
+  spectral_version = excluded.spectral_version,
+  file_size = excluded.file_size,
+  file_mtime = excluded.file_mtime,
+  audio_hash = excluded.audio_hash,
+  analyzer_version = excluded.analyzer_version,
+  acoustic_fingerprint = excluded.acoustic_fingerprint,
+  leading_silence_seconds = excluded.leading_silence_seconds,
+  trailing_silence_seconds = excluded.trailing_silence_seconds,
+  content_start_seconds = excluded.content_start_seconds,
+  content_end_seconds = excluded.content_end_seconds,
+  abrupt_start = excluded.abrupt_start,
+  abrupt_end = excluded.abrupt_end
 `
 
 type UpsertTrackAudioFeaturesParams struct {
-	TrackID                int64           `json:"track_id"`
-	AnalyzedAt             string          `json:"analyzed_at"`
-	FileDurationSeconds    float64         `json:"file_duration_seconds"`
-	MeasuredIntegratedLufs sql.NullFloat64 `json:"measured_integrated_lufs"`
-	MeasuredTruePeak       sql.NullFloat64 `json:"measured_true_peak"`
-	ReplaygainTrackGainDb  sql.NullFloat64 `json:"replaygain_track_gain_db"`
-	ReplaygainTrackPeak    sql.NullFloat64 `json:"replaygain_track_peak"`
-	ReplaygainAlbumGainDb  sql.NullFloat64 `json:"replaygain_album_gain_db"`
-	ReplaygainAlbumPeak    sql.NullFloat64 `json:"replaygain_album_peak"`
-	EffectiveGainDb        sql.NullFloat64 `json:"effective_gain_db"`
-	EffectivePeak          sql.NullFloat64 `json:"effective_peak"`
-	EffectiveGainSource    string          `json:"effective_gain_source"`
-	EffectivePeakSource    string          `json:"effective_peak_source"`
+	TrackID                 int64           `json:"track_id"`
+	AnalyzedAt              string          `json:"analyzed_at"`
+	FileDurationSeconds     float64         `json:"file_duration_seconds"`
+	MeasuredIntegratedLufs  sql.NullFloat64 `json:"measured_integrated_lufs"`
+	MeasuredTruePeak        sql.NullFloat64 `json:"measured_true_peak"`
+	ReplaygainTrackGainDb   sql.NullFloat64 `json:"replaygain_track_gain_db"`
+	ReplaygainTrackPeak     sql.NullFloat64 `json:"replaygain_track_peak"`
+	ReplaygainAlbumGainDb   sql.NullFloat64 `json:"replaygain_album_gain_db"`
+	ReplaygainAlbumPeak     sql.NullFloat64 `json:"replaygain_album_peak"`
+	EffectiveGainDb         sql.NullFloat64 `json:"effective_gain_db"`
+	EffectivePeak           sql.NullFloat64 `json:"effective_peak"`
+	EffectiveGainSource     string          `json:"effective_gain_source"`
+	EffectivePeakSource     string          `json:"effective_peak_source"`
+	MeasuredTempoBpm        sql.NullFloat64 `json:"measured_tempo_bpm"`
+	MeasuredTempoConfidence sql.NullFloat64 `json:"measured_tempo_confidence"`
+	TagBpm                  sql.NullFloat64 `json:"tag_bpm"`
+	EffectiveBpm            sql.NullFloat64 `json:"effective_bpm"`
+	EffectiveBpmSource      string          `json:"effective_bpm_source"`
+	MeasuredKey             sql.NullString  `json:"measured_key"`
+	MeasuredMode            sql.NullString  `json:"measured_mode"`
+	MeasuredKeyConfidence   sql.NullFloat64 `json:"measured_key_confidence"`
+	CamelotKey              sql.NullString  `json:"camelot_key"`
+	MeasuredLoudnessRange   sql.NullFloat64 `json:"measured_loudness_range"`
+	SpectralCentroidHz      sql.NullFloat64 `json:"spectral_centroid_hz"`
+	SpectralRolloffHz       sql.NullFloat64 `json:"spectral_rolloff_hz"`
+	CrestFactorDb           sql.NullFloat64 `json:"crest_factor_db"`
+	ZeroCrossingRate        sql.NullFloat64 `json:"zero_crossing_rate"`
+	OnsetDensity            sql.NullFloat64 `json:"onset_density"`
+	SpectralVersion         sql.NullInt64   `json:"spectral_version"`
+	FileSize                sql.NullInt64   `json:"file_size"`
+	FileMtime               sql.NullString  `json:"file_mtime"`
+	AudioHash               sql.NullString  `json:"audio_hash"`
+	AnalyzerVersion         sql.NullInt64   `json:"analyzer_version"`
+	AcousticFingerprint     []byte          `json:"acoustic_fingerprint"`
+	LeadingSilenceSeconds   sql.NullFloat64 `json:"leading_silence_seconds"`
+	TrailingSilenceSeconds  sql.NullFloat64 `json:"trailing_silence_seconds"`
+	ContentStartSeconds     sql.NullFloat64 `json:"content_start_seconds"`
+	ContentEndSeconds       sql.NullFloat64 `json:"content_end_seconds"`
+	AbruptStart             sql.NullInt64   `json:"abrupt_start"`
+	AbruptEnd               sql.NullInt64   `json:"abrupt_end"`
 }
 
 func (q *Queries) UpsertTrackAudioFeatures(ctx context.Context, arg UpsertTrackAudioFeaturesParams) error {
@@ -622,6 +1305,33 @@ func (q *Queries) UpsertTrackAudioFeatures(ctx context.Context, arg UpsertTrackA
 		arg.EffectivePeak,
 		arg.EffectiveGainSource,
 		arg.EffectivePeakSource,
+		arg.MeasuredTempoBpm,
+		arg.MeasuredTempoConfidence,
+		arg.TagBpm,
+		arg.EffectiveBpm,
+		arg.EffectiveBpmSource,
+		arg.MeasuredKey,
+		arg.MeasuredMode,
+		arg.MeasuredKeyConfidence,
+		arg.CamelotKey,
+		arg.MeasuredLoudnessRange,
+		arg.SpectralCentroidHz,
+		arg.SpectralRolloffHz,
+		arg.CrestFactorDb,
+		arg.ZeroCrossingRate,
+		arg.OnsetDensity,
+		arg.SpectralVersion,
+		arg.FileSize,
+		arg.FileMtime,
+		arg.AudioHash,
+		arg.AnalyzerVersion,
+		arg.AcousticFingerprint,
+		arg.LeadingSilenceSeconds,
+		arg.TrailingSilenceSeconds,
+		arg.ContentStartSeconds,
+		arg.ContentEndSeconds,
+		arg.AbruptStart,
+		arg.AbruptEnd,
 	)
 	return err
 }
@@ -0,0 +1,39 @@
+package embedding
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+// Document renders the text that is embedded for a track. The layout is kept
+// stable so re-embedding an unchanged track yields the same vector.
+func Document(track app.Track) string {
+	var b strings.Builder
+	writeField(&b, "Title", track.Title)
+	writeField(&b, "Artist", track.Artist)
+	if track.AlbumArtist != "" && track.AlbumArtist != track.Artist {
+		writeField(&b, "Album artist", track.AlbumArtist)
+	}
+	writeField(&b, "Album", track.Album)
+	if track.Genre != nil {
+		writeField(&b, "Genre", *track.Genre)
+	}
+	if track.Year != nil {
+		writeField(&b, "Year", fmt.Sprintf("%d", *track.Year))
+	}
+	if track.Duration > 0 {
+		writeField(&b, "Duration", track.Duration.Round(time.Second).String())
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func writeField(b *strings.Builder, label, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "%s: %s\n", label, value)
+}
@@ -0,0 +1,34 @@
+package embedding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestDocumentIncludesTrackMetadata(t *testing.T) {
+	genre := "Jazz"
+	year := 1959
+	got := Document(app.Track{
+		Title:       "So What",
+		Artist:      "Miles Davis",
+		AlbumArtist: "Miles Davis",
+		Album:       "Kind of Blue",
+		Genre:       &genre,
+		Year:        &year,
+		Duration:    562 * time.Second,
+	})
+
+	want := "Title: So What\nArtist: Miles Davis\nAlbum: Kind of Blue\nGenre: Jazz\nYear: 1959\nDuration: 9m22s"
+	if got != want {
+		t.Fatalf("unexpected document:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDocumentSkipsEmptyFields(t *testing.T) {
+	got := Document(app.Track{Title: "Untitled", AlbumArtist: "Various Artists"})
+	if got != "Title: Untitled\nAlbum artist: Various Artists" {
+		t.Fatalf("unexpected document %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout     = 60 * time.Second
+	embeddingsEndpoint = "api/embeddings"
+	// DefaultModel is the Ollama embedding model used when none is configured.
+	DefaultModel = "nomic-embed-text"
+)
+
+// Config drives OllamaClient construction.
+type Config struct {
+	BaseURL    string
+	Model      string
+	HTTPClient *http.Client
+}
+
+// OllamaClient generates embeddings through an Ollama-compatible API.
+type OllamaClient struct {
+	baseURL    *url.URL
+	model      string
+	httpClient *http.Client
+}
+
+// NewOllamaClient builds an Ollama embeddings client.
+func NewOllamaClient(cfg Config) (*OllamaClient, error) {
+	if strings.TrimSpace(cfg.BaseURL) == "" {
+		return nil, errors.New("base URL is required")
+	}
+
+	parsed, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base URL: %w", err)
+	}
+
+	if strings.TrimSpace(cfg.Model) == "" {
+		cfg.Model = DefaultModel
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultTimeout}
+	}
+
+	return &OllamaClient{
+		baseURL:    parsed,
+		model:      cfg.Model,
+		httpClient: cfg.HTTPClient,
+	}, nil
+}
+
+// Model returns the embedding model name sent with each request.
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+// Embed returns the embedding vector for the provided text.
+func (c *OllamaClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("embedding text is empty")
+	}
+
+	body, err := json.Marshal(embeddingRequest{Model: c.model, Prompt: text})
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	u := *c.baseURL
+	u.Path = ensureLeadingSlash(path.Join(c.baseURL.Path, embeddingsEndpoint))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %s: %w", embeddingsEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var payload embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if payload.Error != "" {
+		return nil, fmt.Errorf("ollama error: %s", payload.Error)
+	}
+	if len(payload.Embedding) == 0 {
+		return nil, errors.New("ollama returned an empty embedding")
+	}
+
+	vec := make([]float32, len(payload.Embedding))
+	for i, v := range payload.Embedding {
+		vec[i] = float32(v)
+	}
+	return vec, nil
+}
+
+func ensureLeadingSlash(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(p, "/") {
+		return "/" + p
+	}
+	return p
+}
+
+type embeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type embeddingResponse struct {
+	Embedding []float64 `json:"embedding"`
+	Error     string    `json:"error"`
+}
@@ -0,0 +1,88 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaClientEmbed(t *testing.T) {
+	t.Run("posts model and prompt", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/api/embeddings" {
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			var body embeddingRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+			if body.Model != "test-model" || body.Prompt != "Title: Song" {
+				t.Fatalf("unexpected request body %+v", body)
+			}
+			w.Write([]byte(`{"embedding":[0.5,-1.25,2]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		client, err := NewOllamaClient(Config{BaseURL: server.URL, Model: "test-model"})
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		vec, err := client.Embed(context.Background(), "Title: Song")
+		if err != nil {
+			t.Fatalf("embed: %v", err)
+		}
+		if len(vec) != 3 || vec[0] != 0.5 || vec[1] != -1.25 || vec[2] != 2 {
+			t.Fatalf("unexpected vector %v", vec)
+		}
+	})
+
+	t.Run("defaults model", func(t *testing.T) {
+		client, err := NewOllamaClient(Config{BaseURL: "http://ollama:11434"})
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		if client.Model() != DefaultModel {
+			t.Fatalf("unexpected model %q", client.Model())
+		}
+	})
+
+	t.Run("non-200 includes body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `model "missing" not found`, http.StatusNotFound)
+		}))
+		t.Cleanup(server.Close)
+
+		client, err := NewOllamaClient(Config{BaseURL: server.URL, Model: "missing"})
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		_, err = client.Embed(context.Background(), "Title: Song")
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("empty embedding", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"embedding":[]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		client, err := NewOllamaClient(Config{BaseURL: server.URL})
+		if err != nil {
+			t.Fatalf("new client: %v", err)
+		}
+		if _, err := client.Embed(context.Background(), "Title: Song"); err == nil {
+			t.Fatal("expected error for empty embedding")
+		}
+	})
+}
+
+func TestNewOllamaClientRequiresBaseURL(t *testing.T) {
+	if _, err := NewOllamaClient(Config{}); err == nil {
+		t.Fatal("expected error when base URL missing")
+	}
+}
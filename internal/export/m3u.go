@@ -0,0 +1,224 @@
+package export
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bowmanmike/playlistgen/internal/audio"
+	"github.com/bowmanmike/playlistgen/internal/playlist"
+)
+
+// PathMode selects how track paths are written into a playlist.
+type PathMode string
+
+const (
+	// PathAbsolute joins each track path onto Config.Root.
+	PathAbsolute PathMode = "absolute"
+	// PathRelative writes each track relative to the playlist file, after
+	// joining it onto Config.Root.
+	PathRelative PathMode = "relative"
+	// PathRewrite replaces the longest matching prefix from Config.Rewrites.
+	PathRewrite PathMode = "rewrite"
+)
+
+// Rewrite maps a track path prefix as Navidrome reports it onto the prefix a
+// player expects.
+type Rewrite struct {
+	From string
+	To   string
+}
+
+// ParseRewrites parses "from=to" pairs.
+func ParseRewrites(values []string) ([]Rewrite, error) {
+	rewrites := make([]Rewrite, 0, len(values))
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(from) == "" {
+			return nil, fmt.Errorf("invalid path rewrite %q (want from=to)", value)
+		}
+		rewrites = append(rewrites, Rewrite{From: strings.TrimSpace(from), To: strings.TrimSpace(to)})
+	}
+	return rewrites, nil
+}
+
+// Config drives M3UExporter construction.
+type Config struct {
+	Mode     PathMode
+	Root     string
+	Rewrites []Rewrite
+	// CueHints adds VLC start-time and stop-time options that skip the
+	// leading and trailing silence of each track, where it was measured.
+	CueHints bool
+}
+
+// M3UExporter writes playlists in extended M3U8 format.
+type M3UExporter struct {
+	mode     PathMode
+	root     string
+	rewrites []Rewrite
+	cueHints bool
+}
+
+// NewM3UExporter validates cfg and builds an exporter. Mode defaults to
+// PathAbsolute.
+func NewM3UExporter(cfg Config) (*M3UExporter, error) {
+	mode := cfg.Mode
+	if mode == "" {
+		mode = PathAbsolute
+	}
+	switch mode {
+	case PathAbsolute, PathRelative:
+		if strings.TrimSpace(cfg.Root) == "" {
+			return nil, fmt.Errorf("%s path mode requires a root", mode)
+		}
+	case PathRewrite:
+		if len(cfg.Rewrites) == 0 {
+			return nil, errors.New("rewrite path mode requires at least one rewrite")
+		}
+	default:
+		return nil, fmt.Errorf("unknown path mode %q (want absolute, relative, or rewrite)", mode)
+	}
+
+	rewrites := append([]Rewrite(nil), cfg.Rewrites...)
+	sort.SliceStable(rewrites, func(i, j int) bool { return len(rewrites[i].From) > len(rewrites[j].From) })
+	return &M3UExporter{mode: mode, root: cfg.Root, rewrites: rewrites, cueHints: cfg.CueHints}, nil
+}
+
+// Write renders the playlist to w. playlistPath is where the playlist will
+// live and is only required for PathRelative.
+func (e *M3UExporter) Write(w io.Writer, playlistPath, name string, tracks []playlist.Candidate) error {
+	if e.mode == PathRelative && playlistPath == "" {
+		return errors.New("relative path mode requires a playlist file path")
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#EXTM3U\n#PLAYLIST:%s\n", singleLine(name))
+	for _, candidate := range tracks {
+		path, err := e.trackPath(playlistPath, candidate.Track.Path)
+		if err != nil {
+			return fmt.Errorf("track %s: %w", candidate.Track.ID, err)
+		}
+		seconds := int(math.Round(candidate.Duration().Seconds()))
+		fmt.Fprintf(bw, "#EXTINF:%d,%s - %s\n", seconds, singleLine(candidate.Track.Artist), singleLine(candidate.Track.Title))
+		if e.cueHints {
+			writeCueHints(bw, candidate)
+		}
+		fmt.Fprintf(bw, "%s\n", path)
+	}
+	return bw.Flush()
+}
+
+// writeCueHints writes the options that start a track where its audible
+// content starts and stop it where the content ends. Either is left out when
+// there is no silence to skip.
+func writeCueHints(w io.Writer, candidate playlist.Candidate) {
+	features := candidate.Features
+	if start := features.ContentStartSeconds; start != nil && *start > 0 {
+		fmt.Fprintf(w, "#EXTVLCOPT:start-time=%.3f\n", *start)
+	}
+	if end := features.ContentEndSeconds; end != nil && *end < candidate.Duration().Seconds() {
+		fmt.Fprintf(w, "#EXTVLCOPT:stop-time=%.3f\n", *end)
+	}
+}
+
+// WriteFile renders the playlist to path atomically: it writes a temporary
+// file in the same directory and renames it into place, so players never see
+// a partially written playlist.
+func (e *M3UExporter) WriteFile(path, name string, tracks []playlist.Candidate) (err error) {
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolve playlist path: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create playlist directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp playlist: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := e.Write(tmp, path, name, tracks); err != nil {
+		return fmt.Errorf("write playlist: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync playlist: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close playlist: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("chmod playlist: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename playlist: %w", err)
+	}
+	return nil
+}
+
+func (e *M3UExporter) trackPath(playlistPath, trackPath string) (string, error) {
+	switch e.mode {
+	case PathRewrite:
+		return e.rewrite(trackPath)
+	case PathRelative:
+		resolved, err := audio.ResolveLibraryPath(e.root, trackPath)
+		if err != nil {
+			return "", err
+		}
+		absRoot, err := filepath.Abs(resolved)
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(filepath.Dir(playlistPath), absRoot)
+		if err != nil {
+			return "", err
+		}
+		return filepath.ToSlash(rel), nil
+	default:
+		return audio.ResolveLibraryPath(e.root, trackPath)
+	}
+}
+
+func (e *M3UExporter) rewrite(trackPath string) (string, error) {
+	for _, rw := range e.rewrites {
+		rest, ok := strings.CutPrefix(trackPath, rw.From)
+		if !ok {
+			continue
+		}
+		// Only match whole path segments so /music does not match /musicals.
+		if rest != "" && !strings.HasSuffix(rw.From, "/") && !strings.HasPrefix(rest, "/") {
+			continue
+		}
+		if rw.To == "" {
+			return strings.TrimLeft(rest, "/"), nil
+		}
+		if strings.HasSuffix(rw.To, "/") && strings.HasPrefix(rest, "/") {
+			rest = rest[1:]
+		} else if !strings.HasSuffix(rw.To, "/") && rest != "" && !strings.HasPrefix(rest, "/") {
+			rest = "/" + rest
+		}
+		return rw.To + rest, nil
+	}
+	return "", fmt.Errorf("no path rewrite matches %q", trackPath)
+}
+
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// singleLine keeps metadata from breaking the line-oriented M3U format.
+func singleLine(s string) string {
+	return lineBreaks.Replace(s)
+}
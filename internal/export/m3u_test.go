@@ -0,0 +1,211 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/playlist"
+)
+
+func TestM3UExporterWrite(t *testing.T) {
+	tracks := []playlist.Candidate{
+		testTrack("1", "Miles Davis", "So What", "Jazz/Kind of Blue/01 So What.flac", 9*time.Minute+22*time.Second),
+		testTrack("2", "Bill Evans", "Peace\nPiece", "/Jazz/Everybody Digs/06 Peace Piece.flac", 6*time.Minute+40*time.Second),
+	}
+	tests := []struct {
+		name         string
+		cfg          Config
+		playlistPath string
+		wantPaths    []string
+	}{
+		{
+			name:      "absolute",
+			cfg:       Config{Root: "/mnt/music"},
+			wantPaths: []string{"/mnt/music/Jazz/Kind of Blue/01 So What.flac", "/mnt/music/Jazz/Everybody Digs/06 Peace Piece.flac"},
+		},
+		{
+			name:         "relative to playlist",
+			cfg:          Config{Mode: PathRelative, Root: "/library"},
+			playlistPath: "/playlists/evening.m3u8",
+			wantPaths:    []string{"../library/Jazz/Kind of Blue/01 So What.flac", "../library/Jazz/Everybody Digs/06 Peace Piece.flac"},
+		},
+		{
+			name:         "relative inside root",
+			cfg:          Config{Mode: PathRelative, Root: "/music"},
+			playlistPath: "/music/Jazz/jazz.m3u8",
+			wantPaths:    []string{"Kind of Blue/01 So What.flac", "Everybody Digs/06 Peace Piece.flac"},
+		},
+		{
+			name: "rewrite prefers longest match",
+			cfg: Config{Mode: PathRewrite, Rewrites: []Rewrite{
+				{From: "Jazz", To: "/srv/jazz"},
+				{From: "/Jazz/Everybody Digs", To: "smb://nas/evans/"},
+			}},
+			wantPaths: []string{"/srv/jazz/Kind of Blue/01 So What.flac", "smb://nas/evans/06 Peace Piece.flac"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter, err := NewM3UExporter(tc.cfg)
+			if err != nil {
+				t.Fatalf("NewM3UExporter: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := exporter.Write(&buf, tc.playlistPath, "Late Night", tracks); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			want := "#EXTM3U\n" +
+				"#PLAYLIST:Late Night\n" +
+				"#EXTINF:562,Miles Davis - So What\n" + tc.wantPaths[0] + "\n" +
+				"#EXTINF:400,Bill Evans - Peace Piece\n" + tc.wantPaths[1] + "\n"
+			if buf.String() != want {
+				t.Fatalf("unexpected playlist:\n%s\nwant:\n%s", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestM3UExporterWritesCueHints(t *testing.T) {
+	faded := testTrack("1", "Pink Floyd", "Speak to Me", "Floyd/01.flac", 90*time.Second)
+	faded.Features.ContentStartSeconds = ptr(1.25)
+	faded.Features.ContentEndSeconds = ptr(88.5)
+	segue := testTrack("2", "Pink Floyd", "Breathe", "Floyd/02.flac", 163*time.Second)
+	segue.Features.ContentStartSeconds = ptr(0)
+	segue.Features.ContentEndSeconds = ptr(163)
+	unmeasured := testTrack("3", "Pink Floyd", "On the Run", "Floyd/03.flac", 216*time.Second)
+	tracks := []playlist.Candidate{faded, segue, unmeasured}
+
+	for _, cueHints := range []bool{true, false} {
+		exporter, err := NewM3UExporter(Config{Root: "/music", CueHints: cueHints})
+		if err != nil {
+			t.Fatalf("NewM3UExporter: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := exporter.Write(&buf, "", "Dark Side", tracks); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		hints := ""
+		if cueHints {
+			hints = "#EXTVLCOPT:start-time=1.250\n#EXTVLCOPT:stop-time=88.500\n"
+		}
+		want := "#EXTM3U\n" +
+			"#PLAYLIST:Dark Side\n" +
+			"#EXTINF:90,Pink Floyd - Speak to Me\n" + hints + "/music/Floyd/01.flac\n" +
+			"#EXTINF:163,Pink Floyd - Breathe\n/music/Floyd/02.flac\n" +
+			"#EXTINF:216,Pink Floyd - On the Run\n/music/Floyd/03.flac\n"
+		if buf.String() != want {
+			t.Fatalf("cue hints %v: unexpected playlist:\n%s\nwant:\n%s", cueHints, buf.String(), want)
+		}
+	}
+}
+
+func TestM3UExporterRewriteMatchesWholeSegments(t *testing.T) {
+	exporter, err := NewM3UExporter(Config{Mode: PathRewrite, Rewrites: []Rewrite{{From: "/music", To: "/mnt"}}})
+	if err != nil {
+		t.Fatalf("NewM3UExporter: %v", err)
+	}
+	err = exporter.Write(&bytes.Buffer{}, "", "x", []playlist.Candidate{testTrack("1", "A", "B", "/musicals/song.flac", time.Minute)})
+	if err == nil || !strings.Contains(err.Error(), "no path rewrite matches") {
+		t.Fatalf("expected unmatched rewrite error, got %v", err)
+	}
+}
+
+func TestM3UExporterRejectsEscapingPaths(t *testing.T) {
+	exporter, err := NewM3UExporter(Config{Root: "/library"})
+	if err != nil {
+		t.Fatalf("NewM3UExporter: %v", err)
+	}
+	err = exporter.Write(&bytes.Buffer{}, "", "x", []playlist.Candidate{testTrack("1", "A", "B", "../etc/passwd", time.Minute)})
+	if err == nil || !strings.Contains(err.Error(), "escapes library root") {
+		t.Fatalf("expected escape error, got %v", err)
+	}
+}
+
+func TestNewM3UExporterValidatesConfig(t *testing.T) {
+	tests := []Config{
+		{},
+		{Mode: PathRelative},
+		{Mode: PathRewrite},
+		{Mode: "sideways", Root: "/library"},
+	}
+	for _, cfg := range tests {
+		if _, err := NewM3UExporter(cfg); err == nil {
+			t.Fatalf("expected error for config %+v", cfg)
+		}
+	}
+}
+
+func TestM3UExporterWriteFileIsAtomic(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "playlists")
+	path := filepath.Join(dir, "focus.m3u8")
+	exporter, err := NewM3UExporter(Config{Mode: PathRelative, Root: filepath.Join(filepath.Dir(dir), "library")})
+	if err != nil {
+		t.Fatalf("NewM3UExporter: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatalf("seed playlist: %v", err)
+	}
+
+	tracks := []playlist.Candidate{testTrack("1", "A", "B", "A/B.flac", time.Minute)}
+	if err := exporter.WriteFile(path, "Focus", tracks); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read playlist: %v", err)
+	}
+	if want := "#EXTM3U\n#PLAYLIST:Focus\n#EXTINF:60,A - B\n../library/A/B.flac\n"; string(data) != want {
+		t.Fatalf("unexpected playlist %q", data)
+	}
+
+	// A failed write must leave the previous playlist and no temp files behind.
+	tracks[0].Track.Path = ""
+	if err := exporter.WriteFile(path, "Focus", tracks); err == nil {
+		t.Fatal("expected error for missing track path")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the playlist, found %d entries", len(entries))
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Fatalf("failed write replaced playlist with %q", after)
+	}
+}
+
+func TestParseRewrites(t *testing.T) {
+	got, err := ParseRewrites([]string{"/music=/mnt/nas", "Jazz = "})
+	if err != nil {
+		t.Fatalf("ParseRewrites: %v", err)
+	}
+	if len(got) != 2 || got[0] != (Rewrite{From: "/music", To: "/mnt/nas"}) || got[1] != (Rewrite{From: "Jazz"}) {
+		t.Fatalf("unexpected rewrites %+v", got)
+	}
+	if _, err := ParseRewrites([]string{"no-separator"}); err == nil {
+		t.Fatal("expected error for missing separator")
+	}
+}
+
+func testTrack(id, artist, title, path string, duration time.Duration) playlist.Candidate {
+	return playlist.Candidate{Track: app.Track{
+		ID:       id,
+		Artist:   artist,
+		Title:    title,
+		Path:     path,
+		Duration: duration,
+	}}
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bowmanmike/playlistgen/internal/navidrome"
+	"github.com/bowmanmike/playlistgen/internal/playlist"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+// ManagedComment marks playlists that playlistgen created in Navidrome.
+const ManagedComment = "Managed by playlistgen"
+
+// ErrUnmanagedPlaylist is returned when Navidrome already has a playlist with
+// the requested name that playlistgen did not create.
+var ErrUnmanagedPlaylist = errors.New("navidrome playlist exists but is not managed by playlistgen")
+
+// NavidromeClient is the subset of navidrome.Client used to publish playlists.
+type NavidromeClient interface {
+	GetPlaylists(context.Context) ([]navidrome.Playlist, error)
+	CreatePlaylist(context.Context, string, []string) (navidrome.Playlist, error)
+	UpdatePlaylist(context.Context, navidrome.PlaylistUpdate) error
+	DeletePlaylist(context.Context, string) error
+}
+
+// PlaylistMappings remembers which Navidrome playlist belongs to each name.
+type PlaylistMappings interface {
+	NavidromePlaylist(context.Context, string) (sqlite.NavidromePlaylistMapping, bool, error)
+	SaveNavidromePlaylist(context.Context, sqlite.NavidromePlaylistMapping) error
+	DeleteNavidromePlaylist(context.Context, string) error
+}
+
+// NavidromeTarget publishes playlists to Navidrome by song ID. Exporting the
+// same name twice replaces the tracks of the playlist created the first time
+// rather than creating a duplicate.
+type NavidromeTarget struct {
+	client   NavidromeClient
+	mappings PlaylistMappings
+}
+
+// NavidromeResult describes the outcome of an export.
+type NavidromeResult struct {
+	PlaylistID string
+	Created    bool
+}
+
+// NewNavidromeTarget builds a Navidrome export target.
+func NewNavidromeTarget(client NavidromeClient, mappings PlaylistMappings) (*NavidromeTarget, error) {
+	if client == nil {
+		return nil, errors.New("navidrome client is required")
+	}
+	if mappings == nil {
+		return nil, errors.New("playlist mappings are required")
+	}
+	return &NavidromeTarget{client: client, mappings: mappings}, nil
+}
+
+// Export creates the named playlist, or replaces its tracks when playlistgen
+// already owns a playlist with that name, either through its mapping or,
+// when the mapping is missing, through the ManagedComment tag.
+func (t *NavidromeTarget) Export(ctx context.Context, name string, tracks []playlist.Candidate) (NavidromeResult, error) {
+	songIDs := make([]string, 0, len(tracks))
+	for _, candidate := range tracks {
+		if candidate.Track.ID == "" {
+			return NavidromeResult{}, fmt.Errorf("track %q has no navidrome id", candidate.Track.Title)
+		}
+		songIDs = append(songIDs, candidate.Track.ID)
+	}
+	if len(songIDs) == 0 {
+		return NavidromeResult{}, errors.New("playlist has no tracks")
+	}
+
+	mapping, mapped, err := t.mappings.NavidromePlaylist(ctx, name)
+	if err != nil {
+		return NavidromeResult{}, err
+	}
+	existing, err := t.client.GetPlaylists(ctx)
+	if err != nil {
+		return NavidromeResult{}, fmt.Errorf("list navidrome playlists: %w", err)
+	}
+
+	if mapped {
+		for _, remote := range existing {
+			if remote.ID == mapping.PlaylistID {
+				return t.replace(ctx, name, remote, songIDs)
+			}
+		}
+		// The playlist was deleted in Navidrome; fall through and recreate it.
+	}
+
+	for _, remote := range existing {
+		if remote.Name != name {
+			continue
+		}
+		// A playlist playlistgen tagged but lost track of, e.g. because an
+		// earlier export failed before saving its mapping, is adopted.
+		if remote.Comment == ManagedComment {
+			return t.replace(ctx, name, remote, songIDs)
+		}
+		return NavidromeResult{}, fmt.Errorf("%w: %q (id %s)", ErrUnmanagedPlaylist, name, remote.ID)
+	}
+
+	created, err := t.client.CreatePlaylist(ctx, name, songIDs)
+	if err != nil {
+		return NavidromeResult{}, fmt.Errorf("create navidrome playlist: %w", err)
+	}
+	// Save the mapping before tagging, so a failed tag leaves a playlist the
+	// next export updates in place instead of refusing as unmanaged.
+	if err := t.save(ctx, name, created.ID, len(songIDs)); err != nil {
+		return NavidromeResult{}, err
+	}
+	comment := ManagedComment
+	if err := t.client.UpdatePlaylist(ctx, navidrome.PlaylistUpdate{ID: created.ID, Comment: &comment}); err != nil {
+		return NavidromeResult{}, fmt.Errorf("tag navidrome playlist: %w", err)
+	}
+	return NavidromeResult{PlaylistID: created.ID, Created: true}, nil
+}
+
+// replace swaps the tracks of remote for songIDs, tags it as managed, and
+// saves its mapping.
+func (t *NavidromeTarget) replace(ctx context.Context, name string, remote navidrome.Playlist, songIDs []string) (NavidromeResult, error) {
+	remove := make([]int, remote.SongCount)
+	for i := range remove {
+		remove[i] = i
+	}
+	comment := ManagedComment
+	if err := t.client.UpdatePlaylist(ctx, navidrome.PlaylistUpdate{
+		ID:                  remote.ID,
+		Name:                name,
+		Comment:             &comment,
+		SongIndexesToRemove: remove,
+		SongIDsToAdd:        songIDs,
+	}); err != nil {
+		return NavidromeResult{}, fmt.Errorf("update navidrome playlist: %w", err)
+	}
+	if err := t.save(ctx, name, remote.ID, len(songIDs)); err != nil {
+		return NavidromeResult{}, err
+	}
+	return NavidromeResult{PlaylistID: remote.ID}, nil
+}
+
+// Delete removes the playlist playlistgen owns under name, if any.
+func (t *NavidromeTarget) Delete(ctx context.Context, name string) error {
+	mapping, mapped, err := t.mappings.NavidromePlaylist(ctx, name)
+	if err != nil || !mapped {
+		return err
+	}
+	existing, err := t.client.GetPlaylists(ctx)
+	if err != nil {
+		return fmt.Errorf("list navidrome playlists: %w", err)
+	}
+	for _, remote := range existing {
+		if remote.ID == mapping.PlaylistID {
+			if err := t.client.DeletePlaylist(ctx, remote.ID); err != nil {
+				return fmt.Errorf("delete navidrome playlist: %w", err)
+			}
+			break
+		}
+	}
+	return t.mappings.DeleteNavidromePlaylist(ctx, name)
+}
+
+func (t *NavidromeTarget) save(ctx context.Context, name, id string, songs int) error {
+	return t.mappings.SaveNavidromePlaylist(ctx, sqlite.NavidromePlaylistMapping{
+		Name:       name,
+		PlaylistID: id,
+		SongCount:  songs,
+	})
+}
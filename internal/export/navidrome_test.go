@@ -0,0 +1,251 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/navidrome"
+	"github.com/bowmanmike/playlistgen/internal/playlist"
+	"github.com/bowmanmike/playlistgen/internal/storage/sqlite"
+)
+
+func TestNavidromeTargetCreatesThenUpdatesInPlace(t *testing.T) {
+	client := newFakeNavidrome()
+	mappings := fakeMappings{}
+	target, err := NewNavidromeTarget(client, mappings)
+	if err != nil {
+		t.Fatalf("NewNavidromeTarget: %v", err)
+	}
+	ctx := context.Background()
+
+	first, err := target.Export(ctx, "Focus", candidates("s1", "s2", "s3"))
+	if err != nil {
+		t.Fatalf("first export: %v", err)
+	}
+	if !first.Created || first.PlaylistID == "" {
+		t.Fatalf("expected a created playlist, got %+v", first)
+	}
+	if got := client.songs[first.PlaylistID]; !reflect.DeepEqual(got, []string{"s1", "s2", "s3"}) {
+		t.Fatalf("unexpected songs %v", got)
+	}
+	if client.playlists[first.PlaylistID].Comment != ManagedComment {
+		t.Fatalf("expected playlist to be tagged, got %+v", client.playlists[first.PlaylistID])
+	}
+
+	second, err := target.Export(ctx, "Focus", candidates("s4", "s2"))
+	if err != nil {
+		t.Fatalf("second export: %v", err)
+	}
+	if second.Created || second.PlaylistID != first.PlaylistID {
+		t.Fatalf("expected in-place update of %s, got %+v", first.PlaylistID, second)
+	}
+	if len(client.playlists) != 1 {
+		t.Fatalf("expected one playlist, found %d", len(client.playlists))
+	}
+	if got := client.songs[first.PlaylistID]; !reflect.DeepEqual(got, []string{"s4", "s2"}) {
+		t.Fatalf("unexpected replaced songs %v", got)
+	}
+	if mappings["Focus"].SongCount != 2 {
+		t.Fatalf("unexpected mapping %+v", mappings["Focus"])
+	}
+}
+
+func TestNavidromeTargetRecreatesDeletedPlaylist(t *testing.T) {
+	client := newFakeNavidrome()
+	mappings := fakeMappings{"Focus": {Name: "Focus", PlaylistID: "gone", SongCount: 3}}
+	target, _ := NewNavidromeTarget(client, mappings)
+
+	result, err := target.Export(context.Background(), "Focus", candidates("s1"))
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if !result.Created || result.PlaylistID == "gone" || mappings["Focus"].PlaylistID != result.PlaylistID {
+		t.Fatalf("expected recreated playlist, got %+v mapping %+v", result, mappings["Focus"])
+	}
+}
+
+func TestNavidromeTargetRefusesUnmanagedPlaylist(t *testing.T) {
+	client := newFakeNavidrome()
+	client.add(navidrome.Playlist{ID: "mine", Name: "Focus"}, "s9")
+	target, _ := NewNavidromeTarget(client, fakeMappings{})
+
+	_, err := target.Export(context.Background(), "Focus", candidates("s1"))
+	if !errors.Is(err, ErrUnmanagedPlaylist) {
+		t.Fatalf("expected ErrUnmanagedPlaylist, got %v", err)
+	}
+	if got := client.songs["mine"]; !reflect.DeepEqual(got, []string{"s9"}) {
+		t.Fatalf("unmanaged playlist was modified: %v", got)
+	}
+}
+
+func TestNavidromeTargetAdoptsTaggedPlaylist(t *testing.T) {
+	client := newFakeNavidrome()
+	client.add(navidrome.Playlist{ID: "tagged", Name: "Focus", Comment: ManagedComment}, "s9")
+	mappings := fakeMappings{}
+	target, _ := NewNavidromeTarget(client, mappings)
+
+	result, err := target.Export(context.Background(), "Focus", candidates("s1", "s2"))
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if result.Created || result.PlaylistID != "tagged" || len(client.playlists) != 1 {
+		t.Fatalf("expected the tagged playlist to be updated in place, got %+v", result)
+	}
+	if got := client.songs["tagged"]; !reflect.DeepEqual(got, []string{"s1", "s2"}) {
+		t.Fatalf("unexpected songs %v", got)
+	}
+	if mappings["Focus"].PlaylistID != "tagged" {
+		t.Fatalf("expected the adopted playlist to be mapped, got %+v", mappings["Focus"])
+	}
+}
+
+func TestNavidromeTargetRecoversFromFailedTag(t *testing.T) {
+	client := newFakeNavidrome()
+	client.updateErr = errors.New("navidrome unavailable")
+	mappings := fakeMappings{}
+	target, _ := NewNavidromeTarget(client, mappings)
+	ctx := context.Background()
+
+	if _, err := target.Export(ctx, "Focus", candidates("s1")); err == nil {
+		t.Fatal("expected the failed tag to be reported")
+	}
+	created := mappings["Focus"].PlaylistID
+	if created == "" {
+		t.Fatal("expected the created playlist to be mapped before tagging")
+	}
+
+	result, err := target.Export(ctx, "Focus", candidates("s2"))
+	if err != nil {
+		t.Fatalf("retry export: %v", err)
+	}
+	if result.Created || result.PlaylistID != created || len(client.playlists) != 1 {
+		t.Fatalf("expected the retry to update %s in place, got %+v", created, result)
+	}
+	if client.playlists[created].Comment != ManagedComment {
+		t.Fatalf("expected the retry to tag the playlist, got %+v", client.playlists[created])
+	}
+}
+
+func TestNavidromeTargetDelete(t *testing.T) {
+	client := newFakeNavidrome()
+	mappings := fakeMappings{}
+	target, _ := NewNavidromeTarget(client, mappings)
+	ctx := context.Background()
+
+	result, err := target.Export(ctx, "Focus", candidates("s1"))
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if err := target.Delete(ctx, "Focus"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := client.playlists[result.PlaylistID]; ok {
+		t.Fatal("expected navidrome playlist to be deleted")
+	}
+	if _, ok := mappings["Focus"]; ok {
+		t.Fatal("expected mapping to be deleted")
+	}
+	if err := target.Delete(ctx, "Focus"); err != nil {
+		t.Fatalf("second delete should be a no-op, got %v", err)
+	}
+}
+
+func candidates(ids ...string) []playlist.Candidate {
+	out := make([]playlist.Candidate, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, testTrack(id, "Artist", "Title "+id, id+".flac", time.Minute))
+	}
+	return out
+}
+
+// fakeNavidrome applies playlist calls to in-memory state the way Navidrome
+// does, including removing indexes before adding songs on update.
+type fakeNavidrome struct {
+	playlists map[string]navidrome.Playlist
+	songs     map[string][]string
+	nextID    int
+	// updateErr fails the next UpdatePlaylist call.
+	updateErr error
+}
+
+func newFakeNavidrome() *fakeNavidrome {
+	return &fakeNavidrome{playlists: map[string]navidrome.Playlist{}, songs: map[string][]string{}}
+}
+
+func (f *fakeNavidrome) add(p navidrome.Playlist, songs ...string) {
+	p.SongCount = len(songs)
+	f.playlists[p.ID] = p
+	f.songs[p.ID] = songs
+}
+
+func (f *fakeNavidrome) GetPlaylists(ctx context.Context) ([]navidrome.Playlist, error) {
+	out := make([]navidrome.Playlist, 0, len(f.playlists))
+	for _, p := range f.playlists {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (f *fakeNavidrome) CreatePlaylist(ctx context.Context, name string, songIDs []string) (navidrome.Playlist, error) {
+	f.nextID++
+	p := navidrome.Playlist{ID: fmt.Sprintf("pl%d", f.nextID), Name: name}
+	f.add(p, songIDs...)
+	return f.playlists[p.ID], nil
+}
+
+func (f *fakeNavidrome) UpdatePlaylist(ctx context.Context, update navidrome.PlaylistUpdate) error {
+	if err := f.updateErr; err != nil {
+		f.updateErr = nil
+		return err
+	}
+	p, ok := f.playlists[update.ID]
+	if !ok {
+		return errors.New("playlist not found")
+	}
+	if update.Name != "" {
+		p.Name = update.Name
+	}
+	if update.Comment != nil {
+		p.Comment = *update.Comment
+	}
+	remove := map[int]bool{}
+	for _, i := range update.SongIndexesToRemove {
+		remove[i] = true
+	}
+	var songs []string
+	for i, id := range f.songs[update.ID] {
+		if !remove[i] {
+			songs = append(songs, id)
+		}
+	}
+	songs = append(songs, update.SongIDsToAdd...)
+	f.add(p, songs...)
+	return nil
+}
+
+func (f *fakeNavidrome) DeletePlaylist(ctx context.Context, id string) error {
+	delete(f.playlists, id)
+	delete(f.songs, id)
+	return nil
+}
+
+type fakeMappings map[string]sqlite.NavidromePlaylistMapping
+
+func (m fakeMappings) NavidromePlaylist(ctx context.Context, name string) (sqlite.NavidromePlaylistMapping, bool, error) {
+	mapping, ok := m[name]
+	return mapping, ok, nil
+}
+
+func (m fakeMappings) SaveNavidromePlaylist(ctx context.Context, mapping sqlite.NavidromePlaylistMapping) error {
+	m[mapping.Name] = mapping
+	return nil
+}
+
+func (m fakeMappings) DeleteNavidromePlaylist(ctx context.Context, name string) error {
+	delete(m, name)
+	return nil
+}
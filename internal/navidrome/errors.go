@@ -0,0 +1,80 @@
+package navidrome
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Subsonic error codes that mean retrying cannot help. Match them with
+// errors.Is against any error returned by Client.
+var (
+	// ErrWrongCredentials is Subsonic error 40: wrong username or password.
+	ErrWrongCredentials = errors.New("wrong username or password")
+	// ErrTokenAuthUnsupported is Subsonic error 41: token authentication is
+	// not supported for this user, typically an LDAP account.
+	ErrTokenAuthUnsupported = errors.New("token authentication not supported")
+	// ErrNotAuthorized is Subsonic error 50: the user may not perform the
+	// operation.
+	ErrNotAuthorized = errors.New("user is not authorized for the operation")
+)
+
+// SubsonicError is an error reported inside a Subsonic response envelope.
+type SubsonicError struct {
+	Code    int
+	Message string
+}
+
+func (e *SubsonicError) Error() string {
+	return fmt.Sprintf("subsonic error %d: %s", e.Code, e.Message)
+}
+
+// Is maps Subsonic error codes onto the package's sentinel errors.
+func (e *SubsonicError) Is(target error) bool {
+	switch e.Code {
+	case 40:
+		return target == ErrWrongCredentials
+	case 41:
+		return target == ErrTokenAuthUnsupported
+	case 50:
+		return target == ErrNotAuthorized
+	}
+	return false
+}
+
+// StatusError reports a non-200 HTTP response.
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request %s: unexpected status %d", e.Endpoint, e.StatusCode)
+}
+
+// Is reports 401 and 403 responses as ErrNotAuthorized.
+func (e *StatusError) Is(target error) bool {
+	return target == ErrNotAuthorized && (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden)
+}
+
+// transportError marks failures to reach the server at all.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// isRetryable reports whether a request that failed with err may succeed if
+// repeated: network errors, 5xx responses, and 429 Too Many Requests.
+func isRetryable(err error) bool {
+	var transport *transportError
+	if errors.As(err, &transport) {
+		return true
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= 500 || status.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package navidrome
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestListTracksConcurrentPreservesAlbumOrder(t *testing.T) {
+	const albums = 12
+	var inFlight, maxInFlight atomic.Int32
+	server := newAlbumServer(t, albums, func(r *http.Request, index int) (int, bool) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if n <= seen || maxInFlight.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+		// Later albums finish first so completion order differs from list order.
+		time.Sleep(time.Duration(albums-index) * 2 * time.Millisecond)
+		return index, true
+	})
+
+	client, err := NewClient(Config{BaseURL: server.URL, Username: "user", Password: "pass", AlbumConcurrency: 3})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	tracks, err := client.ListTracks(context.Background())
+	if err != nil {
+		t.Fatalf("ListTracks: %v", err)
+	}
+	if len(tracks) != albums*2 {
+		t.Fatalf("expected %d tracks, got %d", albums*2, len(tracks))
+	}
+	for i, track := range tracks {
+		if want := fmt.Sprintf("song-%d-%d", i/2, i%2); track.ID != want {
+			t.Fatalf("track %d = %s, want %s", i, track.ID, want)
+		}
+	}
+	if got := maxInFlight.Load(); got > 3 || got < 2 {
+		t.Fatalf("expected up to 3 concurrent album requests, saw %d", got)
+	}
+}
+
+func TestListTracksFirstErrorCancelsRemainingAlbums(t *testing.T) {
+	const albums = 50
+	var fetched atomic.Int32
+	server := newAlbumServer(t, albums, func(r *http.Request, index int) (int, bool) {
+		fetched.Add(1)
+		if index == 0 {
+			return index, false
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(20 * time.Millisecond):
+		}
+		return index, true
+	})
+
+	client, err := NewClient(Config{BaseURL: server.URL, Username: "user", Password: "pass", AlbumConcurrency: 2, Retry: RetryPolicy{MaxAttempts: 1}})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	_, err = client.ListTracks(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "album alb-0") {
+		t.Fatalf("expected album error, got %v", err)
+	}
+	if got := fetched.Load(); got >= albums {
+		t.Fatalf("expected remaining albums to be cancelled, but %d were fetched", got)
+	}
+}
+
+func BenchmarkListTracks(b *testing.B) {
+	const albums = 40
+	server := newAlbumServer(b, albums, func(r *http.Request, index int) (int, bool) {
+		time.Sleep(5 * time.Millisecond)
+		return index, true
+	})
+
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run("concurrency="+strconv.Itoa(concurrency), func(b *testing.B) {
+			client, err := NewClient(Config{BaseURL: server.URL, Username: "user", Password: "pass", AlbumConcurrency: concurrency})
+			if err != nil {
+				b.Fatalf("create client: %v", err)
+			}
+			for b.Loop() {
+				if _, err := client.ListTracks(context.Background()); err != nil {
+					b.Fatalf("ListTracks: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// newAlbumServer serves an album list of n albums, each with two songs.
+// handleAlbum runs for every getAlbum request; returning false fails it.
+func newAlbumServer(tb testing.TB, n int, handleAlbum func(*http.Request, int) (int, bool)) *httptest.Server {
+	tb.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/getAlbumList2.view":
+			var ids []string
+			for i := 0; i < n; i++ {
+				ids = append(ids, fmt.Sprintf(`{"id":"alb-%d"}`, i))
+			}
+			fmt.Fprintf(w, `{"subsonic-response":{"status":"ok","albumList2":{"album":[%s]}}}`, strings.Join(ids, ","))
+		case "/rest/getAlbum.view":
+			index, err := strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("id"), "alb-"))
+			if err != nil {
+				http.Error(w, "bad album id", http.StatusBadRequest)
+				return
+			}
+			index, ok := handleAlbum(r, index)
+			if !ok {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintf(w, `{"subsonic-response":{"status":"ok","album":{"song":[{"id":"song-%[1]d-0","title":"A"},{"id":"song-%[1]d-1","title":"B"}]}}}`, index)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
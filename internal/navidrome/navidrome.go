@@ -15,6 +15,8 @@ import (
 	"strings"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+
 	"github.com/bowmanmike/playlistgen/internal/app"
 )
 
@@ -25,22 +27,30 @@ const (
 	apiVersion        = "1.16.1"
 	clientName        = "playlistgen"
 	albumPageSize     = 200
+	// DefaultAlbumConcurrency is how many albums ListTracks fetches at once
+	// when Config.AlbumConcurrency is unset.
+	DefaultAlbumConcurrency = 4
 )
 
 // Config drives Client construction.
 type Config struct {
-	BaseURL    string
-	Username   string
-	Password   string
-	HTTPClient *http.Client
+	BaseURL          string
+	Username         string
+	Password         string
+	HTTPClient       *http.Client
+	AlbumConcurrency int
+	Retry            RetryPolicy
 }
 
 // Client proxies requests to the Navidrome API.
 type Client struct {
-	baseURL    *url.URL
-	username   string
-	password   string
-	httpClient *http.Client
+	baseURL          *url.URL
+	username         string
+	password         string
+	httpClient       *http.Client
+	albumConcurrency int
+	retry            RetryPolicy
+	sleep            func(context.Context, time.Duration) error
 }
 
 // NewClient builds a Navidrome API client.
@@ -57,45 +67,69 @@ func NewClient(cfg Config) (*Client, error) {
 	if cfg.HTTPClient == nil {
 		cfg.HTTPClient = &http.Client{Timeout: defaultTimeout}
 	}
+	if cfg.AlbumConcurrency <= 0 {
+		cfg.AlbumConcurrency = DefaultAlbumConcurrency
+	}
 
 	return &Client{
-		baseURL:    parsed,
-		username:   cfg.Username,
-		password:   cfg.Password,
-		httpClient: cfg.HTTPClient,
+		baseURL:          parsed,
+		username:         cfg.Username,
+		password:         cfg.Password,
+		httpClient:       cfg.HTTPClient,
+		albumConcurrency: cfg.AlbumConcurrency,
+		retry:            cfg.Retry.withDefaults(),
+		sleep:            sleepContext,
 	}, nil
 }
 
-// ListTracks fetches the track list from Navidrome via Subsonic API.
+// ListTracks fetches the track list from Navidrome via Subsonic API. Albums
+// are fetched concurrently, but tracks are returned in album-list order.
+// Extended song metadata is only decoded when the server supports
+// OpenSubsonic.
 func (c *Client) ListTracks(ctx context.Context) ([]app.Track, error) {
+	_, extended, err := c.OpenSubsonicExtensions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("detect OpenSubsonic: %w", err)
+	}
+
 	var (
-		tracks []app.Track
+		albums []albumItem
 		offset int
 	)
 
 	for {
-		albums, err := c.fetchAlbumPage(ctx, offset)
+		page, err := c.fetchAlbumPage(ctx, offset)
 		if err != nil {
 			return nil, err
 		}
-		if len(albums) == 0 {
+		albums = append(albums, page...)
+		if len(page) < albumPageSize {
 			break
 		}
+		offset += len(page)
+	}
 
-		for _, album := range albums {
-			songs, err := c.fetchAlbumSongs(ctx, album.ID)
+	songsByAlbum := make([][]app.Track, len(albums))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.albumConcurrency)
+	for i, album := range albums {
+		g.Go(func() error {
+			songs, err := c.fetchAlbumSongs(gctx, album.ID, extended)
 			if err != nil {
-				return nil, err
+				return fmt.Errorf("album %s: %w", album.ID, err)
 			}
-			tracks = append(tracks, songs...)
-		}
-
-		if len(albums) < albumPageSize {
-			break
-		}
-		offset += len(albums)
+			songsByAlbum[i] = songs
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
 
+	var tracks []app.Track
+	for _, songs := range songsByAlbum {
+		tracks = append(tracks, songs...)
+	}
 	return tracks, nil
 }
 
@@ -117,7 +151,7 @@ func (c *Client) fetchAlbumPage(ctx context.Context, offset int) ([]albumItem, e
 	return resp.Response.AlbumList.Albums, nil
 }
 
-func (c *Client) fetchAlbumSongs(ctx context.Context, albumID string) ([]app.Track, error) {
+func (c *Client) fetchAlbumSongs(ctx context.Context, albumID string, extended bool) ([]app.Track, error) {
 	params := url.Values{}
 	params.Set("id", albumID)
 
@@ -165,7 +199,11 @@ func (c *Client) fetchAlbumSongs(ctx context.Context, albumID string) ([]app.Tra
 		if strings.TrimSpace(song.Changed) != "" {
 			updatedAt = parseSubsonicTime(song.Changed)
 		}
-		songs = append(songs, app.Track{
+		rating := song.UserRating
+		if rating < 0 || rating > 5 {
+			rating = 0
+		}
+		track := app.Track{
 			ID:          song.ID,
 			Title:       song.Title,
 			Artist:      song.Artist,
@@ -185,13 +223,42 @@ func (c *Client) fetchAlbumSongs(ctx context.Context, albumID string) ([]app.Tra
 			Suffix:      song.Suffix,
 			CreatedAt:   createdAt,
 			UpdatedAt:   updatedAt,
-		})
+			Stats: app.UserStats{
+				StarredAt:    parseSubsonicTime(song.Starred),
+				Rating:       rating,
+				PlayCount:    song.PlayCount,
+				LastPlayedAt: parseSubsonicTime(song.Played),
+			},
+		}
+		if extended {
+			track.Extended = song.extendedMetadata()
+		}
+		songs = append(songs, track)
 	}
 
 	return songs, nil
 }
 
+// doRequest issues an idempotent GET, retrying transient failures according
+// to the client's retry policy.
 func (c *Client) doRequest(ctx context.Context, endpoint string, params url.Values, target interface{}) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = c.doRequestOnce(ctx, endpoint, params, target)
+		if err == nil || !isRetryable(err) || attempt >= c.retry.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+		delay := max(c.retry.backoff(attempt), min(retryAfter, c.retry.MaxDelay))
+		if sleepErr := c.sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// doRequestOnce issues a single GET. On a retryable HTTP status it also returns
+// the server's Retry-After hint, if any.
+func (c *Client) doRequestOnce(ctx context.Context, endpoint string, params url.Values, target interface{}) (time.Duration, error) {
 	u := *c.baseURL
 	u.Path = ensureLeadingSlash(path.Join(c.baseURL.Path, endpoint))
 
@@ -205,24 +272,24 @@ func (c *Client) doRequest(ctx context.Context, endpoint string, params url.Valu
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return 0, fmt.Errorf("create request: %w", err)
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request %s: %w", endpoint, err)
+		return 0, &transportError{err: fmt.Errorf("request %s: %w", endpoint, err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), &StatusError{Endpoint: endpoint, StatusCode: resp.StatusCode}
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
-		return fmt.Errorf("decode response: %w", err)
+		return 0, fmt.Errorf("decode response: %w", err)
 	}
 
-	return nil
+	return 0, nil
 }
 
 func authParams(user, password string) url.Values {
@@ -313,7 +380,7 @@ type subsonicEnvelope struct {
 
 func (e subsonicEnvelope) validate() error {
 	if e.Error != nil {
-		return fmt.Errorf("subsonic error %d: %s", e.Error.Code, e.Error.Message)
+		return &SubsonicError{Code: e.Error.Code, Message: e.Error.Message}
 	}
 	if strings.ToLower(e.Status) != "ok" {
 		return fmt.Errorf("subsonic status %s", e.Status)
@@ -350,4 +417,19 @@ type songItem struct {
 	Suffix      string `json:"suffix"`
 	Created     string `json:"created"`
 	Changed     string `json:"changed"`
+	Starred     string `json:"starred"`
+	UserRating  int    `json:"userRating"`
+	PlayCount   int64  `json:"playCount"`
+	Played      string `json:"played"`
+
+	// OpenSubsonic extensions.
+	MusicBrainzID string          `json:"musicBrainzId"`
+	SortName      string          `json:"sortName"`
+	ISRC          []string        `json:"isrc"`
+	BPM           int             `json:"bpm"`
+	Moods         []string        `json:"moods"`
+	Genres        []genreRef      `json:"genres"`
+	Artists       []artistRef     `json:"artists"`
+	AlbumArtists  []artistRef     `json:"albumArtists"`
+	ReplayGain    *replayGainItem `json:"replayGain"`
 }
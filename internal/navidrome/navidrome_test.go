@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
 )
 
 func TestListTracks(t *testing.T) {
@@ -21,6 +23,13 @@ func TestListTracks(t *testing.T) {
 			}
 
 			switch req.URL.Path {
+			case "/rest/getOpenSubsonicExtensions.view":
+				body := `{"subsonic-response":{"status":"ok","openSubsonic":true,"openSubsonicExtensions":[{"name":"songLyrics","versions":[1]}]}}`
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(body)),
+					Header:     make(http.Header),
+				}, nil
 			case "/rest/getAlbumList2.view":
 				body := `{"subsonic-response":{"status":"ok","albumList2":{"album":[{"id":"alb1"}]}}}`
 				return &http.Response{
@@ -32,7 +41,7 @@ func TestListTracks(t *testing.T) {
 				if req.URL.Query().Get("id") != "alb1" {
 					t.Fatalf("unexpected album id %s", req.URL.Query().Get("id"))
 				}
-				body := `{"subsonic-response":{"status":"ok","album":{"song":[{"id":"1","title":"Song","artist":"Artist","artistId":"artist1","album":"Album","albumId":"album1","albumArtist":"AlbumArtist","genre":"Rock","track":2,"discNumber":1,"year":2023,"duration":180,"bitRate":320,"path":"/music/song.mp3","size":123456,"contentType":"audio/flac","suffix":"flac","created":"2023-01-01T10:00:00Z","changed":"2023-01-02T10:00:00Z"}]}}}`
+				body := `{"subsonic-response":{"status":"ok","album":{"song":[{"id":"1","title":"Song","artist":"Artist","artistId":"artist1","album":"Album","albumId":"album1","albumArtist":"AlbumArtist","genre":"Rock","track":2,"discNumber":1,"year":2023,"duration":180,"bitRate":320,"path":"/music/song.mp3","size":123456,"contentType":"audio/flac","suffix":"flac","created":"2023-01-01T10:00:00Z","changed":"2023-01-02T10:00:00Z","starred":"2023-02-01T08:00:00Z","userRating":4,"playCount":17,"played":"2023-03-01T22:00:00.5Z","musicBrainzId":"mbid-1","sortName":"song, the","isrc":["USABC2300001"],"bpm":128,"moods":["happy",""],"genres":[{"name":"Rock"},{"name":"Indie"}],"artists":[{"id":"artist1","name":"Artist"},{"id":"artist2","name":"Guest"}],"albumArtists":[{"id":"aa1","name":"AlbumArtist"}],"replayGain":{"trackGain":-7.5,"albumGain":-6.25,"trackPeak":0.98,"albumPeak":0}}]}}}`
 				return &http.Response{
 					StatusCode: http.StatusOK,
 					Body:       io.NopCloser(strings.NewReader(body)),
@@ -86,8 +95,29 @@ func TestListTracks(t *testing.T) {
 		if track.UpdatedAt.IsZero() {
 			t.Fatalf("expected changed timestamp")
 		}
-		if call != 2 {
-			t.Fatalf("expected two requests, got %d", call)
+		if !track.Stats.StarredAt.Equal(time.Date(2023, 2, 1, 8, 0, 0, 0, time.UTC)) ||
+			track.Stats.Rating != 4 ||
+			track.Stats.PlayCount != 17 ||
+			!track.Stats.LastPlayedAt.Equal(time.Date(2023, 3, 1, 22, 0, 0, 500_000_000, time.UTC)) {
+			t.Fatalf("unexpected user stats %+v", track.Stats)
+		}
+		ext := track.Extended
+		if ext.MusicBrainzID != "mbid-1" ||
+			ext.SortName != "song, the" ||
+			strings.Join(ext.ISRC, ",") != "USABC2300001" ||
+			ext.BPM != 128 ||
+			strings.Join(ext.Moods, ",") != "happy" ||
+			strings.Join(ext.Genres, ",") != "Rock,Indie" ||
+			len(ext.Artists) != 2 || ext.Artists[1] != (app.ArtistCredit{ID: "artist2", Name: "Guest"}) ||
+			len(ext.AlbumArtists) != 1 || ext.AlbumArtists[0].ID != "aa1" {
+			t.Fatalf("unexpected extended metadata %+v", ext)
+		}
+		rg := ext.ReplayGain
+		if ptrToFloat(rg.TrackGainDB) != -7.5 || ptrToFloat(rg.AlbumGainDB) != -6.25 || ptrToFloat(rg.TrackPeak) != 0.98 || rg.AlbumPeak != nil {
+			t.Fatalf("unexpected replay gain %+v", rg)
+		}
+		if call != 3 {
+			t.Fatalf("expected three requests, got %d", call)
 		}
 	})
 
@@ -105,6 +135,7 @@ func TestListTracks(t *testing.T) {
 			Username:   "user",
 			Password:   "pass",
 			HTTPClient: httpClient,
+			Retry:      RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
 		})
 		if err != nil {
 			t.Fatalf("create client: %v", err)
@@ -149,3 +180,10 @@ func ptrToInt64(v *int64) int64 {
 	}
 	return *v
 }
+
+func ptrToFloat(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,133 @@
+package navidrome
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+const openSubsonicExtensionsEndpoint = "rest/getOpenSubsonicExtensions.view"
+
+// Extension is one OpenSubsonic extension advertised by the server.
+type Extension struct {
+	Name     string `json:"name"`
+	Versions []int  `json:"versions"`
+}
+
+// OpenSubsonicExtensions asks the server which OpenSubsonic extensions it
+// supports. ok is false when the server does not implement OpenSubsonic;
+// authentication and transport failures are returned as errors.
+func (c *Client) OpenSubsonicExtensions(ctx context.Context) (extensions []Extension, ok bool, err error) {
+	var resp openSubsonicExtensionsResponse
+	if err := c.doRequest(ctx, openSubsonicExtensionsEndpoint, nil, &resp); err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if err := resp.Response.validate(); err != nil {
+		if isAuthError(err) {
+			return nil, false, err
+		}
+		return nil, false, nil
+	}
+	if !resp.Response.OpenSubsonic {
+		return nil, false, nil
+	}
+	return resp.Response.Extensions, true, nil
+}
+
+func isAuthError(err error) bool {
+	return errors.Is(err, ErrWrongCredentials) ||
+		errors.Is(err, ErrTokenAuthUnsupported) ||
+		errors.Is(err, ErrNotAuthorized)
+}
+
+type openSubsonicExtensionsResponse struct {
+	Response openSubsonicExtensionsPayload `json:"subsonic-response"`
+}
+
+type openSubsonicExtensionsPayload struct {
+	subsonicEnvelope
+	OpenSubsonic bool        `json:"openSubsonic"`
+	Extensions   []Extension `json:"openSubsonicExtensions"`
+}
+
+type genreRef struct {
+	Name string `json:"name"`
+}
+
+type artistRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type replayGainItem struct {
+	TrackGain *float64 `json:"trackGain"`
+	AlbumGain *float64 `json:"albumGain"`
+	TrackPeak *float64 `json:"trackPeak"`
+	AlbumPeak *float64 `json:"albumPeak"`
+}
+
+// extendedMetadata maps the OpenSubsonic fields of a song.
+func (s songItem) extendedMetadata() app.ExtendedMetadata {
+	meta := app.ExtendedMetadata{
+		MusicBrainzID: strings.TrimSpace(s.MusicBrainzID),
+		SortName:      strings.TrimSpace(s.SortName),
+		ISRC:          nonEmpty(s.ISRC),
+		Moods:         nonEmpty(s.Moods),
+		Artists:       artistCredits(s.Artists),
+		AlbumArtists:  artistCredits(s.AlbumArtists),
+	}
+	if s.BPM > 0 {
+		meta.BPM = s.BPM
+	}
+	for _, genre := range s.Genres {
+		if name := strings.TrimSpace(genre.Name); name != "" {
+			meta.Genres = append(meta.Genres, name)
+		}
+	}
+	if s.ReplayGain != nil {
+		meta.ReplayGain = app.ReplayGain{
+			TrackGainDB: s.ReplayGain.TrackGain,
+			TrackPeak:   positive(s.ReplayGain.TrackPeak),
+			AlbumGainDB: s.ReplayGain.AlbumGain,
+			AlbumPeak:   positive(s.ReplayGain.AlbumPeak),
+		}
+	}
+	return meta
+}
+
+func nonEmpty(values []string) []string {
+	var out []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+func artistCredits(refs []artistRef) []app.ArtistCredit {
+	var out []app.ArtistCredit
+	for _, ref := range refs {
+		name := strings.TrimSpace(ref.Name)
+		if name == "" {
+			continue
+		}
+		out = append(out, app.ArtistCredit{ID: ref.ID, Name: name})
+	}
+	return out
+}
+
+// positive drops the zero peaks some servers send for "unknown".
+func positive(v *float64) *float64 {
+	if v == nil || *v <= 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,124 @@
+package navidrome
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOpenSubsonicExtensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    bool
+		wantErr error
+	}{
+		{
+			name:   "supported",
+			status: http.StatusOK,
+			body:   `{"subsonic-response":{"status":"ok","openSubsonic":true,"openSubsonicExtensions":[{"name":"formPost","versions":[1]}]}}`,
+			want:   true,
+		},
+		{
+			name:   "plain subsonic response",
+			status: http.StatusOK,
+			body:   `{"subsonic-response":{"status":"ok"}}`,
+		},
+		{
+			name:   "unknown endpoint error",
+			status: http.StatusOK,
+			body:   `{"subsonic-response":{"status":"failed","error":{"code":0,"message":"unknown"}}}`,
+		},
+		{
+			name:   "not found",
+			status: http.StatusNotFound,
+		},
+		{
+			name:    "wrong credentials",
+			status:  http.StatusOK,
+			body:    `{"subsonic-response":{"status":"failed","error":{"code":40,"message":"Wrong username or password"}}}`,
+			wantErr: ErrWrongCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(Config{
+				BaseURL:  "https://navidrome.local",
+				Username: "user",
+				Password: "pass",
+				HTTPClient: mockHTTPClient(func(req *http.Request) (*http.Response, error) {
+					if req.URL.Path != "/rest/getOpenSubsonicExtensions.view" {
+						t.Fatalf("unexpected path %s", req.URL.Path)
+					}
+					return &http.Response{
+						StatusCode: tt.status,
+						Body:       io.NopCloser(strings.NewReader(tt.body)),
+						Header:     make(http.Header),
+					}, nil
+				}),
+			})
+			if err != nil {
+				t.Fatalf("create client: %v", err)
+			}
+
+			extensions, ok, err := client.OpenSubsonicExtensions(context.Background())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OpenSubsonicExtensions: %v", err)
+			}
+			if ok != tt.want {
+				t.Fatalf("expected supported=%v, got %v", tt.want, ok)
+			}
+			if ok && (len(extensions) != 1 || extensions[0].Name != "formPost") {
+				t.Fatalf("unexpected extensions %+v", extensions)
+			}
+		})
+	}
+}
+
+func TestListTracksIgnoresExtendedFieldsWithoutOpenSubsonic(t *testing.T) {
+	client, err := NewClient(Config{
+		BaseURL:  "https://navidrome.local",
+		Username: "user",
+		Password: "pass",
+		HTTPClient: mockHTTPClient(func(req *http.Request) (*http.Response, error) {
+			var body string
+			switch req.URL.Path {
+			case "/rest/getOpenSubsonicExtensions.view":
+				body = `{"subsonic-response":{"status":"ok"}}`
+			case "/rest/getAlbumList2.view":
+				body = `{"subsonic-response":{"status":"ok","albumList2":{"album":[{"id":"alb1"}]}}}`
+			case "/rest/getAlbum.view":
+				body = `{"subsonic-response":{"status":"ok","album":{"song":[{"id":"1","title":"Song","bpm":120,"genres":[{"name":"Rock"}]}]}}}`
+			default:
+				t.Fatalf("unexpected path %s", req.URL.Path)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     make(http.Header),
+			}, nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	tracks, err := client.ListTracks(context.Background())
+	if err != nil {
+		t.Fatalf("ListTracks: %v", err)
+	}
+	if len(tracks) != 1 || !tracks[0].Extended.IsZero() {
+		t.Fatalf("expected no extended metadata, got %+v", tracks)
+	}
+}
@@ -0,0 +1,181 @@
+package navidrome
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	playlistsEndpoint      = "rest/getPlaylists.view"
+	createPlaylistEndpoint = "rest/createPlaylist.view"
+	updatePlaylistEndpoint = "rest/updatePlaylist.view"
+	deletePlaylistEndpoint = "rest/deletePlaylist.view"
+)
+
+// Playlist is a Navidrome playlist as reported by the Subsonic API.
+type Playlist struct {
+	ID        string
+	Name      string
+	Comment   string
+	Owner     string
+	Public    bool
+	SongCount int
+	Duration  time.Duration
+	Created   time.Time
+	Changed   time.Time
+}
+
+// PlaylistUpdate describes an updatePlaylist.view call. Empty fields are left
+// unchanged on the server. Removals are applied before additions.
+type PlaylistUpdate struct {
+	ID                  string
+	Name                string
+	Comment             *string
+	Public              *bool
+	SongIDsToAdd        []string
+	SongIndexesToRemove []int
+}
+
+// GetPlaylists lists the playlists visible to the configured user.
+func (c *Client) GetPlaylists(ctx context.Context) ([]Playlist, error) {
+	var resp playlistsResponse
+	if err := c.doRequest(ctx, playlistsEndpoint, url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.Response.validate(); err != nil {
+		return nil, err
+	}
+
+	playlists := make([]Playlist, 0, len(resp.Response.Playlists.Playlists))
+	for _, item := range resp.Response.Playlists.Playlists {
+		playlists = append(playlists, item.toPlaylist())
+	}
+	return playlists, nil
+}
+
+// CreatePlaylist creates a playlist named name containing songIDs in order.
+func (c *Client) CreatePlaylist(ctx context.Context, name string, songIDs []string) (Playlist, error) {
+	if strings.TrimSpace(name) == "" {
+		return Playlist{}, errors.New("playlist name is required")
+	}
+	params := url.Values{}
+	params.Set("name", name)
+	for _, id := range songIDs {
+		params.Add("songId", id)
+	}
+
+	var resp playlistResponse
+	if err := c.doMutation(ctx, createPlaylistEndpoint, params, &resp); err != nil {
+		return Playlist{}, err
+	}
+	if err := resp.Response.validate(); err != nil {
+		return Playlist{}, err
+	}
+	return resp.Response.Playlist.toPlaylist(), nil
+}
+
+// UpdatePlaylist applies update to an existing playlist.
+func (c *Client) UpdatePlaylist(ctx context.Context, update PlaylistUpdate) error {
+	if strings.TrimSpace(update.ID) == "" {
+		return errors.New("playlist id is required")
+	}
+	params := url.Values{}
+	params.Set("playlistId", update.ID)
+	if update.Name != "" {
+		params.Set("name", update.Name)
+	}
+	if update.Comment != nil {
+		params.Set("comment", *update.Comment)
+	}
+	if update.Public != nil {
+		params.Set("public", strconv.FormatBool(*update.Public))
+	}
+	for _, index := range update.SongIndexesToRemove {
+		params.Add("songIndexToRemove", strconv.Itoa(index))
+	}
+	for _, id := range update.SongIDsToAdd {
+		params.Add("songIdToAdd", id)
+	}
+
+	var resp emptyResponse
+	if err := c.doMutation(ctx, updatePlaylistEndpoint, params, &resp); err != nil {
+		return err
+	}
+	return resp.Response.validate()
+}
+
+// DeletePlaylist removes the playlist with id.
+func (c *Client) DeletePlaylist(ctx context.Context, id string) error {
+	if strings.TrimSpace(id) == "" {
+		return errors.New("playlist id is required")
+	}
+	params := url.Values{}
+	params.Set("id", id)
+
+	var resp emptyResponse
+	if err := c.doMutation(ctx, deletePlaylistEndpoint, params, &resp); err != nil {
+		return err
+	}
+	return resp.Response.validate()
+}
+
+// doMutation issues a request that changes server state. These are never
+// retried: a request that timed out may still have been applied.
+func (c *Client) doMutation(ctx context.Context, endpoint string, params url.Values, target interface{}) error {
+	_, err := c.doRequestOnce(ctx, endpoint, params, target)
+	return err
+}
+
+type emptyResponse struct {
+	Response subsonicEnvelope `json:"subsonic-response"`
+}
+
+type playlistsResponse struct {
+	Response playlistsPayload `json:"subsonic-response"`
+}
+
+type playlistsPayload struct {
+	subsonicEnvelope
+	Playlists struct {
+		Playlists []playlistItem `json:"playlist"`
+	} `json:"playlists"`
+}
+
+type playlistResponse struct {
+	Response playlistPayload `json:"subsonic-response"`
+}
+
+type playlistPayload struct {
+	subsonicEnvelope
+	Playlist playlistItem `json:"playlist"`
+}
+
+type playlistItem struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Comment   string `json:"comment"`
+	Owner     string `json:"owner"`
+	Public    bool   `json:"public"`
+	SongCount int    `json:"songCount"`
+	Duration  int    `json:"duration"`
+	Created   string `json:"created"`
+	Changed   string `json:"changed"`
+}
+
+func (p playlistItem) toPlaylist() Playlist {
+	return Playlist{
+		ID:        p.ID,
+		Name:      p.Name,
+		Comment:   p.Comment,
+		Owner:     p.Owner,
+		Public:    p.Public,
+		SongCount: p.SongCount,
+		Duration:  time.Duration(p.Duration) * time.Second,
+		Created:   parseSubsonicTime(p.Created),
+		Changed:   parseSubsonicTime(p.Changed),
+	}
+}
@@ -0,0 +1,116 @@
+package navidrome
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlaylistEndpoints(t *testing.T) {
+	var requests []*http.Request
+	client := newPlaylistTestClient(t, func(req *http.Request) string {
+		requests = append(requests, req)
+		switch req.URL.Path {
+		case "/rest/getPlaylists.view":
+			return `{"subsonic-response":{"status":"ok","playlists":{"playlist":[{"id":"pl1","name":"Focus","comment":"made by playlistgen","owner":"user","public":false,"songCount":2,"duration":420,"created":"2024-05-01T10:00:00Z","changed":"2024-05-02T10:00:00Z"}]}}}`
+		case "/rest/createPlaylist.view":
+			return `{"subsonic-response":{"status":"ok","playlist":{"id":"pl2","name":"Warmup","songCount":2}}}`
+		case "/rest/updatePlaylist.view", "/rest/deletePlaylist.view":
+			return `{"subsonic-response":{"status":"ok"}}`
+		}
+		t.Fatalf("unexpected path %s", req.URL.Path)
+		return ""
+	})
+	ctx := context.Background()
+
+	playlists, err := client.GetPlaylists(ctx)
+	if err != nil {
+		t.Fatalf("GetPlaylists: %v", err)
+	}
+	want := Playlist{
+		ID:        "pl1",
+		Name:      "Focus",
+		Comment:   "made by playlistgen",
+		Owner:     "user",
+		SongCount: 2,
+		Duration:  7 * time.Minute,
+		Created:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Changed:   time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+	}
+	if len(playlists) != 1 || !reflect.DeepEqual(playlists[0], want) {
+		t.Fatalf("unexpected playlists %+v", playlists)
+	}
+
+	created, err := client.CreatePlaylist(ctx, "Warmup", []string{"s2", "s1"})
+	if err != nil {
+		t.Fatalf("CreatePlaylist: %v", err)
+	}
+	if created.ID != "pl2" || created.SongCount != 2 {
+		t.Fatalf("unexpected created playlist %+v", created)
+	}
+	if q := requests[1].URL.Query(); q.Get("name") != "Warmup" || !reflect.DeepEqual(q["songId"], []string{"s2", "s1"}) {
+		t.Fatalf("unexpected create query %v", q)
+	}
+
+	comment := "refreshed"
+	if err := client.UpdatePlaylist(ctx, PlaylistUpdate{
+		ID:                  "pl1",
+		Comment:             &comment,
+		SongIndexesToRemove: []int{0, 1},
+		SongIDsToAdd:        []string{"s3"},
+	}); err != nil {
+		t.Fatalf("UpdatePlaylist: %v", err)
+	}
+	q := requests[2].URL.Query()
+	if q.Get("playlistId") != "pl1" || q.Get("comment") != "refreshed" || q.Has("name") || q.Has("public") ||
+		!reflect.DeepEqual(q["songIndexToRemove"], []string{"0", "1"}) || !reflect.DeepEqual(q["songIdToAdd"], []string{"s3"}) {
+		t.Fatalf("unexpected update query %v", q)
+	}
+
+	if err := client.DeletePlaylist(ctx, "pl1"); err != nil {
+		t.Fatalf("DeletePlaylist: %v", err)
+	}
+	if requests[3].URL.Query().Get("id") != "pl1" {
+		t.Fatalf("unexpected delete query %v", requests[3].URL.Query())
+	}
+}
+
+func TestPlaylistEndpointsSurfaceSubsonicErrors(t *testing.T) {
+	client := newPlaylistTestClient(t, func(req *http.Request) string {
+		return `{"subsonic-response":{"status":"failed","error":{"code":70,"message":"Playlist not found"}}}`
+	})
+	err := client.DeletePlaylist(context.Background(), "missing")
+	if err == nil || !strings.Contains(err.Error(), "Playlist not found") {
+		t.Fatalf("expected subsonic error, got %v", err)
+	}
+	if err := client.UpdatePlaylist(context.Background(), PlaylistUpdate{}); err == nil {
+		t.Fatal("expected error for missing playlist id")
+	}
+	if _, err := client.CreatePlaylist(context.Background(), " ", nil); err == nil {
+		t.Fatal("expected error for missing playlist name")
+	}
+}
+
+func newPlaylistTestClient(t *testing.T, respond func(*http.Request) string) *Client {
+	t.Helper()
+	client, err := NewClient(Config{
+		BaseURL:  "https://navidrome.local",
+		Username: "user",
+		Password: "pass",
+		HTTPClient: mockHTTPClient(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(respond(req))),
+				Header:     make(http.Header),
+			}, nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	return client
+}
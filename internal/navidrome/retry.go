@@ -0,0 +1,81 @@
+package navidrome
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 4
+	defaultBaseDelay   = 500 * time.Millisecond
+	defaultMaxDelay    = 15 * time.Second
+)
+
+// RetryPolicy controls how read requests are retried on network errors, 5xx
+// and 429 responses. Zero fields take defaults; set MaxAttempts to 1 to
+// disable retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles each retry.
+	BaseDelay time.Duration
+	// MaxDelay caps both the backoff and any Retry-After the server sends.
+	MaxDelay time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultBaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultMaxDelay
+	}
+	return p
+}
+
+// backoff returns the jittered delay before retry number attempt (1-based):
+// a random duration between half and all of BaseDelay*2^(attempt-1), capped
+// at MaxDelay.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(delay-half)+1))
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an
+// HTTP date. It returns zero when the header is absent or unparseable.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(0, time.Duration(seconds)*time.Second)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, at.Sub(now))
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
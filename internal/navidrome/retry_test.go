@@ -0,0 +1,241 @@
+package navidrome
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const okAlbumList = `{"subsonic-response":{"status":"ok","albumList2":{"album":[]}}}`
+
+func TestDoRequestRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []stubResponse
+		wantCalls int
+		failed    bool
+		wantErr   error
+		check     func(t *testing.T, err error, delays []time.Duration)
+	}{
+		{
+			name:      "retries 5xx until success",
+			responses: []stubResponse{{status: 502}, {status: 503}, {status: 200, body: okAlbumList}},
+			wantCalls: 3,
+		},
+		{
+			name:      "retries network errors",
+			responses: []stubResponse{{err: errors.New("connection reset")}, {status: 200, body: okAlbumList}},
+			wantCalls: 2,
+		},
+		{
+			name:      "honors Retry-After on 429",
+			responses: []stubResponse{{status: 429, retryAfter: "3"}, {status: 200, body: okAlbumList}},
+			wantCalls: 2,
+			check: func(t *testing.T, err error, delays []time.Duration) {
+				if len(delays) != 1 || delays[0] != 3*time.Second {
+					t.Fatalf("expected a 3s delay, got %v", delays)
+				}
+			},
+		},
+		{
+			name:      "caps Retry-After at MaxDelay",
+			responses: []stubResponse{{status: 429, retryAfter: "3600"}, {status: 200, body: okAlbumList}},
+			wantCalls: 2,
+			check: func(t *testing.T, err error, delays []time.Duration) {
+				if len(delays) != 1 || delays[0] != 15*time.Second {
+					t.Fatalf("expected delay capped at 15s, got %v", delays)
+				}
+			},
+		},
+		{
+			name:      "gives up after max attempts",
+			responses: []stubResponse{{status: 500}, {status: 500}, {status: 500}, {status: 500}, {status: 200, body: okAlbumList}},
+			wantCalls: 4,
+			failed:    true,
+			check: func(t *testing.T, err error, delays []time.Duration) {
+				var status *StatusError
+				if !errors.As(err, &status) || status.StatusCode != 500 {
+					t.Fatalf("expected StatusError 500, got %v", err)
+				}
+				if len(delays) != 3 {
+					t.Fatalf("expected three backoffs, got %v", delays)
+				}
+			},
+		},
+		{
+			name:      "does not retry client errors",
+			responses: []stubResponse{{status: 404}, {status: 200, body: okAlbumList}},
+			wantCalls: 1,
+			failed:    true,
+		},
+		{
+			name:      "fails fast on wrong credentials",
+			responses: []stubResponse{{status: 200, body: `{"subsonic-response":{"status":"failed","error":{"code":40,"message":"Wrong username or password"}}}`}},
+			wantCalls: 1,
+			failed:    true,
+			wantErr:   ErrWrongCredentials,
+		},
+		{
+			name:      "maps token auth errors",
+			responses: []stubResponse{{status: 200, body: `{"subsonic-response":{"status":"failed","error":{"code":41,"message":"Token authentication not supported for LDAP users"}}}`}},
+			wantCalls: 1,
+			failed:    true,
+			wantErr:   ErrTokenAuthUnsupported,
+		},
+		{
+			name:      "maps authorization errors",
+			responses: []stubResponse{{status: 200, body: `{"subsonic-response":{"status":"failed","error":{"code":50,"message":"User is not authorized"}}}`}},
+			wantCalls: 1,
+			failed:    true,
+			wantErr:   ErrNotAuthorized,
+		},
+		{
+			name:      "treats 401 as not authorized",
+			responses: []stubResponse{{status: 401}},
+			wantCalls: 1,
+			failed:    true,
+			wantErr:   ErrNotAuthorized,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, calls, delays := newRetryTestClient(t, tc.responses)
+			_, err := client.ListTracks(context.Background())
+			if *calls != tc.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tc.wantCalls, *calls)
+			}
+			if (err != nil) != tc.failed {
+				t.Fatalf("unexpected error state: %v", err)
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			if tc.check != nil {
+				tc.check(t, err, *delays)
+			}
+		})
+	}
+}
+
+func TestDoRequestStopsWhenContextCancelledDuringBackoff(t *testing.T) {
+	client, calls, _ := newRetryTestClient(t, []stubResponse{{status: 502}, {status: 200, body: okAlbumList}})
+	ctx, cancel := context.WithCancel(context.Background())
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+	_, err := client.ListTracks(ctx)
+	var status *StatusError
+	if !errors.As(err, &status) || *calls != 1 {
+		t.Fatalf("expected the 502 after one call, got %v after %d calls", err, *calls)
+	}
+}
+
+func TestMutationsAreNotRetried(t *testing.T) {
+	client, calls, _ := newRetryTestClient(t, []stubResponse{{status: 502}, {status: 200, body: `{"subsonic-response":{"status":"ok","playlist":{"id":"p"}}}`}})
+	if _, err := client.CreatePlaylist(context.Background(), "Focus", []string{"s1"}); err == nil {
+		t.Fatal("expected error from 502")
+	}
+	if *calls != 1 {
+		t.Fatalf("expected a single create attempt, got %d", *calls)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}.withDefaults()
+	tests := []struct {
+		attempt int
+		lo, hi  time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+	}
+	for _, tc := range tests {
+		for range 50 {
+			if got := policy.backoff(tc.attempt); got < tc.lo || got > tc.hi {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", tc.attempt, got, tc.lo, tc.hi)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-3", 0},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 May 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tc := range tests {
+		if got := parseRetryAfter(tc.value, now); got != tc.want {
+			t.Fatalf("parseRetryAfter(%q) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+type stubResponse struct {
+	status     int
+	body       string
+	retryAfter string
+	err        error
+}
+
+// newRetryTestClient serves responses in order and records backoff delays
+// instead of sleeping. OpenSubsonic detection is answered with a 404 and is
+// not counted.
+func newRetryTestClient(t *testing.T, responses []stubResponse) (*Client, *int, *[]time.Duration) {
+	t.Helper()
+	calls := 0
+	var delays []time.Duration
+	client, err := NewClient(Config{
+		BaseURL:  "https://navidrome.local",
+		Username: "user",
+		Password: "pass",
+		HTTPClient: mockHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/rest/getOpenSubsonicExtensions.view" {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       io.NopCloser(strings.NewReader("")),
+					Header:     make(http.Header),
+				}, nil
+			}
+			if calls >= len(responses) {
+				t.Fatalf("unexpected extra request %d", calls+1)
+			}
+			r := responses[calls]
+			calls++
+			if r.err != nil {
+				return nil, r.err
+			}
+			header := make(http.Header)
+			if r.retryAfter != "" {
+				header.Set("Retry-After", r.retryAfter)
+			}
+			return &http.Response{
+				StatusCode: r.status,
+				Body:       io.NopCloser(strings.NewReader(r.body)),
+				Header:     header,
+			}, nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return client, &calls, &delays
+}
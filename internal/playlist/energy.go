@@ -0,0 +1,306 @@
+package playlist
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bowmanmike/playlistgen/internal/audio"
+)
+
+// EnergyProfile describes the desired energy of a playlist over its length.
+// Points are evenly spaced control values in [0, 1] that are linearly
+// interpolated across the playlist positions.
+type EnergyProfile struct {
+	Name   string
+	Points []float64
+}
+
+// Named energy profiles accepted by ParseEnergyProfile.
+var (
+	EnergyBuild      = EnergyProfile{Name: "build", Points: []float64{0, 1}}
+	EnergyPeakMiddle = EnergyProfile{Name: "peak-middle", Points: []float64{0.1, 1, 0.1}}
+	EnergyWindDown   = EnergyProfile{Name: "wind-down", Points: []float64{1, 0}}
+	EnergyFlat       = EnergyProfile{Name: "flat", Points: []float64{0.5, 0.5}}
+)
+
+// ParseEnergyProfile accepts a named profile (build, peak-middle, wind-down,
+// flat) or a comma-separated list of at least two values in [0, 1].
+func ParseEnergyProfile(value string) (EnergyProfile, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, named := range []EnergyProfile{EnergyBuild, EnergyPeakMiddle, EnergyWindDown, EnergyFlat} {
+		if value == named.Name {
+			return named, nil
+		}
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) < 2 {
+		return EnergyProfile{}, fmt.Errorf("unknown energy profile %q (want build, peak-middle, wind-down, flat, or comma-separated points)", value)
+	}
+	points := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return EnergyProfile{}, fmt.Errorf("parse energy point %q: %w", part, err)
+		}
+		if v < 0 || v > 1 {
+			return EnergyProfile{}, fmt.Errorf("energy point %v must be between 0 and 1", v)
+		}
+		points = append(points, v)
+	}
+	return EnergyProfile{Name: "custom", Points: points}, nil
+}
+
+// At returns the profile value at x, where 0 is the first track and 1 the last.
+func (p EnergyProfile) At(x float64) float64 {
+	switch len(p.Points) {
+	case 0:
+		return 0.5
+	case 1:
+		return p.Points[0]
+	}
+	x = clamp01(x)
+	pos := x * float64(len(p.Points)-1)
+	i := int(math.Floor(pos))
+	if i >= len(p.Points)-1 {
+		return p.Points[len(p.Points)-1]
+	}
+	frac := pos - float64(i)
+	return p.Points[i]*(1-frac) + p.Points[i+1]*frac
+}
+
+// Curve samples the profile at n evenly spaced playlist positions.
+func (p EnergyProfile) Curve(n int) []float64 {
+	curve := make([]float64, n)
+	for i := range curve {
+		x := 0.5
+		if n > 1 {
+			x = float64(i) / float64(n-1)
+		}
+		curve[i] = p.At(x)
+	}
+	return curve
+}
+
+// Energy scores a track's intensity in [0, 1] from whichever features are
+// available: loudness, tempo, brightness, onset density, noisiness and how
+// compressed the master is. The busyness features separate a loud drone from
+// a loud punk track. The second return value is false when no feature is
+// known.
+func Energy(f Features) (float64, bool) {
+	type component struct {
+		value  float64
+		weight float64
+	}
+	var parts []component
+	if lufs, ok := loudnessLUFS(f); ok {
+		parts = append(parts, component{scale(lufs, -20, -6), 0.5})
+	}
+	if f.TempoBPM != nil && *f.TempoBPM > 0 {
+		parts = append(parts, component{scale(*f.TempoBPM, 60, 180), 0.3})
+	}
+	if f.SpectralCentroidHz != nil && *f.SpectralCentroidHz > 0 {
+		parts = append(parts, component{scale(*f.SpectralCentroidHz, 500, 4000), 0.2})
+	}
+	if f.OnsetDensity != nil {
+		parts = append(parts, component{scale(*f.OnsetDensity, 0.5, 6), 0.3})
+	}
+	if f.ZeroCrossingRate != nil {
+		parts = append(parts, component{scale(*f.ZeroCrossingRate, 0.02, 0.2), 0.1})
+	}
+	if f.CrestFactorDB != nil && *f.CrestFactorDB > 0 {
+		// A low crest factor means a dense, heavily compressed master.
+		parts = append(parts, component{scale(*f.CrestFactorDB, 18, 6), 0.1})
+	}
+	if len(parts) == 0 {
+		return 0, false
+	}
+
+	var sum, weights float64
+	for _, part := range parts {
+		sum += part.value * part.weight
+		weights += part.weight
+	}
+	return sum / weights, true
+}
+
+// Texture describes how a track sounds independent of how loud it is, as
+// values in [0, 1]: brightness (spectral centroid and rolloff), dynamics
+// (loudness range and crest factor) and noisiness (zero-crossing rate). The
+// second return value is false unless every component is known.
+func Texture(f Features) ([]float64, bool) {
+	if f.SpectralCentroidHz == nil || f.SpectralRolloffHz == nil || f.LoudnessRange == nil ||
+		f.CrestFactorDB == nil || f.ZeroCrossingRate == nil {
+		return nil, false
+	}
+	return []float64{
+		scale(*f.SpectralCentroidHz, 500, 4000),
+		scale(*f.SpectralRolloffHz, 1000, 5500),
+		scale(*f.LoudnessRange, 2, 20),
+		scale(*f.CrestFactorDB, 6, 18),
+		scale(*f.ZeroCrossingRate, 0.02, 0.2),
+	}, true
+}
+
+func loudnessLUFS(f Features) (float64, bool) {
+	if f.IntegratedLUFS != nil {
+		return *f.IntegratedLUFS, true
+	}
+	if f.EffectiveGainDB == nil {
+		return 0, false
+	}
+	switch {
+	case f.EffectiveGainSource == "measured_integrated_lufs":
+		return *f.EffectiveGainDB, true
+	case strings.HasPrefix(f.EffectiveGainSource, "replaygain"),
+		strings.HasPrefix(f.EffectiveGainSource, "server_replaygain"),
+		f.EffectiveGainSource == "measured_album":
+		// ReplayGain gains are relative to the reference level, so the
+		// reference minus the gain recovers a loudness estimate.
+		return audio.ReplayGainReferenceLUFS - *f.EffectiveGainDB, true
+	}
+	return 0, false
+}
+
+// shapeEnergy reorders tracks so their relative energy follows profile, then
+// repairs any adjacency rules the reordering broke. It returns the reordered
+// tracks with the target and achieved curves.
+func shapeEnergy(tracks []Candidate, profile EnergyProfile, rules Rules) ([]Candidate, []float64, []float64) {
+	n := len(tracks)
+	target := profile.Curve(n)
+	if n == 0 {
+		return tracks, target, nil
+	}
+	energies := normalizedEnergies(tracks)
+
+	ordered := make([]Candidate, n)
+	orderedEnergy := make([]float64, n)
+	if isFlat(target) {
+		copy(ordered, tracks)
+		copy(orderedEnergy, energies)
+	} else {
+		// Pair the i-th lowest target position with the i-th lowest energy
+		// track, which minimizes the squared error between the two curves.
+		positions := indexes(n)
+		sort.SliceStable(positions, func(a, b int) bool { return target[positions[a]] < target[positions[b]] })
+		byEnergy := indexes(n)
+		sort.SliceStable(byEnergy, func(a, b int) bool { return energies[byEnergy[a]] < energies[byEnergy[b]] })
+		for i := range positions {
+			ordered[positions[i]] = tracks[byEnergy[i]]
+			orderedEnergy[positions[i]] = energies[byEnergy[i]]
+		}
+	}
+
+	repairAdjacency(ordered, orderedEnergy, target, rules)
+	return ordered, target, orderedEnergy
+}
+
+// repairAdjacency swaps tracks forward when the energy ordering placed two
+// tracks next to each other that the rules forbid. Among the allowed swaps it
+// picks the one whose energy is closest to the position's target.
+func repairAdjacency(tracks []Candidate, energies, target []float64, rules Rules) {
+	for i := 1; i < len(tracks); i++ {
+		if adjacentAllowed(tracks[:i], tracks[i], rules) {
+			continue
+		}
+		best := -1
+		bestDiff := math.Inf(1)
+		for j := i + 1; j < len(tracks); j++ {
+			if !adjacentAllowed(tracks[:i], tracks[j], rules) {
+				continue
+			}
+			if diff := math.Abs(energies[j] - target[i]); diff < bestDiff {
+				best, bestDiff = j, diff
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		tracks[i], tracks[best] = tracks[best], tracks[i]
+		energies[i], energies[best] = energies[best], energies[i]
+	}
+}
+
+func adjacentAllowed(sequence []Candidate, candidate Candidate, rules Rules) bool {
+	rules.MaxTracksPerArtist = 0
+	return allowed(candidate, sequence, nil, rules)
+}
+
+// normalizedEnergies rescales track energies to span [0, 1] within the
+// selection. Tracks without features take the median of the known values.
+func normalizedEnergies(tracks []Candidate) []float64 {
+	raw := make([]float64, len(tracks))
+	known := make([]bool, len(tracks))
+	var values []float64
+	for i, track := range tracks {
+		if e, ok := Energy(track.Features); ok {
+			raw[i], known[i] = e, true
+			values = append(values, e)
+		}
+	}
+	if len(values) == 0 {
+		out := make([]float64, len(tracks))
+		for i := range out {
+			out[i] = 0.5
+		}
+		return out
+	}
+
+	sort.Float64s(values)
+	median := values[len(values)/2]
+	lo, hi := values[0], values[len(values)-1]
+	out := make([]float64, len(tracks))
+	for i := range raw {
+		v := median
+		if known[i] {
+			v = raw[i]
+		}
+		if hi == lo {
+			out[i] = 0.5
+		} else {
+			out[i] = (v - lo) / (hi - lo)
+		}
+	}
+	return out
+}
+
+// CurveError is the root-mean-square difference between two curves.
+func CurveError(target, achieved []float64) float64 {
+	if len(target) == 0 || len(target) != len(achieved) {
+		return 0
+	}
+	var sum float64
+	for i := range target {
+		d := target[i] - achieved[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(target)))
+}
+
+func isFlat(curve []float64) bool {
+	for _, v := range curve {
+		if v != curve[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func indexes(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+func scale(v, lo, hi float64) float64 {
+	return clamp01((v - lo) / (hi - lo))
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,182 @@
+package playlist
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseEnergyProfile(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    EnergyProfile
+		wantErr bool
+	}{
+		{value: "build", want: EnergyBuild},
+		{value: " Peak-Middle ", want: EnergyPeakMiddle},
+		{value: "wind-down", want: EnergyWindDown},
+		{value: "flat", want: EnergyFlat},
+		{value: "0.2, 0.9,0.4", want: EnergyProfile{Name: "custom", Points: []float64{0.2, 0.9, 0.4}}},
+		{value: "sideways", wantErr: true},
+		{value: "0.5", wantErr: true},
+		{value: "0.2,1.5", wantErr: true},
+		{value: "0.2,loud", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseEnergyProfile(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Fatalf("ParseEnergyProfile(%q) expected error", tc.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ParseEnergyProfile(%q): %v", tc.value, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("ParseEnergyProfile(%q) = %+v, want %+v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestEnergyProfileCurve(t *testing.T) {
+	got := EnergyPeakMiddle.Curve(5)
+	want := []float64{0.1, 0.55, 1, 0.55, 0.1}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("unexpected curve %v, want %v", got, want)
+		}
+	}
+	if single := EnergyBuild.Curve(1); len(single) != 1 || single[0] != 0.5 {
+		t.Fatalf("unexpected single-point curve %v", single)
+	}
+}
+
+func TestTexture(t *testing.T) {
+	if _, ok := Texture(Features{SpectralCentroidHz: ptr(1000)}); ok {
+		t.Fatalf("expected incomplete features to have no texture")
+	}
+	got, ok := Texture(Features{
+		SpectralCentroidHz: ptr(4000),
+		SpectralRolloffHz:  ptr(1000),
+		LoudnessRange:      ptr(11),
+		CrestFactorDB:      ptr(30),
+		ZeroCrossingRate:   ptr(0.02),
+	})
+	if !ok || !reflect.DeepEqual(got, []float64{1, 0, 0.5, 1, 0}) {
+		t.Fatalf("unexpected texture %v, %v", got, ok)
+	}
+}
+
+func TestEnergy(t *testing.T) {
+	tests := []struct {
+		name     string
+		features Features
+		want     float64
+		ok       bool
+	}{
+		{name: "no features", features: Features{}},
+		{name: "measured loudness", features: Features{IntegratedLUFS: ptr(-13)}, want: 0.5, ok: true},
+		{name: "replaygain fallback", features: Features{EffectiveGainDB: ptr(-5), EffectiveGainSource: "replaygain_album"}, want: 0.5, ok: true},
+		{name: "server replaygain fallback", features: Features{EffectiveGainDB: ptr(-5), EffectiveGainSource: "server_replaygain_track"}, want: 0.5, ok: true},
+		{name: "measured album gain fallback", features: Features{EffectiveGainDB: ptr(-5), EffectiveGainSource: "measured_album"}, want: 0.5, ok: true},
+		{name: "measured gain fallback", features: Features{EffectiveGainDB: ptr(-6), EffectiveGainSource: "measured_integrated_lufs"}, want: 1, ok: true},
+		{name: "unknown gain source", features: Features{EffectiveGainDB: ptr(-6), EffectiveGainSource: "none"}},
+		{name: "loudness and tempo", features: Features{IntegratedLUFS: ptr(-20), TempoBPM: ptr(180)}, want: 0.375, ok: true},
+		{name: "busy beats raise a loud track", features: Features{IntegratedLUFS: ptr(-6), OnsetDensity: ptr(6), ZeroCrossingRate: ptr(0.2), CrestFactorDB: ptr(6)}, want: 1, ok: true},
+		{name: "a loud drone scores lower", features: Features{IntegratedLUFS: ptr(-6), OnsetDensity: ptr(0.5), ZeroCrossingRate: ptr(0.02), CrestFactorDB: ptr(18)}, want: 0.5, ok: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := Energy(tc.features)
+			if ok != tc.ok || math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("Energy() = %v, %v; want %v, %v", got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestBuildShapesEnergy(t *testing.T) {
+	candidates := []Candidate{
+		withLoudness(track("loud", "A", "A1", 3*time.Minute), -6),
+		withLoudness(track("quiet", "B", "B1", 3*time.Minute), -20),
+		withLoudness(track("mid", "C", "C1", 3*time.Minute), -13),
+		withLoudness(track("soft", "D", "D1", 3*time.Minute), -17),
+		withLoudness(track("hot", "E", "E1", 3*time.Minute), -9),
+	}
+	tests := []struct {
+		name    string
+		profile EnergyProfile
+		want    []string
+	}{
+		{name: "build", profile: EnergyBuild, want: []string{"quiet", "soft", "mid", "hot", "loud"}},
+		{name: "wind-down", profile: EnergyWindDown, want: []string{"loud", "hot", "mid", "soft", "quiet"}},
+		{name: "peak-middle", profile: EnergyPeakMiddle, want: []string{"quiet", "mid", "loud", "hot", "soft"}},
+		{name: "flat keeps selection order", profile: EnergyFlat, want: []string{"loud", "quiet", "mid", "soft", "hot"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := tc.profile
+			got, err := Build(candidates, Rules{TargetDuration: 15 * time.Minute, Energy: &profile})
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if ids := trackIDs(got); !reflect.DeepEqual(ids, tc.want) {
+				t.Fatalf("unexpected order %v, want %v", ids, tc.want)
+			}
+			if len(got.EnergyTarget) != 5 || len(got.EnergyAchieved) != 5 {
+				t.Fatalf("expected curves for every track, got %v and %v", got.EnergyTarget, got.EnergyAchieved)
+			}
+		})
+	}
+}
+
+func TestBuildShapesEnergyWithoutBreakingAdjacency(t *testing.T) {
+	candidates := []Candidate{
+		withLoudness(track("a1", "A", "Same", 3*time.Minute), -20),
+		withLoudness(track("a2", "B", "Same", 3*time.Minute), -18),
+		withLoudness(track("b1", "C", "Other", 3*time.Minute), -12),
+		withLoudness(track("b2", "D", "Other", 3*time.Minute), -8),
+	}
+	got, err := Build(candidates, Rules{TargetDuration: 12 * time.Minute, NoAdjacentAlbum: true, Energy: &EnergyBuild})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	want := []string{"a1", "b1", "a2", "b2"}
+	if ids := trackIDs(got); !reflect.DeepEqual(ids, want) {
+		t.Fatalf("unexpected order %v, want %v", ids, want)
+	}
+}
+
+func TestBuildLeavesOrderWithoutEnergyProfile(t *testing.T) {
+	candidates := []Candidate{
+		withLoudness(track("loud", "A", "A1", 3*time.Minute), -6),
+		withLoudness(track("quiet", "B", "B1", 3*time.Minute), -20),
+	}
+	got, err := Build(candidates, Rules{TargetDuration: 6 * time.Minute})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if ids := trackIDs(got); !reflect.DeepEqual(ids, []string{"loud", "quiet"}) || got.EnergyTarget != nil {
+		t.Fatalf("unexpected result %v target %v", ids, got.EnergyTarget)
+	}
+}
+
+func TestCurveError(t *testing.T) {
+	if got := CurveError([]float64{0, 1}, []float64{0, 1}); got != 0 {
+		t.Fatalf("expected zero error, got %v", got)
+	}
+	if got := CurveError([]float64{0, 0}, []float64{1, 1}); got != 1 {
+		t.Fatalf("expected error 1, got %v", got)
+	}
+}
+
+func withLoudness(c Candidate, lufs float64) Candidate {
+	c.Features.IntegratedLUFS = &lufs
+	return c
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,109 @@
+package playlist
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// harmonicWindow is how many places ahead orderHarmonically looks for a
+// compatible track. Keeping it small preserves the rank and energy order.
+const harmonicWindow = 3
+
+// HarmonicallyCompatible reports whether two Camelot codes mix well: the same
+// key, its relative major or minor, or one step around the wheel. Tracks with
+// an unknown key are compatible with everything.
+func HarmonicallyCompatible(a, b string) bool {
+	numA, letterA, okA := parseCamelot(a)
+	numB, letterB, okB := parseCamelot(b)
+	if !okA || !okB {
+		return true
+	}
+	if numA == numB {
+		return true
+	}
+	if letterA != letterB {
+		return false
+	}
+	diff := (numA - numB + 12) % 12
+	return diff == 1 || diff == 11
+}
+
+// parseCamelot splits a code such as "8A" into its wheel number and letter.
+func parseCamelot(code string) (int, byte, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) < 2 {
+		return 0, 0, false
+	}
+	letter := code[len(code)-1]
+	if letter != 'A' && letter != 'B' {
+		return 0, 0, false
+	}
+	num, err := strconv.Atoi(code[:len(code)-1])
+	if err != nil || num < 1 || num > 12 {
+		return 0, 0, false
+	}
+	return num, letter, true
+}
+
+// orderHarmonically swaps in a track from the next few places whenever two
+// neighbours clash, as long as the swap keeps the adjacency rules. With an
+// energy profile it picks the swap closest to the position's target, and
+// without one the nearest, so the rank order moves as little as possible.
+// energies may be nil. It returns the number of clashes left.
+func orderHarmonically(tracks []Candidate, energies, target []float64, rules Rules) int {
+	for i := 1; i < len(tracks); i++ {
+		previous := tracks[i-1].Features.CamelotKey
+		if HarmonicallyCompatible(previous, tracks[i].Features.CamelotKey) {
+			continue
+		}
+		best := -1
+		bestDiff := math.Inf(1)
+		for j := i + 1; j < len(tracks) && j <= i+harmonicWindow; j++ {
+			if !HarmonicallyCompatible(previous, tracks[j].Features.CamelotKey) {
+				continue
+			}
+			if !swapAllowed(tracks, i, j, rules) {
+				continue
+			}
+			diff := 0.0
+			if energies != nil {
+				diff = math.Abs(energies[j] - target[i])
+			}
+			if diff < bestDiff {
+				best, bestDiff = j, diff
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		tracks[i], tracks[best] = tracks[best], tracks[i]
+		if energies != nil {
+			energies[i], energies[best] = energies[best], energies[i]
+		}
+	}
+	return harmonicClashes(tracks)
+}
+
+// swapAllowed reports whether exchanging tracks i and j keeps every
+// adjacency rule from position i onwards.
+func swapAllowed(tracks []Candidate, i, j int, rules Rules) bool {
+	tracks[i], tracks[j] = tracks[j], tracks[i]
+	defer func() { tracks[i], tracks[j] = tracks[j], tracks[i] }()
+	for k := i; k < len(tracks) && k <= j+1; k++ {
+		if !adjacentAllowed(tracks[:k], tracks[k], rules) {
+			return false
+		}
+	}
+	return true
+}
+
+func harmonicClashes(tracks []Candidate) int {
+	clashes := 0
+	for i := 1; i < len(tracks); i++ {
+		if !HarmonicallyCompatible(tracks[i-1].Features.CamelotKey, tracks[i].Features.CamelotKey) {
+			clashes++
+		}
+	}
+	return clashes
+}
@@ -0,0 +1,79 @@
+package playlist
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHarmonicallyCompatible(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"8A", "8A", true},
+		{"8A", "8B", true},
+		{"8A", "9A", true},
+		{"12B", "1B", true},
+		{"1a", "12A", true},
+		{"8A", "10A", false},
+		{"8A", "9B", false},
+		{"8A", "", true},
+		{"bogus", "3B", true},
+	}
+	for _, tc := range tests {
+		if got := HarmonicallyCompatible(tc.a, tc.b); got != tc.want {
+			t.Fatalf("%q vs %q: got %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestBuildHarmonicSwapsClashingNeighbours(t *testing.T) {
+	candidates := []Candidate{
+		withKey(track("a", "Artist A", "Album A", 3*time.Minute), "8A"),
+		withKey(track("b", "Artist B", "Album B", 3*time.Minute), "3B"),
+		withKey(track("c", "Artist C", "Album C", 3*time.Minute), "9A"),
+		withKey(track("d", "Artist D", "Album D", 3*time.Minute), "3A"),
+	}
+	rules := Rules{TargetDuration: 12 * time.Minute}
+
+	plain, err := Build(candidates, rules)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if plain.HarmonicClashes != 0 || !reflect.DeepEqual(trackIDs(plain), []string{"a", "b", "c", "d"}) {
+		t.Fatalf("expected rank order without harmonic, got %v", trackIDs(plain))
+	}
+
+	rules.Harmonic = true
+	got, err := Build(candidates, rules)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if ids := trackIDs(got); !reflect.DeepEqual(ids, []string{"a", "c", "b", "d"}) {
+		t.Fatalf("unexpected harmonic order %v", ids)
+	}
+	if got.HarmonicClashes != 1 {
+		t.Fatalf("expected the unavoidable 9A to 3B clash to remain, got %d", got.HarmonicClashes)
+	}
+}
+
+func TestBuildHarmonicKeepsAdjacencyRules(t *testing.T) {
+	candidates := []Candidate{
+		withKey(track("a", "Artist A", "Album A", 3*time.Minute), "8A"),
+		withKey(track("b", "Artist B", "Album B", 3*time.Minute), "3B"),
+		withKey(track("a2", "Artist A", "Album A2", 3*time.Minute), "8B"),
+	}
+	got, err := Build(candidates, Rules{TargetDuration: 9 * time.Minute, MinArtistGap: 1, Harmonic: true})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if ids := trackIDs(got); !reflect.DeepEqual(ids, []string{"a", "b", "a2"}) {
+		t.Fatalf("expected the artist gap to win over key, got %v", ids)
+	}
+}
+
+func withKey(c Candidate, camelot string) Candidate {
+	c.Features.CamelotKey = camelot
+	return c
+}
@@ -0,0 +1,258 @@
+// Package playlist turns a ranked list of candidate tracks into an ordered
+// playlist using deterministic, rule-based selection.
+package playlist
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+const defaultTolerancePercent = 5
+
+// Features are the stored audio measurements available for a candidate.
+// Nil fields mean the track has not been analyzed for that feature.
+type Features struct {
+	DurationSeconds     *float64
+	IntegratedLUFS      *float64
+	TruePeak            *float64
+	EffectiveGainDB     *float64
+	EffectiveGainSource string
+	TempoBPM            *float64
+	SpectralCentroidHz  *float64
+	SpectralRolloffHz   *float64
+	LoudnessRange       *float64
+	CrestFactorDB       *float64
+	ZeroCrossingRate    *float64
+	OnsetDensity        *float64
+	// CamelotKey is the track's key as a Camelot code such as "8A", or empty
+	// when unknown.
+	CamelotKey string
+	// DuplicateGroupID is shared by versions of the same recording, and 0
+	// when the track has no known duplicates. Build picks at most one track
+	// from each group.
+	DuplicateGroupID int64
+	// ContentStartSeconds and ContentEndSeconds bound the audible content of
+	// the file, when known.
+	ContentStartSeconds *float64
+	ContentEndSeconds   *float64
+	// FlowsIntoNext marks a track that runs straight into the next one on
+	// its album. Build keeps such runs together.
+	FlowsIntoNext bool
+}
+
+// Candidate is one track offered to the engine. Candidates are passed in rank
+// order, best first.
+type Candidate struct {
+	TrackID  int64
+	Track    app.Track
+	Features Features
+}
+
+// Duration prefers the measured file duration over the Navidrome-reported one.
+func (c Candidate) Duration() time.Duration {
+	if c.Features.DurationSeconds != nil {
+		return time.Duration(*c.Features.DurationSeconds * float64(time.Second))
+	}
+	return c.Track.Duration
+}
+
+// Rules constrain how a playlist is assembled. Zero values disable the
+// corresponding constraint unless noted otherwise.
+type Rules struct {
+	// TargetDuration is required.
+	TargetDuration time.Duration
+	// Tolerance defaults to 5% of TargetDuration.
+	Tolerance time.Duration
+	MaxTracks int
+	// MaxTracksPerArtist caps how many tracks one artist may contribute.
+	MaxTracksPerArtist int
+	// MinArtistGap is the minimum number of other tracks between two tracks
+	// by the same artist.
+	MinArtistGap int
+	// NoAdjacentAlbum forbids two consecutive tracks from the same album.
+	NoAdjacentAlbum bool
+	// Variety lets each candidate drift up to this many places from its rank
+	// before selection, using a generator seeded with Seed.
+	Variety int
+	Seed    uint64
+	// Energy, when set, reorders the selected tracks to follow the profile.
+	Energy *EnergyProfile
+	// Harmonic nudges the final order so neighbouring tracks have compatible
+	// keys. It runs after Energy and only swaps tracks a few places apart.
+	Harmonic bool
+	// Signals filter and boost candidates by the listener's stars, ratings
+	// and plays.
+	Signals Signals
+}
+
+// Playlist is the ordered result of Build.
+type Playlist struct {
+	Tracks   []Candidate
+	Duration time.Duration
+	// WithinTolerance reports whether Duration landed inside the target window.
+	WithinTolerance bool
+	// EnergyTarget and EnergyAchieved hold one value per track when an energy
+	// profile was requested.
+	EnergyTarget   []float64
+	EnergyAchieved []float64
+	// HarmonicClashes counts neighbouring tracks with incompatible keys when
+	// Harmonic was requested.
+	HarmonicClashes int
+}
+
+// ErrNoCandidates is returned when Build is called without candidates.
+var ErrNoCandidates = errors.New("no candidate tracks")
+
+// Build selects and orders candidates according to rules. Given the same
+// input and seed it always returns the same playlist.
+func Build(candidates []Candidate, rules Rules) (Playlist, error) {
+	if rules.TargetDuration <= 0 {
+		return Playlist{}, errors.New("target duration must be greater than zero")
+	}
+	if len(candidates) == 0 {
+		return Playlist{}, ErrNoCandidates
+	}
+	if rules.Tolerance <= 0 {
+		rules.Tolerance = rules.TargetDuration * defaultTolerancePercent / 100
+	}
+
+	runs := flowRuns(rankedPool(candidates, rules))
+	minDuration := rules.TargetDuration - rules.Tolerance
+	maxDuration := rules.TargetDuration + rules.Tolerance
+
+	var (
+		result       Playlist
+		artistCounts = make(map[string]int)
+		groups       = make(map[int64]struct{})
+	)
+	for result.Duration < minDuration {
+		if rules.MaxTracks > 0 && len(result.Tracks) >= rules.MaxTracks {
+			break
+		}
+		next := -1
+		for i, run := range runs {
+			d := runDuration(run)
+			if d <= 0 || result.Duration+d > maxDuration {
+				continue
+			}
+			if rules.MaxTracks > 0 && len(result.Tracks)+len(run) > rules.MaxTracks {
+				continue
+			}
+			if !runAllowed(run, result.Tracks, artistCounts, groups, rules) {
+				continue
+			}
+			next = i
+			break
+		}
+		if next < 0 {
+			break
+		}
+
+		chosen := runs[next]
+		runs = append(runs[:next], runs[next+1:]...)
+		for _, track := range chosen {
+			result.Tracks = append(result.Tracks, track)
+			result.Duration += track.Duration()
+			artistCounts[artistKey(track.Track)]++
+			if group := track.Features.DuplicateGroupID; group != 0 {
+				groups[group] = struct{}{}
+			}
+		}
+	}
+
+	result.WithinTolerance = result.Duration >= minDuration && result.Duration <= maxDuration
+	if rules.Energy != nil {
+		result.Tracks, result.EnergyTarget, result.EnergyAchieved = shapeEnergy(result.Tracks, *rules.Energy, rules)
+	}
+	if rules.Harmonic {
+		result.HarmonicClashes = orderHarmonically(result.Tracks, result.EnergyAchieved, result.EnergyTarget, rules)
+	}
+	if joinRuns(result.Tracks, result.EnergyAchieved) && rules.Harmonic {
+		result.HarmonicClashes = harmonicClashes(result.Tracks)
+	}
+	return result, nil
+}
+
+// rankedPool copies candidates, drops duplicate tracks and those filtered out
+// by Signals, and applies signal boosts and the seeded Variety jitter to the
+// rank order.
+func rankedPool(candidates []Candidate, rules Rules) []Candidate {
+	type ranked struct {
+		candidate Candidate
+		key       float64
+	}
+
+	rng := rand.New(rand.NewPCG(rules.Seed, rules.Seed^0x9e3779b97f4a7c15))
+	seen := make(map[string]struct{}, len(candidates))
+	items := make([]ranked, 0, len(candidates))
+	for i, candidate := range candidates {
+		id := candidate.Track.ID
+		if _, ok := seen[id]; ok && id != "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		if !rules.Signals.keep(candidate.Track.Stats) {
+			continue
+		}
+		key := float64(i) - rules.Signals.boost(candidate.Track.Stats)
+		if rules.Variety > 0 {
+			key += rng.Float64() * float64(rules.Variety)
+		}
+		items = append(items, ranked{candidate: candidate, key: key})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].key < items[j].key })
+
+	pool := make([]Candidate, len(items))
+	for i, item := range items {
+		pool[i] = item.candidate
+	}
+	return pool
+}
+
+func allowed(candidate Candidate, sequence []Candidate, artistCounts map[string]int, rules Rules) bool {
+	artist := artistKey(candidate.Track)
+	if rules.MaxTracksPerArtist > 0 && artistCounts[artist] >= rules.MaxTracksPerArtist {
+		return false
+	}
+	if len(sequence) == 0 {
+		return true
+	}
+	// A track continuing a flow run may follow its album and artist.
+	if flowsInto(sequence[len(sequence)-1], candidate) {
+		return true
+	}
+	if rules.NoAdjacentAlbum && albumKey(sequence[len(sequence)-1].Track) == albumKey(candidate.Track) {
+		return false
+	}
+	if rules.MinArtistGap > 0 {
+		start := len(sequence) - rules.MinArtistGap
+		if start < 0 {
+			start = 0
+		}
+		for _, previous := range sequence[start:] {
+			if artistKey(previous.Track) == artist {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func artistKey(track app.Track) string {
+	if track.ArtistID != "" {
+		return track.ArtistID
+	}
+	return strings.ToLower(strings.TrimSpace(track.Artist))
+}
+
+func albumKey(track app.Track) string {
+	if track.AlbumID != "" {
+		return track.AlbumID
+	}
+	return strings.ToLower(strings.TrimSpace(track.AlbumArtist)) + "\x00" + strings.ToLower(strings.TrimSpace(track.Album))
+}
@@ -0,0 +1,216 @@
+package playlist
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		rules      Rules
+		want       []string
+		within     bool
+	}{
+		{
+			name: "fills target in rank order",
+			candidates: []Candidate{
+				track("a", "Artist A", "Album A", 4*time.Minute),
+				track("b", "Artist B", "Album B", 4*time.Minute),
+				track("c", "Artist C", "Album C", 4*time.Minute),
+				track("d", "Artist D", "Album D", 4*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 12 * time.Minute},
+			want:   []string{"a", "b", "c"},
+			within: true,
+		},
+		{
+			name: "skips tracks that overshoot and backfills shorter ones",
+			candidates: []Candidate{
+				track("a", "Artist A", "Album A", 6*time.Minute),
+				track("long", "Artist B", "Album B", 9*time.Minute),
+				track("c", "Artist C", "Album C", 4*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 10 * time.Minute, Tolerance: 30 * time.Second},
+			want:   []string{"a", "c"},
+			within: true,
+		},
+		{
+			name: "picks one version of each recording",
+			candidates: []Candidate{
+				inDuplicateGroup(track("album", "Artist A", "Album A", 4*time.Minute), 1),
+				track("b", "Artist B", "Album B", 4*time.Minute),
+				inDuplicateGroup(track("live", "Artist A", "Live", 4*time.Minute), 1),
+				inDuplicateGroup(track("c", "Artist C", "Album C", 4*time.Minute), 2),
+				track("d", "Artist D", "Album D", 4*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 16 * time.Minute},
+			want:   []string{"album", "b", "c", "d"},
+			within: true,
+		},
+		{
+			name: "caps tracks per artist",
+			candidates: []Candidate{
+				track("a1", "Artist A", "Album A", 3*time.Minute),
+				track("a2", "Artist A", "Album A2", 3*time.Minute),
+				track("a3", "Artist A", "Album A3", 3*time.Minute),
+				track("b1", "Artist B", "Album B", 3*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 9 * time.Minute, MaxTracksPerArtist: 2},
+			want:   []string{"a1", "a2", "b1"},
+			within: true,
+		},
+		{
+			name: "no back-to-back album",
+			candidates: []Candidate{
+				track("x1", "Artist X", "Album X", 3*time.Minute),
+				track("x2", "Artist Y", "Album X", 3*time.Minute),
+				track("z1", "Artist Z", "Album Z", 3*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 9 * time.Minute, NoAdjacentAlbum: true},
+			want:   []string{"x1", "z1", "x2"},
+			within: true,
+		},
+		{
+			name: "minimum artist gap",
+			candidates: []Candidate{
+				track("a1", "Artist A", "Album A1", 3*time.Minute),
+				track("a2", "Artist A", "Album A2", 3*time.Minute),
+				track("b1", "Artist B", "Album B", 3*time.Minute),
+				track("c1", "Artist C", "Album C", 3*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 12 * time.Minute, MinArtistGap: 2},
+			want:   []string{"a1", "b1", "c1", "a2"},
+			within: true,
+		},
+		{
+			name: "max tracks stops early",
+			candidates: []Candidate{
+				track("a", "Artist A", "Album A", 3*time.Minute),
+				track("b", "Artist B", "Album B", 3*time.Minute),
+				track("c", "Artist C", "Album C", 3*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 9 * time.Minute, MaxTracks: 2},
+			want:   []string{"a", "b"},
+			within: false,
+		},
+		{
+			name: "constraints can leave playlist short",
+			candidates: []Candidate{
+				track("a1", "Artist A", "Album A", 5*time.Minute),
+				track("a2", "Artist A", "Album A", 5*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 10 * time.Minute, MaxTracksPerArtist: 1},
+			want:   []string{"a1"},
+			within: false,
+		},
+		{
+			name: "prefers measured duration",
+			candidates: []Candidate{
+				withMeasuredDuration(track("a", "Artist A", "Album A", 10*time.Minute), 120),
+				track("b", "Artist B", "Album B", 3*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 5 * time.Minute},
+			want:   []string{"a", "b"},
+			within: true,
+		},
+		{
+			name: "drops duplicate tracks",
+			candidates: []Candidate{
+				track("a", "Artist A", "Album A", 3*time.Minute),
+				track("a", "Artist A", "Album A", 3*time.Minute),
+				track("b", "Artist B", "Album B", 3*time.Minute),
+			},
+			rules:  Rules{TargetDuration: 6 * time.Minute},
+			want:   []string{"a", "b"},
+			within: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Build(tc.candidates, tc.rules)
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if ids := trackIDs(got); !reflect.DeepEqual(ids, tc.want) {
+				t.Fatalf("unexpected tracks %v, want %v", ids, tc.want)
+			}
+			if got.WithinTolerance != tc.within {
+				t.Fatalf("unexpected within tolerance %v (duration %v)", got.WithinTolerance, got.Duration)
+			}
+		})
+	}
+}
+
+func TestBuildIsDeterministicForSeed(t *testing.T) {
+	var candidates []Candidate
+	for i := 0; i < 40; i++ {
+		candidates = append(candidates, track(fmt.Sprintf("t%02d", i), fmt.Sprintf("Artist %d", i%7), fmt.Sprintf("Album %d", i%11), 3*time.Minute))
+	}
+	rules := Rules{TargetDuration: 30 * time.Minute, Variety: 10, Seed: 42, MinArtistGap: 2}
+
+	first, err := Build(candidates, rules)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	second, err := Build(candidates, rules)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if !reflect.DeepEqual(trackIDs(first), trackIDs(second)) {
+		t.Fatalf("same seed produced different playlists: %v vs %v", trackIDs(first), trackIDs(second))
+	}
+
+	rules.Seed = 7
+	third, err := Build(candidates, rules)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if reflect.DeepEqual(trackIDs(first), trackIDs(third)) {
+		t.Fatalf("expected a different seed to change the playlist")
+	}
+}
+
+func TestBuildValidatesInput(t *testing.T) {
+	if _, err := Build([]Candidate{track("a", "A", "A", time.Minute)}, Rules{}); err == nil {
+		t.Fatal("expected error for missing target duration")
+	}
+	if _, err := Build(nil, Rules{TargetDuration: time.Minute}); !errors.Is(err, ErrNoCandidates) {
+		t.Fatalf("expected ErrNoCandidates, got %v", err)
+	}
+}
+
+func track(id, artist, album string, duration time.Duration) Candidate {
+	return Candidate{Track: app.Track{
+		ID:       id,
+		Title:    id,
+		Artist:   artist,
+		Album:    album,
+		Duration: duration,
+	}}
+}
+
+func withMeasuredDuration(c Candidate, seconds float64) Candidate {
+	c.Features.DurationSeconds = &seconds
+	return c
+}
+
+func inDuplicateGroup(c Candidate, group int64) Candidate {
+	c.Features.DuplicateGroupID = group
+	return c
+}
+
+func trackIDs(p Playlist) []string {
+	ids := make([]string, 0, len(p.Tracks))
+	for _, c := range p.Tracks {
+		ids = append(ids, c.Track.ID)
+	}
+	return ids
+}
@@ -0,0 +1,133 @@
+package playlist
+
+import (
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+// flowRuns groups tracks that flow into each other, as on live and concept
+// albums, into runs in album order. Every other track is a run of its own.
+// Runs are ordered by the position of their first track in tracks, so a
+// ranked pool gives runs ranked by their best track.
+func flowRuns(tracks []Candidate) [][]Candidate {
+	indexes := flowRunIndexes(tracks)
+	runs := make([][]Candidate, len(indexes))
+	for r, run := range indexes {
+		for _, i := range run {
+			runs[r] = append(runs[r], tracks[i])
+		}
+	}
+	return runs
+}
+
+// flowRunIndexes is flowRuns with each run given as indexes into tracks.
+func flowRunIndexes(tracks []Candidate) [][]int {
+	next := make([]int, len(tracks))
+	previous := make([]int, len(tracks))
+	for i := range tracks {
+		next[i], previous[i] = -1, -1
+	}
+	for i, track := range tracks {
+		if !track.Features.FlowsIntoNext {
+			continue
+		}
+		for j, other := range tracks {
+			if previous[j] < 0 && i != j && flowsInto(track, other) {
+				next[i], previous[j] = j, i
+				break
+			}
+		}
+	}
+
+	runs := make([][]int, 0, len(tracks))
+	placed := make([]bool, len(tracks))
+	for i := range tracks {
+		if placed[i] {
+			continue
+		}
+		head := i
+		for previous[head] >= 0 {
+			head = previous[head]
+		}
+		var run []int
+		for k := head; k >= 0; k = next[k] {
+			run = append(run, k)
+			placed[k] = true
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// joinRuns moves the tracks of each flow run back together where the first
+// of them is, after reordering split them. energies, when not nil, is moved
+// alongside. It reports whether anything moved.
+func joinRuns(tracks []Candidate, energies []float64) bool {
+	joined := make([]Candidate, 0, len(tracks))
+	joinedEnergies := make([]float64, 0, len(energies))
+	moved := false
+	for _, run := range flowRunIndexes(tracks) {
+		for _, i := range run {
+			moved = moved || i != len(joined)
+			joined = append(joined, tracks[i])
+			if energies != nil {
+				joinedEnergies = append(joinedEnergies, energies[i])
+			}
+		}
+	}
+	copy(tracks, joined)
+	copy(energies, joinedEnergies)
+	return moved
+}
+
+// flowsInto reports whether a runs straight into b: a is flagged as flowing
+// into the next track on its album, and b is that track.
+func flowsInto(a, b Candidate) bool {
+	if !a.Features.FlowsIntoNext || a.Track.TrackNumber == nil || b.Track.TrackNumber == nil {
+		return false
+	}
+	return albumKey(a.Track) == albumKey(b.Track) &&
+		discNumber(a.Track) == discNumber(b.Track) &&
+		*b.Track.TrackNumber == *a.Track.TrackNumber+1
+}
+
+func discNumber(track app.Track) int {
+	if track.DiscNumber == nil {
+		return 1
+	}
+	return *track.DiscNumber
+}
+
+func runDuration(run []Candidate) time.Duration {
+	var total time.Duration
+	for _, track := range run {
+		d := track.Duration()
+		if d <= 0 {
+			return 0
+		}
+		total += d
+	}
+	return total
+}
+
+// runAllowed reports whether run may come next: its first track must follow
+// the sequence under the rules, no track may repeat a chosen duplicate group,
+// and the whole run must fit under the per-artist cap.
+func runAllowed(run, sequence []Candidate, artistCounts map[string]int, groups map[int64]struct{}, rules Rules) bool {
+	if !allowed(run[0], sequence, artistCounts, rules) {
+		return false
+	}
+	counts := make(map[string]int, len(run))
+	for _, track := range run {
+		if _, ok := groups[track.Features.DuplicateGroupID]; ok {
+			return false
+		}
+		artist := artistKey(track.Track)
+		counts[artist]++
+		if rules.MaxTracksPerArtist > 0 && artistCounts[artist]+counts[artist] > rules.MaxTracksPerArtist {
+			return false
+		}
+	}
+	return true
+}
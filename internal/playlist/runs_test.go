@@ -0,0 +1,103 @@
+package playlist
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildKeepsFlowRunsTogether(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		rules      Rules
+		want       []string
+	}{
+		{
+			name: "run is placed whole in album order despite adjacency rules",
+			candidates: []Candidate{
+				onAlbum(track("live2", "Band", "Live", 3*time.Minute), 2, true),
+				track("x", "X", "X", 3*time.Minute),
+				onAlbum(track("live1", "Band", "Live", 3*time.Minute), 1, true),
+				onAlbum(track("live3", "Band", "Live", 3*time.Minute), 3, false),
+				track("y", "Y", "Y", 3*time.Minute),
+			},
+			rules: Rules{TargetDuration: 12 * time.Minute, NoAdjacentAlbum: true, MinArtistGap: 1},
+			want:  []string{"live1", "live2", "live3", "x"},
+		},
+		{
+			name: "run that overshoots is skipped whole",
+			candidates: []Candidate{
+				onAlbum(track("live1", "Band", "Live", 4*time.Minute), 1, true),
+				onAlbum(track("live2", "Band", "Live", 4*time.Minute), 2, false),
+				track("x", "X", "X", 3*time.Minute),
+				track("y", "Y", "Y", 3*time.Minute),
+			},
+			rules: Rules{TargetDuration: 6 * time.Minute, Tolerance: time.Minute},
+			want:  []string{"x", "y"},
+		},
+		{
+			name: "run must fit under the artist cap",
+			candidates: []Candidate{
+				onAlbum(track("live1", "Band", "Live", 3*time.Minute), 1, true),
+				onAlbum(track("live2", "Band", "Live", 3*time.Minute), 2, false),
+				track("x", "X", "X", 3*time.Minute),
+				track("y", "Y", "Y", 3*time.Minute),
+			},
+			rules: Rules{TargetDuration: 6 * time.Minute, MaxTracksPerArtist: 1},
+			want:  []string{"x", "y"},
+		},
+		{
+			name: "tracks on other discs do not flow",
+			candidates: []Candidate{
+				onDisc(onAlbum(track("d1", "Band", "Live", 3*time.Minute), 1, true), 1),
+				track("x", "X", "X", 3*time.Minute),
+				onDisc(onAlbum(track("d2", "Band", "Live", 3*time.Minute), 2, false), 2),
+			},
+			rules: Rules{TargetDuration: 9 * time.Minute, NoAdjacentAlbum: true},
+			want:  []string{"d1", "x", "d2"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Build(tc.candidates, tc.rules)
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if ids := trackIDs(got); !reflect.DeepEqual(ids, tc.want) {
+				t.Fatalf("unexpected order %v, want %v", ids, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildRejoinsFlowRunsAfterEnergyShaping(t *testing.T) {
+	candidates := []Candidate{
+		withLoudness(onAlbum(track("live1", "Band", "Live", 3*time.Minute), 1, true), -6),
+		withLoudness(onAlbum(track("live2", "Band", "Live", 3*time.Minute), 2, false), -20),
+		withLoudness(track("mid", "C", "C1", 3*time.Minute), -13),
+		withLoudness(track("soft", "D", "D1", 3*time.Minute), -17),
+	}
+	got, err := Build(candidates, Rules{TargetDuration: 12 * time.Minute, Energy: &EnergyBuild})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	want := []string{"live1", "live2", "soft", "mid"}
+	if ids := trackIDs(got); !reflect.DeepEqual(ids, want) {
+		t.Fatalf("unexpected order %v, want %v", ids, want)
+	}
+	if len(got.EnergyAchieved) != 4 || got.EnergyAchieved[0] <= got.EnergyAchieved[1] {
+		t.Fatalf("expected achieved energy to follow the tracks, got %v", got.EnergyAchieved)
+	}
+}
+
+func onAlbum(c Candidate, number int, flowsIntoNext bool) Candidate {
+	c.Track.TrackNumber = &number
+	c.Features.FlowsIntoNext = flowsIntoNext
+	return c
+}
+
+func onDisc(c Candidate, disc int) Candidate {
+	c.Track.DiscNumber = &disc
+	return c
+}
@@ -0,0 +1,60 @@
+package playlist
+
+import (
+	"math"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+// Signals filter and re-rank candidates using the listening stats synced from
+// Navidrome. Boosts are measured in rank places: a boost of 3 moves a track
+// three places earlier, and negative boosts push tracks later.
+type Signals struct {
+	StarredOnly bool
+	// MinRating drops tracks rated below it; unrated tracks count as 0.
+	MinRating int
+	// SkipPlayedWithin drops tracks played more recently than this before Now.
+	SkipPlayedWithin time.Duration
+	// Now anchors SkipPlayedWithin and defaults to the current time.
+	Now time.Time
+
+	StarredBoost float64
+	// RatingBoost is applied per star above or below 3; unrated tracks get none.
+	RatingBoost float64
+	// PlayCountBoost is applied per doubling of the play count.
+	PlayCountBoost float64
+}
+
+func (s Signals) keep(stats app.UserStats) bool {
+	if s.StarredOnly && stats.StarredAt.IsZero() {
+		return false
+	}
+	if s.MinRating > 0 && stats.Rating < s.MinRating {
+		return false
+	}
+	if s.SkipPlayedWithin > 0 && !stats.LastPlayedAt.IsZero() {
+		now := s.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		if now.Sub(stats.LastPlayedAt) < s.SkipPlayedWithin {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Signals) boost(stats app.UserStats) float64 {
+	var boost float64
+	if !stats.StarredAt.IsZero() {
+		boost += s.StarredBoost
+	}
+	if stats.Rating > 0 {
+		boost += float64(stats.Rating-3) * s.RatingBoost
+	}
+	if stats.PlayCount > 0 {
+		boost += math.Log2(1+float64(stats.PlayCount)) * s.PlayCountBoost
+	}
+	return boost
+}
@@ -0,0 +1,49 @@
+package playlist
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestBuildAppliesSignals(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	candidates := []Candidate{
+		withStats(track("plain", "A", "A1", 3*time.Minute), app.UserStats{}),
+		withStats(track("disliked", "B", "B1", 3*time.Minute), app.UserStats{Rating: 1}),
+		withStats(track("loved", "C", "C1", 3*time.Minute), app.UserStats{StarredAt: now.Add(-24 * time.Hour), Rating: 5}),
+		withStats(track("recent", "D", "D1", 3*time.Minute), app.UserStats{Rating: 4, PlayCount: 31, LastPlayedAt: now.Add(-time.Hour)}),
+	}
+	tests := []struct {
+		name    string
+		signals Signals
+		want    []string
+	}{
+		{name: "no signals keeps rank order", want: []string{"plain", "disliked", "loved", "recent"}},
+		{name: "starred only", signals: Signals{StarredOnly: true}, want: []string{"loved"}},
+		{name: "minimum rating", signals: Signals{MinRating: 4}, want: []string{"loved", "recent"}},
+		{name: "skip recently played", signals: Signals{SkipPlayedWithin: 2 * time.Hour, Now: now}, want: []string{"plain", "disliked", "loved"}},
+		{name: "starred boost", signals: Signals{StarredBoost: 3}, want: []string{"loved", "plain", "disliked", "recent"}},
+		{name: "rating boost", signals: Signals{RatingBoost: 1}, want: []string{"plain", "loved", "recent", "disliked"}},
+		{name: "play count boost", signals: Signals{PlayCountBoost: 1}, want: []string{"recent", "plain", "disliked", "loved"}},
+		{name: "negative play count boost favors fresh tracks", signals: Signals{PlayCountBoost: -1}, want: []string{"plain", "disliked", "loved", "recent"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Build(candidates, Rules{TargetDuration: 12 * time.Minute, Signals: tc.signals})
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if ids := trackIDs(got); !reflect.DeepEqual(ids, tc.want) {
+				t.Fatalf("unexpected tracks %v, want %v", ids, tc.want)
+			}
+		})
+	}
+}
+
+func withStats(c Candidate, stats app.UserStats) Candidate {
+	c.Track.Stats = stats
+	return c
+}
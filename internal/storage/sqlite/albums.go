@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/audio"
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// AlbumAudioFeatures is the stored loudness of an album as a whole.
+type AlbumAudioFeatures struct {
+	AlbumID         string
+	TrackCount      int
+	DurationSeconds float64
+	Loudness        audio.AlbumLoudness
+	ComputedAt      time.Time
+}
+
+// AlbumAudioFeatures returns the stored loudness of an album. The second
+// return value is false when the album has none, because some of its tracks
+// are not measured yet.
+func (s *Store) AlbumAudioFeatures(ctx context.Context, albumID string) (AlbumAudioFeatures, bool, error) {
+	row, err := db.New(s.db).GetAlbumAudioFeatures(ctx, albumID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AlbumAudioFeatures{}, false, nil
+	}
+	if err != nil {
+		return AlbumAudioFeatures{}, false, fmt.Errorf("get album audio features: %w", err)
+	}
+	return AlbumAudioFeatures{
+		AlbumID:         row.AlbumID,
+		TrackCount:      int(row.TrackCount),
+		DurationSeconds: row.DurationSeconds,
+		Loudness: audio.AlbumLoudness{
+			IntegratedLUFS: row.IntegratedLufs,
+			TruePeak:       float64PtrFromSQL(row.TruePeak),
+		},
+		ComputedAt: parseTimestamp(row.ComputedAt),
+	}, true, nil
+}
+
+// TrackLoudness is an active track with its measured loudness. Album is nil
+// unless every track of its album has been measured.
+type TrackLoudness struct {
+	TrackID        int64
+	Track          app.Track
+	IntegratedLUFS float64
+	TruePeak       *float64
+	Album          *audio.AlbumLoudness
+}
+
+// ListTrackLoudness returns every active track with measured loudness,
+// ordered by path.
+func (s *Store) ListTrackLoudness(ctx context.Context) ([]TrackLoudness, error) {
+	rows, err := db.New(s.db).ListTrackLoudness(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list track loudness: %w", err)
+	}
+	tracks := make([]TrackLoudness, 0, len(rows))
+	for _, row := range rows {
+		track := TrackLoudness{
+			TrackID:        row.Track.ID,
+			Track:          convertDBTrack(row.Track),
+			IntegratedLUFS: row.MeasuredIntegratedLufs.Float64,
+			TruePeak:       float64PtrFromSQL(row.MeasuredTruePeak),
+		}
+		if row.AlbumIntegratedLufs.Valid {
+			track.Album = &audio.AlbumLoudness{
+				IntegratedLUFS: row.AlbumIntegratedLufs.Float64,
+				TruePeak:       float64PtrFromSQL(row.AlbumTruePeak),
+			}
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks, nil
+}
+
+// UpdateAlbumLoudness recomputes the loudness of every album whose active
+// tracks have all been measured and replaces the stored album features with
+// the result. It then recomputes the effective gain and peak of every track
+// on an album, so tracks without ReplayGain tags use their album's loudness
+// and tracks on albums that are no longer complete go back to their own. It
+// returns the number of albums stored.
+func (s *Store) UpdateAlbumLoudness(ctx context.Context, computedAt time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin album loudness: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := db.New(tx)
+	incompleteIDs, err := queries.ListIncompleteAlbumIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list incomplete albums: %w", err)
+	}
+	incomplete := make(map[string]struct{}, len(incompleteIDs))
+	for _, id := range incompleteIDs {
+		incomplete[id.String] = struct{}{}
+	}
+	rows, err := queries.ListAlbumLoudnessInputs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list album loudness inputs: %w", err)
+	}
+	if err := queries.ClearAlbumAudioFeatures(ctx); err != nil {
+		return 0, fmt.Errorf("clear album audio features: %w", err)
+	}
+
+	albums := 0
+	for start := 0; start < len(rows); {
+		albumID := rows[start].AlbumID.String
+		end := start
+		for end < len(rows) && rows[end].AlbumID.String == albumID {
+			end++
+		}
+		tracks := rows[start:end]
+		start = end
+
+		var album *audio.AlbumLoudness
+		if _, ok := incomplete[albumID]; !ok {
+			stored, err := storeAlbumLoudness(ctx, queries, albumID, tracks, computedAt)
+			if err != nil {
+				return 0, err
+			}
+			if stored != nil {
+				album = stored
+				albums++
+			}
+		}
+		for _, track := range tracks {
+			if err := updateEffectiveGain(ctx, queries, track, album); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit album loudness: %w", err)
+	}
+	return albums, nil
+}
+
+// storeAlbumLoudness aggregates and stores the loudness of a complete album.
+// It returns nil when the album is silent.
+func storeAlbumLoudness(ctx context.Context, queries *db.Queries, albumID string, tracks []db.ListAlbumLoudnessInputsRow, computedAt time.Time) (*audio.AlbumLoudness, error) {
+	inputs := make([]audio.AlbumTrackLoudness, 0, len(tracks))
+	duration := 0.0
+	for _, track := range tracks {
+		inputs = append(inputs, audio.AlbumTrackLoudness{
+			DurationSeconds: track.FileDurationSeconds,
+			IntegratedLUFS:  track.MeasuredIntegratedLufs.Float64,
+			TruePeak:        float64PtrFromSQL(track.MeasuredTruePeak),
+		})
+		duration += track.FileDurationSeconds
+	}
+	album, ok := audio.AggregateAlbumLoudness(inputs)
+	if !ok {
+		return nil, nil
+	}
+	if err := queries.InsertAlbumAudioFeatures(ctx, db.InsertAlbumAudioFeaturesParams{
+		AlbumID:         albumID,
+		TrackCount:      int64(len(tracks)),
+		DurationSeconds: duration,
+		IntegratedLufs:  album.IntegratedLUFS,
+		TruePeak:        nullFloat64Ptr(album.TruePeak),
+		ComputedAt:      formatTimestamp(computedAt.UTC()),
+	}); err != nil {
+		return nil, fmt.Errorf("insert album audio features for %s: %w", albumID, err)
+	}
+	return &album, nil
+}
+
+// updateEffectiveGain recomputes a track's effective gain and peak with album
+// and writes them when they changed.
+func updateEffectiveGain(ctx context.Context, queries *db.Queries, track db.ListAlbumLoudnessInputsRow, album *audio.AlbumLoudness) error {
+	raw := audio.RawReplayGain{
+		TrackGainDB: float64PtrFromSQL(track.ReplaygainTrackGainDb),
+		TrackPeak:   float64PtrFromSQL(track.ReplaygainTrackPeak),
+		AlbumGainDB: float64PtrFromSQL(track.ReplaygainAlbumGainDb),
+		AlbumPeak:   float64PtrFromSQL(track.ReplaygainAlbumPeak),
+	}
+	server := audio.RawReplayGain{
+		TrackGainDB: float64PtrFromSQL(track.ServerReplaygainTrackGainDb),
+		TrackPeak:   float64PtrFromSQL(track.ServerReplaygainTrackPeak),
+		AlbumGainDB: float64PtrFromSQL(track.ServerReplaygainAlbumGainDb),
+		AlbumPeak:   float64PtrFromSQL(track.ServerReplaygainAlbumPeak),
+	}
+	measured := audio.MeasuredAudio{
+		IntegratedLUFS: float64PtrFromSQL(track.MeasuredIntegratedLufs),
+		TruePeak:       float64PtrFromSQL(track.MeasuredTruePeak),
+	}
+	effective := audio.EffectiveValues(raw, server, album, measured)
+	gain, peak := nullFloat64Ptr(effective.GainDB), nullFloat64Ptr(effective.Peak)
+	if gain == track.EffectiveGainDb && peak == track.EffectivePeak &&
+		effective.GainSource == track.EffectiveGainSource && effective.PeakSource == track.EffectivePeakSource {
+		return nil
+	}
+	if err := queries.UpdateTrackEffectiveGain(ctx, db.UpdateTrackEffectiveGainParams{
+		EffectiveGainDb:     gain,
+		EffectivePeak:       peak,
+		EffectiveGainSource: effective.GainSource,
+		EffectivePeakSource: effective.PeakSource,
+		TrackID:             track.TrackID,
+	}); err != nil {
+		return fmt.Errorf("update effective gain for track %d: %w", track.TrackID, err)
+	}
+	return nil
+}
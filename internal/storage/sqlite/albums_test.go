@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestUpdateAlbumLoudness(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "albums.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	tracks := []app.Track{
+		{ID: "full-1", AlbumID: "full"},
+		{ID: "full-2", AlbumID: "full"},
+		{ID: "tagged", AlbumID: "full"},
+		{ID: "partial-1", AlbumID: "partial"},
+		{ID: "partial-2", AlbumID: "partial"},
+	}
+	for i := range tracks {
+		tracks[i].Title, tracks[i].Artist, tracks[i].Album = tracks[i].ID, "Artist", tracks[i].AlbumID
+		tracks[i].CreatedAt = time.Unix(9000, 0)
+		tracks[i].Path = "/music/" + tracks[i].ID + ".flac"
+	}
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tracks); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+
+	ids := make(map[string]int64, len(tracks))
+	for _, track := range tracks {
+		ids[track.ID] = trackIDByNavidromeID(t, store, track.ID)
+	}
+	measure := func(id string, lufs, peak float64, tagGain *float64) {
+		t.Helper()
+		if err := store.UpsertTrackAudioFeatures(ctx, AudioFeatureRecord{
+			TrackID:                ids[id],
+			AnalyzedAt:             time.Now(),
+			FileDurationSeconds:    100,
+			MeasuredIntegratedLUFS: &lufs,
+			MeasuredTruePeak:       &peak,
+			ReplayGainTrackGainDB:  tagGain,
+			EffectiveGainDB:        &lufs,
+			EffectivePeak:          &peak,
+			EffectiveGainSource:    "measured_integrated_lufs",
+			EffectivePeakSource:    "measured_true_peak",
+		}); err != nil {
+			t.Fatalf("upsert features: %v", err)
+		}
+	}
+	tagGain := -4.0
+	measure("full-1", -20, -3, nil)
+	measure("full-2", -10, -1, nil)
+	measure("tagged", -10, -2, &tagGain)
+	measure("partial-1", -14, -1, nil)
+
+	albums, err := store.UpdateAlbumLoudness(ctx, time.Unix(9500, 0))
+	if err != nil {
+		t.Fatalf("update album loudness: %v", err)
+	}
+	if albums != 1 {
+		t.Fatalf("expected 1 complete album, got %d", albums)
+	}
+	full, ok, err := store.AlbumAudioFeatures(ctx, "full")
+	if err != nil || !ok {
+		t.Fatalf("expected album features, got ok=%v err=%v", ok, err)
+	}
+	wantLUFS := 10 * math.Log10((math.Pow(10, -2)+2*math.Pow(10, -1))/3)
+	if full.TrackCount != 3 || full.DurationSeconds != 300 || math.Abs(full.Loudness.IntegratedLUFS-wantLUFS) > 1e-9 ||
+		full.Loudness.TruePeak == nil || *full.Loudness.TruePeak != -1 || !full.ComputedAt.Equal(time.Unix(9500, 0)) {
+		t.Fatalf("unexpected album features %+v", full)
+	}
+	if _, ok, err := store.AlbumAudioFeatures(ctx, "partial"); err != nil || ok {
+		t.Fatalf("expected no features for a partly measured album, got ok=%v err=%v", ok, err)
+	}
+
+	assertGain := func(id, source string, gain float64) {
+		t.Helper()
+		candidates, err := store.LoadTrackCandidates(ctx, []int64{ids[id]})
+		if err != nil {
+			t.Fatalf("load candidates: %v", err)
+		}
+		features := candidates[0].Features
+		if features.EffectiveGainSource != source || features.EffectiveGainDB == nil || math.Abs(*features.EffectiveGainDB-gain) > 1e-9 {
+			t.Fatalf("expected %s gain %v from %s, got %+v", id, gain, source, features)
+		}
+	}
+	loudness, err := store.ListTrackLoudness(ctx)
+	if err != nil {
+		t.Fatalf("list track loudness: %v", err)
+	}
+	if len(loudness) != 4 || loudness[0].Track.ID != "full-1" || loudness[0].IntegratedLUFS != -20 ||
+		loudness[0].Album == nil || loudness[0].Album.IntegratedLUFS != full.Loudness.IntegratedLUFS ||
+		loudness[2].Track.ID != "partial-1" || loudness[2].Album != nil {
+		t.Fatalf("unexpected track loudness %+v", loudness)
+	}
+
+	assertGain("full-1", "measured_album", -18-wantLUFS)
+	assertGain("full-2", "measured_album", -18-wantLUFS)
+	assertGain("tagged", "replaygain_track", tagGain)
+	assertGain("partial-1", "measured_integrated_lufs", -14)
+
+	// Measuring the rest of the album completes it; a new track on it makes
+	// it incomplete again and its tracks fall back to their own loudness.
+	measure("partial-2", -14, -1, nil)
+	if _, err := store.UpdateAlbumLoudness(ctx, time.Unix(9600, 0)); err != nil {
+		t.Fatalf("update album loudness: %v", err)
+	}
+	assertGain("partial-1", "measured_album", -4)
+
+	added := append(tracks, app.Track{ID: "partial-3", AlbumID: "partial", Title: "partial-3", Artist: "Artist", Album: "partial", CreatedAt: time.Unix(9000, 0), Path: "/music/partial-3.flac"})
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), added); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	if _, err := store.UpdateAlbumLoudness(ctx, time.Unix(9700, 0)); err != nil {
+		t.Fatalf("update album loudness: %v", err)
+	}
+	assertGain("partial-1", "measured_integrated_lufs", -14)
+	if _, ok, err := store.AlbumAudioFeatures(ctx, "partial"); err != nil || ok {
+		t.Fatalf("expected album features to be dropped, got ok=%v err=%v", ok, err)
+	}
+}
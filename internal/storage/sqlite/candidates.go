@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/audio"
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// TrackCandidate is a track considered for a generated playlist, together with
+// whatever audio features have been measured for it so far. Track.Stats holds
+// the synced user stats.
+type TrackCandidate struct {
+	TrackID  int64
+	Track    app.Track
+	Features CandidateFeatures
+}
+
+// CandidateFeatures holds the stored audio features used by playlist rules.
+// Fields are nil when the track has not been analyzed yet, except that the
+// effective gain and tempo fall back to what the server reports.
+type CandidateFeatures struct {
+	FileDurationSeconds *float64
+	IntegratedLUFS      *float64
+	TruePeak            *float64
+	EffectiveGainDB     *float64
+	EffectiveGainSource string
+	TempoBPM            *float64
+	TempoSource         string
+	CamelotKey          string
+	LoudnessRange       *float64
+	SpectralCentroidHz  *float64
+	SpectralRolloffHz   *float64
+	CrestFactorDB       *float64
+	ZeroCrossingRate    *float64
+	OnsetDensity        *float64
+	// DuplicateGroupID is shared by tracks found to be the same recording,
+	// and 0 when the track is in no duplicate group.
+	DuplicateGroupID int64
+	// ContentStartSeconds and ContentEndSeconds bound the audible content
+	// and are nil when unknown. FlowsIntoNext marks a track that runs
+	// straight into the next one on its album.
+	ContentStartSeconds *float64
+	ContentEndSeconds   *float64
+	FlowsIntoNext       bool
+}
+
+// LoadTrackCandidates loads tracks and their audio features, preserving the
+// order of trackIDs. IDs that no longer exist are skipped.
+func (s *Store) LoadTrackCandidates(ctx context.Context, trackIDs []int64) ([]TrackCandidate, error) {
+	if len(trackIDs) == 0 {
+		return nil, nil
+	}
+	queries := db.New(s.db)
+	rows, err := queries.ListTracksWithAudioFeaturesByIDs(ctx, trackIDs)
+	if err != nil {
+		return nil, fmt.Errorf("load track candidates: %w", err)
+	}
+	metadata, err := loadExtendedMetadata(ctx, queries, trackIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]TrackCandidate, len(rows))
+	for _, row := range rows {
+		track := convertDBTrack(row.Track)
+		track.Stats = userStatsFromSQL(row.StarredAt, row.Rating.Int64, row.PlayCount.Int64, row.LastPlayedAt)
+		track.Extended = metadata[row.Track.ID]
+		features := CandidateFeatures{
+			FileDurationSeconds: float64PtrFromSQL(row.FileDurationSeconds),
+			IntegratedLUFS:      float64PtrFromSQL(row.MeasuredIntegratedLufs),
+			TruePeak:            float64PtrFromSQL(row.MeasuredTruePeak),
+			EffectiveGainDB:     float64PtrFromSQL(row.EffectiveGainDb),
+			EffectiveGainSource: row.EffectiveGainSource.String,
+			TempoBPM:            float64PtrFromSQL(row.EffectiveBpm),
+			TempoSource:         row.EffectiveBpmSource.String,
+			CamelotKey:          row.CamelotKey.String,
+			LoudnessRange:       float64PtrFromSQL(row.MeasuredLoudnessRange),
+			SpectralCentroidHz:  float64PtrFromSQL(row.SpectralCentroidHz),
+			SpectralRolloffHz:   float64PtrFromSQL(row.SpectralRolloffHz),
+			CrestFactorDB:       float64PtrFromSQL(row.CrestFactorDb),
+			ZeroCrossingRate:    float64PtrFromSQL(row.ZeroCrossingRate),
+			OnsetDensity:        float64PtrFromSQL(row.OnsetDensity),
+			DuplicateGroupID:    row.DuplicateGroupID.Int64,
+			ContentStartSeconds: float64PtrFromSQL(row.ContentStartSeconds),
+			ContentEndSeconds:   float64PtrFromSQL(row.ContentEndSeconds),
+			FlowsIntoNext:       row.FlowsIntoNext.Int64 == 1,
+		}
+		if features.EffectiveGainDB == nil {
+			server := audio.EffectiveValues(audio.RawReplayGain{}, audio.ServerReplayGain(track.Extended.ReplayGain), nil, audio.MeasuredAudio{})
+			if server.GainDB != nil {
+				features.EffectiveGainDB = server.GainDB
+				features.EffectiveGainSource = server.GainSource
+			}
+		}
+		if features.TempoBPM == nil {
+			server := audio.ServerMetadata(track.Extended)
+			if bpm, source := audio.EffectiveTempo(nil, server.BPM, audio.MeasuredAudio{}); bpm != nil {
+				features.TempoBPM = bpm
+				features.TempoSource = source
+			}
+		}
+		byID[row.Track.ID] = TrackCandidate{
+			TrackID:  row.Track.ID,
+			Track:    track,
+			Features: features,
+		}
+	}
+
+	candidates := make([]TrackCandidate, 0, len(rows))
+	for _, id := range trackIDs {
+		if candidate, ok := byID[id]; ok {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
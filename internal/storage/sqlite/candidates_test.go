@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLoadTrackCandidatesPreservesOrderAndFeatures(t *testing.T) {
+	store, trackIDs := seedEmbeddedTracks(t)
+
+	lufs := -9.5
+	measuredBPM, confidence, tagBPM, lra := 123.9, 0.7, 124.0, 6.5
+	if err := store.UpsertTrackAudioFeatures(context.Background(), AudioFeatureRecord{
+		TrackID:                 trackIDs["vec-north"],
+		AnalyzedAt:              time.Now().UTC(),
+		FileDurationSeconds:     151.5,
+		MeasuredIntegratedLUFS:  &lufs,
+		EffectiveGainSource:     "measured_integrated_lufs",
+		EffectivePeakSource:     "none",
+		MeasuredTempoBPM:        &measuredBPM,
+		MeasuredTempoConfidence: &confidence,
+		TagBPM:                  &tagBPM,
+		EffectiveBPM:            &tagBPM,
+		EffectiveBPMSource:      "tag_bpm",
+		MeasuredKey:             "A",
+		MeasuredMode:            "minor",
+		MeasuredKeyConfidence:   &confidence,
+		CamelotKey:              "8A",
+		MeasuredLoudnessRange:   &lra,
+		Spectral:                &SpectralFeatureRecord{Version: 1, CentroidHz: 1800, RolloffHz: 3900, CrestFactorDB: 9.5, ZeroCrossingRate: 0.08, OnsetDensity: 3.2},
+	}); err != nil {
+		t.Fatalf("upsert audio features: %v", err)
+	}
+
+	ids := []int64{trackIDs["vec-north"], 99999, trackIDs["vec-east"]}
+	candidates, err := store.LoadTrackCandidates(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("load candidates: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(candidates))
+	}
+	if candidates[0].Track.ID != "vec-north" || candidates[1].Track.ID != "vec-east" {
+		t.Fatalf("unexpected candidate order %s, %s", candidates[0].Track.ID, candidates[1].Track.ID)
+	}
+	north := candidates[0].Features
+	if north.FileDurationSeconds == nil || *north.FileDurationSeconds != 151.5 || north.IntegratedLUFS == nil || *north.IntegratedLUFS != lufs || north.EffectiveGainSource != "measured_integrated_lufs" {
+		t.Fatalf("unexpected features %+v", north)
+	}
+	if north.TempoBPM == nil || *north.TempoBPM != tagBPM || north.TempoSource != "tag_bpm" || north.CamelotKey != "8A" {
+		t.Fatalf("unexpected tempo %+v", north)
+	}
+	if north.LoudnessRange == nil || *north.LoudnessRange != lra || north.SpectralCentroidHz == nil || *north.SpectralCentroidHz != 1800 ||
+		north.OnsetDensity == nil || *north.OnsetDensity != 3.2 || north.CrestFactorDB == nil || *north.CrestFactorDB != 9.5 {
+		t.Fatalf("unexpected spectral features %+v", north)
+	}
+	if candidates[1].Features.FileDurationSeconds != nil || candidates[1].Features.TempoBPM != nil || candidates[1].Features.CamelotKey != "" || candidates[1].Features.OnsetDensity != nil {
+		t.Fatalf("expected no features for unanalyzed track, got %+v", candidates[1].Features)
+	}
+}
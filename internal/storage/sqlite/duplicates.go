@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// TrackAcousticFingerprint is an active track with its stored acoustic
+// fingerprint.
+type TrackAcousticFingerprint struct {
+	TrackID     int64
+	Track       app.Track
+	Fingerprint []uint32
+}
+
+// ListAcousticFingerprints returns every active track that has an acoustic
+// fingerprint, ordered by track ID.
+func (s *Store) ListAcousticFingerprints(ctx context.Context) ([]TrackAcousticFingerprint, error) {
+	rows, err := db.New(s.db).ListAcousticFingerprints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list acoustic fingerprints: %w", err)
+	}
+	tracks := make([]TrackAcousticFingerprint, 0, len(rows))
+	for _, row := range rows {
+		fingerprint, err := decodeAcousticFingerprint(row.AcousticFingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("track %d: %w", row.Track.ID, err)
+		}
+		tracks = append(tracks, TrackAcousticFingerprint{
+			TrackID:     row.Track.ID,
+			Track:       convertDBTrack(row.Track),
+			Fingerprint: fingerprint,
+		})
+	}
+	return tracks, nil
+}
+
+// SetDuplicateGroups replaces every stored duplicate group with groups, which
+// maps track IDs to the ID of their group. Tracks not in groups are left
+// without one.
+func (s *Store) SetDuplicateGroups(ctx context.Context, groups map[int64]int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin duplicate groups: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := db.New(tx)
+	if err := queries.ClearDuplicateGroups(ctx); err != nil {
+		return fmt.Errorf("clear duplicate groups: %w", err)
+	}
+	for trackID, groupID := range groups {
+		if err := queries.SetDuplicateGroup(ctx, db.SetDuplicateGroupParams{
+			DuplicateGroupID: sql.NullInt64{Int64: groupID, Valid: true},
+			TrackID:          trackID,
+		}); err != nil {
+			return fmt.Errorf("set duplicate group for track %d: %w", trackID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit duplicate groups: %w", err)
+	}
+	return nil
+}
+
+// encodeAcousticFingerprint packs sub-fingerprints as little-endian uint32
+// values. An empty fingerprint is stored as NULL.
+func encodeAcousticFingerprint(fingerprint []uint32) []byte {
+	if len(fingerprint) == 0 {
+		return nil
+	}
+	buf := make([]byte, 4*len(fingerprint))
+	for i, v := range fingerprint {
+		binary.LittleEndian.PutUint32(buf[i*4:], v)
+	}
+	return buf
+}
+
+func decodeAcousticFingerprint(buf []byte) ([]uint32, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("acoustic fingerprint blob length %d is not a multiple of 4", len(buf))
+	}
+	fingerprint := make([]uint32, len(buf)/4)
+	for i := range fingerprint {
+		fingerprint[i] = binary.LittleEndian.Uint32(buf[i*4:])
+	}
+	return fingerprint, nil
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAcousticFingerprintsAndDuplicateGroups(t *testing.T) {
+	ctx := context.Background()
+	store, trackIDs := seedEmbeddedTracks(t)
+	east, north := trackIDs["vec-east"], trackIDs["vec-north"]
+
+	fingerprints := map[int64][]uint32{east: {1, 2, 0xdeadbeef}, north: {1, 2, 3}}
+	for trackID, fingerprint := range fingerprints {
+		if err := store.UpsertTrackAudioFeatures(ctx, AudioFeatureRecord{
+			TrackID:             trackID,
+			AnalyzedAt:          time.Now(),
+			EffectiveGainSource: "none",
+			EffectivePeakSource: "none",
+			AcousticFingerprint: fingerprint,
+		}); err != nil {
+			t.Fatalf("upsert features: %v", err)
+		}
+	}
+	if err := store.UpsertTrackAudioFeatures(ctx, AudioFeatureRecord{
+		TrackID:             trackIDs["vec-northeast"],
+		AnalyzedAt:          time.Now(),
+		EffectiveGainSource: "none",
+		EffectivePeakSource: "none",
+	}); err != nil {
+		t.Fatalf("upsert features without fingerprint: %v", err)
+	}
+
+	listed, err := store.ListAcousticFingerprints(ctx)
+	if err != nil {
+		t.Fatalf("list fingerprints: %v", err)
+	}
+	if len(listed) != 2 || listed[0].TrackID != east || listed[1].TrackID != north || listed[1].Track.ID != "vec-north" {
+		t.Fatalf("unexpected fingerprints %+v", listed)
+	}
+	if !slices.Equal(listed[0].Fingerprint, fingerprints[east]) {
+		t.Fatalf("expected fingerprint %v, got %v", fingerprints[east], listed[0].Fingerprint)
+	}
+
+	if err := store.SetDuplicateGroups(ctx, map[int64]int64{east: east, north: east}); err != nil {
+		t.Fatalf("set groups: %v", err)
+	}
+	// Re-analysis keeps the group.
+	if err := store.UpsertTrackAudioFeatures(ctx, AudioFeatureRecord{
+		TrackID:             north,
+		AnalyzedAt:          time.Now(),
+		EffectiveGainSource: "none",
+		EffectivePeakSource: "none",
+		AcousticFingerprint: fingerprints[north],
+	}); err != nil {
+		t.Fatalf("re-upsert features: %v", err)
+	}
+	candidates, err := store.LoadTrackCandidates(ctx, []int64{east, north, trackIDs["vec-northeast"]})
+	if err != nil {
+		t.Fatalf("load candidates: %v", err)
+	}
+	if candidates[0].Features.DuplicateGroupID != east || candidates[1].Features.DuplicateGroupID != east ||
+		candidates[2].Features.DuplicateGroupID != 0 {
+		t.Fatalf("unexpected groups %d, %d, %d", candidates[0].Features.DuplicateGroupID,
+			candidates[1].Features.DuplicateGroupID, candidates[2].Features.DuplicateGroupID)
+	}
+
+	if err := store.SetDuplicateGroups(ctx, map[int64]int64{}); err != nil {
+		t.Fatalf("clear groups: %v", err)
+	}
+	candidates, err = store.LoadTrackCandidates(ctx, []int64{east, north})
+	if err != nil {
+		t.Fatalf("load candidates: %v", err)
+	}
+	if candidates[0].Features.DuplicateGroupID != 0 || candidates[1].Features.DuplicateGroupID != 0 {
+		t.Fatalf("expected groups to be cleared, got %+v", candidates)
+	}
+}
@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// EmbeddingJob represents a pending embedding task and its associated track.
+type EmbeddingJob struct {
+	ID      int64
+	TrackID int64
+	Track   app.Track
+}
+
+// EmbeddingRecord stores the embedding vector generated for a track.
+type EmbeddingRecord struct {
+	TrackID    int64
+	Model      string
+	Vector     []float32
+	Document   string
+	EmbeddedAt time.Time
+}
+
+// ClaimPendingEmbeddingJobs atomically claims pending or stale embedding jobs.
+func (s *Store) ClaimPendingEmbeddingJobs(ctx context.Context, opts ClaimOptions) ([]EmbeddingJob, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+	if strings.TrimSpace(opts.ClaimedBy) == "" {
+		opts.ClaimedBy = "embed-process"
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now().UTC()
+	}
+
+	claimedAt := sql.NullString{String: formatTimestamp(opts.Now.UTC()), Valid: true}
+	staleBefore := sql.NullString{}
+	if opts.StaleAfter > 0 {
+		staleBefore = sql.NullString{
+			String: formatTimestamp(opts.Now.UTC().Add(-opts.StaleAfter)),
+			Valid:  true,
+		}
+	}
+
+	queries := db.New(s.db)
+	maxAttempts := int64(s.embeddingRetry.MaxAttempts)
+	if staleBefore.Valid {
+		if err := queries.MarkExhaustedEmbeddingJobsDead(ctx, db.MarkExhaustedEmbeddingJobsDeadParams{
+			ClaimedAt: staleBefore,
+			Attempts:  maxAttempts,
+		}); err != nil {
+			return nil, fmt.Errorf("mark exhausted embedding jobs dead: %w", err)
+		}
+	}
+	claimedRows, err := queries.ClaimPendingEmbeddingJobs(ctx, db.ClaimPendingEmbeddingJobsParams{
+		ClaimedAt:     claimedAt,
+		ClaimedBy:     sql.NullString{String: opts.ClaimedBy, Valid: true},
+		LastAttemptAt: claimedAt,
+		NextAttemptAt: claimedAt,
+		ClaimedAt_2:   staleBefore,
+		Attempts:      maxAttempts,
+		Limit:         int64(opts.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim embedding jobs: %w", err)
+	}
+	if len(claimedRows) == 0 {
+		return nil, nil
+	}
+
+	jobIDs := make([]int64, 0, len(claimedRows))
+	for _, row := range claimedRows {
+		jobIDs = append(jobIDs, row.ID)
+	}
+
+	rows, err := queries.ListEmbeddingJobsByIDs(ctx, jobIDs)
+	if err != nil {
+		return nil, fmt.Errorf("load claimed embedding jobs: %w", err)
+	}
+
+	jobs := make([]EmbeddingJob, 0, len(rows))
+	for _, row := range rows {
+		jobs = append(jobs, EmbeddingJob{
+			ID:      row.JobID,
+			TrackID: row.Track.ID,
+			Track:   convertDBTrack(row.Track),
+		})
+	}
+	return jobs, nil
+}
+
+// CompleteEmbeddingJob marks an embedding job as processed successfully.
+func (s *Store) CompleteEmbeddingJob(ctx context.Context, jobID int64) error {
+	return s.setEmbeddingJobStatus(ctx, jobID, "completed", sql.NullString{}, sql.NullString{})
+}
+
+// FailEmbeddingJob records jobErr and schedules a retry after the embedding
+// retry backoff, or marks the job dead once it has used all its attempts.
+func (s *Store) FailEmbeddingJob(ctx context.Context, jobID int64, jobErr error) error {
+	attempts, err := db.New(s.db).GetEmbeddingJobAttempts(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("get embedding job attempts: %w", err)
+	}
+	status, nextAttemptAt := s.embeddingRetry.schedule(attempts, time.Now())
+	return s.setEmbeddingJobStatus(ctx, jobID, status, nextAttemptAt, jobErrorMessage(jobErr))
+}
+
+func (s *Store) setEmbeddingJobStatus(ctx context.Context, jobID int64, status string, nextAttemptAt, errField sql.NullString) error {
+	processed := sql.NullString{}
+	if status == "completed" {
+		processed = sql.NullString{String: nowUTC(), Valid: true}
+	}
+
+	params := db.UpdateEmbeddingJobStatusParams{
+		Status:        status,
+		ProcessedAt:   processed,
+		Error:         errField,
+		NextAttemptAt: nextAttemptAt,
+		ClaimedAt:     sql.NullString{},
+		ClaimedBy:     sql.NullString{},
+		ID:            jobID,
+	}
+	if err := db.New(s.db).UpdateEmbeddingJobStatus(ctx, params); err != nil {
+		return fmt.Errorf("update embedding job status: %w", err)
+	}
+	return nil
+}
+
+// UpsertTrackEmbedding writes the latest embedding vector for a track.
+func (s *Store) UpsertTrackEmbedding(ctx context.Context, record EmbeddingRecord) error {
+	if len(record.Vector) == 0 {
+		return errors.New("embedding vector is empty")
+	}
+	if strings.TrimSpace(record.Model) == "" {
+		return errors.New("embedding model is required")
+	}
+	params := db.UpsertTrackEmbeddingParams{
+		TrackID:    record.TrackID,
+		Model:      record.Model,
+		Dimensions: int64(len(record.Vector)),
+		Embedding:  encodeVector(record.Vector),
+		Document:   record.Document,
+		EmbeddedAt: formatTimestamp(record.EmbeddedAt.UTC()),
+	}
+	if err := db.New(s.db).UpsertTrackEmbedding(ctx, params); err != nil {
+		return fmt.Errorf("upsert track embedding: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestEmbeddingJobLifecycle(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "embedding-jobs.db")
+	store, err := New(Config{Path: dbPath})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	tracks := []app.Track{
+		{ID: "embed-a", Title: "A", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(9000, 0), Duration: 60 * time.Second, Path: "/music/a.flac", Suffix: "flac"},
+		{ID: "embed-b", Title: "B", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(9001, 0), Duration: 60 * time.Second, Path: "/music/b.flac", Suffix: "flac"},
+	}
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), tracks); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+
+	now := time.Now().UTC()
+	jobs, err := store.ClaimPendingEmbeddingJobs(context.Background(), ClaimOptions{
+		Limit:      5,
+		ClaimedBy:  "embed-runner",
+		StaleAfter: time.Minute,
+		Now:        now,
+	})
+	if err != nil {
+		t.Fatalf("claim embedding jobs: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 claimed jobs, got %d", len(jobs))
+	}
+
+	again, err := store.ClaimPendingEmbeddingJobs(context.Background(), ClaimOptions{
+		Limit:      5,
+		ClaimedBy:  "embed-runner-2",
+		StaleAfter: time.Minute,
+		Now:        now,
+	})
+	if err != nil {
+		t.Fatalf("claim embedding jobs again: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("expected no jobs while claims are fresh, got %d", len(again))
+	}
+
+	stale, err := store.ClaimPendingEmbeddingJobs(context.Background(), ClaimOptions{
+		Limit:      1,
+		ClaimedBy:  "embed-runner-3",
+		StaleAfter: time.Minute,
+		Now:        now.Add(2 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("reclaim stale embedding jobs: %v", err)
+	}
+	if len(stale) != 1 {
+		t.Fatalf("expected 1 reclaimed job, got %d", len(stale))
+	}
+
+	if err := store.UpsertTrackEmbedding(context.Background(), EmbeddingRecord{
+		TrackID:    jobs[0].TrackID,
+		Model:      "test-model",
+		Vector:     []float32{0.25, -0.5, 1},
+		Document:   "Title: A",
+		EmbeddedAt: now,
+	}); err != nil {
+		t.Fatalf("upsert embedding: %v", err)
+	}
+	if err := store.CompleteEmbeddingJob(context.Background(), jobs[0].ID); err != nil {
+		t.Fatalf("complete embedding job: %v", err)
+	}
+	if err := store.FailEmbeddingJob(context.Background(), jobs[1].ID, errors.New("ollama down")); err != nil {
+		t.Fatalf("fail embedding job: %v", err)
+	}
+
+	raw, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	defer raw.Close()
+
+	var model string
+	var dims int
+	var blob []byte
+	if err := raw.QueryRow("SELECT model, dimensions, embedding FROM track_embeddings WHERE track_id = ?", jobs[0].TrackID).Scan(&model, &dims, &blob); err != nil {
+		t.Fatalf("query embedding: %v", err)
+	}
+	if model != "test-model" || dims != 3 || len(blob) != 12 {
+		t.Fatalf("unexpected embedding row model=%s dims=%d bytes=%d", model, dims, len(blob))
+	}
+
+	var status string
+	var jobErr sql.NullString
+	if err := raw.QueryRow("SELECT status, error FROM track_embedding_jobs WHERE id = ?", jobs[0].ID).Scan(&status, &jobErr); err != nil {
+		t.Fatalf("query completed job: %v", err)
+	}
+	if status != "completed" || jobErr.Valid {
+		t.Fatalf("unexpected completed job status=%s error=%v", status, jobErr)
+	}
+	if err := raw.QueryRow("SELECT status, error FROM track_embedding_jobs WHERE id = ?", jobs[1].ID).Scan(&status, &jobErr); err != nil {
+		t.Fatalf("query failed job: %v", err)
+	}
+	if status != "pending" || !jobErr.Valid || jobErr.String != "ollama down" {
+		t.Fatalf("unexpected failed job status=%s error=%v", status, jobErr)
+	}
+}
+
+func TestUpsertTrackEmbeddingRejectsEmptyVector(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "empty-vector.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.UpsertTrackEmbedding(context.Background(), EmbeddingRecord{TrackID: 1, Model: "m"}); err == nil {
+		t.Fatal("expected error for empty vector")
+	}
+}
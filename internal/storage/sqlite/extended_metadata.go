@@ -0,0 +1,196 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+const (
+	artistRoleArtist      = "artist"
+	artistRoleAlbumArtist = "album_artist"
+)
+
+// loadExtendedMetadataIDs returns the IDs of tracks with stored extended
+// metadata.
+func loadExtendedMetadataIDs(ctx context.Context, queries *db.Queries) (map[int64]struct{}, error) {
+	ids, err := queries.ListExtendedMetadataTrackIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list extended metadata track ids: %w", err)
+	}
+	stored := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		stored[id] = struct{}{}
+	}
+	return stored, nil
+}
+
+// syncExtendedMetadata replaces the extended metadata of trackID. Unless
+// changed is set, tracks that already have a row are left alone, so unchanged
+// tracks are only written once to backfill servers that gained OpenSubsonic
+// support.
+func syncExtendedMetadata(ctx context.Context, queries *db.Queries, trackID int64, meta app.ExtendedMetadata, stored map[int64]struct{}, changed bool) error {
+	_, ok := stored[trackID]
+	if ok && !changed {
+		return nil
+	}
+	if !ok && meta.IsZero() {
+		return nil
+	}
+
+	if err := queries.DeleteTrackGenres(ctx, trackID); err != nil {
+		return fmt.Errorf("delete track genres: %w", err)
+	}
+	if err := queries.DeleteTrackArtists(ctx, trackID); err != nil {
+		return fmt.Errorf("delete track artists: %w", err)
+	}
+	if meta.IsZero() {
+		if err := queries.DeleteTrackExtendedMetadata(ctx, trackID); err != nil {
+			return fmt.Errorf("delete track extended metadata: %w", err)
+		}
+		delete(stored, trackID)
+		return nil
+	}
+
+	isrc, err := jsonList(meta.ISRC)
+	if err != nil {
+		return fmt.Errorf("encode isrc: %w", err)
+	}
+	moods, err := jsonList(meta.Moods)
+	if err != nil {
+		return fmt.Errorf("encode moods: %w", err)
+	}
+	var bpm sql.NullInt64
+	if meta.BPM > 0 {
+		bpm = sql.NullInt64{Int64: int64(meta.BPM), Valid: true}
+	}
+	if err := queries.UpsertTrackExtendedMetadata(ctx, db.UpsertTrackExtendedMetadataParams{
+		TrackID:               trackID,
+		MusicbrainzID:         nullStringValue(meta.MusicBrainzID),
+		SortName:              nullStringValue(meta.SortName),
+		Isrc:                  isrc,
+		Bpm:                   bpm,
+		Moods:                 moods,
+		ReplaygainTrackGainDb: nullFloat64Ptr(meta.ReplayGain.TrackGainDB),
+		ReplaygainTrackPeak:   nullFloat64Ptr(meta.ReplayGain.TrackPeak),
+		ReplaygainAlbumGainDb: nullFloat64Ptr(meta.ReplayGain.AlbumGainDB),
+		ReplaygainAlbumPeak:   nullFloat64Ptr(meta.ReplayGain.AlbumPeak),
+		UpdatedAt:             nowUTC(),
+	}); err != nil {
+		return fmt.Errorf("upsert track extended metadata: %w", err)
+	}
+
+	for i, genre := range meta.Genres {
+		if err := queries.InsertTrackGenre(ctx, db.InsertTrackGenreParams{
+			TrackID:  trackID,
+			Position: int64(i),
+			Name:     genre,
+		}); err != nil {
+			return fmt.Errorf("insert track genre: %w", err)
+		}
+	}
+	if err := insertTrackArtists(ctx, queries, trackID, artistRoleArtist, meta.Artists); err != nil {
+		return err
+	}
+	if err := insertTrackArtists(ctx, queries, trackID, artistRoleAlbumArtist, meta.AlbumArtists); err != nil {
+		return err
+	}
+	stored[trackID] = struct{}{}
+	return nil
+}
+
+func insertTrackArtists(ctx context.Context, queries *db.Queries, trackID int64, role string, credits []app.ArtistCredit) error {
+	for i, credit := range credits {
+		if err := queries.InsertTrackArtist(ctx, db.InsertTrackArtistParams{
+			TrackID:           trackID,
+			Role:              role,
+			Position:          int64(i),
+			NavidromeArtistID: nullStringValue(credit.ID),
+			Name:              credit.Name,
+		}); err != nil {
+			return fmt.Errorf("insert track %s: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// loadExtendedMetadata returns the stored extended metadata for trackIDs.
+// Tracks without any are absent from the map.
+func loadExtendedMetadata(ctx context.Context, queries *db.Queries, trackIDs []int64) (map[int64]app.ExtendedMetadata, error) {
+	if len(trackIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := queries.ListTrackExtendedMetadataByIDs(ctx, trackIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list track extended metadata: %w", err)
+	}
+	genres, err := queries.ListTrackGenresByIDs(ctx, trackIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list track genres: %w", err)
+	}
+	artists, err := queries.ListTrackArtistsByIDs(ctx, trackIDs)
+	if err != nil {
+		return nil, fmt.Errorf("list track artists: %w", err)
+	}
+
+	metadata := make(map[int64]app.ExtendedMetadata, len(rows))
+	for _, row := range rows {
+		meta := app.ExtendedMetadata{
+			MusicBrainzID: stringValue(row.MusicbrainzID),
+			SortName:      stringValue(row.SortName),
+			ISRC:          parseJSONList(row.Isrc),
+			BPM:           int(row.Bpm.Int64),
+			Moods:         parseJSONList(row.Moods),
+			ReplayGain: app.ReplayGain{
+				TrackGainDB: float64PtrFromSQL(row.ReplaygainTrackGainDb),
+				TrackPeak:   float64PtrFromSQL(row.ReplaygainTrackPeak),
+				AlbumGainDB: float64PtrFromSQL(row.ReplaygainAlbumGainDb),
+				AlbumPeak:   float64PtrFromSQL(row.ReplaygainAlbumPeak),
+			},
+		}
+		metadata[row.TrackID] = meta
+	}
+	for _, genre := range genres {
+		meta := metadata[genre.TrackID]
+		meta.Genres = append(meta.Genres, genre.Name)
+		metadata[genre.TrackID] = meta
+	}
+	for _, artist := range artists {
+		meta := metadata[artist.TrackID]
+		credit := app.ArtistCredit{ID: stringValue(artist.NavidromeArtistID), Name: artist.Name}
+		switch artist.Role {
+		case artistRoleArtist:
+			meta.Artists = append(meta.Artists, credit)
+		case artistRoleAlbumArtist:
+			meta.AlbumArtists = append(meta.AlbumArtists, credit)
+		}
+		metadata[artist.TrackID] = meta
+	}
+	return metadata, nil
+}
+
+func jsonList(values []string) (sql.NullString, error) {
+	if len(values) == 0 {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+func parseJSONList(ns sql.NullString) []string {
+	if !ns.Valid || ns.String == "" {
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal([]byte(ns.String), &values); err != nil {
+		return nil
+	}
+	return values
+}
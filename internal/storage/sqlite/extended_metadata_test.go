@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestSaveTracksPersistsExtendedMetadata(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "extended.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	albumGain := -6.5
+	trackGain := -7.25
+	trackPeak := 0.97
+	track := app.Track{ID: "ext-1", Title: "Song", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(1000, 0), UpdatedAt: time.Unix(2000, 0), Duration: time.Minute, Path: "song.flac", Suffix: "flac"}
+
+	// Saved before the server advertised OpenSubsonic.
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), []app.Track{track}); err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+
+	// Unchanged metadata still backfills the extended fields once.
+	track.Extended = app.ExtendedMetadata{
+		MusicBrainzID: "mbid-1",
+		SortName:      "Song",
+		ISRC:          []string{"USABC2300001", "USABC2300002"},
+		BPM:           124,
+		Moods:         []string{"happy", "energetic"},
+		Genres:        []string{"Rock", "Indie"},
+		Artists:       []app.ArtistCredit{{ID: "ar-1", Name: "Artist"}, {ID: "ar-2", Name: "Guest"}},
+		AlbumArtists:  []app.ArtistCredit{{Name: "Various Artists"}},
+		ReplayGain:    app.ReplayGain{AlbumGainDB: &albumGain, TrackGainDB: &trackGain, TrackPeak: &trackPeak},
+	}
+	stats, err := store.SaveTracks(ctx, startTestSync(t, store), []app.Track{track})
+	if err != nil {
+		t.Fatalf("backfill save: %v", err)
+	}
+	if stats.Skipped != 1 {
+		t.Fatalf("expected the track to be skipped, got %+v", stats)
+	}
+
+	id := trackIDByNavidromeID(t, store, "ext-1")
+	candidates, err := store.LoadTrackCandidates(ctx, []int64{id})
+	if err != nil {
+		t.Fatalf("load candidates: %v", err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("expected one candidate, got %d", len(candidates))
+	}
+	if got := candidates[0].Track.Extended; !reflect.DeepEqual(got, track.Extended) {
+		t.Fatalf("extended metadata mismatch:\n got %+v\nwant %+v", got, track.Extended)
+	}
+	features := candidates[0].Features
+	if features.EffectiveGainDB == nil || *features.EffectiveGainDB != albumGain || features.EffectiveGainSource != "server_replaygain_album" {
+		t.Fatalf("expected server replay gain fallback, got %+v", features)
+	}
+
+	// A metadata change replaces the child rows.
+	track.UpdatedAt = time.Now().Add(time.Hour)
+	track.Extended.Genres = []string{"Pop"}
+	track.Extended.Artists = nil
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), []app.Track{track}); err != nil {
+		t.Fatalf("update save: %v", err)
+	}
+	candidates, err = store.LoadTrackCandidates(ctx, []int64{id})
+	if err != nil {
+		t.Fatalf("reload candidates: %v", err)
+	}
+	got := candidates[0].Track.Extended
+	if !reflect.DeepEqual(got.Genres, []string{"Pop"}) || got.Artists != nil || len(got.AlbumArtists) != 1 {
+		t.Fatalf("expected replaced genres and artists, got %+v", got)
+	}
+
+	jobs, err := store.ClaimPendingAudioJobs(ctx, ClaimOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("claim audio jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Track.Extended.ReplayGain.AlbumGainDB == nil || *jobs[0].Track.Extended.ReplayGain.AlbumGainDB != albumGain {
+		t.Fatalf("expected claimed job to carry server replay gain, got %+v", jobs)
+	}
+
+	// Losing OpenSubsonic support on a changed track clears the stored fields.
+	track.UpdatedAt = time.Now().Add(2 * time.Hour)
+	track.Extended = app.ExtendedMetadata{}
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), []app.Track{track}); err != nil {
+		t.Fatalf("clear save: %v", err)
+	}
+	var rows int
+	if err := store.db.QueryRowContext(ctx, "SELECT (SELECT COUNT(*) FROM track_extended_metadata) + (SELECT COUNT(*) FROM track_genres) + (SELECT COUNT(*) FROM track_artists)").Scan(&rows); err != nil {
+		t.Fatalf("count rows: %v", err)
+	}
+	if rows != 0 {
+		t.Fatalf("expected extended rows to be cleared, got %d", rows)
+	}
+}
+
+func trackIDByNavidromeID(t *testing.T, store *Store, navID string) int64 {
+	t.Helper()
+	var id int64
+	if err := store.db.QueryRowContext(context.Background(), "SELECT id FROM tracks WHERE navidrome_id = ?", navID).Scan(&id); err != nil {
+		t.Fatalf("select id for %s: %v", navID, err)
+	}
+	return id
+}
@@ -0,0 +1,355 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// Job queue names.
+const (
+	AudioQueue     = "audio"
+	EmbeddingQueue = "embedding"
+)
+
+// JobStatusFailed is a filter-only status matching jobs whose last attempt
+// failed: pending jobs waiting out a retry backoff and dead jobs.
+const JobStatusFailed = "failed"
+
+// Job is one audio or embedding job with the track it belongs to.
+type Job struct {
+	Queue       string
+	ID          int64
+	TrackID     int64
+	NavidromeID string
+	Artist      string
+	Album       string
+	Title       string
+	Path        string
+	// TrackDeleted is set when the track is tombstoned; its jobs are never
+	// claimed.
+	TrackDeleted bool
+	Status       string
+	Error        string
+	Attempts     int
+	// Zero times mean the job has not reached that point.
+	LastAttemptAt time.Time
+	NextAttemptAt time.Time
+	ClaimedAt     time.Time
+	ClaimedBy     string
+	ProcessedAt   time.Time
+	CreatedAt     time.Time
+}
+
+// JobFilter selects jobs. Empty fields match everything.
+type JobFilter struct {
+	// Queue is AudioQueue or EmbeddingQueue.
+	Queue string
+	// Status is a stored status (pending, processing, completed, dead) or
+	// JobStatusFailed.
+	Status string
+	Limit  int
+}
+
+// JobQueueStats counts the jobs of one queue by status.
+type JobQueueStats struct {
+	Queue      string
+	Pending    int
+	Processing int
+	Completed  int
+	Dead       int
+	// Retrying is the part of Pending waiting out a retry backoff.
+	Retrying int
+	// OldestPendingAt is when the oldest pending job was queued, or zero when
+	// nothing is pending.
+	OldestPendingAt time.Time
+}
+
+var jobStatuses = map[string]bool{
+	"pending":       true,
+	"processing":    true,
+	"completed":     true,
+	"dead":          true,
+	JobStatusFailed: true,
+}
+
+// jobQueues returns the queues named by queue; empty means both.
+func jobQueues(queue string) ([]string, error) {
+	switch queue {
+	case "":
+		return []string{AudioQueue, EmbeddingQueue}, nil
+	case AudioQueue, EmbeddingQueue:
+		return []string{queue}, nil
+	default:
+		return nil, fmt.Errorf("unknown job queue %q (want %s or %s)", queue, AudioQueue, EmbeddingQueue)
+	}
+}
+
+// statusFilter converts a JobFilter status into the status and failed
+// arguments of the job queries.
+func statusFilter(status string) (sql.NullString, int64, error) {
+	if status == "" {
+		return sql.NullString{}, 0, nil
+	}
+	if !jobStatuses[status] {
+		return sql.NullString{}, 0, fmt.Errorf("unknown job status %q", status)
+	}
+	if status == JobStatusFailed {
+		return sql.NullString{}, 1, nil
+	}
+	return nullStringValue(status), 0, nil
+}
+
+// ListJobs returns jobs matching filter, newest first. Jobs of tombstoned
+// tracks are left out.
+func (s *Store) ListJobs(ctx context.Context, filter JobFilter) ([]Job, error) {
+	if _, err := jobQueues(filter.Queue); err != nil {
+		return nil, err
+	}
+	status, failed, err := statusFilter(filter.Status)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	rows, err := db.New(s.db).ListProcessingJobs(ctx, db.ListProcessingJobsParams{
+		Queue:  nullStringValue(filter.Queue),
+		Status: status,
+		Failed: failed,
+		Limit:  int64(filter.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+	jobs := make([]Job, 0, len(rows))
+	for _, row := range rows {
+		jobs = append(jobs, convertDBJob(db.GetProcessingJobRow(row)))
+	}
+	return jobs, nil
+}
+
+// Job returns one job of queue by ID. The boolean is false when there is no
+// such job.
+func (s *Store) Job(ctx context.Context, queue string, id int64) (Job, bool, error) {
+	if queue == "" {
+		return Job{}, false, errors.New("job queue is required")
+	}
+	if _, err := jobQueues(queue); err != nil {
+		return Job{}, false, err
+	}
+	row, err := db.New(s.db).GetProcessingJob(ctx, db.GetProcessingJobParams{Queue: queue, ID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, fmt.Errorf("get job: %w", err)
+	}
+	return convertDBJob(row), true, nil
+}
+
+// RetryJobs moves failed jobs back to pending with their attempts reset so
+// the next run picks them up immediately. filter.Status must be empty,
+// JobStatusFailed or "dead". It returns the number of jobs retried.
+func (s *Store) RetryJobs(ctx context.Context, filter JobFilter) (int, error) {
+	queues, err := jobQueues(filter.Queue)
+	if err != nil {
+		return 0, err
+	}
+	var status sql.NullString
+	switch filter.Status {
+	case "", JobStatusFailed:
+	case "dead":
+		status = nullStringValue(filter.Status)
+	default:
+		return 0, fmt.Errorf("cannot retry %q jobs (want %s or dead)", filter.Status, JobStatusFailed)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin retry: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := db.New(tx)
+	var retried int64
+	for _, queue := range queues {
+		var n int64
+		if queue == AudioQueue {
+			n, err = queries.RetryAudioJobs(ctx, status)
+		} else {
+			n, err = queries.RetryEmbeddingJobs(ctx, status)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("retry %s jobs: %w", queue, err)
+		}
+		retried += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit retry: %w", err)
+	}
+	return int(retried), nil
+}
+
+// PurgeJobs deletes completed and dead jobs that finished before cutoff.
+// filter.Status must be empty, "completed" or "dead"; pending and processing
+// jobs are never purged. A track's newest job is always kept, because
+// RetryJobs and RequeueTrackJobs act on it. It returns the number of jobs
+// deleted.
+func (s *Store) PurgeJobs(ctx context.Context, filter JobFilter, cutoff time.Time) (int, error) {
+	queues, err := jobQueues(filter.Queue)
+	if err != nil {
+		return 0, err
+	}
+	var status sql.NullString
+	switch filter.Status {
+	case "":
+	case "completed", "dead":
+		status = nullStringValue(filter.Status)
+	default:
+		return 0, fmt.Errorf("cannot purge %q jobs (want completed or dead)", filter.Status)
+	}
+	if cutoff.IsZero() {
+		return 0, errors.New("purge cutoff is required")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin purge: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := db.New(tx)
+	before := formatTimestamp(cutoff.UTC())
+	var purged int64
+	for _, queue := range queues {
+		var n int64
+		if queue == AudioQueue {
+			n, err = queries.PurgeAudioJobs(ctx, db.PurgeAudioJobsParams{Status: status, Cutoff: before})
+		} else {
+			n, err = queries.PurgeEmbeddingJobs(ctx, db.PurgeEmbeddingJobsParams{Status: status, Cutoff: before})
+		}
+		if err != nil {
+			return 0, fmt.Errorf("purge %s jobs: %w", queue, err)
+		}
+		purged += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit purge: %w", err)
+	}
+	return int(purged), nil
+}
+
+// RequeueTrackJobs queues a fresh job for the track with navidromeID in
+// queue, or in both queues when queue is empty. A pending job has its
+// attempts reset and a job that is being processed is left alone. A requeued
+// audio job always analyzes the file: the stored analyzer version is cleared
+// so audio-process does not skip it as unchanged. The boolean is false when
+// the track is unknown.
+func (s *Store) RequeueTrackJobs(ctx context.Context, navidromeID, queue string) (bool, error) {
+	queues, err := jobQueues(queue)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin requeue: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := db.New(tx)
+	trackID, err := queries.SelectTrackID(ctx, navidromeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("select track: %w", err)
+	}
+	for _, q := range queues {
+		if q == AudioQueue {
+			if err := queries.ClearTrackAnalyzerVersion(ctx, trackID); err != nil {
+				return false, fmt.Errorf("clear analyzer version: %w", err)
+			}
+			err = queries.EnsureTrackAudioJob(ctx, db.EnsureTrackAudioJobParams{
+				TrackID: trackID,
+				Status:  "pending",
+			})
+		} else {
+			err = queries.EnsureTrackEmbeddingJob(ctx, db.EnsureTrackEmbeddingJobParams{
+				TrackID: trackID,
+				Status:  "pending",
+			})
+		}
+		if err != nil {
+			return false, fmt.Errorf("requeue %s job: %w", q, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit requeue: %w", err)
+	}
+	return true, nil
+}
+
+// JobStats counts jobs per queue and status, leaving out tombstoned tracks.
+// Both queues are always returned.
+func (s *Store) JobStats(ctx context.Context) ([]JobQueueStats, error) {
+	rows, err := db.New(s.db).CountProcessingJobs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count jobs: %w", err)
+	}
+	stats := []JobQueueStats{{Queue: AudioQueue}, {Queue: EmbeddingQueue}}
+	for _, row := range rows {
+		var queue *JobQueueStats
+		for i := range stats {
+			if stats[i].Queue == row.Queue {
+				queue = &stats[i]
+			}
+		}
+		if queue == nil {
+			continue
+		}
+		count := int(row.Jobs)
+		switch row.Status {
+		case "pending":
+			queue.Pending = count
+			queue.Retrying = int(row.Failed)
+			queue.OldestPendingAt = parseTimestamp(row.OldestCreatedAt)
+		case "processing":
+			queue.Processing = count
+		case "completed":
+			queue.Completed = count
+		case "dead":
+			queue.Dead = count
+		}
+	}
+	return stats, nil
+}
+
+func convertDBJob(row db.GetProcessingJobRow) Job {
+	return Job{
+		Queue:         row.Queue,
+		ID:            row.ID,
+		TrackID:       row.TrackID,
+		NavidromeID:   row.NavidromeID,
+		Artist:        row.Artist,
+		Album:         row.Album,
+		Title:         row.Title,
+		Path:          row.Path,
+		TrackDeleted:  row.DeletedAt.Valid,
+		Status:        row.Status,
+		Error:         stringValue(row.Error),
+		Attempts:      int(row.Attempts),
+		LastAttemptAt: parseTimestamp(row.LastAttemptAt.String),
+		NextAttemptAt: parseTimestamp(row.NextAttemptAt.String),
+		ClaimedAt:     parseTimestamp(row.ClaimedAt.String),
+		ClaimedBy:     stringValue(row.ClaimedBy),
+		ProcessedAt:   parseTimestamp(row.ProcessedAt.String),
+		CreatedAt:     parseTimestamp(row.CreatedAt),
+	}
+}
@@ -0,0 +1,225 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListJobsStatsAndRetry(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "jobs.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tombstoneTestTracks(3)); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	claimed := claimAudioJobsAt(t, store, time.Now())
+	if len(claimed) != 3 {
+		t.Fatalf("expected three claimed audio jobs, got %d", len(claimed))
+	}
+	failedJob := claimed[0]
+	if err := store.FailAudioJob(ctx, failedJob.ID, errors.New("no such file")); err != nil {
+		t.Fatalf("fail job: %v", err)
+	}
+	if err := store.CompleteAudioJob(ctx, claimed[1].ID); err != nil {
+		t.Fatalf("complete job: %v", err)
+	}
+
+	failed, err := store.ListJobs(ctx, JobFilter{Queue: AudioQueue, Status: JobStatusFailed})
+	if err != nil {
+		t.Fatalf("list failed jobs: %v", err)
+	}
+	if len(failed) != 1 {
+		t.Fatalf("expected one failed job, got %+v", failed)
+	}
+	if got := failed[0]; got.ID != failedJob.ID || got.Status != "pending" || got.Error != "no such file" || got.Attempts != 1 || got.NextAttemptAt.IsZero() || got.NavidromeID != failedJob.Track.ID {
+		t.Fatalf("unexpected failed job %+v", got)
+	}
+
+	pending, err := store.ListJobs(ctx, JobFilter{Status: "pending"})
+	if err != nil {
+		t.Fatalf("list pending jobs: %v", err)
+	}
+	if len(pending) != 4 {
+		t.Fatalf("expected three embedding jobs and one audio retry pending, got %d", len(pending))
+	}
+
+	job, ok, err := store.Job(ctx, AudioQueue, failedJob.ID)
+	if err != nil || !ok {
+		t.Fatalf("get job: ok=%v err=%v", ok, err)
+	}
+	if job.Path != failedJob.Track.Path || job.Title != failedJob.Track.Title || job.CreatedAt.IsZero() || job.LastAttemptAt.IsZero() {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	if _, ok, err := store.Job(ctx, AudioQueue, 9999); err != nil || ok {
+		t.Fatalf("expected missing job, got ok=%v err=%v", ok, err)
+	}
+
+	stats, err := store.JobStats(ctx)
+	if err != nil {
+		t.Fatalf("job stats: %v", err)
+	}
+	audioStats, embeddingStats := stats[0], stats[1]
+	if audioStats.Queue != AudioQueue || audioStats.Pending != 1 || audioStats.Retrying != 1 || audioStats.Processing != 1 || audioStats.Completed != 1 || audioStats.Dead != 0 {
+		t.Fatalf("unexpected audio stats %+v", audioStats)
+	}
+	if embeddingStats.Queue != EmbeddingQueue || embeddingStats.Pending != 3 || embeddingStats.OldestPendingAt.IsZero() {
+		t.Fatalf("unexpected embedding stats %+v", embeddingStats)
+	}
+
+	retried, err := store.RetryJobs(ctx, JobFilter{Queue: AudioQueue, Status: JobStatusFailed})
+	if err != nil {
+		t.Fatalf("retry jobs: %v", err)
+	}
+	if retried != 1 {
+		t.Fatalf("expected one retried job, got %d", retried)
+	}
+	if status, attempts := audioJobState(t, store, failedJob.ID); status != "pending" || attempts != 0 {
+		t.Fatalf("expected a fresh pending job, got status=%s attempts=%d", status, attempts)
+	}
+	if jobs := claimAudioJobsAt(t, store, time.Now()); len(jobs) != 1 || jobs[0].ID != failedJob.ID {
+		t.Fatalf("expected the retried job to be claimable now, got %+v", jobs)
+	}
+
+	if _, err := store.ListJobs(ctx, JobFilter{Queue: "video"}); err == nil {
+		t.Fatalf("expected error for unknown queue")
+	}
+	if _, err := store.ListJobs(ctx, JobFilter{Status: "stuck"}); err == nil {
+		t.Fatalf("expected error for unknown status")
+	}
+	if _, err := store.RetryJobs(ctx, JobFilter{Status: "completed"}); err == nil {
+		t.Fatalf("expected error retrying completed jobs")
+	}
+}
+
+func TestRequeueAndRetryKeepOneActiveJobPerTrack(t *testing.T) {
+	store, err := New(Config{
+		Path:       filepath.Join(t.TempDir(), "requeue.db"),
+		AudioRetry: JobRetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tombstoneTestTracks(1)); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	first := claimAudioJobsAt(t, store, time.Now())
+	if len(first) != 1 {
+		t.Fatalf("expected one claimed job, got %d", len(first))
+	}
+	if err := store.FailAudioJob(ctx, first[0].ID, errors.New("boom")); err != nil {
+		t.Fatalf("fail job: %v", err)
+	}
+
+	found, err := store.RequeueTrackJobs(ctx, "t0", AudioQueue)
+	if err != nil || !found {
+		t.Fatalf("requeue track: found=%v err=%v", found, err)
+	}
+	if retried, err := store.RetryJobs(ctx, JobFilter{Status: "dead"}); err != nil || retried != 0 {
+		t.Fatalf("expected the superseded dead job to stay dead, got retried=%d err=%v", retried, err)
+	}
+
+	second := claimAudioJobsAt(t, store, time.Now())
+	if len(second) != 1 || second[0].ID == first[0].ID {
+		t.Fatalf("expected the requeued job to be a new job, got %+v", second)
+	}
+	if err := store.FailAudioJob(ctx, second[0].ID, errors.New("boom")); err != nil {
+		t.Fatalf("fail requeued job: %v", err)
+	}
+	if retried, err := store.RetryJobs(ctx, JobFilter{Status: "dead"}); err != nil || retried != 1 {
+		t.Fatalf("expected the newest dead job to be retried, got retried=%d err=%v", retried, err)
+	}
+	if status, _ := audioJobState(t, store, first[0].ID); status != "dead" {
+		t.Fatalf("expected the first job to stay dead, got %s", status)
+	}
+	if status, _ := audioJobState(t, store, second[0].ID); status != "pending" {
+		t.Fatalf("expected the newest job to be pending, got %s", status)
+	}
+
+	if found, err := store.RequeueTrackJobs(ctx, "missing", ""); err != nil || found {
+		t.Fatalf("expected unknown track, got found=%v err=%v", found, err)
+	}
+}
+
+func TestPurgeJobsKeepsActiveAndNewestJobs(t *testing.T) {
+	store, err := New(Config{
+		Path:       filepath.Join(t.TempDir(), "purge.db"),
+		AudioRetry: JobRetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tombstoneTestTracks(1)); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	var jobIDs []int64
+	for i, finish := range []func(int64) error{
+		func(id int64) error { return store.CompleteAudioJob(ctx, id) },
+		func(id int64) error { return store.FailAudioJob(ctx, id, errors.New("boom")) },
+		func(id int64) error { return store.CompleteAudioJob(ctx, id) },
+	} {
+		if i > 0 {
+			if found, err := store.RequeueTrackJobs(ctx, "t0", AudioQueue); err != nil || !found {
+				t.Fatalf("requeue track: found=%v err=%v", found, err)
+			}
+		}
+		claimed := claimAudioJobsAt(t, store, time.Now())
+		if len(claimed) != 1 {
+			t.Fatalf("expected one claimed job, got %d", len(claimed))
+		}
+		if err := finish(claimed[0].ID); err != nil {
+			t.Fatalf("finish job: %v", err)
+		}
+		jobIDs = append(jobIDs, claimed[0].ID)
+	}
+	audioJobIDs := func() []int64 {
+		jobs, err := store.ListJobs(ctx, JobFilter{Queue: AudioQueue})
+		if err != nil {
+			t.Fatalf("list jobs: %v", err)
+		}
+		var ids []int64
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return ids
+	}
+
+	if purged, err := store.PurgeJobs(ctx, JobFilter{}, time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+		t.Fatalf("expected recent jobs to be kept, got purged=%d err=%v", purged, err)
+	}
+	later := time.Now().Add(time.Minute)
+	if purged, err := store.PurgeJobs(ctx, JobFilter{Queue: AudioQueue, Status: "dead"}, later); err != nil || purged != 1 {
+		t.Fatalf("expected the dead job to be purged, got purged=%d err=%v", purged, err)
+	}
+	if ids := audioJobIDs(); len(ids) != 2 || ids[0] != jobIDs[2] || ids[1] != jobIDs[0] {
+		t.Fatalf("expected the completed jobs to remain, got %v", ids)
+	}
+	if purged, err := store.PurgeJobs(ctx, JobFilter{}, later); err != nil || purged != 1 {
+		t.Fatalf("expected the superseded completed job to be purged, got purged=%d err=%v", purged, err)
+	}
+	if ids := audioJobIDs(); len(ids) != 1 || ids[0] != jobIDs[2] {
+		t.Fatalf("expected the newest job to be kept, got %v", ids)
+	}
+	if pending, err := store.ListJobs(ctx, JobFilter{Queue: EmbeddingQueue, Status: "pending"}); err != nil || len(pending) != 1 {
+		t.Fatalf("expected the pending embedding job to be kept, got %d (%v)", len(pending), err)
+	}
+
+	if _, err := store.PurgeJobs(ctx, JobFilter{Status: "pending"}, later); err == nil {
+		t.Fatalf("expected error purging pending jobs")
+	}
+	if _, err := store.PurgeJobs(ctx, JobFilter{}, time.Time{}); err == nil {
+		t.Fatalf("expected error for a missing cutoff")
+	}
+}
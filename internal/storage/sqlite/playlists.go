@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// NavidromePlaylistMapping records the Navidrome playlist that playlistgen
+// created for a playlist name, so later exports update it in place.
+type NavidromePlaylistMapping struct {
+	Name       string
+	PlaylistID string
+	SongCount  int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// NavidromePlaylist returns the mapping for name. The boolean is false when
+// playlistgen has never exported a playlist under that name.
+func (s *Store) NavidromePlaylist(ctx context.Context, name string) (NavidromePlaylistMapping, bool, error) {
+	row, err := db.New(s.db).GetNavidromePlaylist(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return NavidromePlaylistMapping{}, false, nil
+	}
+	if err != nil {
+		return NavidromePlaylistMapping{}, false, fmt.Errorf("get navidrome playlist: %w", err)
+	}
+	return NavidromePlaylistMapping{
+		Name:       row.Name,
+		PlaylistID: row.NavidromePlaylistID,
+		SongCount:  int(row.SongCount),
+		CreatedAt:  parseTimestamp(row.CreatedAt),
+		UpdatedAt:  parseTimestamp(row.UpdatedAt),
+	}, true, nil
+}
+
+// SaveNavidromePlaylist creates or updates the mapping for mapping.Name.
+func (s *Store) SaveNavidromePlaylist(ctx context.Context, mapping NavidromePlaylistMapping) error {
+	now := nowUTC()
+	if err := db.New(s.db).UpsertNavidromePlaylist(ctx, db.UpsertNavidromePlaylistParams{
+		Name:                mapping.Name,
+		NavidromePlaylistID: mapping.PlaylistID,
+		SongCount:           int64(mapping.SongCount),
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}); err != nil {
+		return fmt.Errorf("save navidrome playlist: %w", err)
+	}
+	return nil
+}
+
+// DeleteNavidromePlaylist forgets the mapping for name.
+func (s *Store) DeleteNavidromePlaylist(ctx context.Context, name string) error {
+	if err := db.New(s.db).DeleteNavidromePlaylist(ctx, name); err != nil {
+		return fmt.Errorf("delete navidrome playlist: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestNavidromePlaylistMappingLifecycle(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "playlists.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	if _, ok, err := store.NavidromePlaylist(ctx, "Focus"); err != nil || ok {
+		t.Fatalf("expected no mapping, got ok=%v err=%v", ok, err)
+	}
+
+	if err := store.SaveNavidromePlaylist(ctx, NavidromePlaylistMapping{Name: "Focus", PlaylistID: "pl-1", SongCount: 12}); err != nil {
+		t.Fatalf("save mapping: %v", err)
+	}
+	first, ok, err := store.NavidromePlaylist(ctx, "Focus")
+	if err != nil || !ok {
+		t.Fatalf("load mapping: ok=%v err=%v", ok, err)
+	}
+	if first.PlaylistID != "pl-1" || first.SongCount != 12 || first.CreatedAt.IsZero() {
+		t.Fatalf("unexpected mapping %+v", first)
+	}
+
+	if err := store.SaveNavidromePlaylist(ctx, NavidromePlaylistMapping{Name: "Focus", PlaylistID: "pl-2", SongCount: 8}); err != nil {
+		t.Fatalf("update mapping: %v", err)
+	}
+	second, _, err := store.NavidromePlaylist(ctx, "Focus")
+	if err != nil {
+		t.Fatalf("reload mapping: %v", err)
+	}
+	if second.PlaylistID != "pl-2" || second.SongCount != 8 || !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("unexpected updated mapping %+v (first %+v)", second, first)
+	}
+
+	if err := store.DeleteNavidromePlaylist(ctx, "Focus"); err != nil {
+		t.Fatalf("delete mapping: %v", err)
+	}
+	if _, ok, err := store.NavidromePlaylist(ctx, "Focus"); err != nil || ok {
+		t.Fatalf("expected mapping to be gone, got ok=%v err=%v", ok, err)
+	}
+}
@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Job retry defaults, used when a JobRetryPolicy field is left at zero.
+const (
+	DefaultJobMaxAttempts = 5
+	DefaultJobRetryDelay  = time.Minute
+	defaultJobMaxDelay    = 6 * time.Hour
+)
+
+// JobRetryPolicy controls how a failed audio or embedding job is retried.
+// Zero fields take defaults; set MaxAttempts to 1 to disable retries.
+type JobRetryPolicy struct {
+	// MaxAttempts is the number of claims a job gets before it is marked dead.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles each retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between retries.
+	MaxDelay time.Duration
+}
+
+func (p JobRetryPolicy) withDefaults() JobRetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultJobMaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultJobRetryDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultJobMaxDelay
+	}
+	return p
+}
+
+// backoff returns the wait after the attempt-th failed attempt, starting at 1.
+func (p JobRetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// schedule returns the status and next attempt time for a job that failed
+// after attempts claims: pending with a backoff while attempts remain, dead
+// once they are used up.
+func (p JobRetryPolicy) schedule(attempts int64, now time.Time) (string, sql.NullString) {
+	if attempts >= int64(p.MaxAttempts) {
+		return "dead", sql.NullString{}
+	}
+	next := now.UTC().Add(p.backoff(int(attempts)))
+	return "pending", sql.NullString{String: formatTimestamp(next), Valid: true}
+}
+
+// jobErrorMessage converts a job error into the stored error column.
+func jobErrorMessage(jobErr error) sql.NullString {
+	if jobErr == nil {
+		return sql.NullString{}
+	}
+	return nullStringValue(jobErr.Error())
+}
@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJobRetryPolicyBackoff(t *testing.T) {
+	policy := JobRetryPolicy{BaseDelay: time.Minute, MaxDelay: 5 * time.Minute}.withDefaults()
+	for attempt, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 4: 5 * time.Minute, 10: 5 * time.Minute} {
+		if got := policy.backoff(attempt); got != want {
+			t.Fatalf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+	if policy.MaxAttempts != DefaultJobMaxAttempts {
+		t.Fatalf("expected default max attempts, got %d", policy.MaxAttempts)
+	}
+}
+
+func TestFailedAudioJobsRetryWithBackoffThenDie(t *testing.T) {
+	store, err := New(Config{
+		Path:       filepath.Join(t.TempDir(), "retry.db"),
+		AudioRetry: JobRetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tombstoneTestTracks(1)); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	jobs := claimAudioJobsAt(t, store, time.Now())
+	if len(jobs) != 1 {
+		t.Fatalf("expected one claimed job, got %d", len(jobs))
+	}
+	jobID := jobs[0].ID
+	if status, attempts := audioJobState(t, store, jobID); status != "processing" || attempts != 1 {
+		t.Fatalf("expected the claim to count an attempt, got status=%s attempts=%d", status, attempts)
+	}
+
+	if err := store.FailAudioJob(ctx, jobID, errors.New("ffmpeg hiccup")); err != nil {
+		t.Fatalf("fail job: %v", err)
+	}
+	if status, _ := audioJobState(t, store, jobID); status != "pending" {
+		t.Fatalf("expected a retry to be scheduled, got %s", status)
+	}
+	if jobs := claimAudioJobsAt(t, store, time.Now()); len(jobs) != 0 {
+		t.Fatalf("expected the job to wait out its backoff, got %d jobs", len(jobs))
+	}
+
+	jobs = claimAudioJobsAt(t, store, time.Now().Add(2*time.Minute))
+	if len(jobs) != 1 || jobs[0].ID != jobID {
+		t.Fatalf("expected the job to be retried after its backoff, got %+v", jobs)
+	}
+	if err := store.FailAudioJob(ctx, jobID, errors.New("ffmpeg hiccup")); err != nil {
+		t.Fatalf("fail job again: %v", err)
+	}
+	if status, attempts := audioJobState(t, store, jobID); status != "dead" || attempts != 2 {
+		t.Fatalf("expected the job to be dead after 2 attempts, got status=%s attempts=%d", status, attempts)
+	}
+	if jobs := claimAudioJobsAt(t, store, time.Now().Add(24*time.Hour)); len(jobs) != 0 {
+		t.Fatalf("expected dead jobs to stay unclaimed, got %d jobs", len(jobs))
+	}
+}
+
+func TestStaleClaimOnFinalAttemptMarksJobDead(t *testing.T) {
+	store, err := New(Config{
+		Path:       filepath.Join(t.TempDir(), "stale-dead.db"),
+		AudioRetry: JobRetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), tombstoneTestTracks(1)); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	now := time.Now()
+	jobs := claimAudioJobsAt(t, store, now)
+	if len(jobs) != 1 {
+		t.Fatalf("expected one claimed job, got %d", len(jobs))
+	}
+
+	if reclaimed := claimAudioJobsAt(t, store, now.Add(10*time.Minute)); len(reclaimed) != 0 {
+		t.Fatalf("expected no reclaim on the final attempt, got %d jobs", len(reclaimed))
+	}
+	if status, _ := audioJobState(t, store, jobs[0].ID); status != "dead" {
+		t.Fatalf("expected stale job to be dead, got %s", status)
+	}
+}
+
+func TestReleasedAudioJobsGetTheirAttemptBack(t *testing.T) {
+	store, err := New(Config{
+		Path:       filepath.Join(t.TempDir(), "release.db"),
+		AudioRetry: JobRetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tombstoneTestTracks(2)); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	now := time.Now()
+	jobs := claimAudioJobsAt(t, store, now)
+	if len(jobs) != 2 {
+		t.Fatalf("expected two claimed jobs, got %d", len(jobs))
+	}
+	if err := store.CompleteAudioJob(ctx, jobs[0].ID); err != nil {
+		t.Fatalf("complete job: %v", err)
+	}
+
+	if err := store.ReleaseAudioJobs(ctx, []int64{jobs[0].ID, jobs[1].ID}); err != nil {
+		t.Fatalf("release jobs: %v", err)
+	}
+	if status, attempts := audioJobState(t, store, jobs[0].ID); status != "completed" || attempts != 1 {
+		t.Fatalf("expected the finished job to be left alone, got status=%s attempts=%d", status, attempts)
+	}
+	if status, attempts := audioJobState(t, store, jobs[1].ID); status != "pending" || attempts != 0 {
+		t.Fatalf("expected the released job to be pending with no attempts, got status=%s attempts=%d", status, attempts)
+	}
+
+	// On its only attempt, a job left claimed would be marked dead once the
+	// claim goes stale; a released one is claimed again instead.
+	reclaimed := claimAudioJobsAt(t, store, now.Add(10*time.Minute))
+	if len(reclaimed) != 1 || reclaimed[0].ID != jobs[1].ID {
+		t.Fatalf("expected the released job to be claimed again, got %+v", reclaimed)
+	}
+}
+
+func TestRetryPoliciesArePerQueue(t *testing.T) {
+	store, err := New(Config{
+		Path:           filepath.Join(t.TempDir(), "per-queue.db"),
+		EmbeddingRetry: JobRetryPolicy{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tombstoneTestTracks(1)); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	audioJobs := claimAudioJobsAt(t, store, time.Now())
+	embeddingJobs, err := store.ClaimPendingEmbeddingJobs(ctx, ClaimOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("claim embedding jobs: %v", err)
+	}
+	if len(audioJobs) != 1 || len(embeddingJobs) != 1 {
+		t.Fatalf("expected one job per queue, got %d audio and %d embedding", len(audioJobs), len(embeddingJobs))
+	}
+	if err := store.FailAudioJob(ctx, audioJobs[0].ID, errors.New("boom")); err != nil {
+		t.Fatalf("fail audio job: %v", err)
+	}
+	if err := store.FailEmbeddingJob(ctx, embeddingJobs[0].ID, errors.New("boom")); err != nil {
+		t.Fatalf("fail embedding job: %v", err)
+	}
+
+	if status, _ := audioJobState(t, store, audioJobs[0].ID); status != "pending" {
+		t.Fatalf("expected audio job to be retried, got %s", status)
+	}
+	var status string
+	if err := store.db.QueryRowContext(ctx, "SELECT status FROM track_embedding_jobs WHERE id = ?", embeddingJobs[0].ID).Scan(&status); err != nil {
+		t.Fatalf("query embedding job: %v", err)
+	}
+	if status != "dead" {
+		t.Fatalf("expected embedding job to be dead, got %s", status)
+	}
+}
+
+func claimAudioJobsAt(t *testing.T, store *Store, now time.Time) []AudioJob {
+	t.Helper()
+	jobs, err := store.ClaimPendingAudioJobs(context.Background(), ClaimOptions{
+		Limit:      10,
+		ClaimedBy:  "retry-test",
+		StaleAfter: 5 * time.Minute,
+		Now:        now.UTC(),
+	})
+	if err != nil {
+		t.Fatalf("claim audio jobs: %v", err)
+	}
+	return jobs
+}
+
+func audioJobState(t *testing.T, store *Store, jobID int64) (string, int) {
+	t.Helper()
+	var status string
+	var attempts int
+	if err := store.db.QueryRowContext(context.Background(), "SELECT status, attempts FROM track_audio_analysis WHERE id = ?", jobID).Scan(&status, &attempts); err != nil {
+		t.Fatalf("query audio job %d: %v", jobID, err)
+	}
+	return status, attempts
+}
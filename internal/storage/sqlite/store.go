@@ -11,6 +11,7 @@ import (
 	_ "modernc.org/sqlite"
 
 	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/audio"
 	"github.com/bowmanmike/playlistgen/internal/db"
 	"github.com/bowmanmike/playlistgen/internal/migrations"
 )
@@ -19,12 +20,22 @@ import (
 type Config struct {
 	Path                string
 	ForceProcessingJobs bool
+	// MaxDeletePercent aborts a sync that would tombstone more than this
+	// percentage of the active tracks. Zero disables the guard.
+	MaxDeletePercent float64
+	// AudioRetry and EmbeddingRetry control retries of failed jobs in each
+	// queue.
+	AudioRetry     JobRetryPolicy
+	EmbeddingRetry JobRetryPolicy
 }
 
 // Store implements app.TrackStore backed by SQLite.
 type Store struct {
 	db                  *sql.DB
 	forceProcessingJobs bool
+	maxDeletePercent    float64
+	audioRetry          JobRetryPolicy
+	embeddingRetry      JobRetryPolicy
 }
 
 // New creates a Store and ensures schema exists.
@@ -56,7 +67,13 @@ func New(cfg Config) (*Store, error) {
 		return nil, fmt.Errorf("run migrations: %w", err)
 	}
 
-	return &Store{db: db, forceProcessingJobs: cfg.ForceProcessingJobs}, nil
+	return &Store{
+		db:                  db,
+		forceProcessingJobs: cfg.ForceProcessingJobs,
+		maxDeletePercent:    cfg.MaxDeletePercent,
+		audioRetry:          cfg.AudioRetry.withDefaults(),
+		embeddingRetry:      cfg.EmbeddingRetry.withDefaults(),
+	}, nil
 }
 
 // AudioJob represents a pending audio analysis task and its associated track.
@@ -64,9 +81,13 @@ type AudioJob struct {
 	ID      int64
 	TrackID int64
 	Track   app.Track
+	// Fingerprint identifies the file the stored features were measured
+	// from. It is nil when the track has no stored fingerprint.
+	Fingerprint *AudioFingerprintRecord
 }
 
-// ClaimOptions controls how audio jobs are claimed for processing.
+// ClaimOptions controls how audio jobs are claimed for processing. Each claim
+// counts as an attempt; pending jobs waiting out a retry backoff are skipped.
 type ClaimOptions struct {
 	Limit      int
 	ClaimedBy  string
@@ -89,6 +110,60 @@ type AudioFeatureRecord struct {
 	EffectivePeak          *float64
 	EffectiveGainSource    string
 	EffectivePeakSource    string
+	MeasuredTempoBPM       *float64
+	// MeasuredTempoConfidence runs from 0 to 1.
+	MeasuredTempoConfidence *float64
+	TagBPM                  *float64
+	EffectiveBPM            *float64
+	// EffectiveBPMSource defaults to "none" when empty.
+	EffectiveBPMSource string
+	// MeasuredKey is the tonic ("A", "F#") and MeasuredMode "major" or
+	// "minor". All key fields are empty when no key was detected.
+	MeasuredKey           string
+	MeasuredMode          string
+	MeasuredKeyConfidence *float64
+	CamelotKey            string
+	// MeasuredLoudnessRange is the loudness range (LRA) in LU.
+	MeasuredLoudnessRange *float64
+	// Spectral holds the PCM-derived features and is nil when they were not
+	// extracted. SpectralVersion is stored with them.
+	Spectral *SpectralFeatureRecord
+	// Fingerprint is nil when the file was not fingerprinted.
+	Fingerprint *AudioFingerprintRecord
+	// AcousticFingerprint is the stored form of audio.AcousticFingerprint and
+	// is empty when the track was too short or silent to fingerprint.
+	AcousticFingerprint []uint32
+	// Boundaries is nil when the track's content boundaries were not found.
+	Boundaries *BoundaryRecord
+}
+
+// BoundaryRecord is the stored form of audio.Boundaries.
+type BoundaryRecord struct {
+	LeadingSilenceSeconds  float64
+	TrailingSilenceSeconds float64
+	ContentStartSeconds    float64
+	ContentEndSeconds      float64
+	AbruptStart            bool
+	AbruptEnd              bool
+}
+
+// AudioFingerprintRecord is the stored form of audio.Fingerprint together
+// with the analyzer version that measured the file.
+type AudioFingerprintRecord struct {
+	Size            int64
+	ModTime         time.Time
+	AudioHash       string
+	AnalyzerVersion int
+}
+
+// SpectralFeatureRecord is the stored form of audio.SpectralFeatures.
+type SpectralFeatureRecord struct {
+	Version          int
+	CentroidHz       float64
+	RolloffHz        float64
+	CrestFactorDB    float64
+	ZeroCrossingRate float64
+	OnsetDensity     float64
 }
 
 // AudioProcessingRunSummary captures final counters for one audio-process run.
@@ -98,32 +173,76 @@ type AudioProcessingRunSummary struct {
 	JobsClaimed   int
 	JobsCompleted int
 	JobsFailed    int
+	// JobsSkipped counts completed jobs whose file was unchanged and so was
+	// not analyzed again.
+	JobsSkipped int
 }
 
-// SaveTracks inserts or replaces provided tracks and records sync metadata.
-func (s *Store) SaveTracks(ctx context.Context, tracks []app.Track) (app.SaveStats, error) {
+// StartSync records a new in-progress sync run. It is committed on its own so
+// the run stays in the history even if the sync later fails.
+func (s *Store) StartSync(ctx context.Context, startedAt time.Time) (int64, error) {
+	syncID, err := db.New(s.db).CreateSync(ctx, formatTimestamp(startedAt.UTC()))
+	if err != nil {
+		return 0, fmt.Errorf("create sync: %w", err)
+	}
+	return syncID, nil
+}
+
+// FailSync marks a sync run as failed with the phase and error that stopped it.
+func (s *Store) FailSync(ctx context.Context, syncID int64, failure app.SyncFailure) error {
+	message := sql.NullString{}
+	if failure.Err != nil {
+		message = sql.NullString{String: failure.Err.Error(), Valid: true}
+	}
+	if err := db.New(s.db).FailSync(ctx, db.FailSyncParams{
+		CompletedAt:     sql.NullString{String: nowUTC(), Valid: true},
+		FailedPhase:     nullStringValue(failure.Phase),
+		Error:           message,
+		TracksProcessed: int64(failure.Processed),
+		ID:              syncID,
+	}); err != nil {
+		return fmt.Errorf("fail sync: %w", err)
+	}
+	return nil
+}
+
+// SaveTracks inserts or replaces provided tracks and completes the sync run.
+// Tracks missing from the list are tombstoned rather than deleted, and
+// tombstoned tracks that reappear are restored with their analysis intact.
+// Errors are wrapped in an app.PhaseError naming the upsert or delete phase.
+func (s *Store) SaveTracks(ctx context.Context, syncID int64, tracks []app.Track) (app.SaveStats, error) {
 	stats := app.SaveStats{Fetched: len(tracks)}
+	if err := db.New(s.db).MarkSyncFetched(ctx, db.MarkSyncFetchedParams{
+		FetchCompletedAt: sql.NullString{String: nowUTC(), Valid: true},
+		TracksFetched:    int64(len(tracks)),
+		ID:               syncID,
+	}); err != nil {
+		return stats, &app.PhaseError{Phase: app.SyncPhaseUpsert, Err: fmt.Errorf("mark sync fetched: %w", err)}
+	}
 	if len(tracks) == 0 {
+		if err := completeSync(ctx, db.New(s.db), syncID, 0, 0, 0); err != nil {
+			return stats, &app.PhaseError{Phase: app.SyncPhaseUpsert, Err: err}
+		}
 		return stats, nil
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return app.SaveStats{}, fmt.Errorf("begin tx: %w", err)
+	processed, updated, deleted, restored, statsUpdated := 0, 0, 0, 0, 0
+	fail := func(phase string, err error) (app.SaveStats, error) {
+		stats.Updated = updated
+		stats.Skipped = processed - updated
+		return stats, &app.PhaseError{Phase: phase, Err: err}
 	}
 
-	queries := db.New(tx)
-	startedAt := nowUTC()
-	syncID, err := queries.CreateSync(ctx, startedAt)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		tx.Rollback()
-		return app.SaveStats{}, fmt.Errorf("create sync: %w", err)
+		return fail(app.SyncPhaseUpsert, fmt.Errorf("begin tx: %w", err))
 	}
+	queries := db.New(tx)
 
 	statusRows, err := queries.ListTrackSyncStatus(ctx)
 	if err != nil {
 		tx.Rollback()
-		return app.SaveStats{}, fmt.Errorf("list track sync statuses: %w", err)
+		return fail(app.SyncPhaseUpsert, fmt.Errorf("list track sync statuses: %w", err))
 	}
 
 	statusMap := make(map[string]trackSyncStatus, len(statusRows))
@@ -132,11 +251,24 @@ func (s *Store) SaveTracks(ctx context.Context, tracks []app.Track) (app.SaveSta
 		statusMap[row.NavidromeID] = trackSyncStatus{
 			trackID:      row.TrackID,
 			lastSyncedAt: parseTimestamp(row.LastSyncedAt),
+			deleted:      row.DeletedAt.Valid,
+		}
+		if !row.DeletedAt.Valid {
+			existingNavIDs[row.NavidromeID] = struct{}{}
 		}
-		existingNavIDs[row.NavidromeID] = struct{}{}
 	}
 
-	processed, updated, deleted := 0, 0, 0
+	userStats, err := loadUserStats(ctx, queries)
+	if err != nil {
+		tx.Rollback()
+		return fail(app.SyncPhaseUpsert, err)
+	}
+	extendedIDs, err := loadExtendedMetadataIDs(ctx, queries)
+	if err != nil {
+		tx.Rollback()
+		return fail(app.SyncPhaseUpsert, err)
+	}
+
 	remoteNavIDs := make(map[string]struct{}, len(tracks))
 
 	for _, tr := range tracks {
@@ -153,12 +285,31 @@ func (s *Store) SaveTracks(ctx context.Context, tracks []app.Track) (app.SaveSta
 				SyncID:       syncID,
 			}); err != nil {
 				tx.Rollback()
-				return app.SaveStats{}, fmt.Errorf("touch track sync status: %w", err)
+				return fail(app.SyncPhaseUpsert, fmt.Errorf("touch track sync status: %w", err))
+			}
+			if status.deleted {
+				if err := queries.RestoreTrack(ctx, status.trackID); err != nil {
+					tx.Rollback()
+					return fail(app.SyncPhaseUpsert, fmt.Errorf("restore track: %w", err))
+				}
+				restored++
+			}
+			statsChanged, err := syncUserStats(ctx, queries, status.trackID, tr.Stats, userStats)
+			if err != nil {
+				tx.Rollback()
+				return fail(app.SyncPhaseUpsert, err)
+			}
+			if statsChanged {
+				statsUpdated++
+			}
+			if err := syncExtendedMetadata(ctx, queries, status.trackID, tr.Extended, extendedIDs, false); err != nil {
+				tx.Rollback()
+				return fail(app.SyncPhaseUpsert, err)
 			}
 			if s.forceProcessingJobs && status.trackID != 0 {
 				if err := enqueueProcessingJobs(ctx, queries, status.trackID); err != nil {
 					tx.Rollback()
-					return app.SaveStats{}, err
+					return fail(app.SyncPhaseUpsert, err)
 				}
 			}
 			continue
@@ -166,9 +317,12 @@ func (s *Store) SaveTracks(ctx context.Context, tracks []app.Track) (app.SaveSta
 
 		if err := queries.UpsertTrack(ctx, convertTrack(tr)); err != nil {
 			tx.Rollback()
-			return app.SaveStats{}, fmt.Errorf("upsert track: %w", err)
+			return fail(app.SyncPhaseUpsert, fmt.Errorf("upsert track: %w", err))
 		}
 		updated++
+		if status.deleted {
+			restored++
+		}
 
 		trackID := status.trackID
 		if trackID == 0 {
@@ -176,7 +330,7 @@ func (s *Store) SaveTracks(ctx context.Context, tracks []app.Track) (app.SaveSta
 			trackID, err = queries.SelectTrackID(ctx, tr.ID)
 			if err != nil {
 				tx.Rollback()
-				return app.SaveStats{}, fmt.Errorf("select track id: %w", err)
+				return fail(app.SyncPhaseUpsert, fmt.Errorf("select track id: %w", err))
 			}
 		}
 
@@ -188,16 +342,29 @@ func (s *Store) SaveTracks(ctx context.Context, tracks []app.Track) (app.SaveSta
 			SyncID:       syncID,
 		}); err != nil {
 			tx.Rollback()
-			return app.SaveStats{}, fmt.Errorf("update track sync status: %w", err)
+			return fail(app.SyncPhaseUpsert, fmt.Errorf("update track sync status: %w", err))
 		}
 		statusMap[tr.ID] = trackSyncStatus{
 			trackID:      trackID,
 			lastSyncedAt: syncedAt,
 		}
 
+		statsChanged, err := syncUserStats(ctx, queries, trackID, tr.Stats, userStats)
+		if err != nil {
+			tx.Rollback()
+			return fail(app.SyncPhaseUpsert, err)
+		}
+		if statsChanged {
+			statsUpdated++
+		}
+		if err := syncExtendedMetadata(ctx, queries, trackID, tr.Extended, extendedIDs, true); err != nil {
+			tx.Rollback()
+			return fail(app.SyncPhaseUpsert, err)
+		}
+
 		if err := enqueueProcessingJobs(ctx, queries, trackID); err != nil {
 			tx.Rollback()
-			return app.SaveStats{}, err
+			return fail(app.SyncPhaseUpsert, err)
 		}
 	}
 
@@ -209,36 +376,51 @@ func (s *Store) SaveTracks(ctx context.Context, tracks []app.Track) (app.SaveSta
 	}
 
 	if len(toDelete) > 0 {
-		if err := queries.DeleteTracksByNavidromeIDs(ctx, toDelete); err != nil {
+		if err := checkDeleteThreshold(len(toDelete), len(existingNavIDs), s.maxDeletePercent); err != nil {
 			tx.Rollback()
-			return app.SaveStats{}, fmt.Errorf("delete missing tracks: %w", err)
+			return fail(app.SyncPhaseDelete, err)
+		}
+		if err := queries.SoftDeleteTracksByNavidromeIDs(ctx, db.SoftDeleteTracksByNavidromeIDsParams{
+			DeletedAt: sql.NullString{String: nowUTC(), Valid: true},
+			NavIds:    toDelete,
+		}); err != nil {
+			tx.Rollback()
+			return fail(app.SyncPhaseDelete, fmt.Errorf("tombstone missing tracks: %w", err))
 		}
 		deleted = len(toDelete)
 	}
 
-	completedAt := sql.NullString{String: nowUTC(), Valid: true}
-	if err := queries.CompleteSync(ctx, db.CompleteSyncParams{
-		CompletedAt:     completedAt,
-		Status:          "completed",
-		TracksProcessed: int64(processed),
-		TracksUpdated:   int64(updated),
-		TracksDeleted:   int64(deleted),
-		ID:              syncID,
-	}); err != nil {
+	if err := completeSync(ctx, queries, syncID, processed, updated, deleted); err != nil {
 		tx.Rollback()
-		return app.SaveStats{}, fmt.Errorf("complete sync: %w", err)
+		return fail(app.SyncPhaseUpsert, err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return app.SaveStats{}, fmt.Errorf("commit tx: %w", err)
+		return fail(app.SyncPhaseUpsert, fmt.Errorf("commit tx: %w", err))
 	}
 
 	stats.Updated = updated
 	stats.Skipped = processed - updated
 	stats.Deleted = deleted
+	stats.Restored = restored
+	stats.StatsUpdated = statsUpdated
 	return stats, nil
 }
 
+func completeSync(ctx context.Context, queries *db.Queries, syncID int64, processed, updated, deleted int) error {
+	if err := queries.CompleteSync(ctx, db.CompleteSyncParams{
+		CompletedAt:     sql.NullString{String: nowUTC(), Valid: true},
+		Status:          "completed",
+		TracksProcessed: int64(processed),
+		TracksUpdated:   int64(updated),
+		TracksDeleted:   int64(deleted),
+		ID:              syncID,
+	}); err != nil {
+		return fmt.Errorf("complete sync: %w", err)
+	}
+	return nil
+}
+
 // Close releases database resources.
 func (s *Store) Close() error {
 	if s.db == nil {
@@ -250,6 +432,7 @@ func (s *Store) Close() error {
 type trackSyncStatus struct {
 	trackID      int64
 	lastSyncedAt time.Time
+	deleted      bool
 }
 
 func trackChangedAt(tr app.Track) time.Time {
@@ -269,6 +452,10 @@ func parseTimestamp(value string) time.Time {
 	if ts, err := time.Parse(time.RFC3339, value); err == nil {
 		return ts
 	}
+	// Columns defaulting to datetime('now') hold UTC without a zone.
+	if ts, err := time.Parse(time.DateTime, value); err == nil {
+		return ts
+	}
 	return time.Time{}
 }
 
@@ -286,19 +473,55 @@ func nowUTC() string {
 // UpsertTrackAudioFeatures writes the latest durable audio feature snapshot for a track.
 func (s *Store) UpsertTrackAudioFeatures(ctx context.Context, record AudioFeatureRecord) error {
 	params := db.UpsertTrackAudioFeaturesParams{
-		TrackID:                record.TrackID,
-		AnalyzedAt:             formatTimestamp(record.AnalyzedAt.UTC()),
-		FileDurationSeconds:    record.FileDurationSeconds,
-		MeasuredIntegratedLufs: nullFloat64Ptr(record.MeasuredIntegratedLUFS),
-		MeasuredTruePeak:       nullFloat64Ptr(record.MeasuredTruePeak),
-		ReplaygainTrackGainDb:  nullFloat64Ptr(record.ReplayGainTrackGainDB),
-		ReplaygainTrackPeak:    nullFloat64Ptr(record.ReplayGainTrackPeak),
-		ReplaygainAlbumGainDb:  nullFloat64Ptr(record.ReplayGainAlbumGainDB),
-		ReplaygainAlbumPeak:    nullFloat64Ptr(record.ReplayGainAlbumPeak),
-		EffectiveGainDb:        nullFloat64Ptr(record.EffectiveGainDB),
-		EffectivePeak:          nullFloat64Ptr(record.EffectivePeak),
-		EffectiveGainSource:    record.EffectiveGainSource,
-		EffectivePeakSource:    record.EffectivePeakSource,
+		TrackID:                 record.TrackID,
+		AnalyzedAt:              formatTimestamp(record.AnalyzedAt.UTC()),
+		FileDurationSeconds:     record.FileDurationSeconds,
+		MeasuredIntegratedLufs:  nullFloat64Ptr(record.MeasuredIntegratedLUFS),
+		MeasuredTruePeak:        nullFloat64Ptr(record.MeasuredTruePeak),
+		ReplaygainTrackGainDb:   nullFloat64Ptr(record.ReplayGainTrackGainDB),
+		ReplaygainTrackPeak:     nullFloat64Ptr(record.ReplayGainTrackPeak),
+		ReplaygainAlbumGainDb:   nullFloat64Ptr(record.ReplayGainAlbumGainDB),
+		ReplaygainAlbumPeak:     nullFloat64Ptr(record.ReplayGainAlbumPeak),
+		EffectiveGainDb:         nullFloat64Ptr(record.EffectiveGainDB),
+		EffectivePeak:           nullFloat64Ptr(record.EffectivePeak),
+		EffectiveGainSource:     record.EffectiveGainSource,
+		EffectivePeakSource:     record.EffectivePeakSource,
+		MeasuredTempoBpm:        nullFloat64Ptr(record.MeasuredTempoBPM),
+		MeasuredTempoConfidence: nullFloat64Ptr(record.MeasuredTempoConfidence),
+		TagBpm:                  nullFloat64Ptr(record.TagBPM),
+		EffectiveBpm:            nullFloat64Ptr(record.EffectiveBPM),
+		EffectiveBpmSource:      record.EffectiveBPMSource,
+		MeasuredKey:             nullStringValue(record.MeasuredKey),
+		MeasuredMode:            nullStringValue(record.MeasuredMode),
+		MeasuredKeyConfidence:   nullFloat64Ptr(record.MeasuredKeyConfidence),
+		CamelotKey:              nullStringValue(record.CamelotKey),
+		MeasuredLoudnessRange:   nullFloat64Ptr(record.MeasuredLoudnessRange),
+		AcousticFingerprint:     encodeAcousticFingerprint(record.AcousticFingerprint),
+	}
+	if spectral := record.Spectral; spectral != nil {
+		params.SpectralCentroidHz = sql.NullFloat64{Float64: spectral.CentroidHz, Valid: true}
+		params.SpectralRolloffHz = sql.NullFloat64{Float64: spectral.RolloffHz, Valid: true}
+		params.CrestFactorDb = sql.NullFloat64{Float64: spectral.CrestFactorDB, Valid: true}
+		params.ZeroCrossingRate = sql.NullFloat64{Float64: spectral.ZeroCrossingRate, Valid: true}
+		params.OnsetDensity = sql.NullFloat64{Float64: spectral.OnsetDensity, Valid: true}
+		params.SpectralVersion = sql.NullInt64{Int64: int64(spectral.Version), Valid: true}
+	}
+	if fingerprint := record.Fingerprint; fingerprint != nil {
+		params.FileSize = sql.NullInt64{Int64: fingerprint.Size, Valid: true}
+		params.FileMtime = nullStringValue(formatTimestamp(fingerprint.ModTime.UTC()))
+		params.AudioHash = nullStringValue(fingerprint.AudioHash)
+		params.AnalyzerVersion = sql.NullInt64{Int64: int64(fingerprint.AnalyzerVersion), Valid: true}
+	}
+	if boundaries := record.Boundaries; boundaries != nil {
+		params.LeadingSilenceSeconds = sql.NullFloat64{Float64: boundaries.LeadingSilenceSeconds, Valid: true}
+		params.TrailingSilenceSeconds = sql.NullFloat64{Float64: boundaries.TrailingSilenceSeconds, Valid: true}
+		params.ContentStartSeconds = sql.NullFloat64{Float64: boundaries.ContentStartSeconds, Valid: true}
+		params.ContentEndSeconds = sql.NullFloat64{Float64: boundaries.ContentEndSeconds, Valid: true}
+		params.AbruptStart = nullBool(boundaries.AbruptStart)
+		params.AbruptEnd = nullBool(boundaries.AbruptEnd)
+	}
+	if params.EffectiveBpmSource == "" {
+		params.EffectiveBpmSource = "none"
 	}
 	if err := db.New(s.db).UpsertTrackAudioFeatures(ctx, params); err != nil {
 		return fmt.Errorf("upsert track audio features: %w", err)
@@ -306,6 +529,94 @@ func (s *Store) UpsertTrackAudioFeatures(ctx context.Context, record AudioFeatur
 	return nil
 }
 
+// UpdateTrackAudioFingerprint records that trackID's stored features still
+// describe the file fingerprint identifies, after the file changed on disk
+// without its audio changing. The analyzer version is left as it is.
+func (s *Store) UpdateTrackAudioFingerprint(ctx context.Context, trackID int64, fingerprint AudioFingerprintRecord) error {
+	if err := db.New(s.db).UpdateTrackAudioFingerprint(ctx, db.UpdateTrackAudioFingerprintParams{
+		FileSize:  sql.NullInt64{Int64: fingerprint.Size, Valid: true},
+		FileMtime: nullStringValue(formatTimestamp(fingerprint.ModTime.UTC())),
+		AudioHash: nullStringValue(fingerprint.AudioHash),
+		TrackID:   trackID,
+	}); err != nil {
+		return fmt.Errorf("update track audio fingerprint: %w", err)
+	}
+	return nil
+}
+
+// UpdateTrackFileTags stores the ReplayGain and BPM tags read from trackID's
+// file and recomputes its effective gain and tempo from them, server, and the
+// stored measurements. It is used when the audio is unchanged and the file is
+// not analyzed again, since its tags may still have changed. Like a fresh
+// analysis it ignores album loudness, which UpdateAlbumLoudness applies.
+func (s *Store) UpdateTrackFileTags(ctx context.Context, trackID int64, tags audio.FileTags, server audio.ServerTags) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin file tags update: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := db.New(tx)
+	row, err := queries.GetTrackMeasuredAudio(ctx, trackID)
+	if err != nil {
+		return fmt.Errorf("get measured audio for track %d: %w", trackID, err)
+	}
+	measured := audio.MeasuredAudio{
+		IntegratedLUFS:  float64PtrFromSQL(row.MeasuredIntegratedLufs),
+		TruePeak:        float64PtrFromSQL(row.MeasuredTruePeak),
+		TempoBPM:        float64PtrFromSQL(row.MeasuredTempoBpm),
+		TempoConfidence: float64PtrFromSQL(row.MeasuredTempoConfidence),
+	}
+	effective := audio.EffectiveValues(tags.ReplayGain, server.ReplayGain, nil, measured)
+	effective.TempoBPM, effective.TempoSource = audio.EffectiveTempo(tags.BPM, server.BPM, measured)
+	if err := queries.UpdateTrackFileTags(ctx, db.UpdateTrackFileTagsParams{
+		ReplaygainTrackGainDb: nullFloat64Ptr(tags.ReplayGain.TrackGainDB),
+		ReplaygainTrackPeak:   nullFloat64Ptr(tags.ReplayGain.TrackPeak),
+		ReplaygainAlbumGainDb: nullFloat64Ptr(tags.ReplayGain.AlbumGainDB),
+		ReplaygainAlbumPeak:   nullFloat64Ptr(tags.ReplayGain.AlbumPeak),
+		TagBpm:                nullFloat64Ptr(tags.BPM),
+		EffectiveGainDb:       nullFloat64Ptr(effective.GainDB),
+		EffectivePeak:         nullFloat64Ptr(effective.Peak),
+		EffectiveGainSource:   effective.GainSource,
+		EffectivePeakSource:   effective.PeakSource,
+		EffectiveBpm:          nullFloat64Ptr(effective.TempoBPM),
+		EffectiveBpmSource:    effective.TempoSource,
+		TrackID:               trackID,
+	}); err != nil {
+		return fmt.Errorf("update file tags for track %d: %w", trackID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit file tags update: %w", err)
+	}
+	return nil
+}
+
+// RequeueOutdatedAudioJobs queues tracks whose stored features were measured
+// by an analyzer older than version, including features stored before
+// versions were recorded. It returns the number of tracks queued.
+func (s *Store) RequeueOutdatedAudioJobs(ctx context.Context, version int) (int, error) {
+	n, err := db.New(s.db).RequeueOutdatedAudioJobs(ctx, sql.NullInt64{Int64: int64(version), Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("requeue outdated audio jobs: %w", err)
+	}
+	return int(n), nil
+}
+
+// UpdateAlbumFlowThrough flags every analyzed track that flows into the next
+// track on its album: it ends abruptly with at most maxGapSeconds of trailing
+// silence, and the next track starts abruptly after at most maxGapSeconds of
+// leading silence. Flags are recomputed for the whole library.
+func (s *Store) UpdateAlbumFlowThrough(ctx context.Context, maxGapSeconds float64) error {
+	gap := sql.NullFloat64{Float64: maxGapSeconds, Valid: true}
+	if err := db.New(s.db).UpdateAlbumFlowThrough(ctx, db.UpdateAlbumFlowThroughParams{
+		TrailingSilenceSeconds: gap,
+		LeadingSilenceSeconds:  gap,
+	}); err != nil {
+		return fmt.Errorf("update album flow-through: %w", err)
+	}
+	return nil
+}
+
 // StartAudioProcessingRun records the start of one audio-process invocation.
 func (s *Store) StartAudioProcessingRun(ctx context.Context, startedAt time.Time) (int64, error) {
 	runID, err := db.New(s.db).CreateAudioProcessingRun(ctx, formatTimestamp(startedAt.UTC()))
@@ -323,6 +634,7 @@ func (s *Store) CompleteAudioProcessingRun(ctx context.Context, runID int64, sum
 		JobsClaimed:   int64(summary.JobsClaimed),
 		JobsCompleted: int64(summary.JobsCompleted),
 		JobsFailed:    int64(summary.JobsFailed),
+		JobsSkipped:   int64(summary.JobsSkipped),
 		ID:            runID,
 	}
 	if err := db.New(s.db).CompleteAudioProcessingRun(ctx, params); err != nil {
@@ -405,6 +717,15 @@ func nullInt64Ptr(v *int64) sql.NullInt64 {
 	return sql.NullInt64{Int64: *v, Valid: true}
 }
 
+// nullBool stores a known boolean as 0 or 1.
+func nullBool(v bool) sql.NullInt64 {
+	n := sql.NullInt64{Valid: true}
+	if v {
+		n.Int64 = 1
+	}
+	return n
+}
+
 func nullFloat64Ptr(v *float64) sql.NullFloat64 {
 	if v == nil {
 		return sql.NullFloat64{}
@@ -443,6 +764,14 @@ func int64PtrFromSQL(ni sql.NullInt64) *int64 {
 	return &v
 }
 
+func float64PtrFromSQL(nf sql.NullFloat64) *float64 {
+	if !nf.Valid {
+		return nil
+	}
+	v := nf.Float64
+	return &v
+}
+
 func enqueueProcessingJobs(ctx context.Context, queries *db.Queries, trackID int64) error {
 	status := "pending"
 	resetValue := sql.NullString{}
@@ -453,6 +782,7 @@ func enqueueProcessingJobs(ctx context.Context, queries *db.Queries, trackID int
 		Error:         resetValue,
 		Attempts:      0,
 		LastAttemptAt: resetValue,
+		NextAttemptAt: resetValue,
 		ClaimedAt:     resetValue,
 		ClaimedBy:     resetValue,
 	}); err != nil {
@@ -466,6 +796,7 @@ func enqueueProcessingJobs(ctx context.Context, queries *db.Queries, trackID int
 		Error:         resetValue,
 		Attempts:      0,
 		LastAttemptAt: resetValue,
+		NextAttemptAt: resetValue,
 		ClaimedAt:     resetValue,
 		ClaimedBy:     resetValue,
 	}); err != nil {
@@ -496,11 +827,23 @@ func (s *Store) ClaimPendingAudioJobs(ctx context.Context, opts ClaimOptions) ([
 	}
 
 	queries := db.New(s.db)
+	maxAttempts := int64(s.audioRetry.MaxAttempts)
+	if staleBefore.Valid {
+		if err := queries.MarkExhaustedAudioJobsDead(ctx, db.MarkExhaustedAudioJobsDeadParams{
+			ClaimedAt: staleBefore,
+			Attempts:  maxAttempts,
+		}); err != nil {
+			return nil, fmt.Errorf("mark exhausted audio jobs dead: %w", err)
+		}
+	}
 	claimedRows, err := queries.ClaimPendingAudioJobs(ctx, db.ClaimPendingAudioJobsParams{
-		ClaimedAt:   claimedAt,
-		ClaimedBy:   sql.NullString{String: opts.ClaimedBy, Valid: true},
-		ClaimedAt_2: staleBefore,
-		Limit:       int64(opts.Limit),
+		ClaimedAt:     claimedAt,
+		ClaimedBy:     sql.NullString{String: opts.ClaimedBy, Valid: true},
+		LastAttemptAt: claimedAt,
+		NextAttemptAt: claimedAt,
+		ClaimedAt_2:   staleBefore,
+		Attempts:      maxAttempts,
+		Limit:         int64(opts.Limit),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("claim audio jobs: %w", err)
@@ -527,15 +870,68 @@ func (s *Store) ClaimPendingAudioJobs(ctx context.Context, opts ClaimOptions) ([
 			Track:   convertDBTrack(row.Track),
 		})
 	}
+	if err := attachExtendedMetadata(ctx, queries, jobs); err != nil {
+		return nil, err
+	}
+	if err := attachAudioFingerprints(ctx, queries, jobs); err != nil {
+		return nil, err
+	}
 	return jobs, nil
 }
 
+// attachExtendedMetadata fills Track.Extended for audio jobs so the analyzer
+// can fall back to server-reported ReplayGain.
+func attachExtendedMetadata(ctx context.Context, queries *db.Queries, jobs []AudioJob) error {
+	trackIDs := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		trackIDs = append(trackIDs, job.TrackID)
+	}
+	metadata, err := loadExtendedMetadata(ctx, queries, trackIDs)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		jobs[i].Track.Extended = metadata[jobs[i].TrackID]
+	}
+	return nil
+}
+
+// attachAudioFingerprints fills Fingerprint for audio jobs whose track has a
+// stored fingerprint, so unchanged files can skip analysis.
+func attachAudioFingerprints(ctx context.Context, queries *db.Queries, jobs []AudioJob) error {
+	trackIDs := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		trackIDs = append(trackIDs, job.TrackID)
+	}
+	rows, err := queries.ListAudioFingerprintsByTrackIDs(ctx, trackIDs)
+	if err != nil {
+		return fmt.Errorf("load audio fingerprints: %w", err)
+	}
+	fingerprints := make(map[int64]*AudioFingerprintRecord, len(rows))
+	for _, row := range rows {
+		if !row.FileSize.Valid || !row.FileMtime.Valid {
+			continue
+		}
+		fingerprints[row.TrackID] = &AudioFingerprintRecord{
+			Size:            row.FileSize.Int64,
+			ModTime:         parseTimestamp(row.FileMtime.String),
+			AudioHash:       row.AudioHash.String,
+			AnalyzerVersion: int(row.AnalyzerVersion.Int64),
+		}
+	}
+	for i := range jobs {
+		jobs[i].Fingerprint = fingerprints[jobs[i].TrackID]
+	}
+	return nil
+}
+
 // ListPendingAudioJobs returns pending audio jobs up to the provided limit.
 func (s *Store) ListPendingAudioJobs(ctx context.Context, limit int) ([]AudioJob, error) {
 	if limit <= 0 {
 		limit = 50
 	}
-	rows, err := db.New(s.db).ListPendingAudioJobs(ctx, int64(limit))
+	queries := db.New(s.db)
+	rows, err := queries.ListPendingAudioJobs(ctx, int64(limit))
 	if err != nil {
 		return nil, fmt.Errorf("list audio jobs: %w", err)
 	}
@@ -547,38 +943,55 @@ func (s *Store) ListPendingAudioJobs(ctx context.Context, limit int) ([]AudioJob
 			Track:   convertDBTrack(row.Track),
 		})
 	}
+	if err := attachExtendedMetadata(ctx, queries, jobs); err != nil {
+		return nil, err
+	}
+	if err := attachAudioFingerprints(ctx, queries, jobs); err != nil {
+		return nil, err
+	}
 	return jobs, nil
 }
 
 // CompleteAudioJob marks an audio job as processed successfully.
 func (s *Store) CompleteAudioJob(ctx context.Context, jobID int64) error {
-	return s.setAudioJobStatus(ctx, jobID, "completed", nil)
+	return s.setAudioJobStatus(ctx, jobID, "completed", sql.NullString{}, sql.NullString{})
 }
 
-// FailAudioJob marks an audio job as failed with the supplied error.
+// FailAudioJob records jobErr and schedules a retry after the audio retry
+// backoff, or marks the job dead once it has used all its attempts.
 func (s *Store) FailAudioJob(ctx context.Context, jobID int64, jobErr error) error {
-	if jobErr == nil {
-		return s.setAudioJobStatus(ctx, jobID, "failed", nil)
+	attempts, err := db.New(s.db).GetAudioJobAttempts(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("get audio job attempts: %w", err)
+	}
+	status, nextAttemptAt := s.audioRetry.schedule(attempts, time.Now())
+	return s.setAudioJobStatus(ctx, jobID, status, nextAttemptAt, jobErrorMessage(jobErr))
+}
+
+// ReleaseAudioJobs returns claimed audio jobs that were never started to
+// pending and gives back the attempt their claim counted, so they are neither
+// delayed nor marked dead for a run that gave up before reaching them.
+func (s *Store) ReleaseAudioJobs(ctx context.Context, jobIDs []int64) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	if err := db.New(s.db).ReleaseAudioJobs(ctx, jobIDs); err != nil {
+		return fmt.Errorf("release audio jobs: %w", err)
 	}
-	msg := jobErr.Error()
-	return s.setAudioJobStatus(ctx, jobID, "failed", &msg)
+	return nil
 }
 
-func (s *Store) setAudioJobStatus(ctx context.Context, jobID int64, status string, failureMessage *string) error {
+func (s *Store) setAudioJobStatus(ctx context.Context, jobID int64, status string, nextAttemptAt, errField sql.NullString) error {
 	processed := sql.NullString{}
 	if status == "completed" {
 		processed = sql.NullString{String: nowUTC(), Valid: true}
 	}
-	errField := sql.NullString{}
-	if failureMessage != nil && strings.TrimSpace(*failureMessage) != "" {
-		errField = sql.NullString{String: *failureMessage, Valid: true}
-	}
 
 	params := db.UpdateAudioJobStatusParams{
 		Status:        status,
 		ProcessedAt:   processed,
 		Error:         errField,
-		LastAttemptAt: sql.NullString{String: nowUTC(), Valid: true},
+		NextAttemptAt: nextAttemptAt,
 		ClaimedAt:     sql.NullString{},
 		ClaimedBy:     sql.NullString{},
 		ID:            jobID,
@@ -55,7 +55,7 @@ func TestSaveTracks(t *testing.T) {
 		CreatedAt:   baseCreated,
 		UpdatedAt:   initialChanged,
 	}}
-	if _, err := store.SaveTracks(context.Background(), tracks); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), tracks); err != nil {
 		t.Fatalf("save tracks: %v", err)
 	}
 
@@ -82,7 +82,7 @@ func TestSaveTracks(t *testing.T) {
 		CreatedAt:   baseCreated,
 		UpdatedAt:   initialChanged,
 	}}
-	if _, err := store.SaveTracks(context.Background(), tracks); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), tracks); err != nil {
 		t.Fatalf("save tracks second: %v", err)
 	}
 
@@ -109,7 +109,7 @@ func TestSaveTracks(t *testing.T) {
 		CreatedAt:   baseCreated,
 		UpdatedAt:   changedAgain,
 	}}
-	if _, err := store.SaveTracks(context.Background(), tracks); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), tracks); err != nil {
 		t.Fatalf("save tracks third: %v", err)
 	}
 
@@ -158,7 +158,7 @@ func TestSaveTracks(t *testing.T) {
 		CreatedAt:   baseCreated.Add(time.Hour),
 	}
 
-	addStats, err := store.SaveTracks(context.Background(), []app.Track{nav1Latest, secondTrack})
+	addStats, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{nav1Latest, secondTrack})
 	if err != nil {
 		t.Fatalf("save tracks add second: %v", err)
 	}
@@ -167,7 +167,7 @@ func TestSaveTracks(t *testing.T) {
 	}
 
 	secondTrack.UpdatedAt = time.Time{}
-	finalStats, err := store.SaveTracks(context.Background(), []app.Track{secondTrack})
+	finalStats, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{secondTrack})
 	if err != nil {
 		t.Fatalf("delete missing tracks: %v", err)
 	}
@@ -223,28 +223,32 @@ func TestSaveTracks(t *testing.T) {
 	}
 
 	var trackCount int
-	if err := db.QueryRow("SELECT COUNT(*) FROM tracks").Scan(&trackCount); err != nil {
+	if err := db.QueryRow("SELECT COUNT(*) FROM tracks WHERE deleted_at IS NULL").Scan(&trackCount); err != nil {
 		t.Fatalf("count tracks: %v", err)
 	}
 	if trackCount != 1 {
-		t.Fatalf("expected only one track remaining, got %d", trackCount)
+		t.Fatalf("expected only one active track remaining, got %d", trackCount)
 	}
 	var remainingNavID string
-	if err := db.QueryRow("SELECT navidrome_id FROM tracks").Scan(&remainingNavID); err != nil {
+	if err := db.QueryRow("SELECT navidrome_id FROM tracks WHERE deleted_at IS NULL").Scan(&remainingNavID); err != nil {
 		t.Fatalf("query remaining track: %v", err)
 	}
 	if remainingNavID != "nav2" {
 		t.Fatalf("expected nav2 to remain, got %s", remainingNavID)
 	}
+	var deletedAt sql.NullString
+	if err := db.QueryRow("SELECT deleted_at FROM tracks WHERE navidrome_id='nav1'").Scan(&deletedAt); err != nil {
+		t.Fatalf("query tombstoned track: %v", err)
+	}
+	if !deletedAt.Valid {
+		t.Fatalf("expected nav1 to be tombstoned")
+	}
 	var jobCount int
 	if err := db.QueryRow("SELECT COUNT(*) FROM track_audio_analysis WHERE track_id IN (SELECT id FROM tracks WHERE navidrome_id='nav1')").Scan(&jobCount); err != nil {
-		t.Fatalf("count deleted audio jobs: %v", err)
-	}
-	if jobCount != 0 {
-		t.Fatalf("expected audio job rows removed for nav1, got %d", jobCount)
+		t.Fatalf("count tombstoned audio jobs: %v", err)
 	}
-	if err := db.QueryRow("SELECT sync_id, last_synced_at FROM navidrome_track_sync_status WHERE navidrome_id=?", "nav1").Scan(new(sql.NullInt64), new(sql.NullString)); err != sql.ErrNoRows {
-		t.Fatalf("expected nav1 sync status removed, got %v", err)
+	if jobCount == 0 {
+		t.Fatalf("expected audio job rows kept for tombstoned nav1")
 	}
 
 	var pendingAudio int
@@ -294,7 +298,7 @@ func TestAudioJobLifecycle(t *testing.T) {
 		Path:      "/music/jobtrack.flac",
 		Suffix:    "flac",
 	}
-	if _, err := store.SaveTracks(context.Background(), []app.Track{track}); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{track}); err != nil {
 		t.Fatalf("save tracks: %v", err)
 	}
 
@@ -335,8 +339,8 @@ func TestAudioJobLifecycle(t *testing.T) {
 	if err := raw.QueryRow("SELECT status, error FROM track_audio_analysis WHERE id=?", job.ID).Scan(&status, new(sql.NullString)); err != nil {
 		t.Fatalf("query failed job: %v", err)
 	}
-	if status != "failed" {
-		t.Fatalf("expected job failed, got %s", status)
+	if status != "pending" {
+		t.Fatalf("expected failed job to be rescheduled, got %s", status)
 	}
 }
 
@@ -359,12 +363,12 @@ func TestSaveTracksDoesNotCreateDuplicatePendingJobs(t *testing.T) {
 		Path:      "/music/dupe.flac",
 		Suffix:    "flac",
 	}
-	if _, err := store.SaveTracks(context.Background(), []app.Track{track}); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{track}); err != nil {
 		t.Fatalf("save tracks first: %v", err)
 	}
 
 	track.UpdatedAt = track.UpdatedAt.Add(time.Hour)
-	if _, err := store.SaveTracks(context.Background(), []app.Track{track}); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{track}); err != nil {
 		t.Fatalf("save tracks second: %v", err)
 	}
 
@@ -420,7 +424,7 @@ func TestClaimPendingAudioJobsReclaimsStaleProcessingJobs(t *testing.T) {
 		Path:      "/music/stale.flac",
 		Suffix:    "flac",
 	}
-	if _, err := store.SaveTracks(context.Background(), []app.Track{track}); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{track}); err != nil {
 		t.Fatalf("save tracks: %v", err)
 	}
 
@@ -474,7 +478,7 @@ func TestStoreClaimPendingAudioJobsSetsClaimFields(t *testing.T) {
 		Path:      "/music/claim.flac",
 		Suffix:    "flac",
 	}
-	if _, err := store.SaveTracks(context.Background(), []app.Track{track}); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{track}); err != nil {
 		t.Fatalf("save tracks: %v", err)
 	}
 
@@ -576,11 +580,12 @@ func TestFailAudioJobRetainsAttemptsAndClearsOwnership(t *testing.T) {
 	var jobErr sql.NullString
 	var claimedAt sql.NullString
 	var claimedBy sql.NullString
-	if err := raw.QueryRow("SELECT status, attempts, error, claimed_at, claimed_by FROM track_audio_analysis WHERE id=?", jobID).Scan(&status, &attempts, &jobErr, &claimedAt, &claimedBy); err != nil {
+	var nextAttemptAt sql.NullString
+	if err := raw.QueryRow("SELECT status, attempts, error, claimed_at, claimed_by, next_attempt_at FROM track_audio_analysis WHERE id=?", jobID).Scan(&status, &attempts, &jobErr, &claimedAt, &claimedBy, &nextAttemptAt); err != nil {
 		t.Fatalf("query failed job: %v", err)
 	}
-	if status != "failed" {
-		t.Fatalf("expected failed status, got %s", status)
+	if status != "pending" || !nextAttemptAt.Valid {
+		t.Fatalf("expected a scheduled retry, got status=%s next_attempt_at=%v", status, nextAttemptAt)
 	}
 	if attempts != 1 {
 		t.Fatalf("expected attempts=1, got %d", attempts)
@@ -620,7 +625,7 @@ func TestClaimPendingAudioJobsDoesNotReturnSameJobTwice(t *testing.T) {
 			Suffix:    "flac",
 		})
 	}
-	if _, err := storeA.SaveTracks(context.Background(), tracks); err != nil {
+	if _, err := storeA.SaveTracks(context.Background(), startTestSync(t, storeA), tracks); err != nil {
 		t.Fatalf("save tracks: %v", err)
 	}
 
@@ -676,7 +681,7 @@ func seedClaimedAudioJob(t *testing.T, store *Store, dbPath, navidromeID string)
 		Path:      "/music/seed.flac",
 		Suffix:    "flac",
 	}
-	if _, err := store.SaveTracks(context.Background(), []app.Track{track}); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{track}); err != nil {
 		t.Fatalf("save seed track: %v", err)
 	}
 
@@ -713,7 +718,7 @@ func TestUpsertTrackAudioFeaturesPersistsMeasuredAndReplayGainValues(t *testing.
 		Path:      "/music/feature.flac",
 		Suffix:    "flac",
 	}
-	if _, err := store.SaveTracks(context.Background(), []app.Track{track}); err != nil {
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), []app.Track{track}); err != nil {
 		t.Fatalf("save track: %v", err)
 	}
 
@@ -784,6 +789,7 @@ func TestAudioProcessingRunLifecycle(t *testing.T) {
 		JobsClaimed:   5,
 		JobsCompleted: 4,
 		JobsFailed:    1,
+		JobsSkipped:   2,
 	}); err != nil {
 		t.Fatalf("complete run: %v", err)
 	}
@@ -798,14 +804,188 @@ func TestAudioProcessingRunLifecycle(t *testing.T) {
 	var jobsClaimed int
 	var jobsCompleted int
 	var jobsFailed int
+	var jobsSkipped int
 	if err := raw.QueryRow(`
-		SELECT status, jobs_claimed, jobs_completed, jobs_failed
+		SELECT status, jobs_claimed, jobs_completed, jobs_failed, jobs_skipped
 		FROM audio_processing_runs
 		WHERE id = ?
-	`, runID).Scan(&status, &jobsClaimed, &jobsCompleted, &jobsFailed); err != nil {
+	`, runID).Scan(&status, &jobsClaimed, &jobsCompleted, &jobsFailed, &jobsSkipped); err != nil {
 		t.Fatalf("query run row: %v", err)
 	}
-	if status != "completed" || jobsClaimed != 5 || jobsCompleted != 4 || jobsFailed != 1 {
-		t.Fatalf("unexpected run row status=%s claimed=%d completed=%d failed=%d", status, jobsClaimed, jobsCompleted, jobsFailed)
+	if status != "completed" || jobsClaimed != 5 || jobsCompleted != 4 || jobsFailed != 1 || jobsSkipped != 2 {
+		t.Fatalf("unexpected run row status=%s claimed=%d completed=%d failed=%d skipped=%d", status, jobsClaimed, jobsCompleted, jobsFailed, jobsSkipped)
+	}
+}
+
+func TestRequeueOutdatedAudioJobsAndFingerprints(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "fingerprints.db")
+	store, err := New(Config{Path: dbPath})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	tracks := []app.Track{
+		{ID: "current", Title: "Current", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(9000, 0), Path: "/music/current.flac"},
+		{ID: "outdated", Title: "Outdated", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(9000, 0), Path: "/music/outdated.flac"},
+	}
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tracks); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	jobs, err := store.ClaimPendingAudioJobs(ctx, ClaimOptions{Limit: 10, ClaimedBy: "test"})
+	if err != nil || len(jobs) != 2 {
+		t.Fatalf("claim jobs: %v (%d jobs)", err, len(jobs))
+	}
+
+	modTime := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	fingerprint := AudioFingerprintRecord{Size: 4096, ModTime: modTime, AudioHash: "sha256:abc", AnalyzerVersion: 1}
+	for _, job := range jobs {
+		record := AudioFeatureRecord{TrackID: job.TrackID, AnalyzedAt: time.Now(), EffectiveGainSource: "none", EffectivePeakSource: "none"}
+		if job.Track.ID == "current" {
+			record.Fingerprint = &fingerprint
+		}
+		if err := store.UpsertTrackAudioFeatures(ctx, record); err != nil {
+			t.Fatalf("upsert features: %v", err)
+		}
+		if err := store.CompleteAudioJob(ctx, job.ID); err != nil {
+			t.Fatalf("complete job: %v", err)
+		}
+	}
+
+	requeued, err := store.RequeueOutdatedAudioJobs(ctx, 1)
+	if err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	if requeued != 1 {
+		t.Fatalf("expected only the unversioned track to be queued, got %d", requeued)
+	}
+	if requeued, _ := store.RequeueOutdatedAudioJobs(ctx, 1); requeued != 0 {
+		t.Fatalf("expected a pending job to be left alone, got %d", requeued)
+	}
+	pending, err := store.ListPendingAudioJobs(ctx, 10)
+	if err != nil {
+		t.Fatalf("list pending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Track.ID != "outdated" || pending[0].Fingerprint != nil {
+		t.Fatalf("unexpected pending jobs %+v", pending)
+	}
+
+	if requeued, _ := store.RequeueOutdatedAudioJobs(ctx, 2); requeued != 1 {
+		t.Fatalf("expected a version bump to queue the current track, got %d", requeued)
+	}
+	claimed, err := store.ClaimPendingAudioJobs(ctx, ClaimOptions{Limit: 10, ClaimedBy: "test"})
+	if err != nil {
+		t.Fatalf("claim requeued: %v", err)
+	}
+	var got *AudioFingerprintRecord
+	var currentID int64
+	for _, job := range claimed {
+		if job.Track.ID == "current" {
+			got, currentID = job.Fingerprint, job.TrackID
+		}
+	}
+	if got == nil || *got != fingerprint {
+		t.Fatalf("expected the stored fingerprint on the job, got %+v", got)
+	}
+
+	touched := AudioFingerprintRecord{Size: 5000, ModTime: modTime.Add(time.Hour), AudioHash: "sha256:abc", AnalyzerVersion: 9}
+	if err := store.UpdateTrackAudioFingerprint(ctx, currentID, touched); err != nil {
+		t.Fatalf("update fingerprint: %v", err)
+	}
+	raw, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	defer raw.Close()
+	var size, version sql.NullInt64
+	if err := raw.QueryRow("SELECT file_size, analyzer_version FROM track_audio_features WHERE track_id = ?", currentID).Scan(&size, &version); err != nil {
+		t.Fatalf("query fingerprint: %v", err)
+	}
+	if size.Int64 != 5000 || version.Int64 != 1 {
+		t.Fatalf("expected only size and time to change, got size=%v version=%v", size, version)
+	}
+}
+
+func TestUpdateAlbumFlowThrough(t *testing.T) {
+	ctx := context.Background()
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "flow.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	number := func(n int) *int { return &n }
+	tracks := []app.Track{
+		{ID: "live-1", AlbumID: "live", TrackNumber: number(1)},
+		{ID: "live-2", AlbumID: "live", TrackNumber: number(2)},
+		{ID: "live-3", AlbumID: "live", TrackNumber: number(3)},
+		{ID: "studio-1", AlbumID: "studio", TrackNumber: number(1)},
+		{ID: "studio-2", AlbumID: "studio", TrackNumber: number(2)},
+	}
+	for i := range tracks {
+		tracks[i].Title, tracks[i].Artist, tracks[i].Album = tracks[i].ID, "Artist", tracks[i].AlbumID
+		tracks[i].CreatedAt = time.Unix(9000, 0)
+		tracks[i].Path = "/music/" + tracks[i].ID + ".flac"
+	}
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tracks); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+
+	// live-1 runs into live-2, which ends abruptly but before a gap. The
+	// studio album's first track fades out.
+	boundaries := map[string]BoundaryRecord{
+		"live-1":   {ContentStartSeconds: 1, ContentEndSeconds: 200, LeadingSilenceSeconds: 1, TrailingSilenceSeconds: 0.02, AbruptEnd: true},
+		"live-2":   {ContentEndSeconds: 180, TrailingSilenceSeconds: 0.03, AbruptStart: true, AbruptEnd: true},
+		"live-3":   {ContentStartSeconds: 1.5, ContentEndSeconds: 240, LeadingSilenceSeconds: 1.5, AbruptStart: true},
+		"studio-1": {ContentEndSeconds: 210, TrailingSilenceSeconds: 0.01},
+		"studio-2": {ContentEndSeconds: 190, AbruptStart: true},
+	}
+	ids := make([]int64, 0, len(tracks))
+	for _, track := range tracks {
+		id := trackIDByNavidromeID(t, store, track.ID)
+		ids = append(ids, id)
+		record := boundaries[track.ID]
+		if err := store.UpsertTrackAudioFeatures(ctx, AudioFeatureRecord{
+			TrackID:             id,
+			AnalyzedAt:          time.Now(),
+			EffectiveGainSource: "none",
+			EffectivePeakSource: "none",
+			Boundaries:          &record,
+		}); err != nil {
+			t.Fatalf("upsert features: %v", err)
+		}
+	}
+	if err := store.UpdateAlbumFlowThrough(ctx, 0.5); err != nil {
+		t.Fatalf("update flow-through: %v", err)
+	}
+
+	candidates, err := store.LoadTrackCandidates(ctx, ids)
+	if err != nil {
+		t.Fatalf("load candidates: %v", err)
+	}
+	for _, candidate := range candidates {
+		if want := candidate.Track.ID == "live-1"; candidate.Features.FlowsIntoNext != want {
+			t.Fatalf("expected %s flows into next = %v", candidate.Track.ID, want)
+		}
+	}
+	first := candidates[0].Features
+	if first.ContentStartSeconds == nil || *first.ContentStartSeconds != 1 || first.ContentEndSeconds == nil || *first.ContentEndSeconds != 200 {
+		t.Fatalf("unexpected content boundaries %+v", first)
+	}
+
+	// A track that vanishes from the album breaks the run.
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), append(tracks[:1:1], tracks[2:]...)); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+	if err := store.UpdateAlbumFlowThrough(ctx, 0.5); err != nil {
+		t.Fatalf("update flow-through: %v", err)
+	}
+	candidates, err = store.LoadTrackCandidates(ctx, ids[:1])
+	if err != nil {
+		t.Fatalf("load candidates: %v", err)
+	}
+	if candidates[0].Features.FlowsIntoNext {
+		t.Fatalf("expected the run to end with its next track deleted")
 	}
 }
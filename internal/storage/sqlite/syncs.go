@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// SyncRun is one recorded Navidrome sync.
+type SyncRun struct {
+	ID        int64
+	StartedAt time.Time
+	// FetchCompletedAt is zero when the run failed before tracks were fetched.
+	FetchCompletedAt time.Time
+	// CompletedAt is zero while the run is in progress.
+	CompletedAt     time.Time
+	Status          string
+	FailedPhase     string
+	Error           string
+	TracksFetched   int
+	TracksProcessed int
+	TracksUpdated   int
+	TracksDeleted   int
+}
+
+// Duration is the wall time of a finished run, or zero while it is running.
+func (r SyncRun) Duration() time.Duration {
+	if r.CompletedAt.IsZero() {
+		return 0
+	}
+	return r.CompletedAt.Sub(r.StartedAt)
+}
+
+// FetchDuration is how long fetching from Navidrome took, or zero if it did
+// not finish.
+func (r SyncRun) FetchDuration() time.Duration {
+	if r.FetchCompletedAt.IsZero() {
+		return 0
+	}
+	return r.FetchCompletedAt.Sub(r.StartedAt)
+}
+
+// ListSyncRuns returns the most recent sync runs, newest first.
+func (s *Store) ListSyncRuns(ctx context.Context, limit int) ([]SyncRun, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := db.New(s.db).ListRecentSyncs(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("list syncs: %w", err)
+	}
+	runs := make([]SyncRun, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, SyncRun{
+			ID:               row.ID,
+			StartedAt:        parseTimestamp(row.StartedAt),
+			FetchCompletedAt: parseTimestamp(row.FetchCompletedAt.String),
+			CompletedAt:      parseTimestamp(row.CompletedAt.String),
+			Status:           row.Status,
+			FailedPhase:      stringValue(row.FailedPhase),
+			Error:            stringValue(row.Error),
+			TracksFetched:    int(row.TracksFetched),
+			TracksProcessed:  int(row.TracksProcessed),
+			TracksUpdated:    int(row.TracksUpdated),
+			TracksDeleted:    int(row.TracksDeleted),
+		})
+	}
+	return runs, nil
+}
@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestSyncHistoryRecordsCompletedAndFailedRuns(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "syncs.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	started := time.Now().UTC().Add(-time.Minute)
+	okID, err := store.StartSync(ctx, started)
+	if err != nil {
+		t.Fatalf("start sync: %v", err)
+	}
+	tracks := []app.Track{
+		{ID: "h1", Title: "One", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(1000, 0), Duration: time.Minute, Path: "one.flac", Suffix: "flac"},
+		{ID: "h2", Title: "Two", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(1000, 0), Duration: time.Minute, Path: "two.flac", Suffix: "flac"},
+	}
+	if _, err := store.SaveTracks(ctx, okID, tracks); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+
+	failedID, err := store.StartSync(ctx, started.Add(30*time.Second))
+	if err != nil {
+		t.Fatalf("start failed sync: %v", err)
+	}
+	if err := store.FailSync(ctx, failedID, app.SyncFailure{
+		Phase: app.SyncPhaseFetch,
+		Err:   errors.New("fetch tracks: connection refused"),
+	}); err != nil {
+		t.Fatalf("fail sync: %v", err)
+	}
+
+	runs, err := store.ListSyncRuns(ctx, 10)
+	if err != nil {
+		t.Fatalf("list sync runs: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(runs))
+	}
+
+	failed, completed := runs[0], runs[1]
+	if failed.ID != failedID || failed.Status != "failed" || failed.FailedPhase != app.SyncPhaseFetch ||
+		failed.Error != "fetch tracks: connection refused" || failed.CompletedAt.IsZero() || !failed.FetchCompletedAt.IsZero() {
+		t.Fatalf("unexpected failed run %+v", failed)
+	}
+	if completed.ID != okID || completed.Status != "completed" || completed.FailedPhase != "" ||
+		completed.TracksFetched != 2 || completed.TracksProcessed != 2 || completed.TracksUpdated != 2 {
+		t.Fatalf("unexpected completed run %+v", completed)
+	}
+	if completed.Duration() <= 0 || completed.FetchDuration() <= 0 || completed.FetchDuration() > completed.Duration() {
+		t.Fatalf("unexpected timings %v / %v", completed.FetchDuration(), completed.Duration())
+	}
+}
+
+func TestSaveTracksReportsPhaseOnFailure(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "syncs-fail.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	// An out-of-range rating trips the track_user_stats CHECK constraint.
+	tracks := []app.Track{
+		{ID: "ok", Title: "Ok", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(1000, 0), Duration: time.Minute, Path: "ok.flac", Suffix: "flac"},
+		{ID: "bad", Title: "Bad", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(1000, 0), Duration: time.Minute, Path: "bad.flac", Suffix: "flac",
+			Stats: app.UserStats{Rating: 9}},
+	}
+	syncID := startTestSync(t, store)
+	stats, err := store.SaveTracks(ctx, syncID, tracks)
+	var phaseErr *app.PhaseError
+	if !errors.As(err, &phaseErr) || phaseErr.Phase != app.SyncPhaseUpsert {
+		t.Fatalf("expected an upsert phase error, got %v", err)
+	}
+	if stats.Updated+stats.Skipped != 2 {
+		t.Fatalf("expected partial stats to count handled tracks, got %+v", stats)
+	}
+
+	var count int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tracks").Scan(&count); err != nil {
+		t.Fatalf("count tracks: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected the failed save to roll back, found %d tracks", count)
+	}
+}
+
+func startTestSync(t *testing.T, store *Store) int64 {
+	t.Helper()
+	syncID, err := store.StartSync(context.Background(), time.Now().UTC())
+	if err != nil {
+		t.Fatalf("start sync: %v", err)
+	}
+	return syncID
+}
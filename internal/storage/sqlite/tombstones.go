@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// ErrDeleteThreshold is returned when a sync would tombstone more tracks than
+// Config.MaxDeletePercent allows, which usually means Navidrome returned a
+// partial library.
+var ErrDeleteThreshold = errors.New("too many tracks missing from navidrome")
+
+// checkDeleteThreshold fails when missing out of active tracks exceeds
+// maxPercent. A maxPercent of zero disables the check.
+func checkDeleteThreshold(missing, active int, maxPercent float64) error {
+	if maxPercent <= 0 || active == 0 {
+		return nil
+	}
+	percent := float64(missing) * 100 / float64(active)
+	if percent <= maxPercent {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d tracks (%.1f%%) exceeds the %.1f%% limit", ErrDeleteThreshold, missing, active, percent, maxPercent)
+}
+
+// PurgeDeletedTracks permanently removes tracks tombstoned at or before
+// before, together with their analysis, embeddings and stats. It returns the
+// number of tracks removed.
+func (s *Store) PurgeDeletedTracks(ctx context.Context, before time.Time) (int, error) {
+	purged, err := db.New(s.db).PurgeDeletedTracks(ctx, sql.NullString{String: formatTimestamp(before.UTC()), Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("purge deleted tracks: %w", err)
+	}
+	return int(purged), nil
+}
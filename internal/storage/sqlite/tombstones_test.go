@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestSaveTracksTombstonesAndRestoresMissingTracks(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "tombstones.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	tracks := tombstoneTestTracks(2)
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tracks); err != nil {
+		t.Fatalf("initial save: %v", err)
+	}
+	goneID := trackIDByNavidromeID(t, store, "t0")
+	lufs := -11.0
+	if err := store.UpsertTrackAudioFeatures(ctx, AudioFeatureRecord{
+		TrackID:                goneID,
+		AnalyzedAt:             time.Now().UTC(),
+		FileDurationSeconds:    60,
+		MeasuredIntegratedLUFS: &lufs,
+		EffectiveGainSource:    "measured_integrated_lufs",
+		EffectivePeakSource:    "none",
+	}); err != nil {
+		t.Fatalf("upsert audio features: %v", err)
+	}
+
+	stats, err := store.SaveTracks(ctx, startTestSync(t, store), tracks[1:])
+	if err != nil {
+		t.Fatalf("save without t0: %v", err)
+	}
+	if stats.Deleted != 1 {
+		t.Fatalf("expected one tombstoned track, got %+v", stats)
+	}
+
+	candidates, err := store.LoadTrackCandidates(ctx, []int64{goneID})
+	if err != nil {
+		t.Fatalf("load candidates: %v", err)
+	}
+	if len(candidates) != 0 {
+		t.Fatalf("expected tombstoned track to be excluded from candidates, got %+v", candidates)
+	}
+	jobs, err := store.ClaimPendingAudioJobs(ctx, ClaimOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("claim audio jobs: %v", err)
+	}
+	for _, job := range jobs {
+		if job.TrackID == goneID {
+			t.Fatalf("claimed an audio job for a tombstoned track")
+		}
+	}
+
+	// A repeat sync does not tombstone the same track again.
+	stats, err = store.SaveTracks(ctx, startTestSync(t, store), tracks[1:])
+	if err != nil {
+		t.Fatalf("repeat save: %v", err)
+	}
+	if stats.Deleted != 0 {
+		t.Fatalf("expected no new tombstones, got %+v", stats)
+	}
+
+	stats, err = store.SaveTracks(ctx, startTestSync(t, store), tracks)
+	if err != nil {
+		t.Fatalf("save with t0 restored: %v", err)
+	}
+	if stats.Restored != 1 || stats.Updated != 0 {
+		t.Fatalf("expected t0 to be restored without an update, got %+v", stats)
+	}
+	candidates, err = store.LoadTrackCandidates(ctx, []int64{goneID})
+	if err != nil {
+		t.Fatalf("reload candidates: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Features.IntegratedLUFS == nil || *candidates[0].Features.IntegratedLUFS != lufs {
+		t.Fatalf("expected restored track to keep its analysis, got %+v", candidates)
+	}
+}
+
+func TestSaveTracksAbortsWhenTooManyTracksVanish(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "guard.db"), MaxDeletePercent: 25})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	tracks := tombstoneTestTracks(4)
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tracks); err != nil {
+		t.Fatalf("initial save: %v", err)
+	}
+
+	_, err = store.SaveTracks(ctx, startTestSync(t, store), tracks[:2])
+	if !errors.Is(err, ErrDeleteThreshold) {
+		t.Fatalf("expected ErrDeleteThreshold, got %v", err)
+	}
+	var phaseErr *app.PhaseError
+	if !errors.As(err, &phaseErr) || phaseErr.Phase != app.SyncPhaseDelete {
+		t.Fatalf("expected a delete phase error, got %v", err)
+	}
+	var active int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tracks WHERE deleted_at IS NULL").Scan(&active); err != nil {
+		t.Fatalf("count active tracks: %v", err)
+	}
+	if active != 4 {
+		t.Fatalf("expected the aborted sync to keep all tracks, got %d", active)
+	}
+
+	stats, err := store.SaveTracks(ctx, startTestSync(t, store), tracks[:3])
+	if err != nil {
+		t.Fatalf("save within threshold: %v", err)
+	}
+	if stats.Deleted != 1 {
+		t.Fatalf("expected one tombstoned track, got %+v", stats)
+	}
+}
+
+func TestPurgeDeletedTracksHonoursGracePeriod(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "purge.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	tracks := tombstoneTestTracks(2)
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tracks); err != nil {
+		t.Fatalf("initial save: %v", err)
+	}
+	if _, err := store.SaveTracks(ctx, startTestSync(t, store), tracks[1:]); err != nil {
+		t.Fatalf("save without t0: %v", err)
+	}
+
+	purged, err := store.PurgeDeletedTracks(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("purge within grace period: %v", err)
+	}
+	if purged != 0 {
+		t.Fatalf("expected nothing purged within the grace period, got %d", purged)
+	}
+
+	purged, err = store.PurgeDeletedTracks(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected one purged track, got %d", purged)
+	}
+	var remaining, jobs int
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tracks").Scan(&remaining); err != nil {
+		t.Fatalf("count tracks: %v", err)
+	}
+	if err := store.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM track_audio_analysis").Scan(&jobs); err != nil {
+		t.Fatalf("count audio jobs: %v", err)
+	}
+	if remaining != 1 || jobs != 1 {
+		t.Fatalf("expected only t1 and its job to remain, got %d tracks and %d jobs", remaining, jobs)
+	}
+}
+
+func tombstoneTestTracks(n int) []app.Track {
+	tracks := make([]app.Track, 0, n)
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("t%d", i)
+		tracks = append(tracks, app.Track{
+			ID:        id,
+			Title:     id,
+			Artist:    "Artist",
+			Album:     "Album",
+			CreatedAt: time.Unix(1000, 0),
+			Duration:  time.Minute,
+			Path:      id + ".flac",
+			Suffix:    "flac",
+		})
+	}
+	return tracks
+}
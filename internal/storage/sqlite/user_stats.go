@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// loadUserStats returns the stored user stats keyed by track ID.
+func loadUserStats(ctx context.Context, queries *db.Queries) (map[int64]app.UserStats, error) {
+	rows, err := queries.ListTrackUserStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list track user stats: %w", err)
+	}
+	stats := make(map[int64]app.UserStats, len(rows))
+	for _, row := range rows {
+		stats[row.TrackID] = userStatsFromSQL(row.StarredAt, row.Rating, row.PlayCount, row.LastPlayedAt)
+	}
+	return stats, nil
+}
+
+// syncUserStats writes stats for trackID when they differ from what is stored,
+// reporting whether anything changed. Tracks with no stats and no stored row
+// are left alone.
+func syncUserStats(ctx context.Context, queries *db.Queries, trackID int64, stats app.UserStats, stored map[int64]app.UserStats) (bool, error) {
+	previous, ok := stored[trackID]
+	if ok && userStatsEqual(previous, stats) {
+		return false, nil
+	}
+	if !ok && userStatsEqual(app.UserStats{}, stats) {
+		return false, nil
+	}
+	if err := queries.UpsertTrackUserStats(ctx, db.UpsertTrackUserStatsParams{
+		TrackID:      trackID,
+		StarredAt:    nullTimestamp(stats.StarredAt),
+		Rating:       int64(stats.Rating),
+		PlayCount:    stats.PlayCount,
+		LastPlayedAt: nullTimestamp(stats.LastPlayedAt),
+		UpdatedAt:    nowUTC(),
+	}); err != nil {
+		return false, fmt.Errorf("upsert track user stats: %w", err)
+	}
+	stored[trackID] = stats
+	return true, nil
+}
+
+func userStatsEqual(a, b app.UserStats) bool {
+	return a.StarredAt.Equal(b.StarredAt) &&
+		a.Rating == b.Rating &&
+		a.PlayCount == b.PlayCount &&
+		a.LastPlayedAt.Equal(b.LastPlayedAt)
+}
+
+func userStatsFromSQL(starredAt sql.NullString, rating, playCount int64, lastPlayedAt sql.NullString) app.UserStats {
+	return app.UserStats{
+		StarredAt:    parseTimestamp(starredAt.String),
+		Rating:       int(rating),
+		PlayCount:    playCount,
+		LastPlayedAt: parseTimestamp(lastPlayedAt.String),
+	}
+}
+
+func nullTimestamp(ts time.Time) sql.NullString {
+	if ts.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatTimestamp(ts.UTC()), Valid: true}
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestSaveTracksSyncsUserStatsSeparatelyFromMetadata(t *testing.T) {
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "user-stats.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	changed := time.Unix(2000, 0)
+	starred := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	played := time.Date(2024, 4, 2, 21, 15, 0, 0, time.UTC)
+	tracks := []app.Track{
+		{ID: "stats-a", Title: "A", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(1000, 0), UpdatedAt: changed, Duration: time.Minute, Path: "a.flac", Suffix: "flac",
+			Stats: app.UserStats{StarredAt: starred, Rating: 4, PlayCount: 12, LastPlayedAt: played}},
+		{ID: "stats-b", Title: "B", Artist: "Artist", Album: "Album", CreatedAt: time.Unix(1000, 0), UpdatedAt: changed, Duration: time.Minute, Path: "b.flac", Suffix: "flac"},
+	}
+
+	stats, err := store.SaveTracks(ctx, startTestSync(t, store), tracks)
+	if err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+	if stats.Updated != 2 || stats.StatsUpdated != 1 {
+		t.Fatalf("unexpected first stats %+v", stats)
+	}
+
+	stats, err = store.SaveTracks(ctx, startTestSync(t, store), tracks)
+	if err != nil {
+		t.Fatalf("unchanged save: %v", err)
+	}
+	if stats.Skipped != 2 || stats.StatsUpdated != 0 {
+		t.Fatalf("expected nothing to change, got %+v", stats)
+	}
+
+	// A new play and rating on an otherwise unchanged track is a stats change only.
+	tracks[1].Stats = app.UserStats{Rating: 2, PlayCount: 1, LastPlayedAt: played.Add(time.Hour)}
+	stats, err = store.SaveTracks(ctx, startTestSync(t, store), tracks)
+	if err != nil {
+		t.Fatalf("stats-only save: %v", err)
+	}
+	if stats.Updated != 0 || stats.Skipped != 2 || stats.StatsUpdated != 1 {
+		t.Fatalf("expected a stats-only change, got %+v", stats)
+	}
+
+	// Unstarring clears the stored timestamp.
+	tracks[0].Stats.StarredAt = time.Time{}
+	if stats, err = store.SaveTracks(ctx, startTestSync(t, store), tracks); err != nil || stats.StatsUpdated != 1 {
+		t.Fatalf("unstar save: stats=%+v err=%v", stats, err)
+	}
+
+	var ids []int64
+	for _, navID := range []string{"stats-a", "stats-b"} {
+		var id int64
+		if err := store.db.QueryRowContext(ctx, "SELECT id FROM tracks WHERE navidrome_id = ?", navID).Scan(&id); err != nil {
+			t.Fatalf("select id: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	candidates, err := store.LoadTrackCandidates(ctx, ids)
+	if err != nil {
+		t.Fatalf("load candidates: %v", err)
+	}
+	a, b := candidates[0].Track.Stats, candidates[1].Track.Stats
+	if !a.StarredAt.IsZero() || a.Rating != 4 || a.PlayCount != 12 || !a.LastPlayedAt.Equal(played) {
+		t.Fatalf("unexpected stats for a: %+v", a)
+	}
+	if b.Rating != 2 || b.PlayCount != 1 || !b.LastPlayedAt.Equal(played.Add(time.Hour)) {
+		t.Fatalf("unexpected stats for b: %+v", b)
+	}
+}
@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/db"
+)
+
+// VectorFilters narrows a nearest-neighbour search. Model is required; the
+// remaining fields are optional and ignored when left at their zero value.
+type VectorFilters struct {
+	Model           string
+	Genres          []string
+	YearFrom        int
+	YearTo          int
+	MinDuration     time.Duration
+	MaxDuration     time.Duration
+	ExcludeTrackIDs []int64
+}
+
+// VectorMatch is one ranked result of a nearest-neighbour search. Distance is
+// the cosine distance, so 0 means identical direction.
+type VectorMatch struct {
+	TrackID     int64
+	NavidromeID string
+	Distance    float64
+}
+
+// NearestTracks returns up to k tracks whose embeddings are closest to vector,
+// ordered by ascending cosine distance. It scans every embedding for the model
+// in Go: the pure-Go modernc driver cannot load the sqlite-vec extension, and
+// a personal library is small enough that a brute-force scan is fast.
+func (s *Store) NearestTracks(ctx context.Context, vector []float32, k int, filters VectorFilters) ([]VectorMatch, error) {
+	if len(vector) == 0 {
+		return nil, errors.New("query vector is empty")
+	}
+	if strings.TrimSpace(filters.Model) == "" {
+		return nil, errors.New("embedding model is required")
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+
+	matches, err := s.nearestTracksBruteForce(ctx, vector, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].TrackID < matches[j].TrackID
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func (s *Store) nearestTracksBruteForce(ctx context.Context, vector []float32, filters VectorFilters) ([]VectorMatch, error) {
+	rows, err := db.New(s.db).ListTrackEmbeddingCandidates(ctx, db.ListTrackEmbeddingCandidatesParams{
+		Model:      filters.Model,
+		Dimensions: int64(len(vector)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list embedding candidates: %w", err)
+	}
+
+	match := filters.matcher()
+	queryNorm := vectorNorm(vector)
+	matches := make([]VectorMatch, 0, len(rows))
+	for _, row := range rows {
+		if !match(row.TrackID, row.Genre, row.Year, row.DurationSeconds) {
+			continue
+		}
+		candidate, err := decodeVector(row.Embedding)
+		if err != nil {
+			return nil, fmt.Errorf("decode embedding for track %d: %w", row.TrackID, err)
+		}
+		matches = append(matches, VectorMatch{
+			TrackID:     row.TrackID,
+			NavidromeID: row.NavidromeID,
+			Distance:    cosineDistance(vector, queryNorm, candidate),
+		})
+	}
+	return matches, nil
+}
+
+func (f VectorFilters) matcher() func(trackID int64, genre sql.NullString, year sql.NullInt64, durationSeconds int64) bool {
+	excluded := make(map[int64]struct{}, len(f.ExcludeTrackIDs))
+	for _, id := range f.ExcludeTrackIDs {
+		excluded[id] = struct{}{}
+	}
+	genres := make(map[string]struct{}, len(f.Genres))
+	for _, g := range f.Genres {
+		if g = strings.ToLower(strings.TrimSpace(g)); g != "" {
+			genres[g] = struct{}{}
+		}
+	}
+	minSeconds := int64(f.MinDuration / time.Second)
+	maxSeconds := int64(f.MaxDuration / time.Second)
+
+	return func(trackID int64, genre sql.NullString, year sql.NullInt64, durationSeconds int64) bool {
+		if _, ok := excluded[trackID]; ok {
+			return false
+		}
+		if len(genres) > 0 {
+			if !genre.Valid {
+				return false
+			}
+			if _, ok := genres[strings.ToLower(strings.TrimSpace(genre.String))]; !ok {
+				return false
+			}
+		}
+		if f.YearFrom > 0 && (!year.Valid || year.Int64 < int64(f.YearFrom)) {
+			return false
+		}
+		if f.YearTo > 0 && (!year.Valid || year.Int64 > int64(f.YearTo)) {
+			return false
+		}
+		if minSeconds > 0 && durationSeconds < minSeconds {
+			return false
+		}
+		if maxSeconds > 0 && durationSeconds > maxSeconds {
+			return false
+		}
+		return true
+	}
+}
+
+// encodeVector packs a vector as little-endian float32 values.
+func encodeVector(vec []float32) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) ([]float32, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("embedding blob length %d is not a multiple of 4", len(buf))
+	}
+	vec := make([]float32, len(buf)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+	}
+	return vec, nil
+}
+
+func vectorNorm(vec []float32) float64 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum)
+}
+
+// cosineDistance returns 1 - cosine similarity. Zero vectors are treated as
+// maximally distant from everything.
+func cosineDistance(query []float32, queryNorm float64, candidate []float32) float64 {
+	if len(query) != len(candidate) {
+		return 2
+	}
+	var dot float64
+	for i := range query {
+		dot += float64(query[i]) * float64(candidate[i])
+	}
+	candidateNorm := vectorNorm(candidate)
+	if queryNorm == 0 || candidateNorm == 0 {
+		return 2
+	}
+	return 1 - dot/(queryNorm*candidateNorm)
+}
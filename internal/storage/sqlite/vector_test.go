@@ -0,0 +1,201 @@
+package sqlite
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bowmanmike/playlistgen/internal/app"
+)
+
+func TestNearestTracksRanksByCosineDistance(t *testing.T) {
+	store, trackIDs := seedEmbeddedTracks(t)
+
+	matches, err := store.NearestTracks(context.Background(), []float32{1, 0, 0}, 2, VectorFilters{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("nearest tracks: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(matches))
+	}
+	if matches[0].NavidromeID != "vec-east" || matches[0].TrackID != trackIDs["vec-east"] {
+		t.Fatalf("unexpected first match %+v", matches[0])
+	}
+	if math.Abs(matches[0].Distance) > 1e-6 {
+		t.Fatalf("expected zero distance for identical direction, got %f", matches[0].Distance)
+	}
+	if matches[1].NavidromeID != "vec-northeast" {
+		t.Fatalf("unexpected second match %+v", matches[1])
+	}
+}
+
+func TestNearestTracksAppliesFilters(t *testing.T) {
+	store, trackIDs := seedEmbeddedTracks(t)
+	query := []float32{1, 0, 0}
+
+	tests := []struct {
+		name    string
+		filters VectorFilters
+		want    []string
+	}{
+		{
+			name:    "genre",
+			filters: VectorFilters{Model: "test-model", Genres: []string{"jazz"}},
+			want:    []string{"vec-northeast", "vec-north"},
+		},
+		{
+			name:    "year range",
+			filters: VectorFilters{Model: "test-model", YearFrom: 1990, YearTo: 1999},
+			want:    []string{"vec-east"},
+		},
+		{
+			name:    "max duration",
+			filters: VectorFilters{Model: "test-model", MaxDuration: 200 * time.Second},
+			want:    []string{"vec-east", "vec-north"},
+		},
+		{
+			name:    "exclude",
+			filters: VectorFilters{Model: "test-model", ExcludeTrackIDs: []int64{trackIDs["vec-east"]}},
+			want:    []string{"vec-northeast", "vec-north"},
+		},
+		{
+			name:    "other model",
+			filters: VectorFilters{Model: "other-model"},
+			want:    nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, err := store.NearestTracks(context.Background(), query, 10, tc.filters)
+			if err != nil {
+				t.Fatalf("nearest tracks: %v", err)
+			}
+			if len(matches) != len(tc.want) {
+				t.Fatalf("expected %d matches, got %+v", len(tc.want), matches)
+			}
+			for i, want := range tc.want {
+				if matches[i].NavidromeID != want {
+					t.Fatalf("match %d: expected %s, got %s", i, want, matches[i].NavidromeID)
+				}
+			}
+		})
+	}
+}
+
+func TestNearestTracksSkipsMismatchedDimensions(t *testing.T) {
+	store, _ := seedEmbeddedTracks(t)
+
+	matches, err := store.NearestTracks(context.Background(), []float32{1, 0}, 10, VectorFilters{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("nearest tracks: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Fatalf("expected no matches for different dimensions, got %+v", matches)
+	}
+}
+
+func TestCosineDistance(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     []float32
+		candidate []float32
+		want      float64
+	}{
+		{name: "same direction", query: []float32{1, 0}, candidate: []float32{3, 0}, want: 0},
+		{name: "orthogonal", query: []float32{1, 0}, candidate: []float32{0, 2}, want: 1},
+		{name: "opposite", query: []float32{1, 0}, candidate: []float32{-1, 0}, want: 2},
+		{name: "zero candidate", query: []float32{1, 0}, candidate: []float32{0, 0}, want: 2},
+		{name: "zero query", query: []float32{0, 0}, candidate: []float32{1, 0}, want: 2},
+		{name: "mismatched dimensions", query: []float32{1, 0}, candidate: []float32{1, 0, 0}, want: 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := cosineDistance(tc.query, vectorNorm(tc.query), tc.candidate)
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("expected %f, got %f", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestVectorRoundTrip(t *testing.T) {
+	in := []float32{0, 1.5, -2.25, float32(math.Pi)}
+	out, err := decodeVector(encodeVector(in))
+	if err != nil {
+		t.Fatalf("decode vector: %v", err)
+	}
+	for i := range in {
+		if in[i] != out[i] {
+			t.Fatalf("value %d: expected %f, got %f", i, in[i], out[i])
+		}
+	}
+	if _, err := decodeVector([]byte{1, 2, 3}); err == nil {
+		t.Fatal("expected error for truncated blob")
+	}
+}
+
+func seedEmbeddedTracks(t *testing.T) (*Store, map[string]int64) {
+	t.Helper()
+
+	store, err := New(Config{Path: filepath.Join(t.TempDir(), "vectors.db")})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	jazz := "Jazz"
+	rock := "Rock"
+	nineties := 1995
+	sixties := 1962
+	seeds := []struct {
+		track  app.Track
+		vector []float32
+	}{
+		{
+			track:  app.Track{ID: "vec-east", Title: "East", Artist: "A", Album: "X", Genre: &rock, Year: &nineties, Duration: 180 * time.Second},
+			vector: []float32{2, 0, 0},
+		},
+		{
+			track:  app.Track{ID: "vec-northeast", Title: "Northeast", Artist: "B", Album: "Y", Genre: &jazz, Year: &sixties, Duration: 400 * time.Second},
+			vector: []float32{1, 1, 0},
+		},
+		{
+			track:  app.Track{ID: "vec-north", Title: "North", Artist: "C", Album: "Z", Genre: &jazz, Year: &sixties, Duration: 150 * time.Second},
+			vector: []float32{0, 3, 0},
+		},
+	}
+
+	tracks := make([]app.Track, 0, len(seeds))
+	for i, seed := range seeds {
+		seed.track.CreatedAt = time.Unix(int64(9500+i), 0)
+		seed.track.Path = "/music/" + seed.track.ID + ".flac"
+		seed.track.Suffix = "flac"
+		tracks = append(tracks, seed.track)
+	}
+	if _, err := store.SaveTracks(context.Background(), startTestSync(t, store), tracks); err != nil {
+		t.Fatalf("save tracks: %v", err)
+	}
+
+	ids := make(map[string]int64, len(seeds))
+	for _, seed := range seeds {
+		var id int64
+		if err := store.db.QueryRow("SELECT id FROM tracks WHERE navidrome_id = ?", seed.track.ID).Scan(&id); err != nil {
+			t.Fatalf("select track id: %v", err)
+		}
+		ids[seed.track.ID] = id
+		if err := store.UpsertTrackEmbedding(context.Background(), EmbeddingRecord{
+			TrackID:    id,
+			Model:      "test-model",
+			Vector:     seed.vector,
+			Document:   "Title: " + seed.track.Title,
+			EmbeddedAt: time.Now().UTC(),
+		}); err != nil {
+			t.Fatalf("upsert embedding: %v", err)
+		}
+	}
+	return store, ids
+}